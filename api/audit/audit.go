@@ -0,0 +1,382 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"vsphere-facade/api/security"
+	"vsphere-facade/app/logging"
+	"vsphere-facade/config"
+	"vsphere-facade/db/badgerdb"
+)
+
+const (
+	EventRequest  = "request"
+	EventComplete = "complete"
+)
+
+const (
+	OutcomeAccepted = "accepted"
+	OutcomeSuccess  = "success"
+	OutcomeFailure  = "failure"
+	OutcomePartial  = "partial"
+	OutcomeRejected = "rejected"
+)
+
+const keyPrefix = "audit::"
+
+// pendingPrefix 还没有结束的异步任务，重启后任务结束时仍然沿用请求时的调用者信息
+const pendingPrefix = "audit-pending::"
+
+const (
+	DefaultLimit = 100
+	MaxLimit     = 1000
+)
+
+// gin.Context中记录审计目标的键
+const (
+	keyRequestID = "AUDIT_REQUEST_ID"
+	keyVCID      = "AUDIT_VCID"
+	keyObjectIDs = "AUDIT_OBJECT_IDS"
+	keySkip      = "AUDIT_SKIP"
+)
+
+// Entry 审计记录，异步任务在接收请求和任务结束时各记录一条，通过requestId关联
+type Entry struct {
+	ID            string          `json:"id"`
+	Time          time.Time       `json:"time"`
+	Event         string          `json:"event"`
+	User          string          `json:"user"`
+	SourceIP      string          `json:"sourceIp,omitempty"`
+	Method        string          `json:"method,omitempty"`
+	Endpoint      string          `json:"endpoint,omitempty"`
+	VCAddress     string          `json:"vcAddress"`
+	VCID          string          `json:"vcid,omitempty"`
+	ObjectIDs     []string        `json:"objectIds,omitempty"`
+	RequestID     string          `json:"requestId,omitempty"`
+	HTTPRequestID string          `json:"httpRequestId,omitempty"`
+	Body          json.RawMessage `json:"body,omitempty" swaggertype:"object"`
+	Status        int             `json:"status,omitempty"`
+	Outcome       string          `json:"outcome"`
+	Message       string          `json:"message,omitempty"`
+}
+
+// pending 还没有结束的异步任务，任务结束时沿用请求时的调用者信息，同时保存在badger DB中
+var pending sync.Map
+
+func Setup() {
+	c := config.G.Server.Audit
+	if !c.Enable {
+		return
+	}
+	if !badgerdb.Enabled() {
+		logging.L().Warn("没有配置badger DB，审计记录只输出到外部")
+	}
+	if c.File != "" {
+		sink, err := newFileSink(c.File)
+		if err != nil {
+			logging.L().Panic("创建审计文件失败", err)
+		}
+		sinks = append(sinks, sink)
+	}
+	if c.Syslog != nil {
+		sink, err := newSyslogSink(c.Syslog.Network, c.Syslog.Address, c.Syslog.Tag)
+		if err != nil {
+			logging.L().Panic("连接syslog失败", err)
+		}
+		sinks = append(sinks, sink)
+	}
+}
+
+// Middleware 记录认证通过的写操作，需要放在security.Verify之后
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !config.G.Server.Audit.Enable || !mutating(c.Request.Method) {
+			c.Next()
+			return
+		}
+
+		var body []byte
+		if c.Request.Body != nil {
+			body, _ = ioutil.ReadAll(c.Request.Body)
+			c.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+		entry := newEntry(c)
+		entry.Event = EventRequest
+		entry.Body = redactBody(body)
+		entry.ObjectIDs = objectIDs(c, body)
+
+		c.Next()
+
+		if c.GetBool(keySkip) {
+			return
+		}
+		entry.Time = time.Now()
+		entry.Status = c.Writer.Status()
+		if v, ok := c.Get(keyRequestID); ok {
+			entry.RequestID = v.(string)
+		}
+		if v, ok := c.Get(keyVCID); ok {
+			entry.VCID = v.(string)
+		}
+		if v, ok := c.Get(keyObjectIDs); ok {
+			entry.ObjectIDs = v.([]string)
+		}
+		switch {
+		case entry.Status >= http.StatusBadRequest:
+			entry.Outcome = OutcomeRejected
+		case entry.Status == http.StatusAccepted:
+			entry.Outcome = OutcomeAccepted
+		default:
+			entry.Outcome = OutcomeSuccess
+		}
+		record(entry)
+	}
+}
+
+// Skip 用于POST方式的查询接口，不记录审计
+func Skip(c *gin.Context) {
+	c.Set(keySkip, true)
+}
+
+// SetTarget 记录操作的VC和对象，对象为空时使用请求中的id/ids
+func SetTarget(c *gin.Context, VCID string, objectIDs ...string) {
+	c.Set(keyVCID, VCID)
+	if len(objectIDs) > 0 {
+		c.Set(keyObjectIDs, objectIDs)
+	}
+}
+
+// Accept 异步任务创建时调用，任务结束时使用Complete记录结果
+func Accept(c *gin.Context, requestID, VCID string) {
+	c.Set(keyRequestID, requestID)
+	SetTarget(c, VCID)
+	if !config.G.Server.Audit.Enable {
+		return
+	}
+	entry := newEntry(c)
+	entry.VCID = VCID
+	entry.RequestID = requestID
+	pending.Store(requestID, entry)
+	data, err := json.Marshal(entry)
+	if err != nil {
+		logging.L().Error("审计记录序列化失败", err)
+		return
+	}
+	err = save(pendingPrefix+requestID, string(data))
+	if err != nil {
+		logging.L().Error("保存异步任务的审计信息失败", err)
+	}
+}
+
+// Complete 记录异步任务的结果，objectIDs为任务实际操作的对象
+func Complete(requestID, outcome string, objectIDs []string, message string) {
+	if !config.G.Server.Audit.Enable {
+		return
+	}
+	entry := Entry{RequestID: requestID}
+	if v, ok := pending.LoadAndDelete(requestID); ok {
+		entry = v.(Entry)
+	} else if v := badgerdb.Get(pendingPrefix + requestID); v != "" {
+		// 服务重启前接收的任务
+		if err := json.Unmarshal([]byte(v), &entry); err != nil {
+			logging.L().Errorf("解析异步任务[%s]的审计信息失败: %v", requestID, err)
+		}
+	}
+	if badgerdb.Enabled() {
+		if err := badgerdb.Del(pendingPrefix + requestID); err != nil {
+			logging.L().Errorf("删除异步任务[%s]的审计信息失败: %v", requestID, err)
+		}
+	}
+	entry.ID = uuid.NewString()
+	entry.Time = time.Now()
+	entry.Event = EventComplete
+	entry.ObjectIDs = objectIDs
+	entry.Outcome = outcome
+	entry.Message = message
+	record(entry)
+}
+
+func newEntry(c *gin.Context) Entry {
+	entry := Entry{
+		ID:            uuid.NewString(),
+		Time:          time.Now(),
+		SourceIP:      c.ClientIP(),
+		Method:        c.Request.Method,
+		Endpoint:      c.FullPath(),
+		HTTPRequestID: c.Writer.Header().Get(logging.HeaderRequestID),
+	}
+	if _, ok := c.Get(security.CurrentAuth); ok {
+		auth := security.GetCurrentAuth(c)
+		entry.User = auth.Username
+		entry.VCAddress = auth.Address
+	}
+	if _, ok := c.Get(security.CurrentPrincipal); ok {
+		entry.User = security.GetCurrentPrincipal(c).Subject
+	}
+	return entry
+}
+
+func record(entry Entry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		logging.L().Error("审计记录序列化失败", err)
+		return
+	}
+	// 纳秒时间戳补齐位数，保证键的顺序与时间顺序一致，记录ID保证同一时间的记录不会覆盖
+	err = save(fmt.Sprintf("%s%020d::%s", keyPrefix, entry.Time.UnixNano(), entry.ID), string(data))
+	if err != nil {
+		logging.L().Error("保存审计记录失败", err)
+	}
+	for _, sink := range sinks {
+		err := sink.Write(data)
+		if err != nil {
+			logging.L().Error("输出审计记录失败", err)
+		}
+	}
+}
+
+// save 按retention设置过期时间，没有配置badger DB时不保存
+func save(k, v string) error {
+	if !badgerdb.Enabled() {
+		return nil
+	}
+	if days := config.G.Server.Audit.Retention; days > 0 {
+		return badgerdb.SetWithTTL(k, v, time.Duration(days)*24*time.Hour)
+	}
+	badgerdb.Set(k, v)
+	return nil
+}
+
+// Result 一页审计记录，Next不为空时使用它作为cursor查询下一页
+type Result struct {
+	Entries []Entry `json:"entries"`
+	Next    string  `json:"next,omitempty"`
+}
+
+// Query 按时间倒序查询审计记录，每次最多返回q.Limit条
+func Query(q Filter) (Result, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+	start := ""
+	if q.Cursor != "" {
+		start = keyPrefix + q.Cursor
+	}
+
+	result := Result{Entries: []Entry{}}
+	last := ""
+	err := badgerdb.IterateFrom(keyPrefix, start, true, func(k, v string) bool {
+		if len(result.Entries) == limit {
+			// 还有更早的记录，从这一页的最后一条继续查询
+			result.Next = strings.TrimPrefix(last, keyPrefix)
+			return false
+		}
+		entry := Entry{}
+		err := json.Unmarshal([]byte(v), &entry)
+		if err != nil {
+			logging.L().Errorf("解析审计记录[%s]失败: %v", k, err)
+			return true
+		}
+		if q.From != nil && entry.Time.Before(*q.From) {
+			return false
+		}
+		if q.match(entry) {
+			result.Entries = append(result.Entries, entry)
+			last = k
+		}
+		return true
+	})
+	if err != nil {
+		return Result{}, fmt.Errorf("查询审计记录失败: %v", err)
+	}
+	return result, nil
+}
+
+// Filter 审计记录查询条件，为空的条件不过滤
+type Filter struct {
+	// Cursor 上一页返回的Next，为空时从最新的记录开始
+	Cursor string
+	// Limit 最多返回的数量，默认为DefaultLimit，不超过MaxLimit
+	Limit     int
+	From      *time.Time
+	To        *time.Time
+	VCAddress string
+	User      string
+	VCID      string
+	ObjectID  string
+	RequestID string
+	Outcome   string
+	Method    string
+}
+
+func (q Filter) match(entry Entry) bool {
+	if q.To != nil && entry.Time.After(*q.To) {
+		return false
+	}
+	if q.VCAddress != "" && entry.VCAddress != q.VCAddress {
+		return false
+	}
+	if q.User != "" && entry.User != q.User {
+		return false
+	}
+	if q.VCID != "" && entry.VCID != q.VCID {
+		return false
+	}
+	if q.RequestID != "" && entry.RequestID != q.RequestID {
+		return false
+	}
+	if q.Outcome != "" && entry.Outcome != q.Outcome {
+		return false
+	}
+	if q.Method != "" && !strings.EqualFold(entry.Method, q.Method) {
+		return false
+	}
+	if q.ObjectID != "" {
+		for _, id := range entry.ObjectIDs {
+			if id == q.ObjectID {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func mutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// objectIDs 从路径参数id和请求中的id、ids字段提取操作对象
+func objectIDs(c *gin.Context, body []byte) []string {
+	var ids []string
+	if id := c.Param("id"); id != "" {
+		ids = append(ids, id)
+	}
+	req := struct {
+		ID  string   `json:"id"`
+		IDs []string `json:"ids"`
+	}{}
+	if json.Unmarshal(body, &req) == nil {
+		if req.ID != "" {
+			ids = append(ids, req.ID)
+		}
+		ids = append(ids, req.IDs...)
+	}
+	return ids
+}
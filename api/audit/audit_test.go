@@ -0,0 +1,164 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"vsphere-facade/api/security"
+	"vsphere-facade/config"
+	"vsphere-facade/db/badgerdb"
+	"vsphere-facade/db/badgerdb/badgertest"
+	"vsphere-facade/vsphere"
+)
+
+func setup(t *testing.T) string {
+	dir := badgertest.Setup(t)
+
+	config.G.Server.Audit.Enable = true
+	file := filepath.Join(dir, "audit.log")
+	sink, err := newFileSink(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sinks = []Sink{sink}
+	return file
+}
+
+func engine() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set(security.CurrentAuth, vsphere.Auth{Address: "https://vc1", Username: "admin", Password: "pass"})
+	})
+	r.Use(Middleware())
+	r.POST("/virtual_machines/power_off", func(c *gin.Context) {
+		Accept(c, "task-1", "vc-1")
+		c.Status(http.StatusAccepted)
+	})
+	r.POST("/virtual_machines/performance", Skip, func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return r
+}
+
+func TestAudit(t *testing.T) {
+	file := setup(t)
+	r := engine()
+
+	body := `{"ids":["vm-1","vm-2"],"callback":{"httpPost":{"url":"http://cb","headers":{"X-Auth":"abc"}}},"os":{"windows":{"password":"P@ss"}}}`
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/virtual_machines/power_off", strings.NewReader(body)))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/virtual_machines/performance", strings.NewReader(`{"ids":["vm-1"]}`)))
+	Complete("task-1", OutcomePartial, []string{"vm-1", "vm-2"}, "vm-2: 虚拟机不存在")
+
+	result, err := Query(Filter{VCAddress: "https://vc1", ObjectID: "vm-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := result.Entries
+	if len(entries) != 2 {
+		t.Fatal("应该有请求和任务结束两条记录", entries)
+	}
+	complete, request := entries[0], entries[1]
+	if request.Event != EventRequest || request.Outcome != OutcomeAccepted || request.RequestID != "task-1" ||
+		request.User != "admin" || request.VCID != "vc-1" || request.Endpoint != "/virtual_machines/power_off" {
+		t.Error("请求记录不正确", request)
+	}
+	if strings.Contains(string(request.Body), "P@ss") || strings.Contains(string(request.Body), "abc") {
+		t.Error("请求内容中的密码没有隐藏", string(request.Body))
+	}
+	if complete.Event != EventComplete || complete.Outcome != OutcomePartial || complete.User != "admin" || complete.VCID != "vc-1" {
+		t.Error("任务结束记录不正确", complete)
+	}
+
+	result, _ = Query(Filter{VCAddress: "https://vc2"})
+	if len(result.Entries) != 0 {
+		t.Error("不应该返回其他VC的记录", result.Entries)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	lines := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		entry := Entry{}
+		if json.Unmarshal(scanner.Bytes(), &entry) != nil {
+			t.Error("审计文件的每行应该是一条JSON", scanner.Text())
+		}
+		lines++
+	}
+	if lines != 2 {
+		t.Error("审计文件应该有2条记录", lines)
+	}
+}
+
+func TestQueryPage(t *testing.T) {
+	setup(t)
+	for i := 0; i < 5; i++ {
+		record(Entry{ID: fmt.Sprint("entry-", i), Time: time.Unix(1000, 0), Outcome: OutcomeSuccess})
+	}
+
+	var IDs []string
+	cursor := ""
+	for page := 0; page < 3; page++ {
+		result, err := Query(Filter{Limit: 2, Cursor: cursor})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, entry := range result.Entries {
+			IDs = append(IDs, entry.ID)
+		}
+		cursor = result.Next
+		if cursor == "" {
+			break
+		}
+	}
+	if strings.Join(IDs, ",") != "entry-4,entry-3,entry-2,entry-1,entry-0" || cursor != "" {
+		t.Error("同一时间的记录不应该覆盖，分页查询应该返回所有记录", IDs, cursor)
+	}
+}
+
+func TestCompleteAfterRestart(t *testing.T) {
+	setup(t)
+	config.G.Server.Audit.Retention = 1
+	defer func() { config.G.Server.Audit.Retention = 0 }()
+	r := engine()
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/virtual_machines/power_off", strings.NewReader(`{"ids":["vm-1"]}`)))
+
+	// 模拟重启，内存中的任务信息丢失
+	pending = sync.Map{}
+	Complete("task-1", OutcomeFailure, nil, "系统中断，任务失去控制")
+	result, err := Query(Filter{RequestID: "task-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Entries) != 2 || result.Entries[0].Event != EventComplete || result.Entries[0].User != "admin" {
+		t.Fatal("重启后任务结束记录应该沿用请求时的调用者信息", result.Entries)
+	}
+	if badgerdb.Has(pendingPrefix + "task-1") {
+		t.Error("任务结束后应该删除保存的任务信息")
+	}
+}
+
+func TestRedactBody(t *testing.T) {
+	body := redactBody([]byte(`{"key":"k1","apiKey":"k2","api_key":"k3","keyFile":"f","name":"vm"}`))
+	for _, secret := range []string{"k1", "k2", "k3"} {
+		if strings.Contains(string(body), secret) {
+			t.Error("请求内容中的密钥没有隐藏", string(body))
+		}
+	}
+	if !strings.Contains(string(body), `"name":"vm"`) || !strings.Contains(string(body), `"keyFile":"f"`) {
+		t.Error("普通字段不应该隐藏", string(body))
+	}
+}
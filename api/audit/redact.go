@@ -0,0 +1,70 @@
+package audit
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+const redacted = "******"
+
+var sensitiveKeys = []string{"password", "passwd", "secret", "token", "authorization", "cookie", "apikey", "api_key"}
+
+// sensitiveNames 名称完全匹配时才隐藏的字段，避免隐藏名称中包含key的普通字段
+var sensitiveNames = []string{"key"}
+
+// redactBody 隐藏请求中的密码等字段，回调的headers全部隐藏，不是JSON的请求不记录内容
+func redactBody(body []byte) json.RawMessage {
+	if len(body) == 0 {
+		return nil
+	}
+	var v interface{}
+	if json.Unmarshal(body, &v) != nil {
+		return nil
+	}
+	data, err := json.Marshal(redact(v, false))
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+func redact(v interface{}, all bool) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, item := range value {
+			if all || sensitive(k) {
+				if _, ok := item.(string); ok {
+					value[k] = redacted
+					continue
+				}
+			}
+			value[k] = redact(item, all || strings.EqualFold(k, "headers"))
+		}
+		return value
+	case []interface{}:
+		for i, item := range value {
+			value[i] = redact(item, all)
+		}
+		return value
+	case string:
+		if all {
+			return redacted
+		}
+	}
+	return v
+}
+
+func sensitive(k string) bool {
+	lower := strings.ToLower(k)
+	for _, s := range sensitiveNames {
+		if lower == s {
+			return true
+		}
+	}
+	for _, s := range sensitiveKeys {
+		if strings.Contains(lower, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package audit
+
+import (
+	"os"
+	"sync"
+)
+
+// Sink 审计记录的外部输出，每条记录为一行JSON
+type Sink interface {
+	Write(data []byte) error
+}
+
+var sinks []Sink
+
+type fileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func newFileSink(path string) (Sink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &fileSink{file: f}, nil
+}
+
+func (s *fileSink) Write(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.file.Write(append(data, '\n'))
+	return err
+}
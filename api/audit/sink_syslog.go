@@ -0,0 +1,28 @@
+//go:build !windows
+// +build !windows
+
+package audit
+
+import (
+	"log/syslog"
+)
+
+type syslogSink struct {
+	writer *syslog.Writer
+}
+
+// newSyslogSink network和address为空时使用本机syslog
+func newSyslogSink(network, address, tag string) (Sink, error) {
+	if tag == "" {
+		tag = "vsphere-facade"
+	}
+	w, err := syslog.Dial(network, address, syslog.LOG_INFO|syslog.LOG_AUTH, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &syslogSink{writer: w}, nil
+}
+
+func (s *syslogSink) Write(data []byte) error {
+	return s.writer.Info(string(data))
+}
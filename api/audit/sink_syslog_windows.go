@@ -0,0 +1,9 @@
+package audit
+
+import (
+	"errors"
+)
+
+func newSyslogSink(network, address, tag string) (Sink, error) {
+	return nil, errors.New("windows不支持syslog")
+}
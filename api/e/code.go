@@ -12,6 +12,13 @@ const (
 	Unauthorized  = "4010"
 	TokenInvalid  = "4011"
 	TokenExpired  = "4012"
+	NoAccount     = "4013"
+	TokenRevoked  = "4014"
+	Forbidden     = "4030"
+	OutOfScope    = "4031"
+	QuotaExceeded = "4032"
 	NotFound      = "4040"
+	TooManyReqs   = "4290"
+	TooManyTasks  = "4291"
 	FAILED        = "9999"
 )
@@ -35,7 +35,7 @@ func ErrHandler(c *gin.Context) {
 				code = SystemError
 				message = GetMessage(code)
 			}
-			logging.L().Error(string(debug.Stack()))
+			logging.C(c.Request.Context()).Error(string(debug.Stack()))
 			c.JSON(http.StatusInternalServerError, Response{
 				Code:    code,
 				Message: message,
@@ -11,6 +11,13 @@ var Message = map[string]string{
 	VMNotFound:    "虚拟机不存在",
 	TokenInvalid:  "token无效",
 	TokenExpired:  "token已过期",
+	NoAccount:     "令牌没有关联VC账号",
+	TokenRevoked:  "token已注销",
+	Forbidden:     "没有权限",
+	OutOfScope:    "超出允许访问的清单范围",
+	QuotaExceeded: "超出租户资源配额",
+	TooManyReqs:   "请求过于频繁，请稍后重试",
+	TooManyTasks:  "排队和执行中的任务过多，请稍后重试",
 	"ServerFaultCode: Cannot complete login due to an incorrect user name or password.": "无法连接VC，账号或密码错误",
 }
 
@@ -3,6 +3,7 @@ package e
 import (
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"vsphere-facade/vsphere/protocol"
 )
 
 type Gin struct {
@@ -13,6 +14,10 @@ type Response struct {
 	Code    string      `json:"code"`
 	Message string      `json:"message"`
 	Data    interface{} `json:"data,omitempty"`
+	// Page 分页信息，只在列表查询接口中返回
+	Page *protocol.Page `json:"page,omitempty"`
+	// Freshness 查询类接口返回数据的新鲜度
+	Freshness *protocol.Freshness `json:"freshness,omitempty"`
 }
 
 type EmptyData struct {
@@ -27,6 +32,17 @@ func (g *Gin) ResponseOk(httpCode int, errCode string, data interface{}) {
 	return
 }
 
+func (g *Gin) ResponseList(httpCode int, errCode string, data interface{}, page protocol.Page, freshness *protocol.Freshness) {
+	g.C.JSON(httpCode, Response{
+		Code:      errCode,
+		Message:   GetMessage(errCode),
+		Data:      data,
+		Page:      &page,
+		Freshness: freshness,
+	})
+	return
+}
+
 func (g *Gin) ResponseError(httpCode int, errCode string, data interface{}) {
 	g.C.JSON(httpCode, Response{
 		Code:    errCode,
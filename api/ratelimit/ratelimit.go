@@ -0,0 +1,148 @@
+package ratelimit
+
+import (
+	"github.com/gin-gonic/gin"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+	"vsphere-facade/api/e"
+	"vsphere-facade/api/security"
+	"vsphere-facade/app/logging"
+	"vsphere-facade/config"
+)
+
+// idleTimeout 超过该时间没有请求的令牌桶会被清理
+const idleTimeout = 10 * time.Minute
+
+// bucket 令牌桶，按时间补充令牌
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// take 取出一个令牌，没有令牌时返回需要等待的时间
+func (b *bucket) take(rate float64, burst int, now time.Time) (bool, time.Duration) {
+	b.tokens = math.Min(float64(burst), b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / rate * float64(time.Second))
+}
+
+// Limiter 按键维护令牌桶
+type Limiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func NewLimiter() *Limiter {
+	return &Limiter{buckets: make(map[string]*bucket), lastSweep: time.Now()}
+}
+
+// Allow rate为每秒补充的令牌数，burst为桶的容量，rate不大于0时不限制
+func (l *Limiter) Allow(key string, rate float64, burst int, now time.Time) (bool, time.Duration) {
+	if rate <= 0 {
+		return true, 0
+	}
+	if burst < 1 {
+		burst = int(math.Ceil(rate))
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(burst), last: now}
+		l.buckets[key] = b
+	}
+	return b.take(rate, burst, now)
+}
+
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < idleTimeout {
+		return
+	}
+	l.lastSweep = now
+	for k, b := range l.buckets {
+		if now.Sub(b.last) > idleTimeout {
+			delete(l.buckets, k)
+		}
+	}
+}
+
+var limiter = NewLimiter()
+
+// ipLimiter 认证前按IP限流，与认证后的调用方分开计算
+var ipLimiter = NewLimiter()
+
+// IPMiddleware 按IP限流，放在security.Verify之前，避免无效的令牌和API Key不受限制地消耗认证
+func IPMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		conf := config.G.Server.RateLimit
+		if !conf.Enable {
+			c.Next()
+			return
+		}
+
+		rate, burst := conf.IPRate, conf.IPBurst
+		if rate <= 0 {
+			rate, burst = conf.Rate, conf.Burst
+		}
+		ok, wait := ipLimiter.Allow(c.ClientIP(), rate, burst, time.Now())
+		if !ok {
+			logging.C(c.Request.Context()).Warnf("IP[%s]请求%s %s过于频繁", c.ClientIP(), c.Request.Method, c.FullPath())
+			TooMany(c, e.TooManyReqs, wait)
+			return
+		}
+		c.Next()
+	}
+}
+
+// Middleware 按调用方和路由限流，需要放在security.Verify之后，不需要认证的接口按IP限流
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		conf := config.G.Server.RateLimit
+		if !conf.Enable {
+			c.Next()
+			return
+		}
+
+		client := security.ClientID(c)
+		now := time.Now()
+		ok, wait := limiter.Allow(client, conf.Rate, conf.Burst, now)
+		if ok {
+			for _, route := range conf.Routes {
+				if route.Path != c.FullPath() || (route.Method != "" && route.Method != c.Request.Method) {
+					continue
+				}
+				ok, wait = limiter.Allow(client+" "+c.Request.Method+" "+route.Path, route.Rate, route.Burst, now)
+				break
+			}
+		}
+		if !ok {
+			logging.C(c.Request.Context()).Warnf("调用方[%s]请求%s %s过于频繁", client, c.Request.Method, c.FullPath())
+			TooMany(c, e.TooManyReqs, wait)
+			return
+		}
+		c.Next()
+	}
+}
+
+// TooMany 返回429，Retry-After向上取整到秒
+func TooMany(c *gin.Context, code string, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.JSON(http.StatusTooManyRequests, e.Response{
+		Code:    code,
+		Message: e.GetMessage(code),
+	})
+	c.Abort()
+}
@@ -0,0 +1,110 @@
+package ratelimit
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+	"vsphere-facade/app/logging"
+	"vsphere-facade/config"
+)
+
+func TestLimiter(t *testing.T) {
+	l := NewLimiter()
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.Allow("k", 1, 3, now); !ok {
+			t.Fatal("突发请求数内应该允许", i)
+		}
+	}
+	ok, wait := l.Allow("k", 1, 3, now)
+	if ok || wait <= 0 || wait > time.Second {
+		t.Error("超过突发请求数应该拒绝并返回等待时间", ok, wait)
+	}
+	if ok, _ = l.Allow("other", 1, 3, now); !ok {
+		t.Error("不同的键单独计算")
+	}
+	if ok, _ = l.Allow("k", 1, 3, now.Add(time.Second)); !ok {
+		t.Error("补充令牌后应该允许")
+	}
+	if ok, _ = l.Allow("k", 0, 0, now); !ok {
+		t.Error("rate为0时不限制")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	config.G.Server.Log.Path = os.TempDir()
+	config.G.Server.Log.Level = "error"
+	logging.Setup()
+	gin.SetMode(gin.TestMode)
+	conf := &config.G.Server.RateLimit
+	conf.Enable = true
+	conf.Rate = 100
+	conf.Burst = 100
+	conf.Routes = []config.RateLimitRoute{{Method: http.MethodPost, Path: "/limited", Rate: 0.1, Burst: 1}}
+	defer func() { config.G.Server.RateLimit.Enable = false }()
+	limiter = NewLimiter()
+
+	r := gin.New()
+	r.Use(Middleware())
+	r.POST("/limited", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/limited", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	do := func(method string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, "/limited", nil))
+		return w
+	}
+	if w := do(http.MethodPost); w.Code != http.StatusOK {
+		t.Fatal("第一次请求应该允许", w.Code)
+	}
+	w := do(http.MethodPost)
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "10" {
+		t.Error("超过路由限制应该返回429和Retry-After", w.Code, w.Header())
+	}
+	if w = do(http.MethodGet); w.Code != http.StatusOK {
+		t.Error("其他方法不受路由限制", w.Code)
+	}
+}
+
+func TestIPMiddleware(t *testing.T) {
+	config.G.Server.Log.Path = os.TempDir()
+	config.G.Server.Log.Level = "error"
+	logging.Setup()
+	gin.SetMode(gin.TestMode)
+	conf := &config.G.Server.RateLimit
+	conf.Enable = true
+	conf.Rate, conf.Burst = 100, 100
+	conf.IPRate, conf.IPBurst = 0.1, 2
+	defer func() { config.G.Server.RateLimit.Enable = false }()
+	ipLimiter = NewLimiter()
+
+	verified := 0
+	r := gin.New()
+	r.Use(IPMiddleware())
+	r.GET("/verify", func(c *gin.Context) {
+		verified++
+		c.Status(http.StatusUnauthorized)
+	})
+
+	do := func(ip string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/verify", nil)
+		req.RemoteAddr = ip + ":12345"
+		r.ServeHTTP(w, req)
+		return w
+	}
+	for i := 0; i < 2; i++ {
+		if w := do("10.0.0.1"); w.Code != http.StatusUnauthorized {
+			t.Fatal("突发请求数内应该交给认证处理", w.Code)
+		}
+	}
+	if w := do("10.0.0.1"); w.Code != http.StatusTooManyRequests || verified != 2 {
+		t.Error("超过IP限制时应该在认证前返回429", w.Code, verified)
+	}
+	if w := do("10.0.0.2"); w.Code != http.StatusUnauthorized {
+		t.Error("不同的IP单独计算", w.Code)
+	}
+}
@@ -0,0 +1,148 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"sync"
+	"time"
+	"vsphere-facade/app/logging"
+	"vsphere-facade/config"
+	"vsphere-facade/db/badgerdb"
+	"vsphere-facade/helper"
+	"vsphere-facade/vsphere/workerpool"
+)
+
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusDisabled = "disabled"
+	// StatusDegraded 非必需的检查失败，服务仍然就绪
+	StatusDegraded = "degraded"
+)
+
+// ComponentStatus 单项检查结果
+type ComponentStatus struct {
+	Name      string      `json:"name"`
+	Status    string      `json:"status"`
+	LatencyMs float64     `json:"latencyMs"`
+	Error     string      `json:"error,omitempty"`
+	Details   interface{} `json:"details,omitempty"`
+}
+
+type HealthStatus struct {
+	Status string `json:"status"`
+}
+
+type healthCheck struct {
+	name string
+	// optional 检查失败时只标记为degraded，不影响就绪
+	optional bool
+	check    func(ctx context.Context) (details interface{}, err error)
+}
+
+// Healthz 进程存活检查
+func Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, HealthStatus{Status: StatusUp})
+}
+
+// Readyz 就绪检查，必需的检查失败时返回503，VC连接失败时为degraded，不影响其他VC的请求。
+// 不需要认证，只返回汇总状态，每项检查的结果记录在日志中
+func Readyz(c *gin.Context) {
+	components := runChecks(c.Request.Context(), readyChecks())
+	status := HealthStatus{Status: StatusUp}
+	httpCode := http.StatusOK
+	for _, component := range components {
+		if component.Status == StatusDown || component.Status == StatusDegraded {
+			logging.L().Warnf("就绪检查[%s]失败: %s", component.Name, component.Error)
+		}
+		if component.Status == StatusDown {
+			status.Status = StatusDown
+			httpCode = http.StatusServiceUnavailable
+		}
+		if component.Status == StatusDegraded && status.Status == StatusUp {
+			status.Status = StatusDegraded
+		}
+	}
+	c.JSON(httpCode, status)
+}
+
+func readyChecks() []healthCheck {
+	checks := []healthCheck{badgerCheck(), workerPoolCheck()}
+	for _, api := range helper.APIs() {
+		checks = append(checks, sessionCheck(api))
+	}
+	return checks
+}
+
+// runChecks 并发执行检查，每项检查单独计时和超时
+func runChecks(ctx context.Context, checks []healthCheck) []ComponentStatus {
+	timeout := time.Duration(config.G.Server.Health.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	components := make([]ComponentStatus, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check healthCheck) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			start := time.Now()
+			details, err := check.check(checkCtx)
+			component := ComponentStatus{
+				Name:      check.name,
+				Status:    StatusUp,
+				LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+				Details:   details,
+			}
+			if err == errDisabled {
+				component.Status = StatusDisabled
+			} else if err != nil {
+				component.Status = StatusDown
+				if check.optional {
+					component.Status = StatusDegraded
+				}
+				component.Error = err.Error()
+			}
+			components[i] = component
+		}(i, check)
+	}
+	wg.Wait()
+	return components
+}
+
+var errDisabled = fmt.Errorf("未启用")
+
+func badgerCheck() healthCheck {
+	return healthCheck{name: "badger", check: func(ctx context.Context) (interface{}, error) {
+		if !badgerdb.Enabled() {
+			return nil, errDisabled
+		}
+		return nil, badgerdb.Check()
+	}}
+}
+
+// workerPoolCheck 工作池满负荷且排队任务超过maxQueued时视为未就绪
+func workerPoolCheck() healthCheck {
+	return healthCheck{name: "worker_pool", check: func(ctx context.Context) (interface{}, error) {
+		statuses := workerpool.Statuses()
+		maxQueued := config.G.Server.Health.MaxQueued
+		for _, s := range statuses {
+			if s.Running >= s.Capacity && s.Queued > maxQueued {
+				return statuses, fmt.Errorf("VC[%s]的%s工作池已满，排队任务数%d", s.VCID, s.Type, s.Queued)
+			}
+		}
+		return statuses, nil
+	}}
+}
+
+// sessionCheck 只返回VC的地址，不暴露登录的账号
+func sessionCheck(api *helper.API) healthCheck {
+	return healthCheck{name: "vcenter:" + api.ID, optional: true, check: func(ctx context.Context) (interface{}, error) {
+		details := map[string]string{"address": api.Client.URL().Host}
+		return details, api.CheckSession(ctx)
+	}}
+}
@@ -0,0 +1,98 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/vmware/govmomi/simulator"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+	"vsphere-facade/app/logging"
+	"vsphere-facade/config"
+	"vsphere-facade/helper"
+)
+
+func readyz(t *testing.T) (int, HealthStatus) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/readyz", Readyz)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	var body map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(body) != 1 {
+		t.Fatal("就绪检查只返回汇总状态", body)
+	}
+	return w.Code, HealthStatus{Status: body["status"].(string)}
+}
+
+func component(components []ComponentStatus, prefix string) *ComponentStatus {
+	for i := range components {
+		if strings.HasPrefix(components[i].Name, prefix) {
+			return &components[i]
+		}
+	}
+	return nil
+}
+
+func TestReadyz(t *testing.T) {
+	config.G.Server.Log.Path = os.TempDir()
+	config.G.Server.Log.Level = "error"
+	logging.Setup()
+	helper.APITimeout = time.Minute
+
+	model := simulator.VPX()
+	defer model.Remove()
+	err := model.Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := model.Service.NewServer()
+	defer s.Close()
+
+	password, _ := s.URL.User.Password()
+	api, err := helper.Init(s.URL.Scheme+"://"+s.URL.Host, s.URL.User.Username(), password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, status := readyz(t)
+	if code != http.StatusOK || status.Status != StatusUp {
+		t.Fatal("就绪检查应该成功", code, status)
+	}
+	components := runChecks(context.Background(), readyChecks())
+	if c := component(components, "badger"); c == nil || c.Status != StatusDisabled {
+		t.Error("没有配置badger时应为disabled", c)
+	}
+	if c := component(components, "worker_pool"); c == nil || c.Status != StatusUp {
+		t.Error("工作池检查应该成功", c)
+	}
+	c := component(components, "vcenter:"+api.ID)
+	if c == nil || c.Status != StatusUp {
+		t.Fatal("VC会话检查应该成功", c)
+	}
+	if details := c.Details.(map[string]string); details["address"] != s.URL.Host {
+		t.Error("只返回VC的地址，不返回账号", details)
+	}
+
+	err = api.Client.Logout(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, status = readyz(t)
+	if code != http.StatusOK || status.Status != StatusDegraded {
+		t.Error("VC会话失效时服务仍然就绪，状态为degraded", code, status)
+	}
+	components = runChecks(context.Background(), readyChecks())
+	if c := component(components, "vcenter:"+api.ID); c == nil || c.Status != StatusDegraded || c.Error == "" {
+		t.Error("VC会话检查应该失败", c)
+	}
+}
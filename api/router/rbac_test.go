@@ -0,0 +1,78 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"vsphere-facade/api/e"
+	"vsphere-facade/api/security"
+	"vsphere-facade/api/security/bearer"
+	"vsphere-facade/app/logging"
+	"vsphere-facade/app/utils"
+	"vsphere-facade/config"
+	"vsphere-facade/vsphere"
+)
+
+func call(t *testing.T, method, path, token string) (int, e.Response) {
+	r := InitRouter()
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, strings.NewReader(`{"ids":["vm-1"]}`))
+	req.Header.Set("token", token)
+	r.ServeHTTP(w, req)
+
+	res := e.Response{}
+	err := json.Unmarshal(w.Body.Bytes(), &res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w.Code, res
+}
+
+func TestRoles(t *testing.T) {
+	config.G.Server.Log.Path = os.TempDir()
+	config.G.Server.Log.Level = "error"
+	logging.Setup()
+	utils.SetupKeys()
+	config.G.App.Token.Type = bearer.Type
+	config.G.App.Token.CredentialRole = "viewer"
+	config.G.App.Admin.Key = "bootstrap-admin-key"
+	security.Setup()
+	defer func() {
+		config.G.App.Token.CredentialRole = ""
+		config.G.App.Admin.Key = ""
+	}()
+
+	viewer, err := bearer.Token{}.Generate(vsphere.Auth{Address: "https://vc1", Username: "ro", Password: "pass"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, route := range [][]string{
+		{http.MethodPost, "/api/v1/virtual_machines/power_off"},
+		{http.MethodDelete, "/api/v1/virtual_machines"},
+		{http.MethodPost, "/api/v1/caches"},
+		{http.MethodGet, "/api/v1/admin/api_keys"},
+		{http.MethodGet, "/api/v1/admin/audit"},
+	} {
+		code, res := call(t, route[0], route[1], viewer)
+		if code != http.StatusForbidden || res.Code != e.Forbidden {
+			t.Errorf("viewer不能访问%s %s: %d %v", route[0], route[1], code, res)
+		}
+	}
+
+	code, res := call(t, http.MethodGet, "/api/v1/virtual_machines", config.G.App.Admin.Key)
+	if code != http.StatusForbidden || res.Code != e.NoAccount {
+		t.Error("管理员启动密钥没有VC账号，不能访问VC接口", code, res)
+	}
+	code, res = call(t, http.MethodGet, "/api/v1/admin/audit", config.G.App.Admin.Key)
+	if code != http.StatusOK {
+		t.Error("管理员启动密钥可以查询审计记录", code, res)
+	}
+	code, res = call(t, http.MethodGet, "/api/v1/virtual_machines", "")
+	if code != http.StatusUnauthorized {
+		t.Error("没有令牌应该返回401", code, res)
+	}
+}
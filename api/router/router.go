@@ -5,9 +5,16 @@ import (
 	"github.com/gin-gonic/gin"
 	"io/fs"
 	"net/http"
+	"vsphere-facade/api/audit"
 	"vsphere-facade/api/e"
+	"vsphere-facade/api/ratelimit"
 	"vsphere-facade/api/security"
+	"vsphere-facade/api/security/identity"
 	v1 "vsphere-facade/api/v1"
+	"vsphere-facade/app/logging"
+	"vsphere-facade/app/metrics"
+	"vsphere-facade/app/tracing"
+	"vsphere-facade/config"
 	_ "vsphere-facade/docs"
 )
 
@@ -16,6 +23,14 @@ func InitRouter() *gin.Engine {
 	r.NoRoute(e.HandlerNotFound)
 	r.NoMethod(e.HandlerNotFound)
 	r.Use(e.ErrHandler)
+	r.Use(logging.Middleware())
+	if config.G.Server.Tracing.Enable {
+		r.Use(tracing.Middleware())
+	}
+	if config.G.Server.Metrics.Enable {
+		r.Use(metrics.Middleware())
+		r.GET("/metrics", metrics.Handler())
+	}
 	favicon(r)
 
 	r.GET("/", Index)
@@ -23,42 +38,105 @@ func InitRouter() *gin.Engine {
 		c.JSON(http.StatusOK, map[string]string{"status": "on"})
 
 	})
-	r.POST("/api/token", security.GetToken)
+	r.GET("/healthz", Healthz)
+	r.GET("/readyz", Readyz)
+	r.POST("/api/token", ratelimit.Middleware(), security.GetToken)
+	r.POST("/api/token/refresh", ratelimit.Middleware(), security.RefreshToken)
+	r.POST("/api/token/revoke", ratelimit.IPMiddleware(), security.Verify(), ratelimit.Middleware(), security.RevokeToken)
+
+	// 角色由低到高: viewer只读，operator日常操作，deployer创建删除和修改配置，admin管理
+	admin := r.Group("/api/v1/admin")
+	admin.Use(ratelimit.IPMiddleware())
+	admin.Use(security.Verify())
+	admin.Use(ratelimit.Middleware())
+	admin.Use(audit.Middleware())
+	admin.Use(security.RequireRole(identity.RoleAdmin))
+	{
+		admin.GET("/accounts", v1.QueryAccounts)
+		admin.POST("/accounts", v1.CreateAccount)
+		admin.DELETE("/accounts/:id", v1.DeleteAccount)
+		admin.GET("/api_keys", v1.QueryAPIKeys)
+		admin.POST("/api_keys", v1.CreateAPIKey)
+		admin.DELETE("/api_keys/:id", v1.DisableAPIKey)
+
+		// 审计
+		admin.GET("/audit", v1.QueryAudit)
+	}
+
+	// 登记的VC使用服务账号连接，不需要令牌关联VC账号
+	r.GET("/api/v1/vcenters", ratelimit.IPMiddleware(), security.Verify(), ratelimit.Middleware(), audit.Middleware(),
+		security.RequireRole(identity.RoleViewer), v1.QueryVCenters)
 
 	apiV1 := r.Group("/api/v1")
+	apiV1.Use(ratelimit.IPMiddleware())
 	apiV1.Use(security.Verify())
+	apiV1.Use(security.RequireAccount())
+	apiV1.Use(ratelimit.Middleware())
+	apiV1.Use(audit.Middleware())
+
+	viewer := apiV1.Group("", security.RequireRole(identity.RoleViewer))
 	{
-		apiV1.GET("/datacenters", v1.QueryDatacenters)
-		apiV1.GET("/clusters", v1.QueryClusters)
-		apiV1.GET("/clusters/:clusterID/os_families", v1.GetClusterOSFamilies)
-		apiV1.GET("/hosts", v1.QueryHosts)
-		apiV1.GET("/hosts/:hostID/os_families", v1.GetHostOSFamilies)
-		apiV1.GET("/networks", v1.QueryNetworks)
-		apiV1.GET("/datastores", v1.QueryDatastores)
-		apiV1.GET("/resource_pools", v1.QueryResourcePools)
-		apiV1.GET("/storage_policies", v1.QueryStoragePolies)
-		apiV1.GET("/folders", v1.QueryFolders)
-		apiV1.GET("/templates", v1.QueryTemplates)
+		viewer.GET("/datacenters", v1.QueryDatacenters)
+		viewer.GET("/clusters", v1.QueryClusters)
+		viewer.GET("/clusters/:clusterID/os_families", v1.GetClusterOSFamilies)
+		viewer.GET("/clusters/:clusterID/performance", v1.GetClusterPerformance)
+		viewer.GET("/hosts", v1.QueryHosts)
+		viewer.GET("/hosts/:hostID/os_families", v1.GetHostOSFamilies)
+		viewer.GET("/hosts/:hostID/performance", v1.GetHostPerformance)
+		viewer.GET("/networks", v1.QueryNetworks)
+		viewer.GET("/datastores", v1.QueryDatastores)
+		viewer.GET("/datastores/:id/performance", v1.GetDatastorePerformance)
+		viewer.GET("/resource_pools", v1.QueryResourcePools)
+		viewer.GET("/storage_policies", v1.QueryStoragePolies)
+		viewer.GET("/folders", v1.QueryFolders)
+		viewer.GET("/templates", v1.QueryTemplates)
+
+		// 标签和自定义属性
+		viewer.GET("/tag_categories", v1.QueryTagCategories)
+		viewer.GET("/tags", v1.QueryTags)
+		viewer.GET("/custom_attributes", v1.QueryCustomAttributes)
 
 		// 虚拟机
-		apiV1.GET("/virtual_machines", v1.QueryVirtualMachines)
-		apiV1.POST("/virtual_machines", v1.CreateVirtualMachine)
-		apiV1.DELETE("/virtual_machines", v1.DeleteVirtualMachine)
-		apiV1.POST("/virtual_machines/power_on", v1.VirtualMachinePowerOn)
-		apiV1.POST("/virtual_machines/power_off", v1.VirtualMachinePowerOff)
-		apiV1.POST("/virtual_machines/shutdown", v1.VirtualMachineShutdown)
-		apiV1.POST("/virtual_machines/rename", v1.VirtualMachineRename)
-		apiV1.POST("/virtual_machines/reconfigure", v1.ModifyVirtualMachineConfigure)
-		apiV1.POST("/virtual_machines/reconfigure_disk", v1.ReconfigureVirtualMachineDisk)
-		apiV1.POST("/virtual_machines/reconfigure_nic", v1.ReconfigureVirtualMachineNic)
-		apiV1.POST("/virtual_machines/description", v1.VirtualMachineDescript)
+		viewer.GET("/virtual_machines", v1.QueryVirtualMachines)
+		viewer.GET("/virtual_machines/:id", v1.GetVirtualMachine)
+		viewer.GET("/virtual_machines/:id/extra_config", v1.GetVirtualMachineExtraConfig)
+		viewer.GET("/virtual_machines/:id/performance", v1.GetVirtualMachinePerformance)
 
-		// 缓存
-		apiV1.DELETE("caches", v1.CleanCache)
-		apiV1.POST("caches", v1.CreateCache)
+		// 性能
+		viewer.GET("/performance_counters", v1.QueryPerformanceCounters)
+		viewer.POST("/virtual_machines/performance", audit.Skip, v1.QueryVirtualMachinesPerformance)
+	}
+
+	operator := apiV1.Group("", security.RequireRole(identity.RoleOperator))
+	{
+		operator.POST("/tags/attach", v1.AttachTags)
+		operator.POST("/tags/detach", v1.DetachTags)
+		operator.POST("/custom_attributes", v1.SetCustomAttributes)
+
+		operator.POST("/virtual_machines/power_on", v1.VirtualMachinePowerOn)
+		operator.POST("/virtual_machines/power_off", v1.VirtualMachinePowerOff)
+		operator.POST("/virtual_machines/shutdown", v1.VirtualMachineShutdown)
+		operator.POST("/virtual_machines/rename", v1.VirtualMachineRename)
+		operator.POST("/virtual_machines/description", v1.VirtualMachineDescript)
 
 		// 测试
-		apiV1.POST("/test_call_back", v1.ReceiveCallBackData)
+		operator.POST("/test_call_back", audit.Skip, v1.ReceiveCallBackData)
+	}
+
+	deployer := apiV1.Group("", security.RequireRole(identity.RoleDeployer))
+	{
+		deployer.POST("/virtual_machines", v1.CreateVirtualMachine)
+		deployer.DELETE("/virtual_machines", v1.DeleteVirtualMachine)
+		deployer.POST("/virtual_machines/reconfigure", v1.ModifyVirtualMachineConfigure)
+		deployer.POST("/virtual_machines/reconfigure_disk", v1.ReconfigureVirtualMachineDisk)
+		deployer.POST("/virtual_machines/reconfigure_nic", v1.ReconfigureVirtualMachineNic)
+	}
+
+	administrator := apiV1.Group("", security.RequireRole(identity.RoleAdmin))
+	{
+		// 缓存
+		administrator.DELETE("caches", v1.CleanCache)
+		administrator.POST("caches", v1.CreateCache)
 	}
 	return r
 }
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"vsphere-facade/api/e"
+	"vsphere-facade/api/security/identity"
 	"vsphere-facade/app/utils"
 	"vsphere-facade/vsphere"
 )
@@ -21,14 +22,14 @@ func (t Token) Generate(a vsphere.Auth) (string, error) {
 	return utils.AesEncrypt(string(b)), nil
 }
 
-func (t Token) Parse(token string) (*vsphere.Auth, error) {
+func (t Token) Parse(token string) (*identity.Principal, error) {
 	var a vsphere.Auth
 	deToken := utils.AesDecrypt(token)
 	err := json.Unmarshal([]byte(deToken), &a)
 	if err != nil {
 		return nil, fmt.Errorf(e.TokenInvalid)
 	}
-	return &a, nil
+	return identity.CredentialPrincipal(a), nil
 }
 
 func (t Token) Type() string {
@@ -0,0 +1,404 @@
+package identity
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"strings"
+	"time"
+	"vsphere-facade/app/logging"
+	"vsphere-facade/app/utils"
+	"vsphere-facade/config"
+	"vsphere-facade/db/badgerdb"
+	"vsphere-facade/vsphere"
+)
+
+const (
+	accountPrefix = "identity::account::"
+	keyPrefix     = "identity::apikey::"
+	// KeyPrefix API Key的前缀，格式为vfk_<id>.<secret>
+	KeyPrefix = "vfk_"
+)
+
+// 角色由低到高，高级角色包含低级角色的权限
+const (
+	// RoleViewer 只读
+	RoleViewer = "viewer"
+	// RoleOperator 开关机、重命名、标签等日常操作
+	RoleOperator = "operator"
+	// RoleDeployer 创建、删除虚拟机和修改配置
+	RoleDeployer = "deployer"
+	// RoleAdmin 缓存、审计和账号管理
+	RoleAdmin = "admin"
+)
+
+var roleLevels = map[string]int{
+	RoleViewer:   1,
+	RoleOperator: 2,
+	RoleDeployer: 3,
+	RoleAdmin:    4,
+}
+
+// ValidRole 是否是已定义的角色
+func ValidRole(role string) bool {
+	_, ok := roleLevels[role]
+	return ok
+}
+
+var (
+	ErrNotEnabled  = errors.New("账号和API Key需要配置badger DB")
+	ErrKeyInvalid  = errors.New("API Key无效")
+	ErrKeyExpired  = errors.New("API Key已过期")
+	ErrKeyDisabled = errors.New("API Key已停用")
+)
+
+// Principal 认证后的调用者
+type Principal struct {
+	// Subject 调用者标识，API Key为key:<名称>，VC账号为用户名
+	Subject   string
+	KeyID     string
+	AccountID string
+	Roles     []string
+	// Auth 调用者使用的VC账号，管理员启动密钥没有VC账号
+	Auth *vsphere.Auth
+	// Scope 允许访问的清单范围，为空时不限制
+	Scope *vsphere.Scope
+	// TokenID 可以注销的令牌的ID
+	TokenID string
+	// Tenant 资源配额所属的租户，为空时不限制
+	Tenant string
+}
+
+// HasRole 调用者有该角色或更高级的角色
+func (p Principal) HasRole(role string) bool {
+	level, ok := roleLevels[role]
+	if !ok {
+		return false
+	}
+	for _, r := range p.Roles {
+		if roleLevels[r] >= level {
+			return true
+		}
+	}
+	return false
+}
+
+// Account 管理员登记的VC服务账号，密码加密保存
+type Account struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Address   string    `json:"address"`
+	Username  string    `json:"username"`
+	Password  string    `json:"password,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// APIKey 发放给调用方的密钥，只保存密钥的哈希值
+type APIKey struct {
+	ID        string         `json:"id"`
+	Name      string         `json:"name"`
+	AccountID string         `json:"accountId"`
+	Roles     []string       `json:"roles"`
+	Scope     *vsphere.Scope `json:"scope,omitempty"`
+	Tenant    string         `json:"tenant,omitempty"`
+	Hash      string         `json:"hash,omitempty"`
+	Disabled  bool           `json:"disabled"`
+	CreatedAt time.Time      `json:"createdAt"`
+	ExpiresAt *time.Time     `json:"expiresAt,omitempty"`
+}
+
+// CredentialPrincipal 使用VC账号密码获取的令牌，角色使用配置app.token.credentialRole，默认为deployer
+func CredentialPrincipal(a vsphere.Auth) *Principal {
+	role := config.G.App.Token.CredentialRole
+	if role == "" {
+		role = RoleDeployer
+	}
+	return &Principal{
+		Subject: a.Username,
+		Roles:   []string{role},
+		Auth:    &a,
+	}
+}
+
+// CreateAccount 登记VC服务账号，返回的账号不包含密码
+func CreateAccount(name string, auth vsphere.Auth) (*Account, error) {
+	if !badgerdb.Enabled() {
+		return nil, ErrNotEnabled
+	}
+	account := Account{
+		ID:        uuid.NewString(),
+		Name:      name,
+		Address:   auth.Address,
+		Username:  auth.Username,
+		Password:  utils.AesEncrypt(auth.Password),
+		CreatedAt: time.Now(),
+	}
+	err := save(accountPrefix+account.ID, account)
+	if err != nil {
+		return nil, err
+	}
+	account.Password = ""
+	return &account, nil
+}
+
+// GetAccount 返回的账号包含加密后的密码
+func GetAccount(ID string) (*Account, error) {
+	account := Account{}
+	ok, err := load(accountPrefix+ID, &account)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// Accounts 所有账号，不包含密码
+func Accounts() ([]Account, error) {
+	accounts := []Account{}
+	err := list(accountPrefix, func(v string) error {
+		account := Account{}
+		err := json.Unmarshal([]byte(v), &account)
+		account.Password = ""
+		accounts = append(accounts, account)
+		return err
+	})
+	return accounts, err
+}
+
+// DeleteAccount 还有API Key使用的账号不能删除
+func DeleteAccount(ID string) error {
+	keys, err := APIKeys()
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		if k.AccountID == ID {
+			return fmt.Errorf("账号还在被API Key[%s]使用", k.Name)
+		}
+	}
+	return badgerdb.Del(accountPrefix + ID)
+}
+
+// Auth 解密后的VC认证信息
+func (a Account) Auth() vsphere.Auth {
+	return vsphere.Auth{
+		Address:  a.Address,
+		Username: a.Username,
+		Password: utils.AesDecrypt(a.Password),
+	}
+}
+
+// CheckKeys badger DB中保存了VC账号时必须配置加密密钥，否则重启后账号的密码无法解密，拒绝启动
+func CheckKeys() {
+	if !utils.TemporaryKey() {
+		return
+	}
+	stored, err := hasAccounts()
+	if err != nil {
+		logging.L().Panic("读取保存的VC账号失败", err)
+	}
+	if stored {
+		logging.L().Panicf("badger DB中保存了VC账号，需要通过环境变量%s、app.crypto.keyFile或app.crypto.keys配置加密密钥", utils.EnvKeys)
+	}
+}
+
+func hasAccounts() (bool, error) {
+	stored := false
+	err := badgerdb.Iterate(accountPrefix, false, func(k, v string) bool {
+		stored = true
+		return false
+	})
+	return stored, err
+}
+
+// Reencrypt 使用当前密钥重新加密保存的账号密码，用于密钥轮换，返回重新加密的账号数量
+func Reencrypt() (int, error) {
+	var accounts []Account
+	err := list(accountPrefix, func(v string) error {
+		account := Account{}
+		err := json.Unmarshal([]byte(v), &account)
+		if err == nil && utils.KeyIDOf(account.Password) != utils.CurrentKeyID() {
+			accounts = append(accounts, account)
+		}
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	for i, account := range accounts {
+		password := utils.AesDecrypt(account.Password)
+		if password == "" {
+			return i, fmt.Errorf("账号[%s]的密码解密失败", account.Name)
+		}
+		account.Password = utils.AesEncrypt(password)
+		err = save(accountPrefix+account.ID, account)
+		if err != nil {
+			return i, err
+		}
+	}
+	return len(accounts), nil
+}
+
+// CreateKey 发放API Key，使用k中的名称、账号、角色、范围和过期时间，返回的key只在创建时返回一次
+func CreateKey(k APIKey) (string, *APIKey, error) {
+	if !badgerdb.Enabled() {
+		return "", nil, ErrNotEnabled
+	}
+	for _, role := range k.Roles {
+		if !ValidRole(role) {
+			return "", nil, fmt.Errorf("角色[%s]不存在", role)
+		}
+	}
+	if k.AccountID != "" {
+		account, err := GetAccount(k.AccountID)
+		if err != nil {
+			return "", nil, err
+		}
+		if account == nil {
+			return "", nil, fmt.Errorf("账号[%s]不存在", k.AccountID)
+		}
+	}
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", nil, err
+	}
+	s := hex.EncodeToString(secret)
+	apiKey := APIKey{
+		ID:        strings.ReplaceAll(uuid.NewString(), "-", ""),
+		Name:      k.Name,
+		AccountID: k.AccountID,
+		Roles:     k.Roles,
+		Scope:     k.Scope,
+		Tenant:    k.Tenant,
+		Hash:      hash(s),
+		CreatedAt: time.Now(),
+		ExpiresAt: k.ExpiresAt,
+	}
+	err = save(keyPrefix+apiKey.ID, apiKey)
+	if err != nil {
+		return "", nil, err
+	}
+	apiKey.Hash = ""
+	return KeyPrefix + apiKey.ID + "." + s, &apiKey, nil
+}
+
+// APIKeys 所有API Key，不包含哈希值
+func APIKeys() ([]APIKey, error) {
+	keys := []APIKey{}
+	err := list(keyPrefix, func(v string) error {
+		k := APIKey{}
+		err := json.Unmarshal([]byte(v), &k)
+		k.Hash = ""
+		keys = append(keys, k)
+		return err
+	})
+	return keys, err
+}
+
+// DisableKey 停用API Key，保留记录用于审计
+func DisableKey(ID string) error {
+	k := APIKey{}
+	ok, err := load(keyPrefix+ID, &k)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("API Key[%s]不存在", ID)
+	}
+	k.Disabled = true
+	return save(keyPrefix+ID, k)
+}
+
+// Authenticate 校验API Key，返回调用者和对应的VC账号
+func Authenticate(key string) (*Principal, error) {
+	if !badgerdb.Enabled() {
+		return nil, ErrNotEnabled
+	}
+	if !strings.HasPrefix(key, KeyPrefix) {
+		return nil, ErrKeyInvalid
+	}
+	parts := strings.SplitN(strings.TrimPrefix(key, KeyPrefix), ".", 2)
+	if len(parts) != 2 {
+		return nil, ErrKeyInvalid
+	}
+	k := APIKey{}
+	ok, err := load(keyPrefix+parts[0], &k)
+	if err != nil {
+		return nil, err
+	}
+	if !ok || subtle.ConstantTimeCompare([]byte(k.Hash), []byte(hash(parts[1]))) != 1 {
+		return nil, ErrKeyInvalid
+	}
+	if k.Disabled {
+		return nil, ErrKeyDisabled
+	}
+	if k.ExpiresAt != nil && time.Now().After(*k.ExpiresAt) {
+		return nil, ErrKeyExpired
+	}
+
+	principal := &Principal{
+		Subject:   "key:" + k.Name,
+		KeyID:     k.ID,
+		AccountID: k.AccountID,
+		Roles:     k.Roles,
+		Scope:     k.Scope,
+		Tenant:    k.Tenant,
+	}
+	if k.AccountID != "" {
+		account, err := GetAccount(k.AccountID)
+		if err != nil {
+			return nil, err
+		}
+		if account == nil {
+			return nil, fmt.Errorf("API Key对应的账号[%s]不存在", k.AccountID)
+		}
+		auth := account.Auth()
+		principal.Auth = &auth
+	}
+	return principal, nil
+}
+
+func hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func save(k string, v interface{}) error {
+	if !badgerdb.Enabled() {
+		return ErrNotEnabled
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	badgerdb.Set(k, string(b))
+	return nil
+}
+
+func load(k string, v interface{}) (bool, error) {
+	s := badgerdb.Get(k)
+	if s == "" {
+		return false, nil
+	}
+	return true, json.Unmarshal([]byte(s), v)
+}
+
+func list(prefix string, fn func(v string) error) error {
+	if !badgerdb.Enabled() {
+		return ErrNotEnabled
+	}
+	var err error
+	iterErr := badgerdb.Iterate(prefix, false, func(k, v string) bool {
+		err = fn(v)
+		return err == nil
+	})
+	if iterErr != nil {
+		return iterErr
+	}
+	return err
+}
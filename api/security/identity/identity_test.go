@@ -0,0 +1,118 @@
+package identity
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"vsphere-facade/app/utils"
+	"vsphere-facade/db/badgerdb/badgertest"
+	"vsphere-facade/vsphere"
+)
+
+func TestAPIKey(t *testing.T) {
+	badgertest.Setup(t)
+
+	account, err := CreateAccount("deployer", vsphere.Auth{Address: "https://vc1", Username: "svc", Password: "P@ss"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.Password != "" {
+		t.Error("返回的账号不应该包含密码")
+	}
+	stored, _ := GetAccount(account.ID)
+	if stored == nil || stored.Password == "P@ss" {
+		t.Fatal("密码应该加密保存", stored)
+	}
+
+	key, apiKey, err := CreateKey(APIKey{Name: "ci", AccountID: account.ID, Roles: []string{RoleDeployer}, Tenant: "team-a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(key, KeyPrefix+apiKey.ID+".") {
+		t.Fatal("API Key格式不正确", key)
+	}
+
+	principal, err := Authenticate(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if principal.Subject != "key:ci" || !principal.HasRole(RoleOperator) || principal.HasRole(RoleAdmin) || principal.Auth == nil ||
+		principal.Auth.Username != "svc" || principal.Auth.Password != "P@ss" || principal.Tenant != "team-a" {
+		t.Error("认证结果不正确", principal)
+	}
+
+	if _, err = Authenticate(KeyPrefix + apiKey.ID + ".wrong"); err != ErrKeyInvalid {
+		t.Error("错误的密钥应该认证失败", err)
+	}
+	if err = DeleteAccount(account.ID); err == nil {
+		t.Error("还有API Key使用的账号不能删除")
+	}
+
+	if err = DisableKey(apiKey.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = Authenticate(key); err != ErrKeyDisabled {
+		t.Error("停用的API Key应该认证失败", err)
+	}
+
+	expiresAt := time.Now().Add(-time.Minute)
+	key, _, err = CreateKey(APIKey{Name: "expired", Roles: []string{RoleAdmin}, ExpiresAt: &expiresAt})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = Authenticate(key); err != ErrKeyExpired {
+		t.Error("过期的API Key应该认证失败", err)
+	}
+
+	if _, _, err = CreateKey(APIKey{Name: "unknown", Roles: []string{"root"}}); err == nil {
+		t.Error("不存在的角色不能发放API Key")
+	}
+}
+
+func TestReencrypt(t *testing.T) {
+	badgertest.Setup(t)
+
+	account, err := CreateAccount("deployer", vsphere.Auth{Address: "https://vc1", Username: "svc", Password: "P@ss"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := utils.NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key.ID = "rotated"
+	if err = utils.SetKeys(append([]utils.Key{key}, utils.Keys()...)); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := Reencrypt()
+	if err != nil || n != 1 {
+		t.Fatal("应该重新加密一个账号", n, err)
+	}
+	stored, _ := GetAccount(account.ID)
+	if utils.KeyIDOf(stored.Password) != "rotated" || stored.Auth().Password != "P@ss" {
+		t.Error("账号密码应该使用新密钥加密", stored)
+	}
+	if n, _ = Reencrypt(); n != 0 {
+		t.Error("已经使用新密钥的账号不需要重新加密", n)
+	}
+}
+
+func TestCheckKeys(t *testing.T) {
+	badgertest.Setup(t)
+	if !utils.TemporaryKey() {
+		t.Fatal("没有配置密钥时应该使用临时密钥")
+	}
+	CheckKeys()
+
+	_, err := CreateAccount("deployer", vsphere.Auth{Address: "https://vc1", Username: "svc", Password: "P@ss"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("保存了VC账号并使用临时密钥时应该拒绝启动")
+		}
+	}()
+	CheckKeys()
+}
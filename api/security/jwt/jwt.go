@@ -1,10 +1,15 @@
 package jwt
 
 import (
+	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
 	"time"
 	"vsphere-facade/api/e"
+	"vsphere-facade/api/security/identity"
+	"vsphere-facade/api/security/revocation"
+	"vsphere-facade/app/logging"
 	"vsphere-facade/app/utils"
 	"vsphere-facade/config"
 	"vsphere-facade/vsphere"
@@ -14,60 +19,210 @@ var jwtSecret []byte
 
 const Type = "jwt"
 
+const (
+	accessType  = "access"
+	refreshType = "refresh"
+)
+
 type Claims struct {
 	Auth vsphere.Auth
+	// Type 令牌类型，刷新令牌只能用于换取新的令牌，旧版本的令牌没有类型，视为访问令牌
+	Type string `json:"typ,omitempty"`
+	// Session 会话ID，同一次登录签发和刷新得到的令牌使用同一个会话ID，注销时整个会话的令牌都失效
+	Session string `json:"sid,omitempty"`
 	jwt.StandardClaims
 }
 
 type Token struct {
 }
 
+// Pair 访问令牌和刷新令牌
+type Pair struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+	// ExpiresAt 访问令牌的过期时间
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// minSecretLength HS256的密钥至少32字节
+const minSecretLength = 32
+
+// Setup 使用jwt认证时密钥为空或太短拒绝启动
 func Setup() {
 	jwtSecret = []byte(config.G.App.Token.Secret)
+	if config.G.App.Token.Type == Type && len(jwtSecret) < minSecretLength {
+		logging.L().Panicf("jwt密钥app.token.secret不能少于%d个字符", minSecretLength)
+	}
+}
+
+// accessExpire 访问令牌的有效期，默认3小时
+func accessExpire() time.Duration {
+	if m := config.G.App.Token.AccessExpire; m > 0 {
+		return time.Duration(m) * time.Minute
+	}
+	return 3 * time.Hour
+}
+
+// refreshExpire 刷新令牌的有效期，默认24小时
+func refreshExpire() time.Duration {
+	if m := config.G.App.Token.RefreshExpire; m > 0 {
+		return time.Duration(m) * time.Minute
+	}
+	return 24 * time.Hour
 }
 
 func (t Token) Generate(a vsphere.Auth) (string, error) {
+	token, _, err := generate(a, accessType, uuid.NewString(), accessExpire())
+	return token, err
+}
+
+// GeneratePair 开始新的会话，同时签发访问令牌和刷新令牌
+func GeneratePair(a vsphere.Auth) (*Pair, error) {
+	return generatePair(a, uuid.NewString())
+}
+
+func generatePair(a vsphere.Auth, session string) (*Pair, error) {
+	token, expiresAt, err := generate(a, accessType, session, accessExpire())
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, _, err := generate(a, refreshType, session, refreshExpire())
+	if err != nil {
+		return nil, err
+	}
+	return &Pair{Token: token, RefreshToken: refreshToken, ExpiresAt: expiresAt}, nil
+}
+
+func generate(a vsphere.Auth, tokenType, session string, expire time.Duration) (string, time.Time, error) {
 	nowTime := time.Now()
-	expireTime := nowTime.Add(3 * time.Hour)
+	expireTime := nowTime.Add(expire)
 
 	claims := Claims{
-		a,
+		vsphere.Auth{
+			Address:  utils.AesEncrypt(a.Address),
+			Username: utils.AesEncrypt(a.Username),
+			Password: utils.AesEncrypt(a.Password),
+		},
+		tokenType,
+		session,
 		jwt.StandardClaims{
+			Id:        uuid.NewString(),
+			IssuedAt:  nowTime.Unix(),
 			ExpiresAt: expireTime.Unix(),
 			Issuer:    "vsphere-facade",
 		},
 	}
 	tokenClaims := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	token, err := tokenClaims.SignedString(jwtSecret)
-	return token, err
+	return token, expireTime, err
 }
 
-func (t Token) Parse(token string) (*vsphere.Auth, error) {
+func (t Token) Parse(token string) (*identity.Principal, error) {
+	claims, err := parse(token)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Type == refreshType {
+		return nil, fmt.Errorf(e.TokenInvalid)
+	}
+	if claims.sessionRevoked() {
+		return nil, fmt.Errorf(e.TokenRevoked)
+	}
+	principal := identity.CredentialPrincipal(claims.auth())
+	principal.TokenID = claims.Id
+	return principal, nil
+}
+
+// Refresh 使用刷新令牌换取同一个会话的新令牌，原刷新令牌注销，只能使用一次
+func Refresh(refreshToken string) (*Pair, error) {
+	claims, err := parse(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Type != refreshType {
+		return nil, fmt.Errorf(e.TokenInvalid)
+	}
+	if claims.sessionRevoked() {
+		return nil, fmt.Errorf(e.TokenRevoked)
+	}
+	err = revocation.RevokeOnce(claims.Id, time.Unix(claims.ExpiresAt, 0))
+	if errors.Is(err, revocation.ErrRevoked) {
+		return nil, fmt.Errorf(e.TokenRevoked)
+	}
+	if err != nil {
+		return nil, err
+	}
+	session := claims.Session
+	if session == "" {
+		// 旧版本的刷新令牌没有会话ID，开始新的会话
+		session = uuid.NewString()
+	}
+	return generatePair(claims.auth(), session)
+}
+
+// Revoke 注销令牌和令牌所在的会话，会话中的其他令牌(包括刷新令牌)都失效，已过期的令牌不需要注销
+func Revoke(token string) error {
+	claims, err := parse(token)
+	if err != nil {
+		if err.Error() == e.TokenExpired {
+			return nil
+		}
+		return err
+	}
+	if claims.Id == "" {
+		return fmt.Errorf("旧版本的令牌没有ID，不能注销")
+	}
+	err = revocation.Revoke(claims.Id, time.Unix(claims.ExpiresAt, 0))
+	if err != nil || claims.Session == "" {
+		return err
+	}
+	// 会话中的令牌最晚在一个刷新令牌有效期后过期
+	expire := refreshExpire()
+	if accessExpire() > expire {
+		expire = accessExpire()
+	}
+	return revocation.Revoke(sessionPrefix+claims.Session, time.Now().Add(expire))
+}
+
+// sessionPrefix 注销列表中会话ID的前缀，与令牌ID区分
+const sessionPrefix = "session:"
+
+func (c Claims) sessionRevoked() bool {
+	return c.Session != "" && revocation.Revoked(sessionPrefix+c.Session)
+}
+
+func parse(token string) (*Claims, error) {
 	tokenClaims, err := jwt.ParseWithClaims(token, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("不支持的签名算法%v", token.Header["alg"])
+		}
 		return jwtSecret, nil
 	})
 
 	if err != nil {
-		switch err.(*jwt.ValidationError).Errors {
-		case jwt.ValidationErrorExpired:
+		ve, ok := err.(*jwt.ValidationError)
+		if ok && ve.Errors&jwt.ValidationErrorExpired != 0 {
 			return nil, fmt.Errorf(e.TokenExpired)
-		default:
-			return nil, fmt.Errorf(e.Unauthorized)
 		}
+		return nil, fmt.Errorf(e.Unauthorized)
 	}
 
 	if claims, ok := tokenClaims.Claims.(*Claims); ok && tokenClaims.Valid {
-		auth := vsphere.Auth{
-			Address:  utils.AesDecrypt(claims.Auth.Address),
-			Username: utils.AesDecrypt(claims.Auth.Username),
-			Password: utils.AesDecrypt(claims.Auth.Password),
-		}
-		return &auth, nil
+		return claims, nil
 	} else {
 		return nil, fmt.Errorf(e.TokenInvalid)
 	}
 }
 
+// auth 解密令牌中的VC认证信息
+func (c Claims) auth() vsphere.Auth {
+	return vsphere.Auth{
+		Address:  utils.AesDecrypt(c.Auth.Address),
+		Username: utils.AesDecrypt(c.Auth.Username),
+		Password: utils.AesDecrypt(c.Auth.Password),
+	}
+}
+
 func (t Token) Type() string {
 	return Type
 }
@@ -0,0 +1,119 @@
+package jwt
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"vsphere-facade/api/e"
+	"vsphere-facade/api/security/revocation"
+	"vsphere-facade/app/logging"
+	"vsphere-facade/config"
+	"vsphere-facade/db/badgerdb/badgertest"
+	"vsphere-facade/vsphere"
+)
+
+func TestRefreshAndRevoke(t *testing.T) {
+	badgertest.Setup(t)
+	config.G.App.Token.Secret = "test-secret-at-least-32-characters"
+	config.G.App.Token.AccessExpire = 5
+	defer func() { config.G.App.Token.AccessExpire = 0 }()
+	Setup()
+
+	auth := vsphere.Auth{Address: "https://vc1", Username: "svc", Password: "P@ss"}
+	pair, err := GeneratePair(auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Until(pair.ExpiresAt); d > 5*time.Minute || d < 4*time.Minute {
+		t.Error("访问令牌的有效期应该使用配置", pair.ExpiresAt)
+	}
+
+	p, err := Token{}.Parse(pair.Token)
+	if err != nil || p.Auth.Password != "P@ss" || p.TokenID == "" {
+		t.Fatal("访问令牌无效", p, err)
+	}
+	if _, err = (Token{}).Parse(pair.RefreshToken); err == nil || err.Error() != e.TokenInvalid {
+		t.Error("刷新令牌不能作为访问令牌使用", err)
+	}
+	if _, err = Refresh(pair.Token); err == nil {
+		t.Error("访问令牌不能用于刷新")
+	}
+
+	refreshed, err := Refresh(pair.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, err = (Token{}).Parse(refreshed.Token); err != nil || p.Auth.Username != "svc" {
+		t.Error("刷新后的令牌无效", p, err)
+	}
+	if _, err = Refresh(pair.RefreshToken); err == nil || err.Error() != e.TokenRevoked {
+		t.Error("刷新令牌只能使用一次", err)
+	}
+
+	// 并发使用同一个刷新令牌时只有一个成功
+	another, err := GeneratePair(auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	var succeeded int32
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := Refresh(another.RefreshToken); err == nil {
+				atomic.AddInt32(&succeeded, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	if succeeded != 1 {
+		t.Error("刷新令牌只能使用一次", succeeded)
+	}
+
+	if err = Revoke(refreshed.Token); err != nil {
+		t.Fatal(err)
+	}
+	if claims, _ := parse(refreshed.Token); !revocation.Revoked(claims.Id) {
+		t.Error("注销的令牌应该在注销列表中")
+	}
+	if _, err = (Token{}).Parse(pair.Token); err == nil || err.Error() != e.TokenRevoked {
+		t.Error("注销后同一个会话的其他令牌都失效", err)
+	}
+	if _, err = Refresh(refreshed.RefreshToken); err == nil || err.Error() != e.TokenRevoked {
+		t.Error("注销后不能再使用刷新令牌", err)
+	}
+	other, err := GeneratePair(auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pp, err := (Token{}).Parse(other.Token); err != nil || revocation.Revoked(pp.TokenID) {
+		t.Error("其他会话的令牌不受影响", err)
+	}
+}
+
+func TestSetupSecret(t *testing.T) {
+	config.G.Server.Log.Path = t.TempDir()
+	config.G.Server.Log.Level = "error"
+	logging.Setup()
+	saved := config.G.App.Token
+	defer func() { config.G.App.Token = saved }()
+
+	config.G.App.Token.Type = Type
+	for _, secret := range []string{"", "short-secret"} {
+		config.G.App.Token.Secret = secret
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("密钥[%s]太短时应该拒绝启动", secret)
+				}
+			}()
+			Setup()
+		}()
+	}
+
+	config.G.App.Token.Type = "bearer"
+	config.G.App.Token.Secret = ""
+	Setup()
+}
@@ -0,0 +1,324 @@
+package oidc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v4"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"vsphere-facade/api/e"
+	"vsphere-facade/api/security/identity"
+	"vsphere-facade/app/logging"
+	"vsphere-facade/app/utils"
+	"vsphere-facade/config"
+	"vsphere-facade/vsphere"
+)
+
+const Type = "oidc"
+
+// ErrNotIssuer OIDC令牌由身份提供方签发，不能使用VC账号获取
+var ErrNotIssuer = errors.New("OIDC认证方式下令牌由身份提供方签发")
+
+// refreshInterval 遇到未知的kid时重新获取JWKS的最小间隔，防止伪造的令牌频繁触发请求
+const refreshInterval = time.Minute
+
+var validMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// keySet 缓存的JWKS公钥，按kid索引
+type keySet struct {
+	mu        sync.RWMutex
+	keys      map[string]interface{}
+	fetchedAt time.Time
+}
+
+var jwks = &keySet{}
+
+type Token struct {
+}
+
+// Setup 清空缓存的公钥，第一次校验令牌时再获取。使用oidc认证时issuer和audience必须配置
+func Setup() {
+	jwks = &keySet{}
+	conf := config.G.App.Token.OIDC
+	if config.G.App.Token.Type == Type {
+		if conf.Issuer == "" || conf.Audience == "" {
+			logging.L().Panic("OIDC认证需要配置app.token.oidc.issuer和app.token.oidc.audience")
+		}
+		if conf.DefaultRole != "" && !identity.ValidRole(conf.DefaultRole) {
+			logging.L().Panicf("OIDC默认角色[%s]不存在", conf.DefaultRole)
+		}
+	}
+	for _, m := range config.G.App.Token.OIDC.Mappings {
+		if m.Role != "" && !identity.ValidRole(m.Role) {
+			logging.L().Warnf("OIDC组[%s]对应的角色[%s]不存在", m.Group, m.Role)
+		}
+	}
+}
+
+func (t Token) Generate(a vsphere.Auth) (string, error) {
+	return "", ErrNotIssuer
+}
+
+// Parse 校验签名、签发者、受众和有效期，按组映射角色和VC账号
+func (t Token) Parse(token string) (*identity.Principal, error) {
+	conf := config.G.App.Token.OIDC
+	parser := jwt.Parser{ValidMethods: validMethods, SkipClaimsValidation: true}
+	claims := jwt.MapClaims{}
+	_, err := parser.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return jwks.get(kid)
+	})
+	if err != nil {
+		logging.L().Debug("OIDC令牌无效: ", err)
+		return nil, fmt.Errorf(e.TokenInvalid)
+	}
+
+	now := time.Now().Unix()
+	leeway := int64(conf.Leeway)
+	if !claims.VerifyExpiresAt(now-leeway, true) {
+		return nil, fmt.Errorf(e.TokenExpired)
+	}
+	if !claims.VerifyNotBefore(now+leeway, false) || !claims.VerifyIssuer(conf.Issuer, true) ||
+		!claims.VerifyAudience(conf.Audience, true) {
+		logging.L().Debugf("OIDC令牌的签发者、受众或生效时间不正确: iss=%v aud=%v", claims["iss"], claims["aud"])
+		return nil, fmt.Errorf(e.TokenInvalid)
+	}
+
+	return principal(claims)
+}
+
+func (t Token) Type() string {
+	return Type
+}
+
+// principal 拥有所有匹配的组的角色，VC账号和租户使用第一个匹配的组
+func principal(claims jwt.MapClaims) (*identity.Principal, error) {
+	conf := config.G.App.Token.OIDC
+	groups := stringsClaim(claims, conf.GroupsClaim)
+
+	p := &identity.Principal{Subject: "oidc:" + subject(claims)}
+	for _, m := range conf.Mappings {
+		if !utils.SliceContain(groups, m.Group) {
+			continue
+		}
+		if m.Role != "" && !utils.SliceContain(p.Roles, m.Role) {
+			p.Roles = append(p.Roles, m.Role)
+		}
+		if m.Account != "" && p.AccountID == "" {
+			p.AccountID = m.Account
+		}
+		if m.Tenant != "" && p.Tenant == "" {
+			p.Tenant = m.Tenant
+		}
+	}
+	if len(p.Roles) == 0 && conf.DefaultRole != "" {
+		p.Roles = []string{conf.DefaultRole}
+	}
+	if len(p.Roles) == 0 {
+		logging.L().Debugf("OIDC用户[%s]的组%v没有对应的角色", p.Subject, groups)
+		return nil, fmt.Errorf(e.Unauthorized)
+	}
+
+	if p.AccountID != "" {
+		account, err := identity.GetAccount(p.AccountID)
+		if err != nil {
+			return nil, err
+		}
+		// 账号不存在时只能访问不需要VC账号的接口
+		if account == nil {
+			logging.L().Errorf("OIDC组对应的VC账号[%s]不存在", p.AccountID)
+			return p, nil
+		}
+		auth := account.Auth()
+		p.Auth = &auth
+	}
+	return p, nil
+}
+
+// subject 优先使用可读的用户名
+func subject(claims jwt.MapClaims) string {
+	for _, name := range []string{"preferred_username", "email", "sub"} {
+		if s, ok := claims[name].(string); ok && s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// stringsClaim claim可以是字符串数组或空格分隔的字符串
+func stringsClaim(claims jwt.MapClaims, name string) []string {
+	if name == "" {
+		name = "groups"
+	}
+	switch v := claims[name].(type) {
+	case string:
+		return strings.Fields(v)
+	case []interface{}:
+		var values []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// get 返回kid对应的公钥，没有找到时重新获取JWKS
+func (s *keySet) get(kid string) (interface{}, error) {
+	s.mu.RLock()
+	key, ok := s.keys[kid]
+	fetchedAt := s.fetchedAt
+	s.mu.RUnlock()
+	if ok {
+		return key, nil
+	}
+	if time.Since(fetchedAt) < refreshInterval {
+		return nil, fmt.Errorf("公钥[%s]不存在", kid)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if key, ok = s.keys[kid]; ok {
+		return key, nil
+	}
+	if time.Since(s.fetchedAt) < refreshInterval {
+		return nil, fmt.Errorf("公钥[%s]不存在", kid)
+	}
+	s.fetchedAt = time.Now()
+	keys, err := fetchKeys()
+	if err != nil {
+		logging.L().Error("获取OIDC公钥失败", err)
+		return nil, err
+	}
+	s.keys = keys
+	if key, ok = s.keys[kid]; ok {
+		return key, nil
+	}
+	// 只有一个公钥时允许令牌不带kid
+	if kid == "" && len(s.keys) == 1 {
+		for _, key = range s.keys {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("公钥[%s]不存在", kid)
+}
+
+func fetchKeys() (map[string]interface{}, error) {
+	conf := config.G.App.Token.OIDC
+	jwksURL := conf.JWKSURL
+	if jwksURL == "" {
+		discovery := struct {
+			Issuer  string `json:"issuer"`
+			JWKSURI string `json:"jwks_uri"`
+		}{}
+		err := getJSON(strings.TrimSuffix(conf.Issuer, "/")+"/.well-known/openid-configuration", &discovery)
+		if err != nil {
+			return nil, err
+		}
+		if discovery.Issuer != conf.Issuer {
+			return nil, fmt.Errorf("发现的issuer[%s]与配置[%s]不一致", discovery.Issuer, conf.Issuer)
+		}
+		jwksURL = discovery.JWKSURI
+	}
+
+	set := struct {
+		Keys []jsonWebKey `json:"keys"`
+	}{}
+	err := getJSON(jwksURL, &set)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]interface{})
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			logging.L().Warnf("跳过无法解析的OIDC公钥[%s]: %v", k.Kid, err)
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	return keys, nil
+}
+
+func getJSON(url string, v interface{}) error {
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("请求%s失败: %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		exp, err := decodeInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(exp.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("不支持的曲线%s", k.Crv)
+		}
+		x, err := decodeInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("不支持的密钥类型%s", k.Kty)
+}
+
+func decodeInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
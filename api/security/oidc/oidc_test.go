@@ -0,0 +1,157 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/golang-jwt/jwt/v4"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"vsphere-facade/api/e"
+	"vsphere-facade/api/security/identity"
+	"vsphere-facade/app/logging"
+	"vsphere-facade/config"
+	"vsphere-facade/db/badgerdb/badgertest"
+	"vsphere-facade/vsphere"
+)
+
+// mockIssuer 提供发现文档和JWKS的身份提供方
+func mockIssuer(t *testing.T, key *rsa.PrivateKey) *httptest.Server {
+	var s *httptest.Server
+	s = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			_ = json.NewEncoder(w).Encode(map[string]string{"issuer": s.URL, "jwks_uri": s.URL + "/keys"})
+		case "/keys":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+				"kid": "kid1",
+				"kty": "RSA",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func sign(t *testing.T, key *rsa.PrivateKey, kid string, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	s, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestParse(t *testing.T) {
+	badgertest.Setup(t)
+
+	account, err := identity.CreateAccount("svc", vsphere.Auth{Address: "https://vc1", Username: "svc", Password: "P@ss"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer := mockIssuer(t, key)
+	saved := config.G.App.Token.OIDC
+	defer func() { config.G.App.Token.OIDC = saved }()
+	conf := &config.G.App.Token.OIDC
+	conf.Issuer = issuer.URL
+	conf.Audience = "vsphere-facade"
+	conf.Mappings = []config.OIDCMapping{
+		{Group: "vm-viewers", Role: identity.RoleViewer},
+		{Group: "vm-admins", Role: identity.RoleDeployer, Account: account.ID},
+	}
+	Setup()
+
+	claims := func(aud string, exp time.Duration, groups ...string) jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":                issuer.URL,
+			"aud":                aud,
+			"sub":                "u-1",
+			"preferred_username": "alice",
+			"exp":                time.Now().Add(exp).Unix(),
+			"groups":             groups,
+		}
+	}
+
+	p, err := Token{}.Parse(sign(t, key, "kid1", claims("vsphere-facade", time.Hour, "vm-viewers", "vm-admins")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Subject != "oidc:alice" || !p.HasRole(identity.RoleDeployer) || p.HasRole(identity.RoleAdmin) ||
+		p.AccountID != account.ID || p.Auth == nil || p.Auth.Password != "P@ss" {
+		t.Error("组映射的角色和VC账号不正确", p)
+	}
+
+	p, err = Token{}.Parse(sign(t, key, "kid1", claims("vsphere-facade", time.Hour, "vm-viewers")))
+	if err != nil || p.Auth != nil || !p.HasRole(identity.RoleViewer) || p.HasRole(identity.RoleOperator) {
+		t.Error("只有viewer组时没有VC账号", p, err)
+	}
+
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, c := range map[string]struct {
+		token string
+		code  string
+	}{
+		"受众不正确": {sign(t, key, "kid1", claims("other", time.Hour, "vm-admins")), e.TokenInvalid},
+		"已过期":   {sign(t, key, "kid1", claims("vsphere-facade", -time.Hour, "vm-admins")), e.TokenExpired},
+		"签名不正确": {sign(t, other, "kid1", claims("vsphere-facade", time.Hour, "vm-admins")), e.TokenInvalid},
+		"公钥不存在": {sign(t, key, "kid2", claims("vsphere-facade", time.Hour, "vm-admins")), e.TokenInvalid},
+		"没有角色":  {sign(t, key, "kid1", claims("vsphere-facade", time.Hour, "others")), e.Unauthorized},
+	} {
+		if _, err = (Token{}).Parse(c.token); err == nil || err.Error() != c.code {
+			t.Errorf("%s: 应该返回%s，实际为%v", name, c.code, err)
+		}
+	}
+}
+
+func TestSetup(t *testing.T) {
+	config.G.Server.Log.Path = t.TempDir()
+	config.G.Server.Log.Level = "error"
+	logging.Setup()
+	saved := config.G.App.Token
+	defer func() { config.G.App.Token = saved }()
+
+	config.G.App.Token.Type = Type
+	conf := &config.G.App.Token.OIDC
+	for _, c := range []struct {
+		name                          string
+		issuer, audience, defaultRole string
+	}{
+		{"没有issuer", "", "vsphere-facade", ""},
+		{"没有audience", "https://sso.example.com", "", ""},
+		{"默认角色不存在", "https://sso.example.com", "vsphere-facade", "root"},
+	} {
+		conf.Issuer, conf.Audience, conf.DefaultRole = c.issuer, c.audience, c.defaultRole
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error(c.name, "应该拒绝启动")
+				}
+			}()
+			Setup()
+		}()
+	}
+
+	conf.Issuer, conf.Audience, conf.DefaultRole = "https://sso.example.com", "vsphere-facade", identity.RoleViewer
+	Setup()
+	config.G.App.Token.Type = "bearer"
+	conf.Issuer, conf.Audience = "", ""
+	Setup()
+}
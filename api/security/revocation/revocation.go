@@ -0,0 +1,48 @@
+package revocation
+
+import (
+	"errors"
+	"time"
+	"vsphere-facade/db/badgerdb"
+)
+
+const keyPrefix = "security::revoked::"
+
+var ErrNotEnabled = errors.New("注销令牌需要配置badger DB")
+
+// ErrRevoked RevokeOnce注销的令牌已经被注销
+var ErrRevoked = errors.New("令牌已注销")
+
+// Revoke 注销令牌，记录保留到令牌过期
+func Revoke(ID string, expiresAt time.Time) error {
+	if !badgerdb.Enabled() {
+		return ErrNotEnabled
+	}
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	return badgerdb.SetWithTTL(keyPrefix+ID, expiresAt.Format(time.RFC3339), ttl)
+}
+
+// RevokeOnce 令牌没有注销时注销，已注销时返回ErrRevoked。
+// 检查和注销在同一个事务中，并发使用同一个令牌时只有一个成功
+func RevokeOnce(ID string, expiresAt time.Time) error {
+	if !badgerdb.Enabled() {
+		return ErrNotEnabled
+	}
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return ErrRevoked
+	}
+	err := badgerdb.SetNXWithTTL(keyPrefix+ID, expiresAt.Format(time.RFC3339), ttl)
+	if errors.Is(err, badgerdb.ErrKeyExists) {
+		return ErrRevoked
+	}
+	return err
+}
+
+// Revoked 令牌是否已注销
+func Revoked(ID string) bool {
+	return ID != "" && badgerdb.Get(keyPrefix+ID) != ""
+}
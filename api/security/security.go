@@ -1,25 +1,31 @@
 package security
 
 import (
+	"crypto/subtle"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"net/http"
+	"strings"
 	"vsphere-facade/api/e"
 	"vsphere-facade/api/security/bearer"
+	"vsphere-facade/api/security/identity"
 	"vsphere-facade/api/security/jwt"
+	"vsphere-facade/api/security/oidc"
+	"vsphere-facade/api/security/revocation"
 	"vsphere-facade/app/logging"
 	"vsphere-facade/config"
 	"vsphere-facade/vsphere"
 )
 
 const (
-	CurrentAuth = "CURRENT_AUTH"
+	CurrentAuth      = "CURRENT_AUTH"
+	CurrentPrincipal = "CURRENT_PRINCIPAL"
 )
 
 type Token interface {
 	Generate(a vsphere.Auth) (string, error)
-	Parse(t string) (*vsphere.Auth, error)
+	Parse(t string) (*identity.Principal, error)
 	Type() string
 }
 
@@ -34,6 +40,7 @@ func Setup() {
 
 func setUpTokenTool() {
 	jwt.Setup()
+	oidc.Setup()
 }
 
 // GetToken
@@ -62,9 +69,29 @@ func GetToken(c *gin.Context) {
 		return
 	}
 
-	vc := vsphere.Get(auth)
-	if vc == nil {
-		r.ResponseError(http.StatusBadRequest, e.ConnectFailed, nil)
+	if config.G.App.Token.DisableCredentials {
+		r.ResponseError(http.StatusForbidden, "已禁止使用VC账号获取令牌，请使用管理员发放的API Key", nil)
+		return
+	}
+
+	if tokenTool.Type() == oidc.Type {
+		r.ResponseError(http.StatusBadRequest, oidc.ErrNotIssuer.Error(), nil)
+		return
+	}
+
+	if _, err = vsphere.Get(auth); err != nil {
+		logging.C(c.Request.Context()).Warn("连接VC失败: ", err)
+		r.ResponseError(http.StatusBadRequest, e.ConnectFailed, err.Error())
+		return
+	}
+
+	if tokenTool.Type() == jwt.Type {
+		pair, err := jwt.GeneratePair(auth)
+		if err != nil {
+			r.ResponseError(http.StatusInternalServerError, e.SystemError, nil)
+			return
+		}
+		r.ResponseOk(http.StatusOK, e.Success, pair)
 		return
 	}
 
@@ -79,22 +106,123 @@ func GetToken(c *gin.Context) {
 	})
 }
 
+// RefreshReq 刷新令牌
+type RefreshReq struct {
+	RefreshToken string `json:"refreshToken" valid:"Required"`
+}
+
+// RefreshToken
+// @Summary      刷新令牌
+// @Description  使用刷新令牌换取新的令牌和刷新令牌，原刷新令牌只能使用一次。只支持jwt认证方式
+// @Tags         认证
+// @Accept       application/json
+// @Produce      application/json
+// @Param        object  body      security.RefreshReq  true  "刷新令牌"
+// @Success      200     {object}  e.Response{data=jwt.Pair}
+// @Failure      400     {string}  json  "{"code":"","message":"失败","data":{}"
+// @Failure      401     {string}  json  "{"code":"401x","message":"失败","data":{}"
+// @Router       /token/refresh [post]
+func RefreshToken(c *gin.Context) {
+	r := e.Gin{C: c}
+	req := RefreshReq{}
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		r.ResponseError(http.StatusBadRequest, e.BadRequest, nil)
+		return
+	}
+
+	errors := e.ValidReqParam(&req)
+	if len(errors) > 0 {
+		r.ResponseErrors(http.StatusBadRequest, errors, nil)
+		return
+	}
+
+	if tokenTool.Type() != jwt.Type || config.G.App.Token.DisableCredentials {
+		r.ResponseError(http.StatusBadRequest, "当前认证方式不支持刷新令牌", nil)
+		return
+	}
+
+	pair, err := jwt.Refresh(req.RefreshToken)
+	if err != nil {
+		logging.C(c.Request.Context()).Info("刷新令牌失败: ", err)
+		if err == revocation.ErrNotEnabled {
+			r.ResponseError(http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		r.ResponseError(http.StatusUnauthorized, err.Error(), nil)
+		return
+	}
+	r.ResponseOk(http.StatusOK, e.Success, pair)
+}
+
+// RevokeToken
+// @Summary      注销令牌
+// @Description  注销请求头中的令牌所在的会话，同一次登录签发和刷新得到的所有令牌(包括刷新令牌)都失效。只支持jwt认证方式，API Key请由管理员停用
+// @Tags         认证
+// @Accept       application/json
+// @Produce      application/json
+// @Param        object  body      security.RefreshReq  false  "刷新令牌"
+// @Success      200     {object}  e.Response
+// @Failure      400     {string}  json  "{"code":"","message":"失败","data":{}"
+// @Failure      401     {string}  json  "{"code":"401x","message":"失败","data":{}"
+// @Security     ApiKeyAuth
+// @Router       /token/revoke [post]
+func RevokeToken(c *gin.Context) {
+	r := e.Gin{C: c}
+	if GetCurrentPrincipal(c).TokenID == "" {
+		r.ResponseError(http.StatusBadRequest, "当前令牌不支持注销", nil)
+		return
+	}
+
+	tokens := []string{requestToken(c)}
+	req := RefreshReq{}
+	if c.Request.ContentLength > 0 {
+		err := c.ShouldBindJSON(&req)
+		if err != nil {
+			r.ResponseError(http.StatusBadRequest, e.BadRequest, nil)
+			return
+		}
+	}
+	if req.RefreshToken != "" {
+		tokens = append(tokens, req.RefreshToken)
+	}
+
+	for _, token := range tokens {
+		err := jwt.Revoke(token)
+		if err != nil {
+			logging.C(c.Request.Context()).Error("注销令牌失败", err)
+			r.ResponseError(http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+	}
+	r.ResponseOk(http.StatusOK, e.Success, nil)
+}
+
+// Verify
+// token可以是API Key(vfk_开头)、管理员启动密钥、使用VC账号获取的令牌或OIDC令牌，
+// 请求头token为空时使用Authorization: Bearer
 func Verify() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var code, message string
 		code = e.Success
-		token := c.GetHeader("token")
-		logging.L().Debug("token: ", token)
+		token := requestToken(c)
+		logging.C(c.Request.Context()).Debug("token: ", logging.Redact(token))
 		if token == "" {
 			code = e.Unauthorized
 			message = e.GetMessage(code)
 		} else {
-			auth, err := tokenTool.Parse(token)
+			principal, err := parse(token)
 			if err != nil {
 				code = e.Unauthorized
 				message = e.GetMessage(err.Error())
+			} else if revocation.Revoked(principal.TokenID) {
+				code = e.TokenRevoked
+				message = e.GetMessage(code)
 			} else {
-				c.Set(CurrentAuth, *auth)
+				c.Set(CurrentPrincipal, *principal)
+				if principal.Auth != nil {
+					c.Set(CurrentAuth, *principal.Auth)
+				}
 			}
 		}
 
@@ -110,15 +238,113 @@ func Verify() gin.HandlerFunc {
 	}
 }
 
+// RequireRole 调用者需要有其中一个角色，放在Verify之后
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := GetCurrentPrincipal(c)
+		for _, role := range roles {
+			if principal.HasRole(role) {
+				c.Next()
+				return
+			}
+		}
+		c.JSON(http.StatusForbidden, e.Response{
+			Code:    e.Forbidden,
+			Message: e.GetMessage(e.Forbidden),
+		})
+		c.Abort()
+	}
+}
+
+// RequireAccount 访问VC的接口需要令牌关联VC账号
+func RequireAccount() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get(CurrentAuth); !ok {
+			c.JSON(http.StatusForbidden, e.Response{
+				Code:    e.NoAccount,
+				Message: e.GetMessage(e.NoAccount),
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// requestToken 请求头token为空时使用Authorization: Bearer
+func requestToken(c *gin.Context) string {
+	if token := c.GetHeader("token"); token != "" {
+		return token
+	}
+	authorization := c.GetHeader("Authorization")
+	if len(authorization) > 7 && strings.EqualFold(authorization[:7], "Bearer ") {
+		return strings.TrimSpace(authorization[7:])
+	}
+	return ""
+}
+
+func parse(token string) (*identity.Principal, error) {
+	adminKey := config.G.App.Admin.Key
+	if adminKey != "" && subtle.ConstantTimeCompare([]byte(token), []byte(adminKey)) == 1 {
+		return &identity.Principal{Subject: "admin", Roles: []string{identity.RoleAdmin}}, nil
+	}
+	if strings.HasPrefix(token, identity.KeyPrefix) {
+		return identity.Authenticate(token)
+	}
+	if config.G.App.Token.DisableCredentials && tokenTool.Type() != oidc.Type {
+		return nil, fmt.Errorf(e.TokenInvalid)
+	}
+	return tokenTool.Parse(token)
+}
+
+// GetCurrentAuth 令牌没有关联VC账号时返回错误信息
 func GetCurrentAuth(c *gin.Context) vsphere.Auth {
-	auth, _ := c.Get(CurrentAuth)
+	auth, ok := c.Get(CurrentAuth)
+	if !ok {
+		panic(e.NoAccount)
+	}
 	return auth.(vsphere.Auth)
 }
 
+// GetCurrentScope 调用者允许访问的清单范围，nil为不限制
+func GetCurrentScope(c *gin.Context) *vsphere.Scope {
+	return GetCurrentPrincipal(c).Scope
+}
+
+// GetCurrentTenant 调用者所属的租户，为空时不受资源配额限制
+func GetCurrentTenant(c *gin.Context) string {
+	return GetCurrentPrincipal(c).Tenant
+}
+
+// ClientID 用于限流的调用方标识，API Key按Key，其他令牌按用户和VC，没有认证时按IP
+func ClientID(c *gin.Context) string {
+	principal := GetCurrentPrincipal(c)
+	if principal.KeyID != "" {
+		return "key:" + principal.KeyID
+	}
+	if principal.Auth != nil {
+		return principal.Subject + "@" + principal.Auth.Address
+	}
+	if principal.Subject != "" {
+		return principal.Subject
+	}
+	return "ip:" + c.ClientIP()
+}
+
+func GetCurrentPrincipal(c *gin.Context) identity.Principal {
+	principal, ok := c.Get(CurrentPrincipal)
+	if !ok {
+		return identity.Principal{}
+	}
+	return principal.(identity.Principal)
+}
+
 func getTokenTool(t string) Token {
 	switch t {
 	case jwt.Type:
 		return jwt.Token{}
+	case oidc.Type:
+		return oidc.Token{}
 	default:
 		return bearer.Token{}
 	}
@@ -0,0 +1,82 @@
+package v1
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+	"vsphere-facade/api/audit"
+	"vsphere-facade/api/e"
+	"vsphere-facade/app/logging"
+)
+
+// AuditQuery 审计记录查询参数
+type AuditQuery struct {
+	// 开始时间，RFC3339格式
+	From *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	// 结束时间，RFC3339格式
+	To *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	// 调用者
+	User string `form:"user"`
+	// VC ID
+	VCID string `form:"vcid"`
+	// VC地址，管理操作(登记账号、发放API Key)的记录没有VC地址
+	VCAddress string `form:"vcAddress"`
+	// 操作对象ID，如虚拟机ID
+	ObjectID string `form:"objectId"`
+	// 异步任务ID
+	RequestID string `form:"requestId"`
+	// 结果: accepted, success, failure, partial, rejected
+	Outcome string `form:"outcome"`
+	// HTTP方法
+	Method string `form:"method"`
+	// 每页数量，默认100，最大1000
+	Limit int `form:"limit"`
+	// 上一页返回的next，为空时从最新的记录开始
+	Cursor string `form:"cursor"`
+}
+
+// QueryAudit
+// @Summary      审计记录查询
+// @Description  按时间倒序分页查询所有VC和管理操作的审计记录，返回的next作为cursor查询下一页，需要管理员角色，可以使用启动密钥。异步任务在接收请求(event=request)和任务结束(event=complete)时各有一条记录，通过requestId关联
+// @Tags         审计
+// @Accept       json
+// @Produce      json
+// @Param        c     query     v1.AuditQuery  false  "查询参数"
+// @Success      200   {object}  e.Response{data=audit.Result}
+// @Failure      400   {string}  json  "{"code":"400x","message":"失败"}"
+// @Failure      401   {string}  json  "{"code":"401x","message":"失败"}"
+// @Failure      403   {string}  json  "{"code":"403x","message":"失败"}"
+// @Failure      500   {string}  json  "{"code":"500x","message":"失败"}"
+// @Security     ApiKeyAuth
+// @Router       /v1/admin/audit [get]
+func QueryAudit(c *gin.Context) {
+	r := e.Gin{C: c}
+
+	query := AuditQuery{}
+	err := c.ShouldBindQuery(&query)
+	if err != nil {
+		logging.C(c.Request.Context()).Error("解析请求参数出错: ", err)
+		r.ResponseError(http.StatusBadRequest, e.BadRequest, nil)
+		return
+	}
+
+	result, err := audit.Query(audit.Filter{
+		Cursor:    query.Cursor,
+		Limit:     query.Limit,
+		From:      query.From,
+		To:        query.To,
+		VCAddress: query.VCAddress,
+		User:      query.User,
+		VCID:      query.VCID,
+		ObjectID:  query.ObjectID,
+		RequestID: query.RequestID,
+		Outcome:   query.Outcome,
+		Method:    query.Method,
+	})
+	if err != nil {
+		logging.C(c.Request.Context()).Error("查询审计记录失败", err)
+		r.ResponseError(http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	r.ResponseOk(http.StatusOK, e.Success, result)
+}
@@ -4,9 +4,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"vsphere-facade/api/e"
-	"vsphere-facade/api/security"
 	"vsphere-facade/app/logging"
-	"vsphere-facade/vsphere"
 	"vsphere-facade/vsphere/protocol"
 )
 
@@ -21,6 +19,7 @@ type ClusterQuery struct {
 // @Tags         基础设施
 // @Accept       json
 // @Produce      json
+// @Param        list  query     v1.ListQuery  false  "分页、排序、过滤参数"
 // @Param        ids           query     []string  false  "集群ID"
 // @Param        datacenterId  query     string    false  "数据中心ID"
 // @Success      200           {object}  e.Response{data=[]protocol.ClusterInfo}
@@ -31,7 +30,6 @@ type ClusterQuery struct {
 // @Router       /v1/clusters [get]
 func QueryClusters(c *gin.Context) {
 	r := e.Gin{C: c}
-	auth := security.GetCurrentAuth(c)
 
 	query := ClusterQuery{}
 	err := c.ShouldBind(&query)
@@ -47,17 +45,24 @@ func QueryClusters(c *gin.Context) {
 		return
 	}
 
-	var vc = vsphere.Get(auth)
+	opts, ok := bindListQuery(&r)
+	if !ok {
+		return
+	}
+
+	vc, ok := getVCenter(&r)
+	if !ok {
+		return
+	}
 	q := protocol.ClusterQuery{
 		DatacenterID: query.DatacenterID,
 		IDs:          query.IDs,
 	}
-	clusters := vc.QueryClusters(q)
-	if clusters == nil {
-		r.ResponseOk(http.StatusOK, e.Success, e.EmptyArray())
-	} else {
-		r.ResponseOk(http.StatusOK, e.Success, clusters)
+	clusters, err := vc.QueryClusters(q)
+	if !checkScope(&r, err) {
+		return
 	}
+	responseList(&r, opts, clusters, nil)
 }
 
 // GetClusterOSFamilies
@@ -66,6 +71,7 @@ func QueryClusters(c *gin.Context) {
 // @Tags         基础设施
 // @Accept       json
 // @Produce      json
+// @Param        list  query     v1.ListQuery  false  "分页、排序、过滤参数"
 // @Param        clusterID  path      string  true  "集群ID"
 // @Success      200        {object}  e.Response{data=[]protocol.OSFamilyInfo}
 // @Failure      400        {string}  json  "{"code":"400x","message":"失败"}"
@@ -75,14 +81,17 @@ func QueryClusters(c *gin.Context) {
 // @Router       /v1/clusters/{clusterId}/os_families [get]
 func GetClusterOSFamilies(c *gin.Context) {
 	r := e.Gin{C: c}
-	auth := security.GetCurrentAuth(c)
 	clusterID := c.Param("clusterID")
 
-	var vc = vsphere.Get(auth)
-	OSFamilyInfos := vc.GetComputerResourceOSFamilies(clusterID)
-	if OSFamilyInfos == nil {
-		r.ResponseOk(http.StatusOK, e.Success, e.EmptyArray())
-	} else {
-		r.ResponseOk(http.StatusOK, e.Success, OSFamilyInfos)
+	opts, ok := bindListQuery(&r)
+	if !ok {
+		return
+	}
+
+	vc, ok := getVCenter(&r)
+	if !ok {
+		return
 	}
+	OSFamilyInfos := vc.GetComputerResourceOSFamilies(clusterID)
+	responseList(&r, opts, OSFamilyInfos, nil)
 }
@@ -4,8 +4,6 @@ import (
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"vsphere-facade/api/e"
-	"vsphere-facade/api/security"
-	"vsphere-facade/vsphere"
 	"vsphere-facade/vsphere/protocol"
 )
 
@@ -19,6 +17,7 @@ type DatacenterQuery struct {
 // @Tags         基础设施
 // @Accept       json
 // @Produce      json
+// @Param        list  query     v1.ListQuery  false  "分页、排序、过滤参数"
 // @Param        ids  query     string  false  "数据中心ID"
 // @Success      200  {object}  e.Response{data=[]protocol.DatacenterInfo}
 // @Failure      400  {string}  json  "{"code":"400x","message":"失败"}"
@@ -28,7 +27,6 @@ type DatacenterQuery struct {
 // @Router       /v1/datacenters [get]
 func QueryDatacenters(c *gin.Context) {
 	r := e.Gin{C: c}
-	auth := security.GetCurrentAuth(c)
 
 	query := DatacenterQuery{}
 	err := c.ShouldBind(&query)
@@ -43,14 +41,21 @@ func QueryDatacenters(c *gin.Context) {
 		return
 	}
 
-	var vc = vsphere.Get(auth)
+	opts, ok := bindListQuery(&r)
+	if !ok {
+		return
+	}
+
+	vc, ok := getVCenter(&r)
+	if !ok {
+		return
+	}
 	q := protocol.DatacenterQuery{
 		IDs: query.IDs,
 	}
-	datacenters := vc.QueryDatacenters(q)
-	if datacenters == nil {
-		r.ResponseOk(http.StatusOK, e.Success, e.EmptyArray())
-	} else {
-		r.ResponseOk(http.StatusOK, e.Success, datacenters)
+	datacenters, err := vc.QueryDatacenters(q)
+	if !checkScope(&r, err) {
+		return
 	}
+	responseList(&r, opts, datacenters, nil)
 }
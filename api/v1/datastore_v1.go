@@ -4,9 +4,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"vsphere-facade/api/e"
-	"vsphere-facade/api/security"
 	"vsphere-facade/app/logging"
-	"vsphere-facade/vsphere"
 	"vsphere-facade/vsphere/protocol"
 )
 
@@ -21,6 +19,7 @@ type DatastoreQuery struct {
 // @Tags         基础设施
 // @Accept       json
 // @Produce      json
+// @Param        list  query     v1.ListQuery  false  "分页、排序、过滤参数"
 // @Param        ids           query     []string  false  "存储ID"
 // @Param        datacenterId  query     string    false  "数据中心ID"
 // @Success      200           {object}  e.Response{data=[]protocol.DatastoreInfo}
@@ -31,7 +30,6 @@ type DatastoreQuery struct {
 // @Router       /v1/datastores [get]
 func QueryDatastores(c *gin.Context) {
 	r := e.Gin{C: c}
-	auth := security.GetCurrentAuth(c)
 
 	query := DatastoreQuery{}
 	err := c.ShouldBind(&query)
@@ -51,11 +49,19 @@ func QueryDatastores(c *gin.Context) {
 		DatacenterID: query.DatacenterID,
 		IDs:          query.IDs,
 	}
-	var vc = vsphere.Get(auth)
-	datastores := vc.QueryDatastores(q)
-	if datastores == nil {
-		r.ResponseOk(http.StatusOK, e.Success, e.EmptyArray())
-	} else {
-		r.ResponseOk(http.StatusOK, e.Success, datastores)
+
+	opts, ok := bindListQuery(&r)
+	if !ok {
+		return
+	}
+
+	vc, ok := getVCenter(&r)
+	if !ok {
+		return
+	}
+	datastores, err := vc.QueryDatastores(q)
+	if !checkScope(&r, err) {
+		return
 	}
+	responseList(&r, opts, datastores, nil)
 }
@@ -4,9 +4,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"vsphere-facade/api/e"
-	"vsphere-facade/api/security"
 	"vsphere-facade/app/logging"
-	"vsphere-facade/vsphere"
 	"vsphere-facade/vsphere/protocol"
 )
 
@@ -22,6 +20,7 @@ type FolderQuery struct {
 // @Tags         基础设施
 // @Accept       json
 // @Produce      json
+// @Param        list  query     v1.ListQuery  false  "分页、排序、过滤参数"
 // @Param        ids           query     []string  false  "文件夹ID"
 // @Param        datacenterId  query     string    false  "数据中心ID"
 // @Param        folderID      query     string    false  "文件夹ID"
@@ -33,7 +32,6 @@ type FolderQuery struct {
 // @Router       /v1/folders [get]
 func QueryFolders(c *gin.Context) {
 	r := e.Gin{C: c}
-	auth := security.GetCurrentAuth(c)
 
 	query := FolderQuery{}
 	err := c.ShouldBind(&query)
@@ -49,16 +47,23 @@ func QueryFolders(c *gin.Context) {
 		return
 	}
 
-	var vc = vsphere.Get(auth)
+	opts, ok := bindListQuery(&r)
+	if !ok {
+		return
+	}
+
+	vc, ok := getVCenter(&r)
+	if !ok {
+		return
+	}
 	q := protocol.FolderQuery{
 		DatacenterID: query.DatacenterID,
 		FolderID:     query.FolderID,
 		IDs:          query.IDs,
 	}
-	folders := vc.QueryFolders(q)
-	if folders == nil {
-		r.ResponseOk(http.StatusOK, e.Success, e.EmptyArray())
-	} else {
-		r.ResponseOk(http.StatusOK, e.Success, folders)
+	folders, err := vc.QueryFolders(q)
+	if !checkScope(&r, err) {
+		return
 	}
+	responseList(&r, opts, folders, nil)
 }
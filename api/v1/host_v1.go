@@ -4,8 +4,6 @@ import (
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"vsphere-facade/api/e"
-	"vsphere-facade/api/security"
-	"vsphere-facade/vsphere"
 	"vsphere-facade/vsphere/protocol"
 )
 
@@ -21,6 +19,7 @@ type HostQuery struct {
 // @Tags         基础设施
 // @Accept       json
 // @Produce      json
+// @Param        list  query     v1.ListQuery  false  "分页、排序、过滤参数"
 // @Success      200  {object}  e.Response{data=[]protocol.OSFamilyInfo}
 // @Failure      400  {string}  json  "{"code":"400x","message":"失败"}"
 // @Failure      401  {string}  json  "{"code":"401x","message":"失败"}"
@@ -29,7 +28,6 @@ type HostQuery struct {
 // @Router       /v1/hosts [get]
 func QueryHosts(c *gin.Context) {
 	r := e.Gin{C: c}
-	auth := security.GetCurrentAuth(c)
 
 	query := HostQuery{}
 	err := c.ShouldBind(&query)
@@ -49,13 +47,21 @@ func QueryHosts(c *gin.Context) {
 		ClusterID:    query.ClusterID,
 		IDs:          query.IDs,
 	}
-	var vc = vsphere.Get(auth)
-	hosts := vc.QueryHosts(q)
-	if hosts == nil {
-		r.ResponseOk(http.StatusOK, e.Success, e.EmptyArray())
-	} else {
-		r.ResponseOk(http.StatusOK, e.Success, hosts)
+
+	opts, ok := bindListQuery(&r)
+	if !ok {
+		return
+	}
+
+	vc, ok := getVCenter(&r)
+	if !ok {
+		return
+	}
+	hosts, err := vc.QueryHosts(q)
+	if !checkScope(&r, err) {
+		return
 	}
+	responseList(&r, opts, hosts, nil)
 }
 
 // GetHostOSFamilies
@@ -64,6 +70,7 @@ func QueryHosts(c *gin.Context) {
 // @Tags         基础设施
 // @Accept       json
 // @Produce      json
+// @Param        list  query     v1.ListQuery  false  "分页、排序、过滤参数"
 // @Param        hostID  path      string  true  "主机ID"
 // @Success      200     {object}  e.Response{data=[]protocol.OSFamilyInfo}
 // @Failure      400     {string}  json  "{"code":"400x","message":"失败"}"
@@ -73,14 +80,17 @@ func QueryHosts(c *gin.Context) {
 // @Router       /v1/hosts/{hostId}/os_families [get]
 func GetHostOSFamilies(c *gin.Context) {
 	r := e.Gin{C: c}
-	auth := security.GetCurrentAuth(c)
 	hostID := c.Param("hostID")
 
-	var vc = vsphere.Get(auth)
-	OSFamilyInfos := vc.GetHostOSFamilies(hostID)
-	if OSFamilyInfos == nil {
-		r.ResponseOk(http.StatusOK, e.Success, e.EmptyArray())
-	} else {
-		r.ResponseOk(http.StatusOK, e.Success, OSFamilyInfos)
+	opts, ok := bindListQuery(&r)
+	if !ok {
+		return
+	}
+
+	vc, ok := getVCenter(&r)
+	if !ok {
+		return
 	}
+	OSFamilyInfos := vc.GetHostOSFamilies(hostID)
+	responseList(&r, opts, OSFamilyInfos, nil)
 }
@@ -0,0 +1,246 @@
+package v1
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+	"vsphere-facade/api/audit"
+	"vsphere-facade/api/e"
+	"vsphere-facade/api/security/identity"
+	"vsphere-facade/app/logging"
+	"vsphere-facade/vsphere"
+)
+
+// AccountReq 登记VC服务账号
+type AccountReq struct {
+	// 账号名称
+	Name string `json:"name" valid:"Required"`
+	vsphere.Auth
+}
+
+// APIKeyReq 发放API Key
+type APIKeyReq struct {
+	// 名称，用于区分调用方
+	Name string `json:"name" valid:"Required"`
+	// 关联的VC账号ID，为空时只能访问管理接口
+	AccountID string `json:"accountId"`
+	// 角色: viewer, operator, deployer, admin，高级角色包含低级角色的权限
+	Roles []string `json:"roles" valid:"Required"`
+	// 允许访问的清单范围，为空时不限制
+	Scope *vsphere.Scope `json:"scope"`
+	// 资源配额所属的租户，对应配置vsphere.quota.tenants中的name，为空时不限制
+	Tenant string `json:"tenant"`
+	// 过期时间，RFC3339格式，为空时不过期
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+// APIKeyResp 发放的API Key，key只在创建时返回一次
+type APIKeyResp struct {
+	Key string `json:"key"`
+	identity.APIKey
+}
+
+// QueryAccounts
+// @Summary      VC账号查询
+// @Description  查询管理员登记的VC服务账号，不返回密码
+// @Tags         管理
+// @Accept       json
+// @Produce      json
+// @Param        list  query     v1.ListQuery  false  "分页、排序、过滤参数"
+// @Success      200   {object}  e.Response{data=[]identity.Account}
+// @Failure      401   {string}  json  "{"code":"401x","message":"失败"}"
+// @Failure      403   {string}  json  "{"code":"403x","message":"失败"}"
+// @Failure      500   {string}  json  "{"code":"500x","message":"失败"}"
+// @Security     ApiKeyAuth
+// @Router       /v1/admin/accounts [get]
+func QueryAccounts(c *gin.Context) {
+	r := e.Gin{C: c}
+
+	opts, ok := bindListQuery(&r)
+	if !ok {
+		return
+	}
+
+	accounts, err := identity.Accounts()
+	if err != nil {
+		logging.C(c.Request.Context()).Error("查询VC账号失败", err)
+		r.ResponseError(http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	responseList(&r, opts, accounts, nil)
+}
+
+// CreateAccount
+// @Summary      登记VC账号
+// @Description  登记VC服务账号，登记前会使用账号连接VC验证，密码加密保存
+// @Tags         管理
+// @Accept       json
+// @Produce      json
+// @Param        c    body      v1.AccountReq  true  "VC账号"
+// @Success      201  {object}  e.Response{data=identity.Account}
+// @Failure      400  {string}  json  "{"code":"400x","message":"失败"}"
+// @Failure      401  {string}  json  "{"code":"401x","message":"失败"}"
+// @Failure      403  {string}  json  "{"code":"403x","message":"失败"}"
+// @Failure      500  {string}  json  "{"code":"500x","message":"失败"}"
+// @Security     ApiKeyAuth
+// @Router       /v1/admin/accounts [post]
+func CreateAccount(c *gin.Context) {
+	r := e.Gin{C: c}
+
+	req := AccountReq{}
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		logging.C(c.Request.Context()).Error("解析请求参数出错: ", err)
+		r.ResponseError(http.StatusBadRequest, e.BadRequest, nil)
+		return
+	}
+
+	errors := e.ValidReqParam(&req)
+	if len(errors) > 0 {
+		r.ResponseErrors(http.StatusBadRequest, errors, nil)
+		return
+	}
+
+	if _, err = vsphere.Get(req.Auth); err != nil {
+		logging.C(c.Request.Context()).Warn("连接VC失败: ", err)
+		r.ResponseError(http.StatusBadRequest, e.ConnectFailed, err.Error())
+		return
+	}
+
+	account, err := identity.CreateAccount(req.Name, req.Auth)
+	if err != nil {
+		logging.C(c.Request.Context()).Error("登记VC账号失败", err)
+		r.ResponseError(http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	audit.SetTarget(c, "", account.ID)
+	r.ResponseOk(http.StatusCreated, e.Success, account)
+}
+
+// DeleteAccount
+// @Summary      删除VC账号
+// @Description  删除VC服务账号，还有API Key使用的账号不能删除
+// @Tags         管理
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "账号ID"
+// @Success      200  {object}  e.Response
+// @Failure      400  {string}  json  "{"code":"400x","message":"失败"}"
+// @Failure      401  {string}  json  "{"code":"401x","message":"失败"}"
+// @Failure      403  {string}  json  "{"code":"403x","message":"失败"}"
+// @Security     ApiKeyAuth
+// @Router       /v1/admin/accounts/{id} [delete]
+func DeleteAccount(c *gin.Context) {
+	r := e.Gin{C: c}
+
+	err := identity.DeleteAccount(c.Param("id"))
+	if err != nil {
+		logging.C(c.Request.Context()).Error("删除VC账号失败", err)
+		r.ResponseError(http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	r.ResponseOk(http.StatusOK, e.Success, nil)
+}
+
+// QueryAPIKeys
+// @Summary      API Key查询
+// @Description  查询已发放的API Key，不返回密钥
+// @Tags         管理
+// @Accept       json
+// @Produce      json
+// @Param        list  query     v1.ListQuery  false  "分页、排序、过滤参数"
+// @Success      200   {object}  e.Response{data=[]identity.APIKey}
+// @Failure      401   {string}  json  "{"code":"401x","message":"失败"}"
+// @Failure      403   {string}  json  "{"code":"403x","message":"失败"}"
+// @Failure      500   {string}  json  "{"code":"500x","message":"失败"}"
+// @Security     ApiKeyAuth
+// @Router       /v1/admin/api_keys [get]
+func QueryAPIKeys(c *gin.Context) {
+	r := e.Gin{C: c}
+
+	opts, ok := bindListQuery(&r)
+	if !ok {
+		return
+	}
+
+	keys, err := identity.APIKeys()
+	if err != nil {
+		logging.C(c.Request.Context()).Error("查询API Key失败", err)
+		r.ResponseError(http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	responseList(&r, opts, keys, nil)
+}
+
+// CreateAPIKey
+// @Summary      发放API Key
+// @Description  发放API Key，调用方在请求头token中传入。key只在创建时返回一次，请妥善保存
+// @Tags         管理
+// @Accept       json
+// @Produce      json
+// @Param        c    body      v1.APIKeyReq  true  "API Key"
+// @Success      201  {object}  e.Response{data=v1.APIKeyResp}
+// @Failure      400  {string}  json  "{"code":"400x","message":"失败"}"
+// @Failure      401  {string}  json  "{"code":"401x","message":"失败"}"
+// @Failure      403  {string}  json  "{"code":"403x","message":"失败"}"
+// @Failure      500  {string}  json  "{"code":"500x","message":"失败"}"
+// @Security     ApiKeyAuth
+// @Router       /v1/admin/api_keys [post]
+func CreateAPIKey(c *gin.Context) {
+	r := e.Gin{C: c}
+
+	req := APIKeyReq{}
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		logging.C(c.Request.Context()).Error("解析请求参数出错: ", err)
+		r.ResponseError(http.StatusBadRequest, e.BadRequest, nil)
+		return
+	}
+
+	errors := e.ValidReqParam(&req)
+	if len(errors) > 0 {
+		r.ResponseErrors(http.StatusBadRequest, errors, nil)
+		return
+	}
+
+	key, apiKey, err := identity.CreateKey(identity.APIKey{
+		Name:      req.Name,
+		AccountID: req.AccountID,
+		Roles:     req.Roles,
+		Scope:     req.Scope,
+		Tenant:    req.Tenant,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		logging.C(c.Request.Context()).Error("发放API Key失败", err)
+		r.ResponseError(http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	audit.SetTarget(c, "", apiKey.ID)
+	r.ResponseOk(http.StatusCreated, e.Success, APIKeyResp{Key: key, APIKey: *apiKey})
+}
+
+// DisableAPIKey
+// @Summary      停用API Key
+// @Description  停用API Key，停用后立即不能再使用，记录保留用于审计
+// @Tags         管理
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "API Key ID"
+// @Success      200  {object}  e.Response
+// @Failure      400  {string}  json  "{"code":"400x","message":"失败"}"
+// @Failure      401  {string}  json  "{"code":"401x","message":"失败"}"
+// @Failure      403  {string}  json  "{"code":"403x","message":"失败"}"
+// @Security     ApiKeyAuth
+// @Router       /v1/admin/api_keys/{id} [delete]
+func DisableAPIKey(c *gin.Context) {
+	r := e.Gin{C: c}
+
+	err := identity.DisableKey(c.Param("id"))
+	if err != nil {
+		logging.C(c.Request.Context()).Error("停用API Key失败", err)
+		r.ResponseError(http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	r.ResponseOk(http.StatusOK, e.Success, nil)
+}
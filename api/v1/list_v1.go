@@ -0,0 +1,73 @@
+package v1
+
+import (
+	"net/http"
+	"strings"
+	"vsphere-facade/api/e"
+	"vsphere-facade/app/logging"
+	"vsphere-facade/vsphere"
+	"vsphere-facade/vsphere/protocol"
+)
+
+// ListQuery 列表查询通用的分页、排序、过滤参数
+type ListQuery struct {
+	// 每页数量，0为不分页
+	Limit int `form:"limit"`
+	// 跳过的数量
+	Offset int `form:"offset"`
+	// 排序字段，多个使用逗号分隔，-开头为倒序，如: name,-memoryMB
+	Sort string `form:"sort"`
+	// 名称包含，不区分大小写
+	Name string `form:"name"`
+	// 名称正则匹配
+	NameRegex string `form:"nameRegex"`
+	// 电源状态，只适用于虚拟机，如: poweredOn
+	PowerState string `form:"powerState"`
+	// 只返回指定的字段，多个使用逗号分隔，如: id,name
+	Fields string `form:"fields"`
+}
+
+func (q ListQuery) options() protocol.ListOptions {
+	return protocol.ListOptions{
+		Limit:      q.Limit,
+		Offset:     q.Offset,
+		Sort:       splitComma(q.Sort),
+		Name:       q.Name,
+		NameRegex:  q.NameRegex,
+		PowerState: q.PowerState,
+		Fields:     splitComma(q.Fields),
+	}
+}
+
+// bindListQuery 参数错误时直接返回400
+func bindListQuery(r *e.Gin) (protocol.ListOptions, bool) {
+	query := ListQuery{}
+	err := r.C.ShouldBindQuery(&query)
+	if err != nil {
+		logging.L().Error("解析分页参数出错: ", err)
+		r.ResponseError(http.StatusBadRequest, e.BadRequest, nil)
+		return protocol.ListOptions{}, false
+	}
+	return query.options(), true
+}
+
+// responseList 对查询结果过滤、排序、分页后返回
+func responseList(r *e.Gin, opts protocol.ListOptions, items interface{}, freshness *protocol.Freshness) {
+	data, page, err := vsphere.ApplyListOptions(items, opts)
+	if err != nil {
+		r.ResponseError(http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	r.ResponseList(http.StatusOK, e.Success, data, page, freshness)
+}
+
+func splitComma(s string) []string {
+	var values []string
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		if v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -4,8 +4,6 @@ import (
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"vsphere-facade/api/e"
-	"vsphere-facade/api/security"
-	"vsphere-facade/vsphere"
 	"vsphere-facade/vsphere/protocol"
 )
 
@@ -20,6 +18,7 @@ type NetworkQuery struct {
 // @Tags         基础设施
 // @Accept       json
 // @Produce      json
+// @Param        list  query     v1.ListQuery  false  "分页、排序、过滤参数"
 // @Param        ids           query     []string  false  "网络ID"
 // @Param        datacenterId  query     string    false  "数据中心ID"
 // @Success      200           {object}  e.Response{data=[]protocol.NetworkInfo}
@@ -30,7 +29,6 @@ type NetworkQuery struct {
 // @Router       /v1/networks [get]
 func QueryNetworks(c *gin.Context) {
 	r := e.Gin{C: c}
-	auth := security.GetCurrentAuth(c)
 
 	query := NetworkQuery{}
 	err := c.ShouldBind(&query)
@@ -49,11 +47,19 @@ func QueryNetworks(c *gin.Context) {
 		DatacenterID: query.DatacenterID,
 		IDs:          query.IDs,
 	}
-	var vc = vsphere.Get(auth)
-	networks := vc.QueryNetworks(q)
-	if networks == nil {
-		r.ResponseOk(http.StatusOK, e.Success, e.EmptyArray())
-	} else {
-		r.ResponseOk(http.StatusOK, e.Success, networks)
+
+	opts, ok := bindListQuery(&r)
+	if !ok {
+		return
+	}
+
+	vc, ok := getVCenter(&r)
+	if !ok {
+		return
+	}
+	networks, err := vc.QueryNetworks(q)
+	if !checkScope(&r, err) {
+		return
 	}
+	responseList(&r, opts, networks, nil)
 }
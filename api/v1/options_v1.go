@@ -4,8 +4,6 @@ import (
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"vsphere-facade/api/e"
-	"vsphere-facade/api/security"
-	"vsphere-facade/vsphere"
 	"vsphere-facade/vsphere/protocol"
 )
 
@@ -24,7 +22,6 @@ import (
 // @Router       /v1/datacenters [get]
 func QueryDatacenters44(c *gin.Context) {
 	r := e.Gin{C: c}
-	auth := security.GetCurrentAuth(c)
 
 	query := DatacenterQuery{}
 	err := c.ShouldBind(&query)
@@ -39,11 +36,17 @@ func QueryDatacenters44(c *gin.Context) {
 		return
 	}
 
-	var vc = vsphere.Get(auth)
+	vc, ok := getVCenter(&r)
+	if !ok {
+		return
+	}
 	q := protocol.DatacenterQuery{
 		IDs: query.IDs,
 	}
-	datacenters := vc.QueryDatacenters(q)
+	datacenters, err := vc.QueryDatacenters(q)
+	if !checkScope(&r, err) {
+		return
+	}
 	if datacenters == nil {
 		r.ResponseOk(http.StatusOK, e.Success, e.EmptyArray())
 	} else {
@@ -0,0 +1,246 @@
+package v1
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+	"vsphere-facade/api/e"
+	"vsphere-facade/app/logging"
+	"vsphere-facade/helper/clustercomputerresource"
+	"vsphere-facade/helper/datastore"
+	"vsphere-facade/helper/hostsystem"
+	"vsphere-facade/helper/virtualmachine"
+	"vsphere-facade/vsphere/protocol"
+)
+
+// PerformanceQuery 性能数据查询参数
+type PerformanceQuery struct {
+	// 指标名称，多个使用逗号分隔，格式为group.name.rollup，如: cpu.usage.average，为空时查询默认指标
+	Counters string `form:"counters"`
+	// 指标名称省略rollup时使用的汇总方式，默认为average
+	Rollup string `form:"rollup"`
+	// 采样间隔(s)，20为实时数据，其他为VC中开启的历史数据间隔，如: 300、1800、7200、86400
+	Interval int32 `form:"interval"`
+	// 实例，为空表示汇总数据，*表示所有实例(如每个CPU、每块磁盘)
+	Instance string `form:"instance"`
+	// 开始时间，RFC3339格式
+	StartTime *time.Time `form:"startTime" time_format:"2006-01-02T15:04:05Z07:00"`
+	// 结束时间，RFC3339格式
+	EndTime *time.Time `form:"endTime" time_format:"2006-01-02T15:04:05Z07:00"`
+	// 最多返回的样本数，没有指定开始时间时默认为1，最大为1440
+	MaxSample int32 `form:"maxSample"`
+}
+
+// BatchPerformanceReq 批量查询虚拟机性能数据
+type BatchPerformanceReq struct {
+	// 虚拟机ID，一次最多100个
+	IDs       []string   `json:"ids" valid:"Required;MinSize(1);MaxSize(100)"`
+	Counters  []string   `json:"counters"`
+	Rollup    string     `json:"rollup"`
+	Interval  int32      `json:"interval"`
+	Instance  string     `json:"instance"`
+	StartTime *time.Time `json:"startTime"`
+	EndTime   *time.Time `json:"endTime"`
+	MaxSample int32      `json:"maxSample"`
+}
+
+// GetVirtualMachinePerformance
+// @Summary      查询虚拟机性能数据
+// @Description  查询虚拟机的实时或历史性能数据，默认查询最新的CPU、内存、磁盘和网络使用情况
+// @Tags         性能
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "虚拟机ID"
+// @Param        c    query     v1.PerformanceQuery  false  "查询参数"
+// @Success      200  {object}  e.Response{data=[]protocol.PerformanceInfo}
+// @Failure      400  {string}  json  "{"code":"400x","message":"失败"}"
+// @Failure      401  {string}  json  "{"code":"401x","message":"失败"}"
+// @Failure      500  {string}  json  "{"code":"500x","message":"失败"}"
+// @Security     ApiKeyAuth
+// @Router       /v1/virtual_machines/{id}/performance [get]
+func GetVirtualMachinePerformance(c *gin.Context) {
+	queryPerformance(c, virtualmachine.Type, c.Param("id"))
+}
+
+// GetHostPerformance
+// @Summary      查询主机性能数据
+// @Description  查询主机的实时或历史性能数据，默认查询最新的CPU、内存、磁盘和网络使用情况
+// @Tags         性能
+// @Accept       json
+// @Produce      json
+// @Param        hostID  path      string  true  "主机ID"
+// @Param        c       query     v1.PerformanceQuery  false  "查询参数"
+// @Success      200     {object}  e.Response{data=[]protocol.PerformanceInfo}
+// @Failure      400     {string}  json  "{"code":"400x","message":"失败"}"
+// @Failure      401     {string}  json  "{"code":"401x","message":"失败"}"
+// @Failure      500     {string}  json  "{"code":"500x","message":"失败"}"
+// @Security     ApiKeyAuth
+// @Router       /v1/hosts/{hostID}/performance [get]
+func GetHostPerformance(c *gin.Context) {
+	queryPerformance(c, hostsystem.Type, c.Param("hostID"))
+}
+
+// GetClusterPerformance
+// @Summary      查询集群性能数据
+// @Description  查询集群的历史性能数据(集群没有实时数据)，默认查询最新的CPU和内存使用情况
+// @Tags         性能
+// @Accept       json
+// @Produce      json
+// @Param        clusterID  path      string  true  "集群ID"
+// @Param        c          query     v1.PerformanceQuery  false  "查询参数"
+// @Success      200        {object}  e.Response{data=[]protocol.PerformanceInfo}
+// @Failure      400        {string}  json  "{"code":"400x","message":"失败"}"
+// @Failure      401        {string}  json  "{"code":"401x","message":"失败"}"
+// @Failure      500        {string}  json  "{"code":"500x","message":"失败"}"
+// @Security     ApiKeyAuth
+// @Router       /v1/clusters/{clusterID}/performance [get]
+func GetClusterPerformance(c *gin.Context) {
+	queryPerformance(c, clustercomputerresource.Type, c.Param("clusterID"))
+}
+
+// GetDatastorePerformance
+// @Summary      查询存储性能数据
+// @Description  查询存储的历史性能数据(存储没有实时数据)，默认查询最新的容量、已使用和已置备空间
+// @Tags         性能
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "存储ID"
+// @Param        c    query     v1.PerformanceQuery  false  "查询参数"
+// @Success      200  {object}  e.Response{data=[]protocol.PerformanceInfo}
+// @Failure      400  {string}  json  "{"code":"400x","message":"失败"}"
+// @Failure      401  {string}  json  "{"code":"401x","message":"失败"}"
+// @Failure      500  {string}  json  "{"code":"500x","message":"失败"}"
+// @Security     ApiKeyAuth
+// @Router       /v1/datastores/{id}/performance [get]
+func GetDatastorePerformance(c *gin.Context) {
+	queryPerformance(c, datastore.Type, c.Param("id"))
+}
+
+// QueryVirtualMachinesPerformance
+// @Summary      批量查询虚拟机性能数据
+// @Description  一次查询多台虚拟机的实时或历史性能数据，参数含义与单台查询相同
+// @Tags         性能
+// @Accept       json
+// @Produce      json
+// @Param        c    body      v1.BatchPerformanceReq  true  "查询参数"
+// @Success      200  {object}  e.Response{data=[]protocol.PerformanceInfo}
+// @Failure      400  {string}  json  "{"code":"400x","message":"失败"}"
+// @Failure      401  {string}  json  "{"code":"401x","message":"失败"}"
+// @Failure      500  {string}  json  "{"code":"500x","message":"失败"}"
+// @Security     ApiKeyAuth
+// @Router       /v1/virtual_machines/performance [post]
+func QueryVirtualMachinesPerformance(c *gin.Context) {
+	r := e.Gin{C: c}
+
+	p := BatchPerformanceReq{}
+	err := c.ShouldBind(&p)
+	if err != nil {
+		logging.L().Error("解析请求参数出错: ", err)
+		r.ResponseError(http.StatusBadRequest, e.BadRequest, nil)
+		return
+	}
+
+	errors := e.ValidReqParam(&p)
+	if len(errors) > 0 {
+		r.ResponseErrors(http.StatusBadRequest, errors, nil)
+		return
+	}
+
+	vc, ok := getVCenter(&r)
+	if !ok {
+		return
+	}
+	performanceInfos, err := vc.QueryPerformance(protocol.PerformanceQuery{
+		EntityType: virtualmachine.Type,
+		IDs:        p.IDs,
+		Counters:   p.Counters,
+		Rollup:     p.Rollup,
+		Interval:   p.Interval,
+		Instance:   p.Instance,
+		StartTime:  p.StartTime,
+		EndTime:    p.EndTime,
+		MaxSample:  p.MaxSample,
+	})
+	if outOfScope(err) {
+		checkScope(&r, err)
+		return
+	}
+	if err != nil {
+		logging.L().Error("查询性能数据失败", err)
+		r.ResponseError(http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	r.ResponseOk(http.StatusOK, e.Success, performanceInfos)
+}
+
+// QueryPerformanceCounters
+// @Summary      查询性能指标
+// @Description  查询VC支持的所有性能指标，name可以作为性能数据查询的counters参数
+// @Tags         性能
+// @Accept       json
+// @Produce      json
+// @Param        list  query     v1.ListQuery  false  "分页、排序、过滤参数"
+// @Success      200  {object}  e.Response{data=[]protocol.PerformanceCounterInfo}
+// @Failure      400  {string}  json  "{"code":"400x","message":"失败"}"
+// @Failure      401  {string}  json  "{"code":"401x","message":"失败"}"
+// @Failure      500  {string}  json  "{"code":"500x","message":"失败"}"
+// @Security     ApiKeyAuth
+// @Router       /v1/performance_counters [get]
+func QueryPerformanceCounters(c *gin.Context) {
+	r := e.Gin{C: c}
+
+	opts, ok := bindListQuery(&r)
+	if !ok {
+		return
+	}
+
+	vc, ok := getVCenter(&r)
+	if !ok {
+		return
+	}
+	counters, err := vc.QueryPerformanceCounters()
+	if err != nil {
+		logging.L().Error("查询性能指标失败", err)
+		r.ResponseError(http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	responseList(&r, opts, counters, nil)
+}
+
+func queryPerformance(c *gin.Context, entityType, ID string) {
+	r := e.Gin{C: c}
+
+	query := PerformanceQuery{}
+	err := c.ShouldBindQuery(&query)
+	if err != nil {
+		logging.L().Error("解析请求参数出错: ", err)
+		r.ResponseError(http.StatusBadRequest, e.BadRequest, nil)
+		return
+	}
+
+	vc, ok := getVCenter(&r)
+	if !ok {
+		return
+	}
+	performanceInfos, err := vc.QueryPerformance(protocol.PerformanceQuery{
+		EntityType: entityType,
+		IDs:        []string{ID},
+		Counters:   splitComma(query.Counters),
+		Rollup:     query.Rollup,
+		Interval:   query.Interval,
+		Instance:   query.Instance,
+		StartTime:  query.StartTime,
+		EndTime:    query.EndTime,
+		MaxSample:  query.MaxSample,
+	})
+	if outOfScope(err) {
+		checkScope(&r, err)
+		return
+	}
+	if err != nil {
+		logging.L().Error("查询性能数据失败", err)
+		r.ResponseError(http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	r.ResponseOk(http.StatusOK, e.Success, performanceInfos)
+}
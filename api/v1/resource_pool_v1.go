@@ -4,8 +4,6 @@ import (
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"vsphere-facade/api/e"
-	"vsphere-facade/api/security"
-	"vsphere-facade/vsphere"
 	"vsphere-facade/vsphere/protocol"
 )
 
@@ -22,6 +20,7 @@ type ResourcePoolQuery struct {
 // @Tags         基础设施
 // @Accept       json
 // @Produce      json
+// @Param        list  query     v1.ListQuery  false  "分页、排序、过滤参数"
 // @Param        ids           query     []string  false  "资源池ID"
 // @Param        datacenterId  query     string    false  "数据中心ID"
 // @Param        clusterId     query     string    false  "集群ID"
@@ -34,7 +33,6 @@ type ResourcePoolQuery struct {
 // @Router       /v1/resource_pools [get]
 func QueryResourcePools(c *gin.Context) {
 	r := e.Gin{C: c}
-	auth := security.GetCurrentAuth(c)
 
 	query := ResourcePoolQuery{}
 	err := c.ShouldBind(&query)
@@ -55,11 +53,19 @@ func QueryResourcePools(c *gin.Context) {
 		HostID:       query.HostID,
 		IDs:          query.IDs,
 	}
-	var vc = vsphere.Get(auth)
-	resourcePools := vc.QueryResourcePools(q)
-	if resourcePools == nil {
-		r.ResponseOk(http.StatusOK, e.Success, e.EmptyArray())
-	} else {
-		r.ResponseOk(http.StatusOK, e.Success, resourcePools)
+
+	opts, ok := bindListQuery(&r)
+	if !ok {
+		return
+	}
+
+	vc, ok := getVCenter(&r)
+	if !ok {
+		return
+	}
+	resourcePools, err := vc.QueryResourcePools(q)
+	if !checkScope(&r, err) {
+		return
 	}
+	responseList(&r, opts, resourcePools, nil)
 }
@@ -44,7 +44,7 @@ func CreateSnapshot(c *gin.Context) {
 	res.RequestID = taskreceiver.Receive(workerpool.WorkerTypeDeployment, p)
 	go func() {
 		defer taskreceiver.Done(res.RequestID)
-		vsphere.Get(auth)
+		_, _ = vsphere.Get(auth)
 	}()
 	r.ResponseOk(http.StatusAccepted, e.Accepted, res)
 }
@@ -83,7 +83,7 @@ func DeleteSnapshot(c *gin.Context) {
 	res.RequestID = taskreceiver.Receive(workerpool.WorkerTypeDeployment, p)
 	go func() {
 		defer taskreceiver.Done(res.RequestID)
-		vsphere.Get(auth)
+		_, _ = vsphere.Get(auth)
 	}()
 	r.ResponseOk(http.StatusAccepted, e.Accepted, res)
 }
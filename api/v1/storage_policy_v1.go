@@ -4,8 +4,6 @@ import (
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"vsphere-facade/api/e"
-	"vsphere-facade/api/security"
-	"vsphere-facade/vsphere"
 	"vsphere-facade/vsphere/protocol"
 )
 
@@ -18,6 +16,7 @@ type StoragePolicyQuery struct {
 // @Tags         基础设施
 // @Accept       json
 // @Produce      json
+// @Param        list  query     v1.ListQuery  false  "分页、排序、过滤参数"
 // @Success      200  {object}  e.Response{data=[]protocol.StoragePolicyInfo}
 // @Failure      400  {string}  json  "{"code":"400x","message":"失败"}"
 // @Failure      401  {string}  json  "{"code":"401x","message":"失败"}"
@@ -26,7 +25,6 @@ type StoragePolicyQuery struct {
 // @Router       /v1/storage_policies [get]
 func QueryStoragePolies(c *gin.Context) {
 	r := e.Gin{C: c}
-	auth := security.GetCurrentAuth(c)
 
 	query := StoragePolicyQuery{}
 	err := c.ShouldBind(&query)
@@ -41,11 +39,15 @@ func QueryStoragePolies(c *gin.Context) {
 		return
 	}
 
-	var vc = vsphere.Get(auth)
-	policies := vc.QueryStoragePolicies(protocol.StoragePolicyQuery{})
-	if policies == nil {
-		r.ResponseOk(http.StatusOK, e.Success, e.EmptyArray())
-	} else {
-		r.ResponseOk(http.StatusOK, e.Success, policies)
+	opts, ok := bindListQuery(&r)
+	if !ok {
+		return
+	}
+
+	vc, ok := getVCenter(&r)
+	if !ok {
+		return
 	}
+	policies := vc.QueryStoragePolicies(protocol.StoragePolicyQuery{})
+	responseList(&r, opts, policies, nil)
 }
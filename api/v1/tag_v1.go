@@ -0,0 +1,255 @@
+package v1
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"vsphere-facade/api/audit"
+	"vsphere-facade/api/e"
+	"vsphere-facade/app/logging"
+	"vsphere-facade/vsphere/protocol"
+)
+
+type TagQuery struct {
+	CategoryID string `form:"categoryId"`
+}
+
+type TagAssociationReq struct {
+	TagIDs  []string             `json:"tagIds" valid:"Required"`
+	Objects []protocol.ObjectRef `json:"objects" valid:"Required"`
+}
+
+type CustomAttributeReq struct {
+	Object protocol.ObjectRef `json:"object" valid:"Required"`
+	Values map[string]string  `json:"values" valid:"Required"`
+}
+
+// QueryTagCategories
+// @Summary      标签分类查询
+// @Description  标签分类查询
+// @Tags         标签
+// @Accept       json
+// @Produce      json
+// @Param        list  query     v1.ListQuery  false  "分页、排序、过滤参数"
+// @Success      200  {object}  e.Response{data=[]protocol.TagCategoryInfo}
+// @Failure      400  {string}  json  "{"code":"400x","message":"失败"}"
+// @Failure      401  {string}  json  "{"code":"401x","message":"失败"}"
+// @Failure      500  {string}  json  "{"code":"500x","message":"失败"}"
+// @Security     ApiKeyAuth
+// @Router       /v1/tag_categories [get]
+func QueryTagCategories(c *gin.Context) {
+	r := e.Gin{C: c}
+
+	opts, ok := bindListQuery(&r)
+	if !ok {
+		return
+	}
+
+	vc, ok := getVCenter(&r)
+	if !ok {
+		return
+	}
+	categories := vc.QueryTagCategories()
+	responseList(&r, opts, categories, nil)
+}
+
+// QueryTags
+// @Summary      标签查询
+// @Description  标签查询
+// @Tags         标签
+// @Accept       json
+// @Produce      json
+// @Param        list  query     v1.ListQuery  false  "分页、排序、过滤参数"
+// @Param        c    query     v1.TagQuery  false  "查询参数"
+// @Success      200  {object}  e.Response{data=[]protocol.TagInfo}
+// @Failure      400  {string}  json  "{"code":"400x","message":"失败"}"
+// @Failure      401  {string}  json  "{"code":"401x","message":"失败"}"
+// @Failure      500  {string}  json  "{"code":"500x","message":"失败"}"
+// @Security     ApiKeyAuth
+// @Router       /v1/tags [get]
+func QueryTags(c *gin.Context) {
+	r := e.Gin{C: c}
+
+	query := TagQuery{}
+	err := c.ShouldBind(&query)
+	if err != nil {
+		r.ResponseError(http.StatusBadRequest, e.BadRequest, nil)
+		return
+	}
+
+	opts, ok := bindListQuery(&r)
+	if !ok {
+		return
+	}
+
+	vc, ok := getVCenter(&r)
+	if !ok {
+		return
+	}
+	tags := vc.QueryTags(protocol.TagQuery{
+		CategoryID: query.CategoryID,
+	})
+	responseList(&r, opts, tags, nil)
+}
+
+// AttachTags
+// @Summary      添加标签
+// @Description  为虚拟机或其他清单对象添加标签
+// @Tags         标签
+// @Accept       json
+// @Produce      json
+// @Param        c    body      v1.TagAssociationReq  true  "请求参数"
+// @Success      200  {object}  e.Response
+// @Failure      400  {string}  json  "{"code":"400x","message":"失败"}"
+// @Failure      401  {string}  json  "{"code":"401x","message":"失败"}"
+// @Failure      500  {string}  json  "{"code":"500x","message":"失败"}"
+// @Security     ApiKeyAuth
+// @Router       /v1/tags/attach [post]
+func AttachTags(c *gin.Context) {
+	r := e.Gin{C: c}
+
+	p := TagAssociationReq{}
+	err := c.ShouldBind(&p)
+	if err != nil {
+		logging.L().Error("解析请求参数出错: ", err)
+		r.ResponseError(http.StatusBadRequest, e.BadRequest, nil)
+		return
+	}
+
+	errors := e.ValidReqParam(&p)
+	if len(errors) > 0 {
+		r.ResponseErrors(http.StatusBadRequest, errors, nil)
+		return
+	}
+
+	vc, ok := getVCenter(&r)
+	if !ok {
+		return
+	}
+	audit.SetTarget(c, vc.Api.ID, objectRefIDs(p.Objects)...)
+	err = vc.AttachTags(p.TagIDs, p.Objects)
+	if !checkScope(&r, err) {
+		return
+	}
+	r.ResponseOk(http.StatusOK, e.Success, nil)
+}
+
+// DetachTags
+// @Summary      移除标签
+// @Description  移除虚拟机或其他清单对象的标签
+// @Tags         标签
+// @Accept       json
+// @Produce      json
+// @Param        c    body      v1.TagAssociationReq  true  "请求参数"
+// @Success      200  {object}  e.Response
+// @Failure      400  {string}  json  "{"code":"400x","message":"失败"}"
+// @Failure      401  {string}  json  "{"code":"401x","message":"失败"}"
+// @Failure      500  {string}  json  "{"code":"500x","message":"失败"}"
+// @Security     ApiKeyAuth
+// @Router       /v1/tags/detach [post]
+func DetachTags(c *gin.Context) {
+	r := e.Gin{C: c}
+
+	p := TagAssociationReq{}
+	err := c.ShouldBind(&p)
+	if err != nil {
+		logging.L().Error("解析请求参数出错: ", err)
+		r.ResponseError(http.StatusBadRequest, e.BadRequest, nil)
+		return
+	}
+
+	errors := e.ValidReqParam(&p)
+	if len(errors) > 0 {
+		r.ResponseErrors(http.StatusBadRequest, errors, nil)
+		return
+	}
+
+	vc, ok := getVCenter(&r)
+	if !ok {
+		return
+	}
+	audit.SetTarget(c, vc.Api.ID, objectRefIDs(p.Objects)...)
+	err = vc.DetachTags(p.TagIDs, p.Objects)
+	if !checkScope(&r, err) {
+		return
+	}
+	r.ResponseOk(http.StatusOK, e.Success, nil)
+}
+
+// QueryCustomAttributes
+// @Summary      自定义属性查询
+// @Description  自定义属性定义查询
+// @Tags         标签
+// @Accept       json
+// @Produce      json
+// @Param        list  query     v1.ListQuery  false  "分页、排序、过滤参数"
+// @Success      200  {object}  e.Response{data=[]protocol.CustomAttributeInfo}
+// @Failure      400  {string}  json  "{"code":"400x","message":"失败"}"
+// @Failure      401  {string}  json  "{"code":"401x","message":"失败"}"
+// @Failure      500  {string}  json  "{"code":"500x","message":"失败"}"
+// @Security     ApiKeyAuth
+// @Router       /v1/custom_attributes [get]
+func QueryCustomAttributes(c *gin.Context) {
+	r := e.Gin{C: c}
+
+	opts, ok := bindListQuery(&r)
+	if !ok {
+		return
+	}
+
+	vc, ok := getVCenter(&r)
+	if !ok {
+		return
+	}
+	attributes := vc.QueryCustomAttributes()
+	responseList(&r, opts, attributes, nil)
+}
+
+// SetCustomAttributes
+// @Summary      设置自定义属性
+// @Description  设置虚拟机或其他清单对象的自定义属性值
+// @Tags         标签
+// @Accept       json
+// @Produce      json
+// @Param        c    body      v1.CustomAttributeReq  true  "请求参数"
+// @Success      200  {object}  e.Response
+// @Failure      400  {string}  json  "{"code":"400x","message":"失败"}"
+// @Failure      401  {string}  json  "{"code":"401x","message":"失败"}"
+// @Failure      500  {string}  json  "{"code":"500x","message":"失败"}"
+// @Security     ApiKeyAuth
+// @Router       /v1/custom_attributes [post]
+func SetCustomAttributes(c *gin.Context) {
+	r := e.Gin{C: c}
+
+	p := CustomAttributeReq{}
+	err := c.ShouldBind(&p)
+	if err != nil {
+		logging.L().Error("解析请求参数出错: ", err)
+		r.ResponseError(http.StatusBadRequest, e.BadRequest, nil)
+		return
+	}
+
+	errors := e.ValidReqParam(&p)
+	if len(errors) > 0 {
+		r.ResponseErrors(http.StatusBadRequest, errors, nil)
+		return
+	}
+
+	vc, ok := getVCenter(&r)
+	if !ok {
+		return
+	}
+	audit.SetTarget(c, vc.Api.ID, p.Object.ID)
+	err = vc.SetCustomAttributes(p.Object, p.Values)
+	if !checkScope(&r, err) {
+		return
+	}
+	r.ResponseOk(http.StatusOK, e.Success, nil)
+}
+
+func objectRefIDs(refs []protocol.ObjectRef) []string {
+	var ids []string
+	for _, ref := range refs {
+		ids = append(ids, ref.ID)
+	}
+	return ids
+}
@@ -4,7 +4,6 @@ import (
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"vsphere-facade/api/e"
-	"vsphere-facade/api/security"
 	"vsphere-facade/app/logging"
 	"vsphere-facade/config"
 	"vsphere-facade/vsphere"
@@ -29,7 +28,6 @@ type CleanCacheKey struct {
 // @Router       /v1/caches [delete]
 func CleanCache(c *gin.Context) {
 	r := e.Gin{C: c}
-	auth := security.GetCurrentAuth(c)
 
 	p := CleanCacheKey{}
 	err := c.ShouldBind(&p)
@@ -45,10 +43,15 @@ func CleanCache(c *gin.Context) {
 		return
 	}
 
-	var vc = vsphere.Get(auth)
+	vc, ok := getVCenter(&r)
+	if !ok {
+		return
+	}
 	if p.Keys != nil {
 		vc.Cache.Clean(*p.Keys...)
 	} else {
+		// 清除全部缓存时同时停止清单变化监听，重新创建缓存时再启动
+		vc.StopWatcher()
 		vc.Cache.CleanAll()
 	}
 	r.ResponseOk(http.StatusOK, e.Success, nil)
@@ -56,7 +59,7 @@ func CleanCache(c *gin.Context) {
 
 // CreateCache
 // @Summary      创建缓存
-// @Description  创建缓存
+// @Description  创建缓存，并在后台监听清单变化增量更新缓存
 // @Tags         缓存
 // @Accept       json
 // @Produce      json
@@ -68,13 +71,31 @@ func CleanCache(c *gin.Context) {
 // @Router       /v1/caches [post]
 func CreateCache(c *gin.Context) {
 	r := e.Gin{C: c}
-	auth := security.GetCurrentAuth(c)
 
 	if config.G.Vsphere.Cache.Enable {
-		var vc = vsphere.Get(auth)
+		vc, ok := getVCenter(&r)
+		if !ok {
+			return
+		}
 		go vc.CreateCache()
 		r.ResponseOk(http.StatusAccepted, e.Accepted, nil)
 	} else {
 		r.ResponseOk(http.StatusBadRequest, e.NotEnabled, nil)
 	}
 }
+
+// QueryVCenters
+// @Summary      查询登记的VC
+// @Description  查询配置中登记的VC及其服务账号缓存会话的状态，不会登录VC，不需要令牌关联VC账号
+// @Tags         VC
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  e.Response{data=[]vsphere.VCenterStatus}
+// @Failure      401  {string}  json  "{"code":"401x","message":"失败"}"
+// @Failure      500  {string}  json  "{"code":"500x","message":"失败"}"
+// @Security     ApiKeyAuth
+// @Router       /v1/vcenters [get]
+func QueryVCenters(c *gin.Context) {
+	r := e.Gin{C: c}
+	r.ResponseOk(http.StatusOK, e.Success, vsphere.VCenterStatuses())
+}
@@ -1,11 +1,23 @@
 package v1
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"vsphere-facade/api/audit"
 	"vsphere-facade/api/e"
+	"vsphere-facade/api/ratelimit"
 	"vsphere-facade/api/security"
 	"vsphere-facade/app/logging"
+	"vsphere-facade/config"
+	"vsphere-facade/helper"
+	"vsphere-facade/helper/virtualmachine/virtualmachinereconfig"
 	"vsphere-facade/vsphere"
 	"vsphere-facade/vsphere/callback"
 	"vsphere-facade/vsphere/protocol"
@@ -57,6 +69,7 @@ type VirtualMachineQuery struct {
 	ClusterID    string   `json:"clusterId"`
 	HostID       string   `json:"hostId"`
 	IDs          []string `json:"ids"`
+	TagIDs       []string `json:"tagIds" form:"tagIds"`
 }
 
 type TemplateQuery struct {
@@ -113,17 +126,18 @@ type RelocateReq struct {
 // @Success      202  {object}  e.Response{data=[]v1.DeployRes}
 // @Failure      400  {string}  json  "{"code":"400x","message":"失败"}"
 // @Failure      401  {string}  json  "{"code":"401x","message":"失败"}"
+// @Failure      403  {string}  json  "{"code":"403x","message":"失败"}"
+// @Failure      429  {string}  json  "{"code":"429x","message":"失败"}"
 // @Failure      500  {string}  json  "{"code":"500x","message":"失败"}"
 // @Security     ApiKeyAuth
 // @Router       /v1/virtual_machines [post]
 func CreateVirtualMachine(c *gin.Context) {
 	r := e.Gin{C: c}
-	auth := security.GetCurrentAuth(c)
 
 	p := DeployReq{}
 	err := c.ShouldBind(&p)
 	if err != nil {
-		logging.L().Error("解析请求参数出错: ", err)
+		logging.C(c.Request.Context()).Error("解析请求参数出错: ", err)
 		r.ResponseError(http.StatusBadRequest, err.Error(), nil)
 		return
 	}
@@ -134,43 +148,60 @@ func CreateVirtualMachine(c *gin.Context) {
 		return
 	}
 
+	vc, ok := getVCenter(&r)
+	if !ok {
+		return
+	}
+	if !checkScope(&r, vc.CheckDeployment(p.Parameter)) {
+		return
+	}
+	reservation, err := vc.CheckDeploymentQuota(security.GetCurrentTenant(c), &p.Parameter)
+	if !checkQuota(&r, err) {
+		return
+	}
+
 	res := DeployRes{}
 	res.RequestID = taskreceiver.Receive(workerpool.WorkerTypeDeployment, p)
-	var vc = vsphere.Get(auth)
 	vmDeployer := workerpool.NewVirtualMachineDeployer(vc.Api)
 	vmDeployer.DeployID = res.RequestID
 	vmDeployer.Parameter = p.Parameter
 	vmDeployer.TimeoutSetting = p.Timeout
 	errs := vmDeployer.Verify()
 	if errs != nil {
+		reservation.Release()
 		r.ResponseError(http.StatusBadRequest, e.BadRequest, errs)
 		return
 	}
 
-	err = workerpool.AddTask(vc.Api.ID, workerpool.WorkerTypeDeployment, func() {
+	ctx := taskContext(c, res.RequestID, vc.Api.ID)
+	err = workerpool.AddTask(ctx, vc.Api.ID, workerpool.WorkerTypeDeployment, func(ctx context.Context) {
 		defer taskreceiver.Done(res.RequestID)
+		defer reservation.Release()
 		var callBack = p.CallBack
 		callBack.RequestID = vmDeployer.DeployID
-		err := vmDeployer.Deploy()
+		err := vmDeployer.Deploy(ctx)
 		if err == nil {
 			VMID := vmDeployer.NewMachineID()
+			vc.RefreshVirtualMachineCache(VMID)
 			instanceInfo := vc.GetVirtualMachine(VMID)
-			deploymentCallBack(callBack, DeploymentCallBackRes{
+			deploymentCallBack(ctx, callBack, DeploymentCallBackRes{
 				IsSuccess: true,
 				Instance:  instanceInfo,
 			})
 		} else {
 			message := err.Error()
-			deploymentCallBack(callBack, DeploymentCallBackRes{
+			deploymentCallBack(ctx, callBack, DeploymentCallBackRes{
 				IsSuccess: false,
 				Message:   &message,
 			})
 		}
 	})
 	if err != nil {
-		logging.L().Error("创建部署任务失败: ", err)
+		logging.C(ctx).Error("创建部署任务失败: ", err)
+		reservation.Release()
 		taskreceiver.Cancel(res.RequestID, "任务创建失败")
-		r.ResponseOk(http.StatusInternalServerError, e.SystemError, nil)
+		audit.Complete(res.RequestID, audit.OutcomeFailure, nil, "任务创建失败")
+		responseTaskError(&r, err)
 	} else {
 		r.ResponseOk(http.StatusAccepted, e.Accepted, res)
 	}
@@ -186,17 +217,17 @@ func CreateVirtualMachine(c *gin.Context) {
 // @Success      202  {object}  e.Response{data=[]v1.OperationRes}
 // @Failure      400  {string}  json  "{"code":"400x","message":"失败"}"
 // @Failure      401  {string}  json  "{"code":"401x","message":"失败"}"
+// @Failure      429  {string}  json  "{"code":"429x","message":"失败"}"
 // @Failure      500  {string}  json  "{"code":"500x","message":"失败"}"
 // @Security     ApiKeyAuth
 // @Router       /v1/virtual_machines [delete]
 func DeleteVirtualMachine(c *gin.Context) {
 	r := e.Gin{C: c}
-	auth := security.GetCurrentAuth(c)
 
 	p := OperationReq{}
 	err := c.ShouldBind(&p)
 	if err != nil {
-		logging.L().Error("解析请求参数出错: ", err)
+		logging.C(c.Request.Context()).Error("解析请求参数出错: ", err)
 		r.ResponseError(http.StatusBadRequest, e.BadRequest, nil)
 		return
 	}
@@ -207,23 +238,32 @@ func DeleteVirtualMachine(c *gin.Context) {
 		return
 	}
 
+	vc, ok := getVCenter(&r)
+	if !ok {
+		return
+	}
+	machines, err := vc.GetVirtualMachineOperators(p.IDs...)
+	if !checkScope(&r, err) {
+		return
+	}
+
 	res := OperationRes{}
 	res.RequestID = taskreceiver.Receive(workerpool.WorkerTypeOperation, p)
-	var vc = vsphere.Get(auth)
-	err = workerpool.AddTask(vc.Api.ID, workerpool.WorkerTypeOperation, func() {
+	ctx := taskContext(c, res.RequestID, vc.Api.ID)
+	err = workerpool.AddTask(ctx, vc.Api.ID, workerpool.WorkerTypeOperation, func(ctx context.Context) {
 		defer taskreceiver.Done(res.RequestID)
 		var success, notFound []string
 		var failed []OperationFailed
 		var callBack = p.CallBack
 		for _, ID := range p.IDs {
-			machine := workerpool.GetVirtualMachineOperator(vc.Api, ID)
+			machine := machines[ID]
 			if machine == nil {
 				notFound = append(notFound, ID)
 				continue
 			}
-			err := machine.Destroy()
+			err := machine.WithContext(ctx).Destroy()
 			if err != nil {
-				logging.L().Error("开机失败: ", err)
+				logging.C(ctx).With(logging.FieldVMID, ID).Error("开机失败: ", err)
 				failed = append(failed, OperationFailed{
 					ID:    ID,
 					Error: err.Error(),
@@ -232,14 +272,16 @@ func DeleteVirtualMachine(c *gin.Context) {
 				success = append(success, ID)
 			}
 		}
+		vc.RefreshVirtualMachineCache(p.IDs...)
 		callBack.RequestID = res.RequestID
-		operationCallBack(callBack, success, notFound, failed)
+		operationCallBack(ctx, callBack, success, notFound, failed)
 	})
 
 	if err != nil {
-		logging.L().Error("删除任务创建失败: ", err)
+		logging.C(ctx).Error("删除任务创建失败: ", err)
 		taskreceiver.Cancel(res.RequestID, "任务创建失败")
-		r.ResponseOk(http.StatusInternalServerError, e.SystemError, nil)
+		audit.Complete(res.RequestID, audit.OutcomeFailure, nil, "任务创建失败")
+		responseTaskError(&r, err)
 	} else {
 		r.ResponseOk(http.StatusAccepted, e.Accepted, res)
 	}
@@ -255,17 +297,18 @@ func DeleteVirtualMachine(c *gin.Context) {
 // @Success      202  {object}  e.Response{data=[]v1.OperationRes}
 // @Failure      400  {string}  json  "{"code":"400x","message":"失败"}"
 // @Failure      401  {string}  json  "{"code":"401x","message":"失败"}"
+// @Failure      403  {string}  json  "{"code":"403x","message":"失败"}"
+// @Failure      429  {string}  json  "{"code":"429x","message":"失败"}"
 // @Failure      500  {string}  json  "{"code":"500x","message":"失败"}"
 // @Security     ApiKeyAuth
 // @Router       /v1/virtual_machines/reconfigure [post]
 func ModifyVirtualMachineConfigure(c *gin.Context) {
 	r := e.Gin{C: c}
-	auth := security.GetCurrentAuth(c)
 
 	p := ReconfigureReq{}
 	err := c.ShouldBind(&p)
 	if err != nil {
-		logging.L().Error("解析请求参数出错: ", err)
+		logging.C(c.Request.Context()).Error("解析请求参数出错: ", err)
 		r.ResponseError(http.StatusBadRequest, e.BadRequest, nil)
 		return
 	}
@@ -276,24 +319,42 @@ func ModifyVirtualMachineConfigure(c *gin.Context) {
 		return
 	}
 
-	var vc = vsphere.Get(auth)
-	machine := workerpool.GetVirtualMachineOperator(vc.Api, p.ID)
+	err = virtualmachinereconfig.CheckExtraConfig(p.ExtraConfig)
+	if err != nil {
+		r.ResponseErrors(http.StatusBadRequest, []e.ReqParamError{{Key: "extraConfig", Message: err.Error()}}, nil)
+		return
+	}
+
+	vc, ok := getVCenter(&r)
+	if !ok {
+		return
+	}
+	machine, err := vc.GetVirtualMachineOperator(p.ID)
+	if !checkScope(&r, err) {
+		return
+	}
 	if machine == nil {
 		r.ResponseError(http.StatusBadRequest, e.VMNotFound, nil)
 		return
 	}
+	reservation, err := vc.CheckReconfigureQuota(security.GetCurrentTenant(c), p.ID, p.ReconfigureParameter)
+	if !checkQuota(&r, err) {
+		return
+	}
 
 	res := OperationRes{}
 	res.RequestID = taskreceiver.Receive(workerpool.WorkerTypeOperation, p)
-	err = workerpool.AddTask(vc.Api.ID, workerpool.WorkerTypeOperation, func() {
+	ctx := taskContext(c, res.RequestID, vc.Api.ID)
+	err = workerpool.AddTask(ctx, vc.Api.ID, workerpool.WorkerTypeOperation, func(ctx context.Context) {
 		defer taskreceiver.Done(res.RequestID)
+		defer reservation.Release()
 		var success, notFound []string
 		var failed []OperationFailed
 		var callBack = p.CallBack
 
-		err = machine.Reconfigure(p.ReconfigureParameter)
+		err = machine.WithContext(ctx).Reconfigure(p.ReconfigureParameter)
 		if err != nil {
-			logging.L().Error("修改配置失败", err)
+			logging.C(ctx).With(logging.FieldVMID, p.ID).Error("修改配置失败", err)
 			failed = append(failed, OperationFailed{
 				ID:    p.ID,
 				Error: err.Error(),
@@ -301,13 +362,16 @@ func ModifyVirtualMachineConfigure(c *gin.Context) {
 		} else {
 			success = append(success, p.ID)
 		}
+		vc.RefreshVirtualMachineCache(p.ID)
 		callBack.RequestID = res.RequestID
-		operationCallBack(callBack, success, notFound, failed)
+		operationCallBack(ctx, callBack, success, notFound, failed)
 	})
 	if err != nil {
-		logging.L().Error("创建配置修改任务失败: ", err)
+		logging.C(ctx).Error("创建配置修改任务失败: ", err)
+		reservation.Release()
 		taskreceiver.Cancel(res.RequestID, "任务创建失败")
-		r.ResponseOk(http.StatusInternalServerError, e.SystemError, nil)
+		audit.Complete(res.RequestID, audit.OutcomeFailure, nil, "任务创建失败")
+		responseTaskError(&r, err)
 	} else {
 		r.ResponseOk(http.StatusAccepted, e.Accepted, res)
 	}
@@ -323,17 +387,17 @@ func ModifyVirtualMachineConfigure(c *gin.Context) {
 // @Success      202  {object}  e.Response{data=[]v1.OperationRes}
 // @Failure      400  {string}  json  "{"code":"400x","message":"失败"}"
 // @Failure      401  {string}  json  "{"code":"401x","message":"失败"}"
+// @Failure      429  {string}  json  "{"code":"429x","message":"失败"}"
 // @Failure      500  {string}  json  "{"code":"500x","message":"失败"}"
 // @Security     ApiKeyAuth
 // @Router       /v1/virtual_machines/reconfigure_nic [post]
 func ReconfigureVirtualMachineNic(c *gin.Context) {
 	r := e.Gin{C: c}
-	auth := security.GetCurrentAuth(c)
 
 	p := NicReconfigureReq{}
 	err := c.ShouldBind(&p)
 	if err != nil {
-		logging.L().Error("解析请求参数出错: ", err)
+		logging.C(c.Request.Context()).Error("解析请求参数出错: ", err)
 		r.ResponseError(http.StatusBadRequest, e.BadRequest, nil)
 		return
 	}
@@ -344,8 +408,14 @@ func ReconfigureVirtualMachineNic(c *gin.Context) {
 		return
 	}
 
-	vc := vsphere.Get(auth)
-	machine := workerpool.GetVirtualMachineOperator(vc.Api, p.ID)
+	vc, ok := getVCenter(&r)
+	if !ok {
+		return
+	}
+	machine, err := vc.GetVirtualMachineOperator(p.ID)
+	if !checkScope(&r, err) {
+		return
+	}
 	if machine == nil {
 		r.ResponseError(http.StatusBadRequest, e.VMNotFound, nil)
 		return
@@ -353,18 +423,19 @@ func ReconfigureVirtualMachineNic(c *gin.Context) {
 
 	res := OperationRes{}
 	res.RequestID = taskreceiver.Receive(workerpool.WorkerTypeOperation, p)
-	err = workerpool.AddTask(vc.Api.ID, workerpool.WorkerTypeOperation, func() {
+	ctx := taskContext(c, res.RequestID, vc.Api.ID)
+	err = workerpool.AddTask(ctx, vc.Api.ID, workerpool.WorkerTypeOperation, func(ctx context.Context) {
 		defer taskreceiver.Done(res.RequestID)
 		var success, notFound []string
 		var failed []OperationFailed
 		var callBack = p.CallBack
-		err := machine.ReconfigureNic(workerpool.ReconfigureNicParameter{
+		err := machine.WithContext(ctx).ReconfigureNic(workerpool.ReconfigureNicParameter{
 			Add:    p.Add,
 			Edit:   p.Edit,
 			Remove: p.Remove,
 		})
 		if err != nil {
-			logging.L().Error("修改硬盘配置失败", err)
+			logging.C(ctx).With(logging.FieldVMID, p.ID).Error("修改硬盘配置失败", err)
 			failed = append(failed, OperationFailed{
 				ID:    p.ID,
 				Error: err.Error(),
@@ -372,14 +443,16 @@ func ReconfigureVirtualMachineNic(c *gin.Context) {
 		} else {
 			success = append(success, p.ID)
 		}
+		vc.RefreshVirtualMachineCache(p.ID)
 		callBack.RequestID = res.RequestID
-		operationCallBack(callBack, success, notFound, failed)
+		operationCallBack(ctx, callBack, success, notFound, failed)
 	})
 
 	if err != nil {
-		logging.L().Error("创建网卡修改任务失败: ", err)
+		logging.C(ctx).Error("创建网卡修改任务失败: ", err)
 		taskreceiver.Cancel(res.RequestID, "任务创建失败")
-		r.ResponseOk(http.StatusInternalServerError, e.SystemError, nil)
+		audit.Complete(res.RequestID, audit.OutcomeFailure, nil, "任务创建失败")
+		responseTaskError(&r, err)
 	} else {
 		r.ResponseOk(http.StatusAccepted, e.Accepted, res)
 	}
@@ -395,17 +468,18 @@ func ReconfigureVirtualMachineNic(c *gin.Context) {
 // @Success      202  {object}  e.Response{data=[]v1.OperationRes}
 // @Failure      400  {string}  json  "{"code":"400x","message":"失败"}"
 // @Failure      401  {string}  json  "{"code":"401x","message":"失败"}"
+// @Failure      403  {string}  json  "{"code":"403x","message":"失败"}"
+// @Failure      429  {string}  json  "{"code":"429x","message":"失败"}"
 // @Failure      500  {string}  json  "{"code":"500x","message":"失败"}"
 // @Security     ApiKeyAuth
 // @Router       /v1/virtual_machines/reconfigure_disk [post]
 func ReconfigureVirtualMachineDisk(c *gin.Context) {
 	r := e.Gin{C: c}
-	auth := security.GetCurrentAuth(c)
 
 	p := DiskReconfigureReq{}
 	err := c.ShouldBind(&p)
 	if err != nil {
-		logging.L().Error("解析请求参数出错: ", err)
+		logging.C(c.Request.Context()).Error("解析请求参数出错: ", err)
 		r.ResponseError(http.StatusBadRequest, e.BadRequest, nil)
 		return
 	}
@@ -416,27 +490,40 @@ func ReconfigureVirtualMachineDisk(c *gin.Context) {
 		return
 	}
 
-	vc := vsphere.Get(auth)
-	machine := workerpool.GetVirtualMachineOperator(vc.Api, p.ID)
+	vc, ok := getVCenter(&r)
+	if !ok {
+		return
+	}
+	machine, err := vc.GetVirtualMachineOperator(p.ID)
+	if !checkScope(&r, err) {
+		return
+	}
 	if machine == nil {
 		r.ResponseError(http.StatusBadRequest, e.VMNotFound, nil)
 		return
 	}
+	diskParameter := workerpool.ReconfigureDiskParameter{
+		Add:    p.Add,
+		Edit:   p.Edit,
+		Remove: p.Remove,
+	}
+	reservation, err := vc.CheckDiskQuota(security.GetCurrentTenant(c), p.ID, diskParameter)
+	if !checkQuota(&r, err) {
+		return
+	}
 
 	res := OperationRes{}
 	res.RequestID = taskreceiver.Receive(workerpool.WorkerTypeOperation, p)
-	err = workerpool.AddTask(vc.Api.ID, workerpool.WorkerTypeOperation, func() {
+	ctx := taskContext(c, res.RequestID, vc.Api.ID)
+	err = workerpool.AddTask(ctx, vc.Api.ID, workerpool.WorkerTypeOperation, func(ctx context.Context) {
 		defer taskreceiver.Done(res.RequestID)
+		defer reservation.Release()
 		var success, notFound []string
 		var failed []OperationFailed
 		var callBack = p.CallBack
-		err := machine.ReconfigureDisk(workerpool.ReconfigureDiskParameter{
-			Add:    p.Add,
-			Edit:   p.Edit,
-			Remove: p.Remove,
-		})
+		err := machine.WithContext(ctx).ReconfigureDisk(diskParameter)
 		if err != nil {
-			logging.L().Error("修改硬盘配置失败", err)
+			logging.C(ctx).With(logging.FieldVMID, p.ID).Error("修改硬盘配置失败", err)
 			failed = append(failed, OperationFailed{
 				ID:    p.ID,
 				Error: err.Error(),
@@ -444,14 +531,17 @@ func ReconfigureVirtualMachineDisk(c *gin.Context) {
 		} else {
 			success = append(success, p.ID)
 		}
+		vc.RefreshVirtualMachineCache(p.ID)
 		callBack.RequestID = res.RequestID
-		operationCallBack(callBack, success, notFound, failed)
+		operationCallBack(ctx, callBack, success, notFound, failed)
 	})
 
 	if err != nil {
-		logging.L().Error("创建硬盘修改任务失败: ", err)
+		logging.C(ctx).Error("创建硬盘修改任务失败: ", err)
+		reservation.Release()
 		taskreceiver.Cancel(res.RequestID, "任务创建失败")
-		r.ResponseOk(http.StatusInternalServerError, e.SystemError, nil)
+		audit.Complete(res.RequestID, audit.OutcomeFailure, nil, "任务创建失败")
+		responseTaskError(&r, err)
 	} else {
 		r.ResponseOk(http.StatusAccepted, e.Accepted, res)
 	}
@@ -467,17 +557,17 @@ func ReconfigureVirtualMachineDisk(c *gin.Context) {
 // @Success      202  {object}  e.Response{data=[]v1.OperationRes}
 // @Failure      400  {string}  json  "{"code":"400x","message":"失败"}"
 // @Failure      401  {string}  json  "{"code":"401x","message":"失败"}"
+// @Failure      429  {string}  json  "{"code":"429x","message":"失败"}"
 // @Failure      500  {string}  json  "{"code":"500x","message":"失败"}"
 // @Security     ApiKeyAuth
 // @Router       /v1/virtual_machines/power_on [post]
 func VirtualMachinePowerOn(c *gin.Context) {
 	r := e.Gin{C: c}
-	auth := security.GetCurrentAuth(c)
 
 	p := OperationReq{}
 	err := c.ShouldBind(&p)
 	if err != nil {
-		logging.L().Error("解析请求参数出错: ", err)
+		logging.C(c.Request.Context()).Error("解析请求参数出错: ", err)
 		r.ResponseError(http.StatusBadRequest, e.BadRequest, nil)
 		return
 	}
@@ -488,23 +578,32 @@ func VirtualMachinePowerOn(c *gin.Context) {
 		return
 	}
 
+	vc, ok := getVCenter(&r)
+	if !ok {
+		return
+	}
+	machines, err := vc.GetVirtualMachineOperators(p.IDs...)
+	if !checkScope(&r, err) {
+		return
+	}
+
 	res := OperationRes{}
 	res.RequestID = taskreceiver.Receive(workerpool.WorkerTypeOperation, p)
-	var vc = vsphere.Get(auth)
-	err = workerpool.AddTask(vc.Api.ID, workerpool.WorkerTypeOperation, func() {
+	ctx := taskContext(c, res.RequestID, vc.Api.ID)
+	err = workerpool.AddTask(ctx, vc.Api.ID, workerpool.WorkerTypeOperation, func(ctx context.Context) {
 		defer taskreceiver.Done(res.RequestID)
 		var success, notFound []string
 		var failed []OperationFailed
 		var callBack = p.CallBack
 		for _, ID := range p.IDs {
-			machine := workerpool.GetVirtualMachineOperator(vc.Api, ID)
+			machine := machines[ID]
 			if machine == nil {
 				notFound = append(notFound, ID)
 				continue
 			}
-			err := machine.PowerOn()
+			err := machine.WithContext(ctx).PowerOn()
 			if err != nil {
-				logging.L().Error("开机失败: ", err)
+				logging.C(ctx).With(logging.FieldVMID, ID).Error("开机失败: ", err)
 				failed = append(failed, OperationFailed{
 					ID:    ID,
 					Error: err.Error(),
@@ -513,14 +612,16 @@ func VirtualMachinePowerOn(c *gin.Context) {
 				success = append(success, ID)
 			}
 		}
+		vc.RefreshVirtualMachineCache(p.IDs...)
 		callBack.RequestID = res.RequestID
-		operationCallBack(callBack, success, notFound, failed)
+		operationCallBack(ctx, callBack, success, notFound, failed)
 	})
 
 	if err != nil {
-		logging.L().Error("创建开机任务失败: ", err)
+		logging.C(ctx).Error("创建开机任务失败: ", err)
 		taskreceiver.Cancel(res.RequestID, "任务创建失败")
-		r.ResponseOk(http.StatusInternalServerError, e.SystemError, nil)
+		audit.Complete(res.RequestID, audit.OutcomeFailure, nil, "任务创建失败")
+		responseTaskError(&r, err)
 	} else {
 		r.ResponseOk(http.StatusAccepted, e.Accepted, res)
 	}
@@ -536,17 +637,17 @@ func VirtualMachinePowerOn(c *gin.Context) {
 // @Success      202  {object}  e.Response{data=[]v1.OperationRes}
 // @Failure      400  {string}  json  "{"code":"400x","message":"失败"}"
 // @Failure      401  {string}  json  "{"code":"401x","message":"失败"}"
+// @Failure      429  {string}  json  "{"code":"429x","message":"失败"}"
 // @Failure      500  {string}  json  "{"code":"500x","message":"失败"}"
 // @Security     ApiKeyAuth
 // @Router       /v1/virtual_machines/power_off [post]
 func VirtualMachinePowerOff(c *gin.Context) {
 	r := e.Gin{C: c}
-	auth := security.GetCurrentAuth(c)
 
 	p := OperationReq{}
 	err := c.ShouldBind(&p)
 	if err != nil {
-		logging.L().Error("解析请求参数出错: ", err)
+		logging.C(c.Request.Context()).Error("解析请求参数出错: ", err)
 		r.ResponseError(http.StatusBadRequest, e.BadRequest, nil)
 		return
 	}
@@ -557,23 +658,32 @@ func VirtualMachinePowerOff(c *gin.Context) {
 		return
 	}
 
+	vc, ok := getVCenter(&r)
+	if !ok {
+		return
+	}
+	machines, err := vc.GetVirtualMachineOperators(p.IDs...)
+	if !checkScope(&r, err) {
+		return
+	}
+
 	res := OperationRes{}
 	res.RequestID = taskreceiver.Receive(workerpool.WorkerTypeOperation, p)
-	var vc = vsphere.Get(auth)
-	err = vc.AddTask(workerpool.WorkerTypeOperation, func() {
+	ctx := taskContext(c, res.RequestID, vc.Api.ID)
+	err = vc.AddTask(ctx, workerpool.WorkerTypeOperation, func(ctx context.Context) {
 		defer taskreceiver.Done(res.RequestID)
 		var success, notFound []string
 		var failed []OperationFailed
 		var callBack = p.CallBack
 		for _, ID := range p.IDs {
-			machine := workerpool.GetVirtualMachineOperator(vc.Api, ID)
+			machine := machines[ID]
 			if machine == nil {
 				notFound = append(notFound, ID)
 				continue
 			}
-			err := machine.PowerOff()
+			err := machine.WithContext(ctx).PowerOff()
 			if err != nil {
-				logging.L().Error("开机失败: ", err)
+				logging.C(ctx).With(logging.FieldVMID, ID).Error("开机失败: ", err)
 				failed = append(failed, OperationFailed{
 					ID:    ID,
 					Error: err.Error(),
@@ -582,14 +692,16 @@ func VirtualMachinePowerOff(c *gin.Context) {
 				success = append(success, ID)
 			}
 		}
+		vc.RefreshVirtualMachineCache(p.IDs...)
 		callBack.RequestID = res.RequestID
-		operationCallBack(callBack, success, notFound, failed)
+		operationCallBack(ctx, callBack, success, notFound, failed)
 	})
 
 	if err != nil {
-		logging.L().Error("关闭电源任务创建失败: ", err)
+		logging.C(ctx).Error("关闭电源任务创建失败: ", err)
 		taskreceiver.Cancel(res.RequestID, "任务创建失败")
-		r.ResponseOk(http.StatusInternalServerError, e.SystemError, nil)
+		audit.Complete(res.RequestID, audit.OutcomeFailure, nil, "任务创建失败")
+		responseTaskError(&r, err)
 	} else {
 		r.ResponseOk(http.StatusAccepted, e.Accepted, res)
 	}
@@ -605,17 +717,17 @@ func VirtualMachinePowerOff(c *gin.Context) {
 // @Success      202  {object}  e.Response{data=[]v1.OperationRes}
 // @Failure      400  {string}  json  "{"code":"400x","message":"失败"}"
 // @Failure      401  {string}  json  "{"code":"401x","message":"失败"}"
+// @Failure      429  {string}  json  "{"code":"429x","message":"失败"}"
 // @Failure      500  {string}  json  "{"code":"500x","message":"失败"}"
 // @Security     ApiKeyAuth
 // @Router       /v1/virtual_machines/shutdown [post]
 func VirtualMachineShutdown(c *gin.Context) {
 	r := e.Gin{C: c}
-	auth := security.GetCurrentAuth(c)
 
 	p := OperationReq{}
 	err := c.ShouldBind(&p)
 	if err != nil {
-		logging.L().Error("解析请求参数出错: ", err)
+		logging.C(c.Request.Context()).Error("解析请求参数出错: ", err)
 		r.ResponseError(http.StatusBadRequest, e.BadRequest, nil)
 		return
 	}
@@ -626,23 +738,32 @@ func VirtualMachineShutdown(c *gin.Context) {
 		return
 	}
 
+	vc, ok := getVCenter(&r)
+	if !ok {
+		return
+	}
+	machines, err := vc.GetVirtualMachineOperators(p.IDs...)
+	if !checkScope(&r, err) {
+		return
+	}
+
 	res := OperationRes{}
 	res.RequestID = taskreceiver.Receive(workerpool.WorkerTypeOperation, p)
-	var vc = vsphere.Get(auth)
-	err = vc.AddTask(workerpool.WorkerTypeOperation, func() {
+	ctx := taskContext(c, res.RequestID, vc.Api.ID)
+	err = vc.AddTask(ctx, workerpool.WorkerTypeOperation, func(ctx context.Context) {
 		defer taskreceiver.Done(res.RequestID)
 		var success, notFound []string
 		var failed []OperationFailed
 		var callBack = p.CallBack
 		for _, ID := range p.IDs {
-			machine := workerpool.GetVirtualMachineOperator(vc.Api, ID)
+			machine := machines[ID]
 			if machine == nil {
 				notFound = append(notFound, ID)
 				continue
 			}
-			err := machine.Shutdown()
+			err := machine.WithContext(ctx).Shutdown()
 			if err != nil {
-				logging.L().Error("开机失败: ", err)
+				logging.C(ctx).With(logging.FieldVMID, ID).Error("开机失败: ", err)
 				failed = append(failed, OperationFailed{
 					ID:    ID,
 					Error: err.Error(),
@@ -651,14 +772,16 @@ func VirtualMachineShutdown(c *gin.Context) {
 				success = append(success, ID)
 			}
 		}
+		vc.RefreshVirtualMachineCache(p.IDs...)
 		callBack.RequestID = res.RequestID
-		operationCallBack(callBack, success, notFound, failed)
+		operationCallBack(ctx, callBack, success, notFound, failed)
 	})
 
 	if err != nil {
-		logging.L().Error("关闭操作系统任务创建失败: ", err)
+		logging.C(ctx).Error("关闭操作系统任务创建失败: ", err)
 		taskreceiver.Cancel(res.RequestID, "任务创建失败")
-		r.ResponseOk(http.StatusInternalServerError, e.SystemError, nil)
+		audit.Complete(res.RequestID, audit.OutcomeFailure, nil, "任务创建失败")
+		responseTaskError(&r, err)
 	} else {
 		r.ResponseOk(http.StatusAccepted, e.Accepted, res)
 	}
@@ -674,17 +797,17 @@ func VirtualMachineShutdown(c *gin.Context) {
 // @Success      202  {object}  e.Response{data=[]v1.OperationRes}
 // @Failure      400  {string}  json  "{"code":"400x","message":"失败"}"
 // @Failure      401  {string}  json  "{"code":"401x","message":"失败"}"
+// @Failure      429  {string}  json  "{"code":"429x","message":"失败"}"
 // @Failure      500  {string}  json  "{"code":"500x","message":"失败"}"
 // @Security     ApiKeyAuth
 // @Router       /v1/virtual_machines/{id}/relocate [post]
 func VirtualMachineRelocate(c *gin.Context) {
 	r := e.Gin{C: c}
-	auth := security.GetCurrentAuth(c)
 
 	p := RelocateReq{}
 	err := c.ShouldBind(&p)
 	if err != nil {
-		logging.L().Error("解析请求参数出错: ", err)
+		logging.C(c.Request.Context()).Error("解析请求参数出错: ", err)
 		r.ResponseError(http.StatusBadRequest, e.BadRequest, nil)
 		return
 	}
@@ -695,8 +818,14 @@ func VirtualMachineRelocate(c *gin.Context) {
 		return
 	}
 
-	var vc = vsphere.Get(auth)
-	machine := workerpool.GetVirtualMachineOperator(vc.Api, p.ID)
+	vc, ok := getVCenter(&r)
+	if !ok {
+		return
+	}
+	machine, err := vc.GetVirtualMachineOperator(p.ID)
+	if !checkScope(&r, err) {
+		return
+	}
 	if machine == nil {
 		r.ResponseError(http.StatusBadRequest, e.VMNotFound, nil)
 		return
@@ -704,15 +833,16 @@ func VirtualMachineRelocate(c *gin.Context) {
 
 	res := OperationRes{}
 	res.RequestID = taskreceiver.Receive(workerpool.WorkerTypeOperation, p)
-	err = workerpool.AddTask(vc.Api.ID, workerpool.WorkerTypeOperation, func() {
+	ctx := taskContext(c, res.RequestID, vc.Api.ID)
+	err = workerpool.AddTask(ctx, vc.Api.ID, workerpool.WorkerTypeOperation, func(ctx context.Context) {
 		defer taskreceiver.Done(res.RequestID)
 		var success, notFound []string
 		var failed []OperationFailed
 		var callBack = p.CallBack
 
-		err = machine.Relocate(p.RelocateParameter)
+		err = machine.WithContext(ctx).Relocate(p.RelocateParameter)
 		if err != nil {
-			logging.L().Error("迁移失败", err)
+			logging.C(ctx).With(logging.FieldVMID, p.ID).Error("迁移失败", err)
 			failed = append(failed, OperationFailed{
 				ID:    p.ID,
 				Error: err.Error(),
@@ -720,13 +850,15 @@ func VirtualMachineRelocate(c *gin.Context) {
 		} else {
 			success = append(success, p.ID)
 		}
+		vc.RefreshVirtualMachineCache(p.ID)
 		callBack.RequestID = res.RequestID
-		operationCallBack(callBack, success, notFound, failed)
+		operationCallBack(ctx, callBack, success, notFound, failed)
 	})
 	if err != nil {
-		logging.L().Error("创建迁移任务失败: ", err)
+		logging.C(ctx).Error("创建迁移任务失败: ", err)
 		taskreceiver.Cancel(res.RequestID, "任务创建失败")
-		r.ResponseOk(http.StatusInternalServerError, e.SystemError, nil)
+		audit.Complete(res.RequestID, audit.OutcomeFailure, nil, "任务创建失败")
+		responseTaskError(&r, err)
 	} else {
 		r.ResponseOk(http.StatusAccepted, e.Accepted, res)
 	}
@@ -747,12 +879,11 @@ func VirtualMachineRelocate(c *gin.Context) {
 // @Router       /v1/virtual_machines/rename [post]
 func VirtualMachineRename(c *gin.Context) {
 	r := e.Gin{C: c}
-	auth := security.GetCurrentAuth(c)
 
 	p := RenameReq{}
 	err := c.ShouldBind(&p)
 	if err != nil {
-		logging.L().Error("解析请求参数出错: ", err)
+		logging.C(c.Request.Context()).Error("解析请求参数出错: ", err)
 		r.ResponseError(http.StatusBadRequest, err.Error(), nil)
 		return
 	}
@@ -763,19 +894,27 @@ func VirtualMachineRename(c *gin.Context) {
 		return
 	}
 
-	vc := vsphere.Get(auth)
-	machine := workerpool.GetVirtualMachineOperator(vc.Api, p.ID)
+	vc, ok := getVCenter(&r)
+	if !ok {
+		return
+	}
+	audit.SetTarget(c, vc.Api.ID, p.ID)
+	machine, err := vc.GetVirtualMachineOperator(p.ID)
+	if !checkScope(&r, err) {
+		return
+	}
 	if machine == nil {
-		logging.L().Error("修改名称失败", err)
+		logging.C(c.Request.Context()).Error("修改名称失败", err)
 		r.ResponseError(http.StatusBadRequest, e.VMNotFound, nil)
 		return
 	}
-	err = machine.Rename(p.NewName)
+	err = machine.WithContext(c.Request.Context()).Rename(p.NewName)
 	if err != nil {
-		logging.L().Error("修改名称失败", err)
+		logging.C(c.Request.Context()).Error("修改名称失败", err)
 		r.ResponseError(http.StatusBadRequest, err.Error(), nil)
 		return
 	}
+	vc.RefreshVirtualMachineCache(p.ID)
 	r.ResponseOk(http.StatusOK, e.Success, nil)
 }
 
@@ -794,7 +933,6 @@ func VirtualMachineRename(c *gin.Context) {
 // @Router       /v1/virtual_machines/description [post]
 func VirtualMachineDescript(c *gin.Context) {
 	r := e.Gin{C: c}
-	auth := security.GetCurrentAuth(c)
 
 	p := DescriptionReq{}
 	err := c.ShouldBind(&p)
@@ -806,32 +944,41 @@ func VirtualMachineDescript(c *gin.Context) {
 	}
 
 	if err != nil {
-		logging.L().Error("解析请求参数出错: ", err)
+		logging.C(c.Request.Context()).Error("解析请求参数出错: ", err)
 		r.ResponseError(http.StatusBadRequest, err.Error(), nil)
 		return
 	}
-	vc := vsphere.Get(auth)
-	machine := workerpool.GetVirtualMachineOperator(vc.Api, p.ID)
+	vc, ok := getVCenter(&r)
+	if !ok {
+		return
+	}
+	audit.SetTarget(c, vc.Api.ID, p.ID)
+	machine, err := vc.GetVirtualMachineOperator(p.ID)
+	if !checkScope(&r, err) {
+		return
+	}
 	if machine == nil {
-		logging.L().Error("修改备注失败", err)
+		logging.C(c.Request.Context()).Error("修改备注失败", err)
 		r.ResponseError(http.StatusBadRequest, e.VMNotFound, nil)
 		return
 	}
-	err = machine.Descript(p.Description)
+	err = machine.WithContext(c.Request.Context()).Descript(p.Description)
 	if err != nil {
-		logging.L().Error("修改备注失败", err)
+		logging.C(c.Request.Context()).Error("修改备注失败", err)
 		r.ResponseError(http.StatusBadRequest, err.Error(), nil)
 		return
 	}
+	vc.RefreshVirtualMachineCache(p.ID)
 	r.ResponseOk(http.StatusOK, e.Success, nil)
 }
 
 // QueryVirtualMachines
 // @Summary      查询虚拟机
-// @Description  查询虚拟机
+// @Description  查询虚拟机，开启缓存时优先使用缓存，freshness表示数据来源和缓存更新时间
 // @Tags         虚拟机
 // @Accept       json
 // @Produce      json
+// @Param        list  query     v1.ListQuery  false  "分页、排序、过滤参数"
 // @Param        c    body      v1.VirtualMachineQuery  true  "查询参数"
 // @Success      200  {object}  e.Response{data=[]protocol.VirtualMachineInfo}
 // @Failure      400  {string}  json  "{"code":"400x","message":"失败"}"
@@ -841,7 +988,6 @@ func VirtualMachineDescript(c *gin.Context) {
 // @Router       /v1/virtual_machines [get]
 func QueryVirtualMachines(c *gin.Context) {
 	r := e.Gin{C: c}
-	auth := security.GetCurrentAuth(c)
 
 	query := VirtualMachineQuery{}
 	err := c.ShouldBind(&query)
@@ -862,22 +1008,90 @@ func QueryVirtualMachines(c *gin.Context) {
 		ClusterID:    query.ClusterID,
 		HostID:       query.HostID,
 		IDs:          query.IDs,
+		TagIDs:       query.TagIDs,
 	}
-	var vc = vsphere.Get(auth)
-	templates := vc.QueryVirtualMachines(q)
-	if templates == nil {
-		r.ResponseOk(http.StatusOK, e.Success, e.EmptyArray())
-	} else {
-		r.ResponseOk(http.StatusOK, e.Success, templates)
+
+	opts, ok := bindListQuery(&r)
+	if !ok {
+		return
+	}
+
+	vc, ok := getVCenter(&r)
+	if !ok {
+		return
+	}
+	virtualMachines, freshness, err := vc.QueryVirtualMachines(q)
+	if !checkScope(&r, err) {
+		return
+	}
+	responseList(&r, opts, virtualMachines, &freshness)
+}
+
+// GetVirtualMachine
+// @Summary      查询虚拟机详情
+// @Description  查询单个虚拟机的详细信息，包括启动选项、控制器、光驱、快照概要、Tools状态、运行时长、资源分配、所在主机和存储名称、允许管理的高级参数，直接查询VC
+// @Tags         虚拟机
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "虚拟机ID"
+// @Success      200  {object}  e.Response{data=protocol.VirtualMachineDetail}
+// @Failure      400  {string}  json  "{"code":"400x","message":"失败"}"
+// @Failure      401  {string}  json  "{"code":"401x","message":"失败"}"
+// @Failure      500  {string}  json  "{"code":"500x","message":"失败"}"
+// @Security     ApiKeyAuth
+// @Router       /v1/virtual_machines/{id} [get]
+func GetVirtualMachine(c *gin.Context) {
+	r := e.Gin{C: c}
+	ID := c.Param("id")
+
+	vc, ok := getVCenter(&r)
+	if !ok {
+		return
+	}
+	detail := vc.GetVirtualMachineDetail(ID)
+	if detail == nil {
+		r.ResponseError(http.StatusBadRequest, e.VMNotFound, nil)
+		return
 	}
+	r.ResponseOk(http.StatusOK, e.Success, detail)
+}
+
+// GetVirtualMachineExtraConfig
+// @Summary      查询虚拟机高级参数
+// @Description  查询虚拟机高级参数(extraConfig)，只返回vsphere.extraConfig中允许管理的参数，allow为空时返回所有未被deny的参数
+// @Tags         虚拟机
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "虚拟机ID"
+// @Success      200  {object}  e.Response{data=[]protocol.ExtraConfigInfo}
+// @Failure      400  {string}  json  "{"code":"400x","message":"失败"}"
+// @Failure      401  {string}  json  "{"code":"401x","message":"失败"}"
+// @Failure      500  {string}  json  "{"code":"500x","message":"失败"}"
+// @Security     ApiKeyAuth
+// @Router       /v1/virtual_machines/{id}/extra_config [get]
+func GetVirtualMachineExtraConfig(c *gin.Context) {
+	r := e.Gin{C: c}
+	ID := c.Param("id")
+
+	vc, ok := getVCenter(&r)
+	if !ok {
+		return
+	}
+	extraConfig := vc.GetVirtualMachineExtraConfig(ID)
+	if extraConfig == nil {
+		r.ResponseError(http.StatusBadRequest, e.VMNotFound, nil)
+		return
+	}
+	r.ResponseOk(http.StatusOK, e.Success, extraConfig)
 }
 
 // QueryTemplates
 // @Summary      查询模板
-// @Description  查询模板
+// @Description  查询模板，开启缓存时优先使用缓存，freshness表示数据来源和缓存更新时间
 // @Tags         模版
 // @Accept       json
 // @Produce      json
+// @Param        list  query     v1.ListQuery  false  "分页、排序、过滤参数"
 // @Param        c    body      v1.TemplateQuery  true  "查询参数"
 // @Success      200  {object}  e.Response{data=[]protocol.TemplateInfo}
 // @Failure      400  {string}  json  "{"code":"400x","message":"失败"}"
@@ -887,7 +1101,6 @@ func QueryVirtualMachines(c *gin.Context) {
 // @Router       /v1/templates [get]
 func QueryTemplates(c *gin.Context) {
 	r := e.Gin{C: c}
-	auth := security.GetCurrentAuth(c)
 
 	query := TemplateQuery{}
 	err := c.ShouldBind(&query)
@@ -907,17 +1120,26 @@ func QueryTemplates(c *gin.Context) {
 		FolderID:     query.FolderID,
 		IDs:          query.IDs,
 	}
-	var vc = vsphere.Get(auth)
-	templates := vc.QueryTemplates(q)
-	if templates == nil {
-		r.ResponseOk(http.StatusOK, e.Success, e.EmptyArray())
-	} else {
-		r.ResponseOk(http.StatusOK, e.Success, templates)
+
+	opts, ok := bindListQuery(&r)
+	if !ok {
+		return
+	}
+
+	vc, ok := getVCenter(&r)
+	if !ok {
+		return
 	}
+	templates, freshness, err := vc.QueryTemplates(q)
+	if !checkScope(&r, err) {
+		return
+	}
+	responseList(&r, opts, templates, &freshness)
 }
 
-func operationCallBack(c protocol.CallbackReq, success, notFound []string, failed []OperationFailed) {
-	cb := callback.NewCallbacker(c)
+func operationCallBack(ctx context.Context, c protocol.CallbackReq, success, notFound []string, failed []OperationFailed) {
+	auditOperation(c.RequestID, success, notFound, failed)
+	cb := callback.NewCallbacker(c).WithContext(ctx)
 	cb.CallbackArr(c.RequestID, OperationCallBackRes{
 		Success:  success,
 		NotFound: notFound,
@@ -925,7 +1147,117 @@ func operationCallBack(c protocol.CallbackReq, success, notFound []string, faile
 	})
 }
 
-func deploymentCallBack(c protocol.CallbackReq, res DeploymentCallBackRes) {
-	cb := callback.NewCallbacker(c)
+func deploymentCallBack(ctx context.Context, c protocol.CallbackReq, res DeploymentCallBackRes) {
+	auditDeployment(c.RequestID, res)
+	cb := callback.NewCallbacker(c).WithContext(ctx)
 	cb.CallbackObj(c.RequestID, res)
 }
+
+// checkScope 对象不在调用者允许访问的清单范围内时返回403
+func checkScope(r *e.Gin, err error) bool {
+	if err == nil {
+		return true
+	}
+	logging.C(r.C.Request.Context()).Warn(err)
+	if outOfScope(err) {
+		r.ResponseError(http.StatusForbidden, e.OutOfScope, nil)
+	} else {
+		r.ResponseError(http.StatusInternalServerError, err.Error(), nil)
+	}
+	return false
+}
+
+// getVCenter 连接当前令牌的VC并限制清单范围，连接失败时返回ConnectFailed，熔断中时返回503
+func getVCenter(r *e.Gin) (*vsphere.VCenter, bool) {
+	vc, err := vsphere.Get(security.GetCurrentAuth(r.C))
+	if err == nil {
+		return vc.Scoped(security.GetCurrentScope(r.C)), true
+	}
+	logging.C(r.C.Request.Context()).Error("连接VC失败: ", err)
+	var circuitErr *helper.CircuitOpenError
+	if errors.As(err, &circuitErr) {
+		r.C.Header("Retry-After", strconv.Itoa(int(math.Ceil(circuitErr.RetryAfter.Seconds()))))
+		r.ResponseError(http.StatusServiceUnavailable, e.ConnectFailed, err.Error())
+	} else {
+		r.ResponseError(http.StatusBadGateway, e.ConnectFailed, err.Error())
+	}
+	return nil, false
+}
+
+func outOfScope(err error) bool {
+	return errors.Is(err, vsphere.ErrOutOfScope)
+}
+
+// checkQuota 超出租户配额时返回403，data为超出配额的资源
+func checkQuota(r *e.Gin, err error) bool {
+	if err == nil {
+		return true
+	}
+	logging.C(r.C.Request.Context()).Warn(err)
+	var quotaErr *vsphere.QuotaError
+	switch {
+	case errors.As(err, &quotaErr):
+		r.ResponseError(http.StatusForbidden, e.QuotaExceeded, quotaErr.Violations)
+	case errors.Is(err, vsphere.ErrQuotaExceeded):
+		r.ResponseError(http.StatusForbidden, e.QuotaExceeded, err.Error())
+	default:
+		r.ResponseError(http.StatusInternalServerError, err.Error(), nil)
+	}
+	return false
+}
+
+// taskContext 任务的日志带上任务ID和VC ID，同时记录审计的任务ID和限制任务数的调用方
+func taskContext(c *gin.Context, requestID, VCID string) context.Context {
+	audit.Accept(c, requestID, VCID)
+	ctx := logging.With(c.Request.Context(), logging.FieldRequestID, requestID, logging.FieldVCID, VCID)
+	return workerpool.WithClient(ctx, security.ClientID(c))
+}
+
+// responseTaskError 任务数超过限制时返回429
+func responseTaskError(r *e.Gin, err error) {
+	if errors.Is(err, workerpool.ErrTooManyTasks) {
+		retryAfter := config.G.Server.RateLimit.RetryAfter
+		if retryAfter <= 0 {
+			retryAfter = 10
+		}
+		ratelimit.TooMany(r.C, e.TooManyTasks, time.Duration(retryAfter)*time.Second)
+		return
+	}
+	r.ResponseOk(http.StatusInternalServerError, e.SystemError, nil)
+}
+
+// auditOperation 全部成功为success，全部失败为failure，否则为partial
+func auditOperation(requestID string, success, notFound []string, failed []OperationFailed) {
+	ids := append(append([]string{}, success...), notFound...)
+	var messages []string
+	for _, f := range failed {
+		ids = append(ids, f.ID)
+		messages = append(messages, fmt.Sprintf("%s: %s", f.ID, f.Error))
+	}
+	for _, ID := range notFound {
+		messages = append(messages, fmt.Sprintf("%s: 虚拟机不存在", ID))
+	}
+	outcome := audit.OutcomePartial
+	if len(messages) == 0 {
+		outcome = audit.OutcomeSuccess
+	} else if len(success) == 0 {
+		outcome = audit.OutcomeFailure
+	}
+	audit.Complete(requestID, outcome, ids, strings.Join(messages, "; "))
+}
+
+func auditDeployment(requestID string, res DeploymentCallBackRes) {
+	if !res.IsSuccess {
+		var message string
+		if res.Message != nil {
+			message = *res.Message
+		}
+		audit.Complete(requestID, audit.OutcomeFailure, nil, message)
+		return
+	}
+	var ids []string
+	if vm, ok := res.Instance.(*protocol.VirtualMachineInfo); ok && vm != nil {
+		ids = append(ids, vm.ID)
+	}
+	audit.Complete(requestID, audit.OutcomeSuccess, ids, "")
+}
@@ -0,0 +1,105 @@
+package logging
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"strings"
+)
+
+// 关联ID字段名
+const (
+	FieldHTTPRequestID = "http_request_id"
+	FieldRequestID     = "request_id"
+	FieldVCID          = "vcid"
+	FieldVMID          = "vm_id"
+	FieldTraceID       = "trace_id"
+)
+
+// HeaderRequestID 上游传入或生成的HTTP请求ID，会写回响应头
+const HeaderRequestID = "X-Request-ID"
+
+type loggerKey struct{}
+
+// With 返回携带日志字段的ctx，之后通过C(ctx)打印的日志都会带上这些字段
+func With(ctx context.Context, keysAndValues ...interface{}) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, loggerKey{}, logger(ctx).With(keysAndValues...))
+}
+
+// Inherit 将src中的日志字段带到dst中，用于工作池这类不能沿用请求ctx的场景
+func Inherit(dst, src context.Context) context.Context {
+	if src == nil {
+		return dst
+	}
+	l, ok := src.Value(loggerKey{}).(*zap.SugaredLogger)
+	if !ok {
+		return dst
+	}
+	return context.WithValue(dst, loggerKey{}, l)
+}
+
+// C 获取ctx中的日志，ctx中有链路信息时带上trace_id
+func C(ctx context.Context) *zap.SugaredLogger {
+	if ctx == nil {
+		return L()
+	}
+	l := logger(ctx)
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		l = l.With(FieldTraceID, sc.TraceID().String())
+	}
+	return l
+}
+
+func logger(ctx context.Context) *zap.SugaredLogger {
+	if l, ok := ctx.Value(loggerKey{}).(*zap.SugaredLogger); ok {
+		return l
+	}
+	return L()
+}
+
+// Middleware 为每个请求生成HTTP请求ID，放入c.Request的ctx和响应头
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(HeaderRequestID)
+		if id == "" || len(id) > 128 {
+			id = uuid.NewString()
+		}
+		c.Header(HeaderRequestID, id)
+		c.Request = c.Request.WithContext(With(c.Request.Context(), FieldHTTPRequestID, id))
+		c.Next()
+	}
+}
+
+// Redact 隐藏凭证，只保留前4位用于排查
+func Redact(s string) string {
+	if s == "" {
+		return ""
+	}
+	if len(s) <= 8 {
+		return "****"
+	}
+	return s[:4] + "****"
+}
+
+var sensitiveHeaders = []string{"authorization", "token", "cookie", "password", "secret", "key"}
+
+// RedactHeaders 隐藏请求头中的凭证，返回新的map
+func RedactHeaders(headers map[string]string) map[string]string {
+	redacted := make(map[string]string, len(headers))
+	for k, v := range headers {
+		redacted[k] = v
+		lower := strings.ToLower(k)
+		for _, s := range sensitiveHeaders {
+			if strings.Contains(lower, s) {
+				redacted[k] = Redact(v)
+				break
+			}
+		}
+	}
+	return redacted
+}
@@ -0,0 +1,73 @@
+package logging
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func observe() *observer.ObservedLogs {
+	core, logs := observer.New(zapcore.DebugLevel)
+	log = zap.New(core)
+	return logs
+}
+
+func TestWithAndInherit(t *testing.T) {
+	logs := observe()
+	ctx := With(context.Background(), FieldRequestID, "task-1", FieldVCID, "vc-1")
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+
+	worker := Inherit(context.Background(), ctx)
+	C(worker).With(FieldVMID, "vm-1").Info("开机")
+
+	fields := logs.All()[0].ContextMap()
+	for k, v := range map[string]string{FieldRequestID: "task-1", FieldVCID: "vc-1", FieldVMID: "vm-1"} {
+		if fields[k] != v {
+			t.Errorf("日志字段%s为%v，应为%s", k, fields[k], v)
+		}
+	}
+	if worker.Err() != nil {
+		t.Error("工作池的ctx不应随请求取消")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	logs := observe()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware())
+	r.GET("/", func(c *gin.Context) {
+		C(c.Request.Context()).Info("请求")
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderRequestID, "req-1")
+	r.ServeHTTP(w, req)
+
+	if w.Header().Get(HeaderRequestID) != "req-1" {
+		t.Error("响应头中没有请求ID")
+	}
+	if logs.All()[0].ContextMap()[FieldHTTPRequestID] != "req-1" {
+		t.Error("日志中没有请求ID")
+	}
+}
+
+func TestRedact(t *testing.T) {
+	if Redact("eyJhbGciOiJIUzI1NiJ9.secret") != "eyJh****" {
+		t.Error(Redact("eyJhbGciOiJIUzI1NiJ9.secret"))
+	}
+	if Redact("short") != "****" {
+		t.Error(Redact("short"))
+	}
+	headers := RedactHeaders(map[string]string{"Authorization": "Bearer abcdefghijk", "X-Tenant": "t1"})
+	if headers["Authorization"] != "Bear****" || headers["X-Tenant"] != "t1" {
+		t.Error(headers)
+	}
+}
@@ -12,6 +12,9 @@ import (
 	"vsphere-facade/config"
 )
 
+// FormatJSON 日志输出为JSON，方便日志平台按关联ID检索
+const FormatJSON = "json"
+
 var log *zap.Logger
 
 var settingLevel zapcore.Level
@@ -25,7 +28,12 @@ func Setup() {
 	if config.G.Server.Log.EnableFullPath {
 		encoderConfig.EncodeCaller = zapcore.FullCallerEncoder //显示完整文件路径
 	}
-	encoder := zapcore.NewConsoleEncoder(encoderConfig)
+	var encoder zapcore.Encoder
+	if config.G.Server.Log.Format == FormatJSON {
+		encoder = zapcore.NewJSONEncoder(encoderConfig)
+	} else {
+		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+	}
 
 	//日志级别
 	level, err := zapcore.ParseLevel(config.G.Server.Log.Level)
@@ -0,0 +1,127 @@
+package metrics
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"strconv"
+	"time"
+)
+
+const namespace = "vsphere_facade"
+
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+	ResultHit     = "hit"
+	ResultMiss    = "miss"
+)
+
+var (
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP请求耗时",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	callbacks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "callbacks_total",
+		Help:      "回调次数",
+	}, []string{"result"})
+
+	cacheQueries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_queries_total",
+		Help:      "查询是否命中缓存的次数",
+	}, []string{"vcid", "kind", "result"})
+
+	taskDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "task_duration_seconds",
+		Help:      "虚拟机部署和操作任务耗时",
+		Buckets:   []float64{1, 5, 10, 30, 60, 120, 300, 600, 1200, 1800, 3600},
+	}, []string{"type", "outcome"})
+)
+
+func init() {
+	prometheus.MustRegister(httpRequestDuration, callbacks, cacheQueries, taskDuration)
+}
+
+// Handler /metrics接口
+func Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.Handler())
+}
+
+// Middleware 记录每个路由的请求耗时，没有匹配到路由的请求记为unmatched，避免路径作为标签导致指标数量无限增长
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		httpRequestDuration.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
+
+func Callback(err error) {
+	callbacks.WithLabelValues(result(err)).Inc()
+}
+
+func CacheQuery(VCID, kind string, hit bool) {
+	r := ResultMiss
+	if hit {
+		r = ResultHit
+	}
+	cacheQueries.WithLabelValues(VCID, kind, r).Inc()
+}
+
+// ObserveTask 在任务方法中使用defer调用，err为任务返回值的指针
+func ObserveTask(taskType string, start time.Time, err *error) {
+	var e error
+	if err != nil {
+		e = *err
+	}
+	taskDuration.WithLabelValues(taskType, result(e)).Observe(time.Since(start).Seconds())
+}
+
+// Gauge 抓取时生成的指标值，Labels与注册时的标签一一对应
+type Gauge struct {
+	Labels []string
+	Value  float64
+}
+
+type gaugeCollector struct {
+	desc    *prometheus.Desc
+	collect func() []Gauge
+}
+
+func (g gaugeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- g.desc
+}
+
+func (g gaugeCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, gauge := range g.collect() {
+		ch <- prometheus.MustNewConstMetric(g.desc, prometheus.GaugeValue, gauge.Value, gauge.Labels...)
+	}
+}
+
+// RegisterGauges
+// 注册在抓取时才计算的指标，用于工作池、会话、清单这类已经在别处维护了状态的数据
+func RegisterGauges(name, help string, labels []string, collect func() []Gauge) {
+	prometheus.MustRegister(gaugeCollector{
+		desc:    prometheus.NewDesc(prometheus.BuildFQName(namespace, "", name), help, labels, nil),
+		collect: collect,
+	})
+}
+
+func result(err error) string {
+	if err != nil {
+		return ResultFailure
+	}
+	return ResultSuccess
+}
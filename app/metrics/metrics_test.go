@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegisterGauges(t *testing.T) {
+	RegisterGauges("test_pool_running", "测试", []string{"vcid"}, func() []Gauge {
+		return []Gauge{{Labels: []string{"vc1"}, Value: 2}, {Labels: []string{"vc2"}, Value: 3}}
+	})
+	expected := `
+# HELP vsphere_facade_test_pool_running 测试
+# TYPE vsphere_facade_test_pool_running gauge
+vsphere_facade_test_pool_running{vcid="vc1"} 2
+vsphere_facade_test_pool_running{vcid="vc2"} 3
+`
+	if err := testutil.GatherAndCompare(prometheus.DefaultGatherer, strings.NewReader(expected), "vsphere_facade_test_pool_running"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCounters(t *testing.T) {
+	Callback(nil)
+	Callback(errors.New("x"))
+	if v := testutil.ToFloat64(callbacks.WithLabelValues(ResultFailure)); v != 1 {
+		t.Fatalf("回调失败次数错误: %v", v)
+	}
+
+	ObserveTask("power_on", time.Now(), nil)
+	if n := testutil.CollectAndCount(taskDuration); n != 1 {
+		t.Fatalf("任务耗时指标数量错误: %d", n)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware())
+	r.GET("/api/v1/virtual_machines/:id", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	for _, path := range []string{"/api/v1/virtual_machines/vm-1", "/api/v1/virtual_machines/vm-2", "/nope"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if n := testutil.CollectAndCount(httpRequestDuration); n != 2 {
+		t.Fatalf("请求耗时指标应该按路由聚合，实际%d个", n)
+	}
+}
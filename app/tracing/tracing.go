@@ -0,0 +1,131 @@
+package tracing
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"time"
+	"vsphere-facade/app/logging"
+	"vsphere-facade/config"
+)
+
+const instrumentationName = "vsphere-facade"
+
+var provider *sdktrace.TracerProvider
+
+func Setup() {
+	c := config.G.Server.Tracing
+	if !c.Enable {
+		return
+	}
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(c.Endpoint)}
+	if c.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(context.Background(), opts...)
+	if err != nil {
+		logging.L().Panic("创建OTLP导出器失败", err)
+	}
+	ratio := c.SampleRatio
+	if ratio <= 0 {
+		ratio = 1
+	}
+	SetExporter(exporter, c.ServiceName, ratio, sdktrace.WithBatcher(exporter))
+}
+
+// SetExporter 设置全局的TracerProvider，测试时可以传入内存导出器和sdktrace.WithSyncer
+func SetExporter(exporter sdktrace.SpanExporter, serviceName string, ratio float64, opts ...sdktrace.TracerProviderOption) {
+	if serviceName == "" {
+		serviceName = instrumentationName
+	}
+	if len(opts) == 0 {
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+	opts = append(opts,
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(serviceName))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))))
+	provider = sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+}
+
+// Enabled 没有开启时，不再为vSphere调用创建包装客户端
+func Enabled() bool {
+	return provider != nil
+}
+
+// Shutdown 导出缓存中还没有发送的数据
+func Shutdown() {
+	if provider == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err := provider.Shutdown(ctx)
+	if err != nil {
+		logging.L().Error("关闭链路追踪失败", err)
+	}
+}
+
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End 结束span，err不为空时记录为错误
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Detach 只保留ctx中的链路信息，用于请求返回后还在执行的异步任务，避免任务随请求一起被取消
+func Detach(ctx context.Context) context.Context {
+	if ctx == nil {
+		return context.Background()
+	}
+	return trace.ContextWithSpanContext(context.Background(), trace.SpanContextFromContext(ctx))
+}
+
+// Inject 将链路信息写入HTTP头，用于回调
+func Inject(ctx context.Context, header http.Header) {
+	if ctx == nil {
+		return
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// Middleware 从请求头中提取上游的链路信息，为每个请求创建span，并放入c.Request的context中
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		ctx, span := otel.Tracer(instrumentationName).Start(ctx, c.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(semconv.HTTPServerAttributesFromHTTPRequest(instrumentationName, route, c.Request)...))
+		defer span.End()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(status)...)
+		span.SetStatus(semconv.SpanStatusFromHTTPStatusCodeAndSpanKind(status, trace.SpanKindServer))
+	}
+}
@@ -0,0 +1,102 @@
+package tracing
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func setup() *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	SetExporter(exporter, "", 1, sdktrace.WithSyncer(exporter))
+	return exporter
+}
+
+func findSpan(spans tracetest.SpanStubs, name string) *tracetest.SpanStub {
+	for i := range spans {
+		if spans[i].Name == name {
+			return &spans[i]
+		}
+	}
+	return nil
+}
+
+func TestMiddleware(t *testing.T) {
+	exporter := setup()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware())
+	var handlerSpan trace.SpanContext
+	r.GET("/vm/:id", func(c *gin.Context) {
+		handlerSpan = trace.SpanContextFromContext(c.Request.Context())
+		c.Status(http.StatusAccepted)
+	})
+
+	traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/vm/vm-1", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	span := findSpan(exporter.GetSpans(), "GET /vm/:id")
+	if span == nil {
+		t.Fatal("没有记录请求的span", exporter.GetSpans())
+	}
+	if span.SpanContext.TraceID().String() != traceID {
+		t.Error("没有继承上游的链路", span.SpanContext.TraceID())
+	}
+	if handlerSpan.SpanID() != span.SpanContext.SpanID() {
+		t.Error("处理函数中拿不到请求的span")
+	}
+}
+
+func TestInject(t *testing.T) {
+	setup()
+	ctx, span := Start(Detach(context.Background()), "callback")
+	defer span.End()
+
+	header := http.Header{}
+	Inject(ctx, header)
+	if !strings.Contains(header.Get("traceparent"), span.SpanContext().TraceID().String()) {
+		t.Error("回调请求头中没有链路信息", header)
+	}
+}
+
+func TestRoundTripper(t *testing.T) {
+	exporter := setup()
+	simulator.Test(func(ctx context.Context, c *vim25.Client) {
+		root, span := Start(context.Background(), "deploy")
+		scope := NewScope(root)
+		traced := *c
+		traced.RoundTripper = RoundTripper(c.RoundTripper, scope)
+
+		end := scope.Step("deploy.clone")
+		_, err := methods.GetCurrentTime(context.Background(), &traced)
+		end(err)
+		span.End()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		spans := exporter.GetSpans()
+		step := findSpan(spans, "deploy.clone")
+		call := findSpan(spans, "vsphere.CurrentTime")
+		if step == nil || call == nil {
+			t.Fatal("没有记录步骤或vSphere调用的span", spans)
+		}
+		if call.Parent.SpanID() != step.SpanContext.SpanID() {
+			t.Error("vSphere调用没有记录在步骤下")
+		}
+		if step.Parent.SpanID() != span.SpanContext().SpanID() {
+			t.Error("步骤没有记录在部署下")
+		}
+	})
+}
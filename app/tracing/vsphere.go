@@ -0,0 +1,80 @@
+package tracing
+
+import (
+	"context"
+	"github.com/vmware/govmomi/vim25/soap"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Scope
+// 记录部署、操作任务当前所在的步骤，helper中的vSphere调用大多不传递context，
+// 包装后的客户端通过Scope找到父span
+type Scope struct {
+	mu   sync.Mutex
+	root context.Context
+	ctx  context.Context
+}
+
+func NewScope(ctx context.Context) *Scope {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return &Scope{root: ctx, ctx: ctx}
+}
+
+func (s *Scope) Context() context.Context {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ctx
+}
+
+// Step 开始一个步骤，返回结束函数，步骤之间是顺序执行的
+func (s *Scope) Step(name string, attrs ...attribute.KeyValue) func(err error) {
+	ctx, span := Start(s.root, name, attrs...)
+	s.set(ctx)
+	return func(err error) {
+		End(span, err)
+		s.set(s.root)
+	}
+}
+
+func (s *Scope) set(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ctx = ctx
+}
+
+type roundTripper struct {
+	rt    soap.RoundTripper
+	scope *Scope
+}
+
+// RoundTripper 为每次SOAP调用创建span，调用方传入的ctx中没有span时使用scope中的父span
+func RoundTripper(rt soap.RoundTripper, scope *Scope) soap.RoundTripper {
+	return &roundTripper{rt: rt, scope: scope}
+}
+
+func (r *roundTripper) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	parent := ctx
+	if !trace.SpanContextFromContext(ctx).IsValid() && r.scope != nil {
+		parent = trace.ContextWithSpan(ctx, trace.SpanFromContext(r.scope.Context()))
+	}
+	method := methodName(req)
+	_, span := Start(parent, "vsphere."+method, attribute.String("vsphere.method", method))
+	err := r.rt.RoundTrip(ctx, req, res)
+	End(span, err)
+	return err
+}
+
+// methodName 请求类型为methods.XxxBody
+func methodName(req soap.HasFault) string {
+	t := reflect.TypeOf(req)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return strings.TrimSuffix(t.Name(), "Body")
+}
@@ -4,36 +4,293 @@ import (
 	"bytes"
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/rand"
 	"encoding/base64"
 	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
 	"vsphere-facade/app/logging"
+	"vsphere-facade/config"
 )
 
+// EnvKeys 环境变量中的密钥，多个使用逗号分隔，优先于密钥文件和配置
+const EnvKeys = "VSPHERE_FACADE_KEYS"
+
+// cipherPrefix 密文格式为v2:<密钥ID>:<base64(nonce+密文)>
+const cipherPrefix = "v2:"
+
+// legacyKey 旧版本固定的CBC密钥，只在app.crypto.allowLegacy开启时用于解密
+var legacyKey = []byte("480055b0a0c4d10c")
+
+var keyIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Key 加密密钥，格式为<ID>:<base64密钥>，密钥长度为16、24或32字节
+type Key struct {
+	ID     string
+	Secret []byte
+}
+
+func (k Key) String() string {
+	return k.ID + ":" + base64.StdEncoding.EncodeToString(k.Secret)
+}
+
+// ParseKey 解析<ID>:<base64密钥>格式的密钥
+func ParseKey(s string) (Key, error) {
+	parts := strings.SplitN(strings.TrimSpace(s), ":", 2)
+	if len(parts) != 2 || !keyIDPattern.MatchString(parts[0]) {
+		return Key{}, fmt.Errorf("密钥格式应为<ID>:<base64密钥>")
+	}
+	secret, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return Key{}, fmt.Errorf("密钥[%s]不是有效的base64: %v", parts[0], err)
+	}
+	switch len(secret) {
+	case 16, 24, 32:
+	default:
+		return Key{}, fmt.Errorf("密钥[%s]长度应为16、24或32字节", parts[0])
+	}
+	return Key{ID: parts[0], Secret: secret}, nil
+}
+
+// NewKey 生成32字节的随机密钥，ID为生成时间
+func NewKey() (Key, error) {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return Key{}, err
+	}
+	return Key{ID: "k" + time.Now().Format("20060102150405"), Secret: secret}, nil
+}
+
 var (
-	key = []byte("480055b0a0c4d10c")
+	keysMu sync.RWMutex
+	// keys 第一个密钥用于加密，其他密钥只用于解密轮换前的数据
+	keys []Key
+	// temporaryKey 没有配置密钥时使用临时密钥
+	temporaryKey bool
 )
 
-func AesEncrypt(plaintext string) string {
-	block, err := aes.NewCipher(key)
+// SetupKeys
+// 依次从环境变量、密钥文件(app.crypto.keyFile)和配置(app.crypto.keys)读取密钥，使用第一个不为空的来源。
+// 都没有配置时使用临时密钥，重启后之前的令牌和保存的密码都无法解密
+func SetupKeys() {
+	loaded, source, err := LoadKeys()
+	temporaryKey = false
 	if err != nil {
-		logging.L().Error("", errors.New("加密失败，Key无效"))
+		logging.L().Panic("读取加密密钥失败", err)
+	}
+	if len(loaded) == 0 {
+		key, err := NewKey()
+		if err != nil {
+			logging.L().Panic("生成临时加密密钥失败", err)
+		}
+		logging.L().Warn("没有配置加密密钥，使用临时密钥，重启后之前的令牌和保存的密码将无法解密")
+		loaded = []Key{key}
+		source = "临时密钥"
+		temporaryKey = true
+	}
+	err = SetKeys(loaded)
+	if err != nil {
+		logging.L().Panic("加密密钥无效", err)
+	}
+	logging.L().Infof("从%s读取到%d个加密密钥，当前密钥为[%s]", source, len(loaded), loaded[0].ID)
+}
+
+// TemporaryKey 没有配置密钥，当前使用的是启动时生成的临时密钥
+func TemporaryKey() bool {
+	return temporaryKey
+}
+
+// LoadKeys 读取配置的密钥和来源，没有配置时返回空
+func LoadKeys() ([]Key, string, error) {
+	if env := os.Getenv(EnvKeys); env != "" {
+		loaded, err := parseKeys(strings.Split(env, ","))
+		return loaded, "环境变量" + EnvKeys, err
+	}
+	if file := config.G.App.Crypto.KeyFile; file != "" {
+		loaded, err := ReadKeyFile(file)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, "", err
+		}
+		if len(loaded) > 0 {
+			return loaded, "密钥文件" + file, nil
+		}
+	}
+	loaded, err := parseKeys(config.G.App.Crypto.Keys)
+	return loaded, "配置", err
+}
+
+func parseKeys(lines []string) ([]Key, error) {
+	var parsed []Key
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, err := ParseKey(line)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, key)
+	}
+	return parsed, nil
+}
+
+// ReadKeyFile 密钥文件每行一个密钥，第一行为当前密钥，#开头为注释
+func ReadKeyFile(file string) ([]Key, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return parseKeys(strings.Split(string(b), "\n"))
+}
+
+// WriteKeyFile 写入密钥文件，只有当前用户可以读写
+func WriteKeyFile(file string, ks []Key) error {
+	var b bytes.Buffer
+	b.WriteString("# vsphere-facade加密密钥，第一行为当前密钥，其他密钥只用于解密轮换前的数据\n")
+	for _, k := range ks {
+		b.WriteString(k.String())
+		b.WriteString("\n")
+	}
+	return ioutil.WriteFile(file, b.Bytes(), 0600)
+}
+
+// SetKeys 替换使用的密钥，第一个密钥用于加密
+func SetKeys(ks []Key) error {
+	if len(ks) == 0 {
+		return errors.New("至少需要一个密钥")
+	}
+	seen := make(map[string]bool)
+	for _, k := range ks {
+		if seen[k.ID] {
+			return fmt.Errorf("密钥ID[%s]重复", k.ID)
+		}
+		seen[k.ID] = true
+		if _, err := aes.NewCipher(k.Secret); err != nil {
+			return fmt.Errorf("密钥[%s]无效: %v", k.ID, err)
+		}
+	}
+	keysMu.Lock()
+	defer keysMu.Unlock()
+	keys = append([]Key{}, ks...)
+	return nil
+}
+
+// Keys 当前使用的所有密钥
+func Keys() []Key {
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+	return append([]Key{}, keys...)
+}
+
+// CurrentKeyID 用于加密的密钥ID
+func CurrentKeyID() string {
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+	if len(keys) == 0 {
 		return ""
 	}
+	return keys[0].ID
+}
 
-	var b = []byte(plaintext)
+// KeyIDOf 密文使用的密钥ID，旧版本的密文返回空
+func KeyIDOf(ciphertext string) string {
+	if !strings.HasPrefix(ciphertext, cipherPrefix) {
+		return ""
+	}
+	parts := strings.SplitN(strings.TrimPrefix(ciphertext, cipherPrefix), ":", 2)
+	if len(parts) != 2 {
+		return ""
+	}
+	return parts[0]
+}
 
-	blockSize := block.BlockSize()
-	b = PKCS5Padding(b, blockSize)
-	blockMode := cipher.NewCBCEncrypter(block, key)
+func findKey(ID string) (Key, bool) {
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+	if ID == "" && len(keys) > 0 {
+		return keys[0], true
+	}
+	for _, k := range keys {
+		if k.ID == ID {
+			return k, true
+		}
+	}
+	return Key{}, false
+}
 
-	ciphertext := make([]byte, len(b))
-	blockMode.CryptBlocks(ciphertext, b)
+func newGCM(secret []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(secret)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
 
-	return base64.StdEncoding.EncodeToString(ciphertext)
+// AesEncrypt 使用当前密钥AES-GCM加密，每次使用随机nonce，密文中带有密钥ID
+func AesEncrypt(plaintext string) string {
+	key, ok := findKey("")
+	if !ok {
+		logging.L().Error("", errors.New("加密失败，没有可用的密钥"))
+		return ""
+	}
+	gcm, err := newGCM(key.Secret)
+	if err != nil {
+		logging.L().Error("", errors.New("加密失败，Key无效"))
+		return ""
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		logging.L().Error("加密失败，生成随机数失败", err)
+		return ""
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), []byte(key.ID))
+	return cipherPrefix + key.ID + ":" + base64.StdEncoding.EncodeToString(sealed)
 }
 
+// AesDecrypt 使用密文中的密钥ID对应的密钥解密，失败时返回空
 func AesDecrypt(ciphertext string) string {
-	block, err := aes.NewCipher(key)
+	if !strings.HasPrefix(ciphertext, cipherPrefix) {
+		return legacyDecrypt(ciphertext)
+	}
+	ID := KeyIDOf(ciphertext)
+	key, ok := findKey(ID)
+	if ID == "" || !ok {
+		logging.L().Error("", fmt.Errorf("解密失败，密钥[%s]不存在", ID))
+		return ""
+	}
+	gcm, err := newGCM(key.Secret)
+	if err != nil {
+		logging.L().Error("", errors.New("解密失败，Key无效"))
+		return ""
+	}
+	b, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(ciphertext, cipherPrefix+ID+":"))
+	if err != nil || len(b) < gcm.NonceSize() {
+		logging.L().Error("", errors.New("解密失败，解密源无效"))
+		return ""
+	}
+	plaintext, err := gcm.Open(nil, b[:gcm.NonceSize()], b[gcm.NonceSize():], []byte(key.ID))
+	if err != nil {
+		logging.L().Error("", errors.New("解密失败，密文被篡改或密钥不匹配"))
+		return ""
+	}
+	return string(plaintext)
+}
+
+// legacyDecrypt 解密旧版本固定密钥CBC加密的数据，用于迁移
+func legacyDecrypt(ciphertext string) string {
+	if !config.G.App.Crypto.AllowLegacy {
+		logging.L().Error("", errors.New("解密失败，不支持旧版本的密文"))
+		return ""
+	}
+	block, err := aes.NewCipher(legacyKey)
 	if err != nil {
 		logging.L().Error("", errors.New("解密失败，Key无效"))
 		return ""
@@ -52,7 +309,7 @@ func AesDecrypt(ciphertext string) string {
 		return ""
 	}
 
-	blockModel := cipher.NewCBCDecrypter(block, key)
+	blockModel := cipher.NewCBCDecrypter(block, legacyKey)
 
 	plaintext := make([]byte, len(b))
 	blockModel.CryptBlocks(plaintext, b)
@@ -70,5 +327,8 @@ func PKCS5Padding(src []byte, blockSize int) []byte {
 func PKCS5UnPadding(src []byte) []byte {
 	length := len(src)
 	unpadding := int(src[length-1])
+	if unpadding == 0 || unpadding > length {
+		return nil
+	}
 	return src[:(length - unpadding)]
 }
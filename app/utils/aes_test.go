@@ -1,17 +1,123 @@
 package utils
 
 import (
-	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"vsphere-facade/app/logging"
+	"vsphere-facade/config"
 )
 
+func TestMain(m *testing.M) {
+	config.G.Server.Log.Path = os.TempDir()
+	config.G.Server.Log.Level = "fatal"
+	logging.Setup()
+	os.Exit(m.Run())
+}
+
+func testKey(t *testing.T, ID string) Key {
+	k, err := NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	k.ID = ID
+	return k
+}
+
 func TestAesEncrypt(t *testing.T) {
+	if err := SetKeys([]Key{testKey(t, "k1")}); err != nil {
+		t.Fatal(err)
+	}
+
 	en := AesEncrypt("administrator@vsphere.local")
+	if !strings.HasPrefix(en, "v2:k1:") || KeyIDOf(en) != "k1" {
+		t.Fatal("密文应该带有密钥ID", en)
+	}
+	if en == AesEncrypt("administrator@vsphere.local") {
+		t.Error("相同的明文每次加密结果应该不同")
+	}
+	if de := AesDecrypt(en); de != "administrator@vsphere.local" {
+		t.Error("解密结果不正确", de)
+	}
 
-	fmt.Println(en)
+	tampered := []byte(en)
+	tampered[len(tampered)-2] ^= 1
+	if de := AesDecrypt(string(tampered)); de != "" {
+		t.Error("被篡改的密文不能解密", de)
+	}
+}
+
+func TestRotate(t *testing.T) {
+	old := testKey(t, "old")
+	if err := SetKeys([]Key{old}); err != nil {
+		t.Fatal(err)
+	}
+	en := AesEncrypt("P@ss")
+
+	if err := SetKeys([]Key{testKey(t, "new"), old}); err != nil {
+		t.Fatal(err)
+	}
+	if CurrentKeyID() != "new" || KeyIDOf(AesEncrypt("P@ss")) != "new" {
+		t.Error("轮换后应该使用新密钥加密")
+	}
+	if de := AesDecrypt(en); de != "P@ss" {
+		t.Error("轮换后旧密钥加密的数据应该还能解密", de)
+	}
+
+	if err := SetKeys([]Key{testKey(t, "new")}); err != nil {
+		t.Fatal(err)
+	}
+	if de := AesDecrypt(en); de != "" {
+		t.Error("移除旧密钥后不能再解密", de)
+	}
+	if err := SetKeys([]Key{old, old}); err == nil {
+		t.Error("密钥ID不能重复")
+	}
+}
+
+func TestLoadKeys(t *testing.T) {
+	k1, k2 := testKey(t, "k1"), testKey(t, "k2")
+	file := filepath.Join(t.TempDir(), "keys")
+	if err := WriteKeyFile(file, []Key{k2, k1}); err != nil {
+		t.Fatal(err)
+	}
+	config.G.App.Crypto.KeyFile = file
+	config.G.App.Crypto.Keys = []string{k1.String()}
+	defer func() {
+		config.G.App.Crypto.KeyFile = ""
+		config.G.App.Crypto.Keys = nil
+	}()
+
+	loaded, _, err := LoadKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != 2 || loaded[0].ID != "k2" || string(loaded[1].Secret) != string(k1.Secret) {
+		t.Error("密钥文件优先于配置", loaded)
+	}
+
+	_ = os.Setenv(EnvKeys, k1.String())
+	defer os.Unsetenv(EnvKeys)
+	loaded, _, err = LoadKeys()
+	if err != nil || len(loaded) != 1 || loaded[0].ID != "k1" {
+		t.Error("环境变量优先于密钥文件", loaded, err)
+	}
+
+	if _, err = ParseKey("k3:c2hvcnQ="); err == nil {
+		t.Error("密钥长度不正确")
+	}
 }
 
 func TestAesDecrypt(t *testing.T) {
-	de := AesDecrypt("3PxvnuL02IaQOz63pGxIhYbblUbZrHmxKOCAmA7zYjY=")
-	fmt.Println(de)
+	legacy := "3PxvnuL02IaQOz63pGxIhYbblUbZrHmxKOCAmA7zYjY="
+	if de := AesDecrypt(legacy); de != "" {
+		t.Error("默认不能解密旧版本的密文", de)
+	}
+
+	config.G.App.Crypto.AllowLegacy = true
+	defer func() { config.G.App.Crypto.AllowLegacy = false }()
+	if de := AesDecrypt(legacy); de == "" {
+		t.Error("开启allowLegacy后应该能解密旧版本的密文")
+	}
 }
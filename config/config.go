@@ -13,14 +13,59 @@ type Config struct {
 	Server struct {
 		Mode string `mapstructure:"mode"`
 		Port int    `mapstructure:"port"`
-		Log  struct {
+		TLS  struct {
+			Enable       bool   `mapstructure:"enable"`
+			CertFile     string `mapstructure:"certFile"`
+			KeyFile      string `mapstructure:"keyFile"`
+			ClientCAFile string `mapstructure:"clientCaFile"`
+			ClientAuth   string `mapstructure:"clientAuth"`
+		} `mapstructure:"tls"`
+		Log struct {
 			Path           string `mapstructure:"path"`
 			Level          string `mapstructure:"level"`
 			MaxSize        int    `mapstructure:"maxSize"`
 			MaxBackups     int    `mapstructure:"maxBackups"`
 			MaxAge         int    `mapstructure:"maxAge"`
 			EnableFullPath bool   `mapstructure:"enableFullPath"`
+			Format         string `mapstructure:"format"`
 		}
+		Metrics struct {
+			Enable    bool `mapstructure:"enable"`
+			Inventory bool `mapstructure:"inventory"`
+		} `mapstructure:"metrics"`
+		Tracing struct {
+			Enable      bool    `mapstructure:"enable"`
+			Endpoint    string  `mapstructure:"endpoint"`
+			Insecure    bool    `mapstructure:"insecure"`
+			ServiceName string  `mapstructure:"serviceName"`
+			SampleRatio float64 `mapstructure:"sampleRatio"`
+		} `mapstructure:"tracing"`
+		Health struct {
+			Timeout   int   `mapstructure:"timeout"`
+			MaxQueued int64 `mapstructure:"maxQueued"`
+		} `mapstructure:"health"`
+		Audit struct {
+			Enable bool `mapstructure:"enable"`
+			// Retention badger DB中审计记录的保存天数，0为不过期
+			Retention int    `mapstructure:"retention"`
+			File      string `mapstructure:"file"`
+			Syslog    *struct {
+				Network string `mapstructure:"network"`
+				Address string `mapstructure:"address"`
+				Tag     string `mapstructure:"tag"`
+			} `mapstructure:"syslog"`
+		} `mapstructure:"audit"`
+		RateLimit struct {
+			Enable            bool             `mapstructure:"enable"`
+			Rate              float64          `mapstructure:"rate"`
+			Burst             int              `mapstructure:"burst"`
+			IPRate            float64          `mapstructure:"ipRate"`
+			IPBurst           int              `mapstructure:"ipBurst"`
+			Routes            []RateLimitRoute `mapstructure:"routes"`
+			MaxTasksPerClient int              `mapstructure:"maxTasksPerClient"`
+			MaxTasksPerVC     int              `mapstructure:"maxTasksPerVc"`
+			RetryAfter        int              `mapstructure:"retryAfter"`
+		} `mapstructure:"rateLimit"`
 		Db struct {
 			Badger *struct {
 				Path string `mapstructure:"path"`
@@ -30,9 +75,30 @@ type Config struct {
 
 	App struct {
 		Token struct {
-			Type   string `mapstructure:"type"`
-			Secret string `mapstructure:"secret"`
+			Type               string `mapstructure:"type"`
+			Secret             string `mapstructure:"secret"`
+			DisableCredentials bool   `mapstructure:"disableCredentials"`
+			CredentialRole     string `mapstructure:"credentialRole"`
+			AccessExpire       int    `mapstructure:"accessExpire"`
+			RefreshExpire      int    `mapstructure:"refreshExpire"`
+			OIDC               struct {
+				Issuer      string        `mapstructure:"issuer"`
+				Audience    string        `mapstructure:"audience"`
+				JWKSURL     string        `mapstructure:"jwksUrl"`
+				GroupsClaim string        `mapstructure:"groupsClaim"`
+				Leeway      int           `mapstructure:"leeway"`
+				DefaultRole string        `mapstructure:"defaultRole"`
+				Mappings    []OIDCMapping `mapstructure:"mappings"`
+			} `mapstructure:"oidc"`
 		}
+		Admin struct {
+			Key string `mapstructure:"key" json:"-"`
+		} `mapstructure:"admin"`
+		Crypto struct {
+			Keys        []string `mapstructure:"keys" json:"-"`
+			KeyFile     string   `mapstructure:"keyFile"`
+			AllowLegacy bool     `mapstructure:"allowLegacy"`
+		} `mapstructure:"crypto"`
 	}
 
 	Vsphere struct {
@@ -58,9 +124,9 @@ type Config struct {
 			WaitForRelocate int32 `mapstructure:"waitForRelocate"`
 		}
 		Cache struct {
-			Enable          bool `mapstructure:"enable"`
-			RefreshDuration int  `mapstructure:"refreshDuration"`
-			Ignore          []struct {
+			Enable        bool `mapstructure:"enable"`
+			RetryInterval int  `mapstructure:"retryInterval"`
+			Ignore        []struct {
 				VCID  string   `mapstructure:"vcid"`
 				Items []string `mapstructure:"items"`
 			}
@@ -69,9 +135,98 @@ type Config struct {
 			Operation  int `mapstructure:"operation"`
 			Deployment int `mapstructure:"deployment"`
 		} `mapstructure:"routineCount"`
+		ExtraConfig struct {
+			Allow []string `mapstructure:"allow"`
+			Deny  []string `mapstructure:"deny"`
+		} `mapstructure:"extraConfig"`
+		Quota struct {
+			Enable  bool          `mapstructure:"enable"`
+			Tenants []TenantQuota `mapstructure:"tenants"`
+		} `mapstructure:"quota"`
+		TLS struct {
+			VCenterTLS `mapstructure:",squash"`
+			Hosts      []VCenterTLS `mapstructure:"hosts"`
+		} `mapstructure:"tls"`
+		VCenters []VCenterConfig `mapstructure:"vcenters"`
+		Session  struct {
+			KeepAlive        int `mapstructure:"keepAlive"`
+			FailureThreshold int `mapstructure:"failureThreshold"`
+			OpenTimeout      int `mapstructure:"openTimeout"`
+		} `mapstructure:"session"`
 	}
 }
 
+// VCenterConfig 登记的VC，使用服务账号连接，后台任务不需要客户端令牌
+type VCenterConfig struct {
+	Alias    string `mapstructure:"alias" json:"alias"`
+	Address  string `mapstructure:"address" json:"address"`
+	Username string `mapstructure:"username" json:"username"`
+	// Password 明文或使用当前密钥加密的密文
+	Password string     `mapstructure:"password" json:"-"`
+	TLS      VCenterTLS `mapstructure:"tls" json:"tls"`
+	Default  struct {
+		AdapterType *string `mapstructure:"adapterType" json:"adapterType,omitempty"`
+		DiskMode    *string `mapstructure:"diskMode" json:"diskMode,omitempty"`
+		// StoragePolicies 数据存储类型对应的存储策略ID
+		StoragePolicies map[string]string `mapstructure:"storagePolicies" json:"storagePolicies,omitempty"`
+	} `mapstructure:"default" json:"default"`
+	RoutineCount struct {
+		Operation  int `mapstructure:"operation" json:"operation,omitempty"`
+		Deployment int `mapstructure:"deployment" json:"deployment,omitempty"`
+	} `mapstructure:"routineCount" json:"routineCount"`
+	// WarmUp 启动时创建缓存
+	WarmUp bool `mapstructure:"warmUp" json:"warmUp"`
+}
+
+// VCenterTLS 连接VC时的证书校验，配置了指纹时只校验指纹，insecure需要显式开启
+type VCenterTLS struct {
+	Host       string `mapstructure:"host" json:"host,omitempty"`
+	CAFile     string `mapstructure:"caFile" json:"caFile,omitempty"`
+	Thumbprint string `mapstructure:"thumbprint" json:"thumbprint,omitempty"`
+	Insecure   bool   `mapstructure:"insecure" json:"insecure,omitempty"`
+}
+
+// QuotaLimit 资源配额，0为不限制
+type QuotaLimit struct {
+	CPU      int64 `mapstructure:"cpu" json:"cpu,omitempty"`
+	MemoryGB int64 `mapstructure:"memoryGb" json:"memoryGb,omitempty"`
+	DiskGB   int64 `mapstructure:"diskGb" json:"diskGb,omitempty"`
+	VMs      int64 `mapstructure:"vms" json:"vms,omitempty"`
+}
+
+// ObjectQuota 单个集群或数据存储的配额，数据存储只限制diskGb
+type ObjectQuota struct {
+	ID         string `mapstructure:"id" json:"id"`
+	QuotaLimit `mapstructure:",squash"`
+}
+
+// TenantQuota 租户的资源配额，租户的虚拟机按标签或文件夹归属
+type TenantQuota struct {
+	Name       string `mapstructure:"name" json:"name"`
+	VCID       string `mapstructure:"vcid" json:"vcid,omitempty"`
+	TagID      string `mapstructure:"tagId" json:"tagId,omitempty"`
+	FolderID   string `mapstructure:"folderId" json:"folderId,omitempty"`
+	QuotaLimit `mapstructure:",squash"`
+	Clusters   []ObjectQuota `mapstructure:"clusters" json:"clusters,omitempty"`
+	Datastores []ObjectQuota `mapstructure:"datastores" json:"datastores,omitempty"`
+}
+
+// RateLimitRoute 单个路由的限流，每个调用方单独计算
+type RateLimitRoute struct {
+	Method string  `mapstructure:"method"`
+	Path   string  `mapstructure:"path"`
+	Rate   float64 `mapstructure:"rate"`
+	Burst  int     `mapstructure:"burst"`
+}
+
+// OIDCMapping OIDC令牌中的组对应的角色和VC账号
+type OIDCMapping struct {
+	Group   string `mapstructure:"group"`
+	Role    string `mapstructure:"role"`
+	Account string `mapstructure:"account"`
+	Tenant  string `mapstructure:"tenant"`
+}
+
 var G Config
 
 func Setup() {
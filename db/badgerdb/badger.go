@@ -1,8 +1,10 @@
 package badgerdb
 
 import (
+	"errors"
 	"github.com/dgraph-io/badger/v3"
 	"log"
+	"time"
 	"vsphere-facade/app/logging"
 	"vsphere-facade/app/utils"
 	"vsphere-facade/config"
@@ -10,6 +12,11 @@ import (
 
 var db *badger.DB
 
+const healthCheckKey = "__health_check__"
+
+// ErrKeyExists SetNXWithTTL写入的键已经存在
+var ErrKeyExists = errors.New("键已存在")
+
 func Setup() {
 	dataPath := config.G.Server.Db.Badger.Path
 	if dataPath != "" {
@@ -21,6 +28,16 @@ func Setup() {
 	}
 }
 
+// Close 关闭badger DB，关闭后不可用
+func Close() error {
+	if db == nil {
+		return nil
+	}
+	err := db.Close()
+	db = nil
+	return err
+}
+
 func Set(k, v string) {
 	if !isAvailable() {
 		return
@@ -40,6 +57,35 @@ func Set(k, v string) {
 	}
 }
 
+// SetWithTTL 写入的数据在ttl后过期
+func SetWithTTL(k, v string, ttl time.Duration) error {
+	if !isAvailable() {
+		return errors.New("badger DB不可用")
+	}
+
+	return db.Update(func(txn *badger.Txn) error {
+		return txn.SetEntry(badger.NewEntry([]byte(k), []byte(v)).WithTTL(ttl))
+	})
+}
+
+// SetNXWithTTL 键不存在时写入，检查和写入在同一个事务中，键已存在时返回ErrKeyExists
+func SetNXWithTTL(k, v string, ttl time.Duration) error {
+	if !isAvailable() {
+		return errors.New("badger DB不可用")
+	}
+
+	return db.Update(func(txn *badger.Txn) error {
+		_, err := txn.Get([]byte(k))
+		if err == nil {
+			return ErrKeyExists
+		}
+		if err != badger.ErrKeyNotFound {
+			return err
+		}
+		return txn.SetEntry(badger.NewEntry([]byte(k), []byte(v)).WithTTL(ttl))
+	})
+}
+
 func Get(k string) string {
 	if !isAvailable() {
 		return ""
@@ -54,7 +100,7 @@ func Get(k string) string {
 		val, err = item.ValueCopy(nil)
 		return err
 	})
-	if err != nil {
+	if err != nil && err != badger.ErrKeyNotFound {
 		logging.L().Errorf("读取键[%s]的值错误：%v", k, err)
 	}
 	return string(val)
@@ -126,3 +172,66 @@ func isAvailable() bool {
 	}
 	return true
 }
+
+// Enabled 是否配置了badger
+func Enabled() bool {
+	return db != nil
+}
+
+// Check 读取一个不存在的键，确认数据库可以正常访问
+func Check() error {
+	if db == nil {
+		return errors.New("badger DB未启用")
+	}
+	if db.IsClosed() {
+		return errors.New("badger DB已关闭")
+	}
+	return db.View(func(txn *badger.Txn) error {
+		_, err := txn.Get([]byte(healthCheckKey))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		return err
+	})
+}
+
+// Iterate 按键的顺序遍历指定前缀的数据，reverse为倒序，fn返回false时停止
+func Iterate(prefix string, reverse bool, fn func(k, v string) bool) error {
+	return IterateFrom(prefix, "", reverse, fn)
+}
+
+// IterateFrom 与Iterate相同，从键start之后开始遍历，不包括start，start为空时从头开始
+func IterateFrom(prefix, start string, reverse bool, fn func(k, v string) bool) error {
+	if !isAvailable() {
+		return nil
+	}
+
+	return db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(prefix)
+		opts.Reverse = reverse
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		seek := []byte(prefix)
+		if start != "" {
+			seek = []byte(start)
+		} else if reverse {
+			// 倒序时需要从前缀范围的末尾开始
+			seek = append(seek, 0xFF)
+		}
+		for it.Seek(seek); it.ValidForPrefix([]byte(prefix)); it.Next() {
+			item := it.Item()
+			if start != "" && string(item.Key()) == start {
+				continue
+			}
+			v, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			if !fn(string(item.KeyCopy(nil)), string(v)) {
+				return nil
+			}
+		}
+		return nil
+	})
+}
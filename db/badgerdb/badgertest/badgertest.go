@@ -0,0 +1,31 @@
+package badgertest
+
+import (
+	"path/filepath"
+	"testing"
+	"vsphere-facade/app/logging"
+	"vsphere-facade/app/utils"
+	"vsphere-facade/config"
+	"vsphere-facade/db/badgerdb"
+)
+
+// Setup 初始化日志和临时加密密钥，在临时目录中打开badger DB，返回临时目录。
+// 测试结束时关闭badger DB，临时目录由t.TempDir删除
+func Setup(t *testing.T) string {
+	dir := t.TempDir()
+	config.G.Server.Log.Path = dir
+	config.G.Server.Log.Level = "error"
+	logging.Setup()
+	utils.SetupKeys()
+	config.G.Server.Db.Badger = &struct {
+		Path string `mapstructure:"path"`
+	}{Path: filepath.Join(dir, "db")}
+	badgerdb.Setup()
+	t.Cleanup(func() {
+		if err := badgerdb.Close(); err != nil {
+			t.Error("关闭badger DB失败", err)
+		}
+		config.G.Server.Db.Badger = nil
+	})
+	return dir
+}
@@ -62,14 +62,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/caches": {
+        "/token/refresh": {
             "post": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "创建缓存",
+                "description": "使用刷新令牌换取新的令牌和刷新令牌，原刷新令牌只能使用一次。只支持jwt认证方式",
                 "consumes": [
                     "application/json"
                 ],
@@ -77,43 +72,62 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "缓存"
+                    "认证"
+                ],
+                "summary": "刷新令牌",
+                "parameters": [
+                    {
+                        "description": "刷新令牌",
+                        "name": "object",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/security.RefreshReq"
+                        }
+                    }
                 ],
-                "summary": "创建缓存",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/e.Response"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/e.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/jwt.Pair"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
-                        "description": "{\"code\":\"400x\",\"message\":\"失败\"}",
+                        "description": "{\"code\":\"\",\"message\":\"失败\",\"data\":{}",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "401": {
-                        "description": "{\"code\":\"401x\",\"message\":\"失败\"}",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "500": {
-                        "description": "{\"code\":\"500x\",\"message\":\"失败\"}",
+                        "description": "{\"code\":\"401x\",\"message\":\"失败\",\"data\":{}",
                         "schema": {
                             "type": "string"
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/token/revoke": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "清除缓存",
+                "description": "注销请求头中的令牌所在的会话，同一次登录签发和刷新得到的所有令牌(包括刷新令牌)都失效。只支持jwt认证方式，API Key请由管理员停用",
                 "consumes": [
                     "application/json"
                 ],
@@ -121,16 +135,16 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "缓存"
+                    "认证"
                 ],
-                "summary": "清除缓存",
+                "summary": "注销令牌",
                 "parameters": [
                     {
-                        "description": "需要清除的缓存Key，空为全部",
-                        "name": "c",
+                        "description": "刷新令牌",
+                        "name": "object",
                         "in": "body",
                         "schema": {
-                            "$ref": "#/definitions/v1.CleanCacheKey"
+                            "$ref": "#/definitions/security.RefreshReq"
                         }
                     }
                 ],
@@ -142,19 +156,13 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "{\"code\":\"400x\",\"message\":\"失败\"}",
+                        "description": "{\"code\":\"\",\"message\":\"失败\",\"data\":{}",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "401": {
-                        "description": "{\"code\":\"401x\",\"message\":\"失败\"}",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "500": {
-                        "description": "{\"code\":\"500x\",\"message\":\"失败\"}",
+                        "description": "{\"code\":\"401x\",\"message\":\"失败\",\"data\":{}",
                         "schema": {
                             "type": "string"
                         }
@@ -162,14 +170,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/clusters": {
+        "/v1/admin/accounts": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "集群查询",
+                "description": "查询管理员登记的VC服务账号，不返回密码",
                 "consumes": [
                     "application/json"
                 ],
@@ -177,23 +185,50 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "基础设施"
+                    "管理"
                 ],
-                "summary": "集群查询",
+                "summary": "VC账号查询",
                 "parameters": [
                     {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "description": "集群ID",
-                        "name": "ids",
+                        "type": "string",
+                        "description": "只返回指定的字段，多个使用逗号分隔，如: id,name",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量，0为不分页",
+                        "name": "limit",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "数据中心ID",
-                        "name": "datacenterId",
+                        "description": "名称包含，不区分大小写",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "名称正则匹配",
+                        "name": "nameRegex",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "跳过的数量",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "电源状态，只适用于虚拟机，如: poweredOn",
+                        "name": "powerState",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序字段，多个使用逗号分隔，-开头为倒序，如: name,-memoryMB",
+                        "name": "sort",
                         "in": "query"
                     }
                 ],
@@ -211,7 +246,7 @@ const docTemplate = `{
                                         "data": {
                                             "type": "array",
                                             "items": {
-                                                "$ref": "#/definitions/protocol.ClusterInfo"
+                                                "$ref": "#/definitions/identity.Account"
                                             }
                                         }
                                     }
@@ -219,14 +254,14 @@ const docTemplate = `{
                             ]
                         }
                     },
-                    "400": {
-                        "description": "{\"code\":\"400x\",\"message\":\"失败\"}",
+                    "401": {
+                        "description": "{\"code\":\"401x\",\"message\":\"失败\"}",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "401": {
-                        "description": "{\"code\":\"401x\",\"message\":\"失败\"}",
+                    "403": {
+                        "description": "{\"code\":\"403x\",\"message\":\"失败\"}",
                         "schema": {
                             "type": "string"
                         }
@@ -238,16 +273,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/v1/clusters/{clusterId}/os_families": {
-            "get": {
+            },
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "集群支持的操作系统",
+                "description": "登记VC服务账号，登记前会使用账号连接VC验证，密码加密保存",
                 "consumes": [
                     "application/json"
                 ],
@@ -255,21 +288,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "基础设施"
+                    "管理"
                 ],
-                "summary": "集群支持的操作系统",
+                "summary": "登记VC账号",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "集群ID",
-                        "name": "clusterID",
-                        "in": "path",
-                        "required": true
+                        "description": "VC账号",
+                        "name": "c",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.AccountReq"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
                             "allOf": [
                                 {
@@ -279,10 +314,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "array",
-                                            "items": {
-                                                "$ref": "#/definitions/protocol.OSFamilyInfo"
-                                            }
+                                            "$ref": "#/definitions/identity.Account"
                                         }
                                     }
                                 }
@@ -301,6 +333,12 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "403": {
+                        "description": "{\"code\":\"403x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "{\"code\":\"500x\",\"message\":\"失败\"}",
                         "schema": {
@@ -310,14 +348,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/datacenters": {
-            "get": {
+        "/v1/admin/accounts/{id}": {
+            "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "数据中心查询",
+                "description": "删除VC服务账号，还有API Key使用的账号不能删除",
                 "consumes": [
                     "application/json"
                 ],
@@ -325,37 +363,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "基础设施"
+                    "管理"
                 ],
-                "summary": "数据中心查询",
+                "summary": "删除VC账号",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "数据中心ID",
-                        "name": "ids",
-                        "in": "query"
+                        "description": "账号ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/e.Response"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "type": "array",
-                                            "items": {
-                                                "$ref": "#/definitions/protocol.DatacenterInfo"
-                                            }
-                                        }
-                                    }
-                                }
-                            ]
+                            "$ref": "#/definitions/e.Response"
                         }
                     },
                     "400": {
@@ -370,8 +394,8 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
-                    "500": {
-                        "description": "{\"code\":\"500x\",\"message\":\"失败\"}",
+                    "403": {
+                        "description": "{\"code\":\"403x\",\"message\":\"失败\"}",
                         "schema": {
                             "type": "string"
                         }
@@ -379,14 +403,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/datastores": {
+        "/v1/admin/api_keys": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "存储查询",
+                "description": "查询已发放的API Key，不返回密钥",
                 "consumes": [
                     "application/json"
                 ],
@@ -394,23 +418,50 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "基础设施"
+                    "管理"
                 ],
-                "summary": "存储查询",
+                "summary": "API Key查询",
                 "parameters": [
                     {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "description": "存储ID",
-                        "name": "ids",
+                        "type": "string",
+                        "description": "只返回指定的字段，多个使用逗号分隔，如: id,name",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量，0为不分页",
+                        "name": "limit",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "数据中心ID",
-                        "name": "datacenterId",
+                        "description": "名称包含，不区分大小写",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "名称正则匹配",
+                        "name": "nameRegex",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "跳过的数量",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "电源状态，只适用于虚拟机，如: poweredOn",
+                        "name": "powerState",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序字段，多个使用逗号分隔，-开头为倒序，如: name,-memoryMB",
+                        "name": "sort",
                         "in": "query"
                     }
                 ],
@@ -428,7 +479,7 @@ const docTemplate = `{
                                         "data": {
                                             "type": "array",
                                             "items": {
-                                                "$ref": "#/definitions/protocol.DatastoreInfo"
+                                                "$ref": "#/definitions/identity.APIKey"
                                             }
                                         }
                                     }
@@ -436,14 +487,14 @@ const docTemplate = `{
                             ]
                         }
                     },
-                    "400": {
-                        "description": "{\"code\":\"400x\",\"message\":\"失败\"}",
+                    "401": {
+                        "description": "{\"code\":\"401x\",\"message\":\"失败\"}",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "401": {
-                        "description": "{\"code\":\"401x\",\"message\":\"失败\"}",
+                    "403": {
+                        "description": "{\"code\":\"403x\",\"message\":\"失败\"}",
                         "schema": {
                             "type": "string"
                         }
@@ -455,16 +506,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/v1/folders": {
-            "get": {
+            },
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "文件夹查询",
+                "description": "发放API Key，调用方在请求头token中传入。key只在创建时返回一次，请妥善保存",
                 "consumes": [
                     "application/json"
                 ],
@@ -472,35 +521,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "基础设施"
+                    "管理"
                 ],
-                "summary": "文件夹查询",
+                "summary": "发放API Key",
                 "parameters": [
                     {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "description": "文件夹ID",
-                        "name": "ids",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "数据中心ID",
-                        "name": "datacenterId",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "文件夹ID",
-                        "name": "folderID",
-                        "in": "query"
+                        "description": "API Key",
+                        "name": "c",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.APIKeyReq"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
                             "allOf": [
                                 {
@@ -510,10 +547,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "array",
-                                            "items": {
-                                                "$ref": "#/definitions/protocol.FolderInfo"
-                                            }
+                                            "$ref": "#/definitions/v1.APIKeyResp"
                                         }
                                     }
                                 }
@@ -532,6 +566,12 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "403": {
+                        "description": "{\"code\":\"403x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "{\"code\":\"500x\",\"message\":\"失败\"}",
                         "schema": {
@@ -541,14 +581,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/hosts": {
-            "get": {
+        "/v1/admin/api_keys/{id}": {
+            "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "主机查询",
+                "description": "停用API Key，停用后立即不能再使用，记录保留用于审计",
                 "consumes": [
                     "application/json"
                 ],
@@ -556,29 +596,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "基础设施"
+                    "管理"
+                ],
+                "summary": "停用API Key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API Key ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "主机查询",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/e.Response"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "type": "array",
-                                            "items": {
-                                                "$ref": "#/definitions/protocol.OSFamilyInfo"
-                                            }
-                                        }
-                                    }
-                                }
-                            ]
+                            "$ref": "#/definitions/e.Response"
                         }
                     },
                     "400": {
@@ -593,8 +627,8 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
-                    "500": {
-                        "description": "{\"code\":\"500x\",\"message\":\"失败\"}",
+                    "403": {
+                        "description": "{\"code\":\"403x\",\"message\":\"失败\"}",
                         "schema": {
                             "type": "string"
                         }
@@ -602,14 +636,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/hosts/{hostId}/os_families": {
+        "/v1/admin/audit": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "主机支持的操作系统",
+                "description": "按时间倒序分页查询所有VC和管理操作的审计记录，返回的next作为cursor查询下一页，需要管理员角色，可以使用启动密钥。异步任务在接收请求(event=request)和任务结束(event=complete)时各有一条记录，通过requestId关联",
                 "consumes": [
                     "application/json"
                 ],
@@ -617,16 +651,75 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "基础设施"
+                    "审计"
                 ],
-                "summary": "主机支持的操作系统",
+                "summary": "审计记录查询",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "主机ID",
-                        "name": "hostID",
-                        "in": "path",
-                        "required": true
+                        "description": "上一页返回的next，为空时从最新的记录开始",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "开始时间，RFC3339格式",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量，默认100，最大1000",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "HTTP方法",
+                        "name": "method",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "操作对象ID，如虚拟机ID",
+                        "name": "objectID",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "结果: accepted, success, failure, partial, rejected",
+                        "name": "outcome",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "异步任务ID",
+                        "name": "requestID",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "结束时间，RFC3339格式",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "调用者",
+                        "name": "user",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "VC地址，管理操作(登记账号、发放API Key)的记录没有VC地址",
+                        "name": "vcaddress",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "VC ID",
+                        "name": "vcid",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -641,10 +734,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "array",
-                                            "items": {
-                                                "$ref": "#/definitions/protocol.OSFamilyInfo"
-                                            }
+                                            "$ref": "#/definitions/audit.Result"
                                         }
                                     }
                                 }
@@ -663,6 +753,12 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "403": {
+                        "description": "{\"code\":\"403x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "{\"code\":\"500x\",\"message\":\"失败\"}",
                         "schema": {
@@ -672,14 +768,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/networks": {
-            "get": {
+        "/v1/caches": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "网络查询",
+                "description": "创建缓存，并在后台监听清单变化增量更新缓存",
                 "consumes": [
                     "application/json"
                 ],
@@ -687,46 +783,68 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "基础设施"
+                    "缓存"
                 ],
-                "summary": "网络查询",
-                "parameters": [
-                    {
-                        "type": "array",
-                        "items": {
+                "summary": "创建缓存",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/e.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "{\"code\":\"400x\",\"message\":\"失败\"}",
+                        "schema": {
                             "type": "string"
-                        },
-                        "description": "网络ID",
-                        "name": "ids",
-                        "in": "query"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"code\":\"401x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
                     },
+                    "500": {
+                        "description": "{\"code\":\"500x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
                     {
-                        "type": "string",
-                        "description": "数据中心ID",
-                        "name": "datacenterId",
-                        "in": "query"
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "清除缓存",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "缓存"
+                ],
+                "summary": "清除缓存",
+                "parameters": [
+                    {
+                        "description": "需要清除的缓存Key，空为全部",
+                        "name": "c",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/v1.CleanCacheKey"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/e.Response"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "type": "array",
-                                            "items": {
-                                                "$ref": "#/definitions/protocol.NetworkInfo"
-                                            }
-                                        }
-                                    }
-                                }
-                            ]
+                            "$ref": "#/definitions/e.Response"
                         }
                     },
                     "400": {
@@ -750,14 +868,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/resource_pools": {
+        "/v1/clusters": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "资源池查询",
+                "description": "集群查询",
                 "consumes": [
                     "application/json"
                 ],
@@ -767,33 +885,63 @@ const docTemplate = `{
                 "tags": [
                     "基础设施"
                 ],
-                "summary": "资源池查询",
+                "summary": "集群查询",
                 "parameters": [
                     {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "description": "资源池ID",
-                        "name": "ids",
+                        "type": "string",
+                        "description": "只返回指定的字段，多个使用逗号分隔，如: id,name",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量，0为不分页",
+                        "name": "limit",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "数据中心ID",
-                        "name": "datacenterId",
+                        "description": "名称包含，不区分大小写",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "名称正则匹配",
+                        "name": "nameRegex",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "跳过的数量",
+                        "name": "offset",
                         "in": "query"
                     },
                     {
                         "type": "string",
+                        "description": "电源状态，只适用于虚拟机，如: poweredOn",
+                        "name": "powerState",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序字段，多个使用逗号分隔，-开头为倒序，如: name,-memoryMB",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
                         "description": "集群ID",
-                        "name": "clusterId",
+                        "name": "ids",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "主机ID",
-                        "name": "hostId",
+                        "description": "数据中心ID",
+                        "name": "datacenterId",
                         "in": "query"
                     }
                 ],
@@ -811,7 +959,7 @@ const docTemplate = `{
                                         "data": {
                                             "type": "array",
                                             "items": {
-                                                "$ref": "#/definitions/protocol.ResourcePoolInfo"
+                                                "$ref": "#/definitions/protocol.ClusterInfo"
                                             }
                                         }
                                     }
@@ -840,14 +988,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/storage_policies": {
+        "/v1/clusters/{clusterID}/performance": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "存储策略查询",
+                "description": "查询集群的历史性能数据(集群没有实时数据)，默认查询最新的CPU和内存使用情况",
                 "consumes": [
                     "application/json"
                 ],
@@ -855,9 +1003,60 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "基础设施"
+                    "性能"
+                ],
+                "summary": "查询集群性能数据",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "集群ID",
+                        "name": "clusterID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "指标名称，多个使用逗号分隔，格式为group.name.rollup，如: cpu.usage.average，为空时查询默认指标",
+                        "name": "counters",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "结束时间，RFC3339格式",
+                        "name": "endTime",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "实例，为空表示汇总数据，*表示所有实例(如每个CPU、每块磁盘)",
+                        "name": "instance",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "采样间隔(s)，20为实时数据，其他为VC中开启的历史数据间隔，如: 300、1800、7200、86400",
+                        "name": "interval",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "最多返回的样本数，没有指定开始时间时默认为1，最大为1440",
+                        "name": "maxSample",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "指标名称省略rollup时使用的汇总方式，默认为average",
+                        "name": "rollup",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "开始时间，RFC3339格式",
+                        "name": "startTime",
+                        "in": "query"
+                    }
                 ],
-                "summary": "存储策略查询",
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -872,7 +1071,7 @@ const docTemplate = `{
                                         "data": {
                                             "type": "array",
                                             "items": {
-                                                "$ref": "#/definitions/protocol.StoragePolicyInfo"
+                                                "$ref": "#/definitions/protocol.PerformanceInfo"
                                             }
                                         }
                                     }
@@ -901,14 +1100,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/templates": {
+        "/v1/clusters/{clusterId}/os_families": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "查询模板",
+                "description": "集群支持的操作系统",
                 "consumes": [
                     "application/json"
                 ],
@@ -916,18 +1115,58 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "模版"
+                    "基础设施"
                 ],
-                "summary": "查询模板",
+                "summary": "集群支持的操作系统",
                 "parameters": [
                     {
-                        "description": "查询参数",
-                        "name": "c",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/v1.TemplateQuery"
-                        }
+                        "type": "string",
+                        "description": "只返回指定的字段，多个使用逗号分隔，如: id,name",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量，0为不分页",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "名称包含，不区分大小写",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "名称正则匹配",
+                        "name": "nameRegex",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "跳过的数量",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "电源状态，只适用于虚拟机，如: poweredOn",
+                        "name": "powerState",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序字段，多个使用逗号分隔，-开头为倒序，如: name,-memoryMB",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "集群ID",
+                        "name": "clusterID",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -944,7 +1183,7 @@ const docTemplate = `{
                                         "data": {
                                             "type": "array",
                                             "items": {
-                                                "$ref": "#/definitions/protocol.TemplateInfo"
+                                                "$ref": "#/definitions/protocol.OSFamilyInfo"
                                             }
                                         }
                                     }
@@ -973,14 +1212,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/test_call_back": {
-            "post": {
+        "/v1/custom_attributes": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "测试http回调",
+                "description": "自定义属性定义查询",
                 "consumes": [
                     "application/json"
                 ],
@@ -988,72 +1227,51 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "测试"
+                    "标签"
                 ],
-                "summary": "测试http回调",
+                "summary": "自定义属性查询",
                 "parameters": [
                     {
-                        "description": "回调结果",
-                        "name": "c",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/protocol.CallbackRes"
-                        }
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": ""
+                        "type": "string",
+                        "description": "只返回指定的字段，多个使用逗号分隔，如: id,name",
+                        "name": "fields",
+                        "in": "query"
                     },
-                    "400": {
-                        "description": "{\"code\":\"400x\",\"message\":\"失败\"}",
-                        "schema": {
-                            "type": "string"
-                        }
+                    {
+                        "type": "integer",
+                        "description": "每页数量，0为不分页",
+                        "name": "limit",
+                        "in": "query"
                     },
-                    "401": {
-                        "description": "{\"code\":\"401x\",\"message\":\"失败\"}",
-                        "schema": {
-                            "type": "string"
-                        }
+                    {
+                        "type": "string",
+                        "description": "名称包含，不区分大小写",
+                        "name": "name",
+                        "in": "query"
                     },
-                    "500": {
-                        "description": "{\"code\":\"500x\",\"message\":\"失败\"}",
-                        "schema": {
-                            "type": "string"
-                        }
-                    }
-                }
-            }
-        },
-        "/v1/virtual_machines": {
-            "get": {
-                "security": [
                     {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "查询虚拟机",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "虚拟机"
-                ],
-                "summary": "查询虚拟机",
-                "parameters": [
+                        "type": "string",
+                        "description": "名称正则匹配",
+                        "name": "nameRegex",
+                        "in": "query"
+                    },
                     {
-                        "description": "查询参数",
-                        "name": "c",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/v1.VirtualMachineQuery"
-                        }
+                        "type": "integer",
+                        "description": "跳过的数量",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "电源状态，只适用于虚拟机，如: poweredOn",
+                        "name": "powerState",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序字段，多个使用逗号分隔，-开头为倒序，如: name,-memoryMB",
+                        "name": "sort",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -1070,7 +1288,7 @@ const docTemplate = `{
                                         "data": {
                                             "type": "array",
                                             "items": {
-                                                "$ref": "#/definitions/protocol.VirtualMachineInfo"
+                                                "$ref": "#/definitions/protocol.CustomAttributeInfo"
                                             }
                                         }
                                     }
@@ -1104,7 +1322,7 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "创建虚拟机",
+                "description": "设置虚拟机或其他清单对象的自定义属性值",
                 "consumes": [
                     "application/json"
                 ],
@@ -1112,40 +1330,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "虚拟机"
+                    "标签"
                 ],
-                "summary": "创建虚拟机",
+                "summary": "设置自定义属性",
                 "parameters": [
                     {
-                        "description": "创建参数",
+                        "description": "请求参数",
                         "name": "c",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/v1.DeployReq"
+                            "$ref": "#/definitions/v1.CustomAttributeReq"
                         }
                     }
                 ],
                 "responses": {
-                    "202": {
-                        "description": "Accepted",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/e.Response"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "type": "array",
-                                            "items": {
-                                                "$ref": "#/definitions/v1.DeployRes"
-                                            }
-                                        }
-                                    }
-                                }
-                            ]
+                            "$ref": "#/definitions/e.Response"
                         }
                     },
                     "400": {
@@ -1167,14 +1370,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/v1/datacenters": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "删除虚拟机",
+                "description": "数据中心查询",
                 "consumes": [
                     "application/json"
                 ],
@@ -1182,23 +1387,20 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "虚拟机"
+                    "基础设施"
                 ],
-                "summary": "删除虚拟机",
+                "summary": "数据中心查询",
                 "parameters": [
                     {
-                        "description": "删除虚拟机参数",
-                        "name": "c",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/v1.OperationReq"
-                        }
+                        "type": "string",
+                        "description": "数据中心ID",
+                        "name": "ids",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "202": {
-                        "description": "Accepted",
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -1210,7 +1412,7 @@ const docTemplate = `{
                                         "data": {
                                             "type": "array",
                                             "items": {
-                                                "$ref": "#/definitions/v1.OperationRes"
+                                                "$ref": "#/definitions/protocol.DatacenterInfo"
                                             }
                                         }
                                     }
@@ -1239,14 +1441,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/virtual_machines/description": {
-            "post": {
+        "/v1/datastores": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "修改备注",
+                "description": "存储查询",
                 "consumes": [
                     "application/json"
                 ],
@@ -1254,25 +1456,88 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "虚拟机"
+                    "基础设施"
                 ],
-                "summary": "修改备注",
+                "summary": "存储查询",
                 "parameters": [
                     {
-                        "description": "请求参数",
-                        "name": "c",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/v1.DescriptionReq"
-                        }
+                        "type": "string",
+                        "description": "只返回指定的字段，多个使用逗号分隔，如: id,name",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量，0为不分页",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "名称包含，不区分大小写",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "名称正则匹配",
+                        "name": "nameRegex",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "跳过的数量",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "电源状态，只适用于虚拟机，如: poweredOn",
+                        "name": "powerState",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序字段，多个使用逗号分隔，-开头为倒序，如: name,-memoryMB",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "description": "存储ID",
+                        "name": "ids",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "数据中心ID",
+                        "name": "datacenterId",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/e.Response"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/e.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/protocol.DatastoreInfo"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
@@ -1296,14 +1561,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/virtual_machines/power_off": {
-            "post": {
+        "/v1/datastores/{id}/performance": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "关闭电源",
+                "description": "查询存储的历史性能数据(存储没有实时数据)，默认查询最新的容量、已使用和已置备空间",
                 "consumes": [
                     "application/json"
                 ],
@@ -1311,23 +1576,63 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "虚拟机"
+                    "性能"
                 ],
-                "summary": "关闭电源",
+                "summary": "查询存储性能数据",
                 "parameters": [
                     {
-                        "description": "关闭电源参数",
-                        "name": "c",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/v1.OperationReq"
-                        }
+                        "type": "string",
+                        "description": "存储ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "指标名称，多个使用逗号分隔，格式为group.name.rollup，如: cpu.usage.average，为空时查询默认指标",
+                        "name": "counters",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "结束时间，RFC3339格式",
+                        "name": "endTime",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "实例，为空表示汇总数据，*表示所有实例(如每个CPU、每块磁盘)",
+                        "name": "instance",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "采样间隔(s)，20为实时数据，其他为VC中开启的历史数据间隔，如: 300、1800、7200、86400",
+                        "name": "interval",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "最多返回的样本数，没有指定开始时间时默认为1，最大为1440",
+                        "name": "maxSample",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "指标名称省略rollup时使用的汇总方式，默认为average",
+                        "name": "rollup",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "开始时间，RFC3339格式",
+                        "name": "startTime",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "202": {
-                        "description": "Accepted",
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -1339,7 +1644,7 @@ const docTemplate = `{
                                         "data": {
                                             "type": "array",
                                             "items": {
-                                                "$ref": "#/definitions/v1.OperationRes"
+                                                "$ref": "#/definitions/protocol.PerformanceInfo"
                                             }
                                         }
                                     }
@@ -1368,14 +1673,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/virtual_machines/power_on": {
-            "post": {
+        "/v1/folders": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "开机",
+                "description": "文件夹查询",
                 "consumes": [
                     "application/json"
                 ],
@@ -1383,23 +1688,77 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "虚拟机"
+                    "基础设施"
                 ],
-                "summary": "开机",
+                "summary": "文件夹查询",
                 "parameters": [
                     {
-                        "description": "开机参数",
-                        "name": "c",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/v1.OperationReq"
-                        }
+                        "type": "string",
+                        "description": "只返回指定的字段，多个使用逗号分隔，如: id,name",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量，0为不分页",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "名称包含，不区分大小写",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "名称正则匹配",
+                        "name": "nameRegex",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "跳过的数量",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "电源状态，只适用于虚拟机，如: poweredOn",
+                        "name": "powerState",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序字段，多个使用逗号分隔，-开头为倒序，如: name,-memoryMB",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "description": "文件夹ID",
+                        "name": "ids",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "数据中心ID",
+                        "name": "datacenterId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "文件夹ID",
+                        "name": "folderID",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "202": {
-                        "description": "Accepted",
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -1411,7 +1770,7 @@ const docTemplate = `{
                                         "data": {
                                             "type": "array",
                                             "items": {
-                                                "$ref": "#/definitions/v1.OperationRes"
+                                                "$ref": "#/definitions/protocol.FolderInfo"
                                             }
                                         }
                                     }
@@ -1440,14 +1799,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/virtual_machines/reconfigure": {
-            "post": {
+        "/v1/hosts": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "修改虚拟机配置",
+                "description": "主机查询",
                 "consumes": [
                     "application/json"
                 ],
@@ -1455,23 +1814,56 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "虚拟机"
+                    "基础设施"
                 ],
-                "summary": "修改虚拟机配置",
+                "summary": "主机查询",
                 "parameters": [
                     {
-                        "description": "修改虚拟机配置参数",
-                        "name": "c",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/v1.ReconfigureReq"
-                        }
+                        "type": "string",
+                        "description": "只返回指定的字段，多个使用逗号分隔，如: id,name",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量，0为不分页",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "名称包含，不区分大小写",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "名称正则匹配",
+                        "name": "nameRegex",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "跳过的数量",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "电源状态，只适用于虚拟机，如: poweredOn",
+                        "name": "powerState",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序字段，多个使用逗号分隔，-开头为倒序，如: name,-memoryMB",
+                        "name": "sort",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "202": {
-                        "description": "Accepted",
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -1483,7 +1875,7 @@ const docTemplate = `{
                                         "data": {
                                             "type": "array",
                                             "items": {
-                                                "$ref": "#/definitions/v1.OperationRes"
+                                                "$ref": "#/definitions/protocol.OSFamilyInfo"
                                             }
                                         }
                                     }
@@ -1512,14 +1904,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/virtual_machines/reconfigure_disk": {
-            "post": {
+        "/v1/hosts/{hostID}/performance": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "修改虚拟机硬盘",
+                "description": "查询主机的实时或历史性能数据，默认查询最新的CPU、内存、磁盘和网络使用情况",
                 "consumes": [
                     "application/json"
                 ],
@@ -1527,23 +1919,63 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "虚拟机"
+                    "性能"
                 ],
-                "summary": "修改虚拟机硬盘",
+                "summary": "查询主机性能数据",
                 "parameters": [
                     {
-                        "description": "修改虚拟机硬盘参数",
-                        "name": "c",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/v1.DiskReconfigureReq"
-                        }
+                        "type": "string",
+                        "description": "主机ID",
+                        "name": "hostID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "指标名称，多个使用逗号分隔，格式为group.name.rollup，如: cpu.usage.average，为空时查询默认指标",
+                        "name": "counters",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "结束时间，RFC3339格式",
+                        "name": "endTime",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "实例，为空表示汇总数据，*表示所有实例(如每个CPU、每块磁盘)",
+                        "name": "instance",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "采样间隔(s)，20为实时数据，其他为VC中开启的历史数据间隔，如: 300、1800、7200、86400",
+                        "name": "interval",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "最多返回的样本数，没有指定开始时间时默认为1，最大为1440",
+                        "name": "maxSample",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "指标名称省略rollup时使用的汇总方式，默认为average",
+                        "name": "rollup",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "开始时间，RFC3339格式",
+                        "name": "startTime",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "202": {
-                        "description": "Accepted",
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -1555,7 +1987,7 @@ const docTemplate = `{
                                         "data": {
                                             "type": "array",
                                             "items": {
-                                                "$ref": "#/definitions/v1.OperationRes"
+                                                "$ref": "#/definitions/protocol.PerformanceInfo"
                                             }
                                         }
                                     }
@@ -1584,14 +2016,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/virtual_machines/reconfigure_nic": {
-            "post": {
+        "/v1/hosts/{hostId}/os_families": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "修改虚拟机网卡",
+                "description": "主机支持的操作系统",
                 "consumes": [
                     "application/json"
                 ],
@@ -1599,23 +2031,63 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "虚拟机"
+                    "基础设施"
                 ],
-                "summary": "修改虚拟机网卡",
+                "summary": "主机支持的操作系统",
                 "parameters": [
                     {
-                        "description": "修改虚拟机网卡参数",
-                        "name": "c",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/v1.NicReconfigureReq"
-                        }
+                        "type": "string",
+                        "description": "只返回指定的字段，多个使用逗号分隔，如: id,name",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量，0为不分页",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "名称包含，不区分大小写",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "名称正则匹配",
+                        "name": "nameRegex",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "跳过的数量",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "电源状态，只适用于虚拟机，如: poweredOn",
+                        "name": "powerState",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序字段，多个使用逗号分隔，-开头为倒序，如: name,-memoryMB",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "主机ID",
+                        "name": "hostID",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "202": {
-                        "description": "Accepted",
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -1627,7 +2099,7 @@ const docTemplate = `{
                                         "data": {
                                             "type": "array",
                                             "items": {
-                                                "$ref": "#/definitions/v1.OperationRes"
+                                                "$ref": "#/definitions/protocol.OSFamilyInfo"
                                             }
                                         }
                                     }
@@ -1656,14 +2128,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/virtual_machines/rename": {
-            "post": {
+        "/v1/networks": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "重命名",
+                "description": "网络查询",
                 "consumes": [
                     "application/json"
                 ],
@@ -1671,25 +2143,88 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "虚拟机"
+                    "基础设施"
                 ],
-                "summary": "重命名",
+                "summary": "网络查询",
                 "parameters": [
                     {
-                        "description": "请求参数",
-                        "name": "c",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/v1.RenameReq"
-                        }
+                        "type": "string",
+                        "description": "只返回指定的字段，多个使用逗号分隔，如: id,name",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量，0为不分页",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "名称包含，不区分大小写",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "名称正则匹配",
+                        "name": "nameRegex",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "跳过的数量",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "电源状态，只适用于虚拟机，如: poweredOn",
+                        "name": "powerState",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序字段，多个使用逗号分隔，-开头为倒序，如: name,-memoryMB",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "description": "网络ID",
+                        "name": "ids",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "数据中心ID",
+                        "name": "datacenterId",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/e.Response"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/e.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/protocol.NetworkInfo"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
@@ -1713,14 +2248,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/virtual_machines/shutdown": {
-            "post": {
+        "/v1/performance_counters": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "关闭操作系统",
+                "description": "查询VC支持的所有性能指标，name可以作为性能数据查询的counters参数",
                 "consumes": [
                     "application/json"
                 ],
@@ -1728,23 +2263,56 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "虚拟机"
+                    "性能"
                 ],
-                "summary": "关闭操作系统",
+                "summary": "查询性能指标",
                 "parameters": [
                     {
-                        "description": "关闭操作系统参数",
-                        "name": "c",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/v1.OperationReq"
-                        }
+                        "type": "string",
+                        "description": "只返回指定的字段，多个使用逗号分隔，如: id,name",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量，0为不分页",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "名称包含，不区分大小写",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "名称正则匹配",
+                        "name": "nameRegex",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "跳过的数量",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "电源状态，只适用于虚拟机，如: poweredOn",
+                        "name": "powerState",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序字段，多个使用逗号分隔，-开头为倒序，如: name,-memoryMB",
+                        "name": "sort",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "202": {
-                        "description": "Accepted",
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -1756,7 +2324,7 @@ const docTemplate = `{
                                         "data": {
                                             "type": "array",
                                             "items": {
-                                                "$ref": "#/definitions/v1.OperationRes"
+                                                "$ref": "#/definitions/protocol.PerformanceCounterInfo"
                                             }
                                         }
                                     }
@@ -1785,14 +2353,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/virtual_machines/{id}/relocate": {
-            "post": {
+        "/v1/resource_pools": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "虚拟机迁移",
+                "description": "资源池查询",
                 "consumes": [
                     "application/json"
                 ],
@@ -1800,23 +2368,83 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "虚拟机"
+                    "基础设施"
                 ],
-                "summary": "虚拟机迁移",
+                "summary": "资源池查询",
                 "parameters": [
                     {
-                        "description": "虚拟机迁移参数",
-                        "name": "c",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/v1.OperationReq"
-                        }
+                        "type": "string",
+                        "description": "只返回指定的字段，多个使用逗号分隔，如: id,name",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量，0为不分页",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "名称包含，不区分大小写",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "名称正则匹配",
+                        "name": "nameRegex",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "跳过的数量",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "电源状态，只适用于虚拟机，如: poweredOn",
+                        "name": "powerState",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序字段，多个使用逗号分隔，-开头为倒序，如: name,-memoryMB",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "description": "资源池ID",
+                        "name": "ids",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "数据中心ID",
+                        "name": "datacenterId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "集群ID",
+                        "name": "clusterId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "主机ID",
+                        "name": "hostId",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "202": {
-                        "description": "Accepted",
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -1828,7 +2456,7 @@ const docTemplate = `{
                                         "data": {
                                             "type": "array",
                                             "items": {
-                                                "$ref": "#/definitions/v1.OperationRes"
+                                                "$ref": "#/definitions/protocol.ResourcePoolInfo"
                                             }
                                         }
                                     }
@@ -1857,14 +2485,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/virtual_machines/{id}/snapshots": {
-            "post": {
+        "/v1/storage_policies": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "创建快照",
+                "description": "存储策略查询",
                 "consumes": [
                     "application/json"
                 ],
@@ -1872,23 +2500,56 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "快照"
+                    "基础设施"
                 ],
-                "summary": "创建快照",
+                "summary": "存储策略查询",
                 "parameters": [
                     {
-                        "description": "创建参数",
-                        "name": "c",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/v1.OperationReq"
-                        }
+                        "type": "string",
+                        "description": "只返回指定的字段，多个使用逗号分隔，如: id,name",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量，0为不分页",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "名称包含，不区分大小写",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "名称正则匹配",
+                        "name": "nameRegex",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "跳过的数量",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "电源状态，只适用于虚拟机，如: poweredOn",
+                        "name": "powerState",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序字段，多个使用逗号分隔，-开头为倒序，如: name,-memoryMB",
+                        "name": "sort",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "202": {
-                        "description": "Accepted",
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -1900,7 +2561,7 @@ const docTemplate = `{
                                         "data": {
                                             "type": "array",
                                             "items": {
-                                                "$ref": "#/definitions/v1.OperationRes"
+                                                "$ref": "#/definitions/protocol.StoragePolicyInfo"
                                             }
                                         }
                                     }
@@ -1927,14 +2588,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/v1/tag_categories": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "删除快照",
+                "description": "标签分类查询",
                 "consumes": [
                     "application/json"
                 ],
@@ -1942,25 +2605,58 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "快照"
+                    "标签"
                 ],
-                "summary": "删除快照",
+                "summary": "标签分类查询",
                 "parameters": [
                     {
-                        "description": "创建参数",
-                        "name": "c",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/v1.OperationReq"
-                        }
-                    }
-                ],
-                "responses": {
-                    "202": {
-                        "description": "Accepted",
-                        "schema": {
-                            "allOf": [
+                        "type": "string",
+                        "description": "只返回指定的字段，多个使用逗号分隔，如: id,name",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量，0为不分页",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "名称包含，不区分大小写",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "名称正则匹配",
+                        "name": "nameRegex",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "跳过的数量",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "电源状态，只适用于虚拟机，如: poweredOn",
+                        "name": "powerState",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序字段，多个使用逗号分隔，-开头为倒序，如: name,-memoryMB",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
                                 {
                                     "$ref": "#/definitions/e.Response"
                                 },
@@ -1970,7 +2666,117 @@ const docTemplate = `{
                                         "data": {
                                             "type": "array",
                                             "items": {
-                                                "$ref": "#/definitions/v1.OperationRes"
+                                                "$ref": "#/definitions/protocol.TagCategoryInfo"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "{\"code\":\"400x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"code\":\"401x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"code\":\"500x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/tags": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "标签查询",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "标签"
+                ],
+                "summary": "标签查询",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "只返回指定的字段，多个使用逗号分隔，如: id,name",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量，0为不分页",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "名称包含，不区分大小写",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "名称正则匹配",
+                        "name": "nameRegex",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "跳过的数量",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "电源状态，只适用于虚拟机，如: poweredOn",
+                        "name": "powerState",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序字段，多个使用逗号分隔，-开头为倒序，如: name,-memoryMB",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "name": "categoryID",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/e.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/protocol.TagInfo"
                                             }
                                         }
                                     }
@@ -1998,67 +2804,2496 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "e.Response": {
+        },
+        "/v1/tags/attach": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "为虚拟机或其他清单对象添加标签",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "标签"
+                ],
+                "summary": "添加标签",
+                "parameters": [
+                    {
+                        "description": "请求参数",
+                        "name": "c",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.TagAssociationReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/e.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "{\"code\":\"400x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"code\":\"401x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"code\":\"500x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/tags/detach": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "移除虚拟机或其他清单对象的标签",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "标签"
+                ],
+                "summary": "移除标签",
+                "parameters": [
+                    {
+                        "description": "请求参数",
+                        "name": "c",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.TagAssociationReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/e.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "{\"code\":\"400x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"code\":\"401x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"code\":\"500x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/templates": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "查询模板，开启缓存时优先使用缓存，freshness表示数据来源和缓存更新时间",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "模版"
+                ],
+                "summary": "查询模板",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "只返回指定的字段，多个使用逗号分隔，如: id,name",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量，0为不分页",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "名称包含，不区分大小写",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "名称正则匹配",
+                        "name": "nameRegex",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "跳过的数量",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "电源状态，只适用于虚拟机，如: poweredOn",
+                        "name": "powerState",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序字段，多个使用逗号分隔，-开头为倒序，如: name,-memoryMB",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "description": "查询参数",
+                        "name": "c",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.TemplateQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/e.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/protocol.TemplateInfo"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "{\"code\":\"400x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"code\":\"401x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"code\":\"500x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/test_call_back": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "测试http回调",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "测试"
+                ],
+                "summary": "测试http回调",
+                "parameters": [
+                    {
+                        "description": "回调结果",
+                        "name": "c",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/protocol.CallbackRes"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": ""
+                    },
+                    "400": {
+                        "description": "{\"code\":\"400x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"code\":\"401x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"code\":\"500x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/vcenters": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "查询配置中登记的VC及其服务账号缓存会话的状态，不会登录VC，不需要令牌关联VC账号",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "VC"
+                ],
+                "summary": "查询登记的VC",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/e.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/vsphere.VCenterStatus"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "{\"code\":\"401x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"code\":\"500x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/virtual_machines": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "查询虚拟机，开启缓存时优先使用缓存，freshness表示数据来源和缓存更新时间",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "虚拟机"
+                ],
+                "summary": "查询虚拟机",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "只返回指定的字段，多个使用逗号分隔，如: id,name",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量，0为不分页",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "名称包含，不区分大小写",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "名称正则匹配",
+                        "name": "nameRegex",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "跳过的数量",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "电源状态，只适用于虚拟机，如: poweredOn",
+                        "name": "powerState",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序字段，多个使用逗号分隔，-开头为倒序，如: name,-memoryMB",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "description": "查询参数",
+                        "name": "c",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.VirtualMachineQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/e.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/protocol.VirtualMachineInfo"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "{\"code\":\"400x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"code\":\"401x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"code\":\"500x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "创建虚拟机",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "虚拟机"
+                ],
+                "summary": "创建虚拟机",
+                "parameters": [
+                    {
+                        "description": "创建参数",
+                        "name": "c",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.DeployReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/e.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/v1.DeployRes"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "{\"code\":\"400x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"code\":\"401x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "{\"code\":\"403x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "{\"code\":\"429x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"code\":\"500x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "删除虚拟机",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "虚拟机"
+                ],
+                "summary": "删除虚拟机",
+                "parameters": [
+                    {
+                        "description": "删除虚拟机参数",
+                        "name": "c",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.OperationReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/e.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/v1.OperationRes"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "{\"code\":\"400x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"code\":\"401x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "{\"code\":\"429x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"code\":\"500x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/virtual_machines/description": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "修改备注",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "虚拟机"
+                ],
+                "summary": "修改备注",
+                "parameters": [
+                    {
+                        "description": "请求参数",
+                        "name": "c",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.DescriptionReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/e.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "{\"code\":\"400x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"code\":\"401x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"code\":\"500x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/virtual_machines/performance": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "一次查询多台虚拟机的实时或历史性能数据，参数含义与单台查询相同",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "性能"
+                ],
+                "summary": "批量查询虚拟机性能数据",
+                "parameters": [
+                    {
+                        "description": "查询参数",
+                        "name": "c",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.BatchPerformanceReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/e.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/protocol.PerformanceInfo"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "{\"code\":\"400x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"code\":\"401x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"code\":\"500x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/virtual_machines/power_off": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "关闭电源",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "虚拟机"
+                ],
+                "summary": "关闭电源",
+                "parameters": [
+                    {
+                        "description": "关闭电源参数",
+                        "name": "c",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.OperationReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/e.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/v1.OperationRes"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "{\"code\":\"400x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"code\":\"401x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "{\"code\":\"429x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"code\":\"500x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/virtual_machines/power_on": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "开机",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "虚拟机"
+                ],
+                "summary": "开机",
+                "parameters": [
+                    {
+                        "description": "开机参数",
+                        "name": "c",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.OperationReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/e.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/v1.OperationRes"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "{\"code\":\"400x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"code\":\"401x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "{\"code\":\"429x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"code\":\"500x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/virtual_machines/reconfigure": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "修改虚拟机配置",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "虚拟机"
+                ],
+                "summary": "修改虚拟机配置",
+                "parameters": [
+                    {
+                        "description": "修改虚拟机配置参数",
+                        "name": "c",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.ReconfigureReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/e.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/v1.OperationRes"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "{\"code\":\"400x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"code\":\"401x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "{\"code\":\"403x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "{\"code\":\"429x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"code\":\"500x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/virtual_machines/reconfigure_disk": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "修改虚拟机硬盘",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "虚拟机"
+                ],
+                "summary": "修改虚拟机硬盘",
+                "parameters": [
+                    {
+                        "description": "修改虚拟机硬盘参数",
+                        "name": "c",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.DiskReconfigureReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/e.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/v1.OperationRes"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "{\"code\":\"400x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"code\":\"401x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "{\"code\":\"403x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "{\"code\":\"429x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"code\":\"500x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/virtual_machines/reconfigure_nic": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "修改虚拟机网卡",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "虚拟机"
+                ],
+                "summary": "修改虚拟机网卡",
+                "parameters": [
+                    {
+                        "description": "修改虚拟机网卡参数",
+                        "name": "c",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.NicReconfigureReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/e.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/v1.OperationRes"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "{\"code\":\"400x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"code\":\"401x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "{\"code\":\"429x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"code\":\"500x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/virtual_machines/rename": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "重命名",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "虚拟机"
+                ],
+                "summary": "重命名",
+                "parameters": [
+                    {
+                        "description": "请求参数",
+                        "name": "c",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.RenameReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/e.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "{\"code\":\"400x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"code\":\"401x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"code\":\"500x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/virtual_machines/shutdown": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "关闭操作系统",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "虚拟机"
+                ],
+                "summary": "关闭操作系统",
+                "parameters": [
+                    {
+                        "description": "关闭操作系统参数",
+                        "name": "c",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.OperationReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/e.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/v1.OperationRes"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "{\"code\":\"400x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"code\":\"401x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "{\"code\":\"429x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"code\":\"500x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/virtual_machines/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "查询单个虚拟机的详细信息，包括启动选项、控制器、光驱、快照概要、Tools状态、运行时长、资源分配、所在主机和存储名称、允许管理的高级参数，直接查询VC",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "虚拟机"
+                ],
+                "summary": "查询虚拟机详情",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "虚拟机ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/e.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/protocol.VirtualMachineDetail"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "{\"code\":\"400x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"code\":\"401x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"code\":\"500x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/virtual_machines/{id}/extra_config": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "查询虚拟机高级参数(extraConfig)，只返回vsphere.extraConfig中允许管理的参数，allow为空时返回所有未被deny的参数",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "虚拟机"
+                ],
+                "summary": "查询虚拟机高级参数",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "虚拟机ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/e.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/protocol.ExtraConfigInfo"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "{\"code\":\"400x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"code\":\"401x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"code\":\"500x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/virtual_machines/{id}/performance": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "查询虚拟机的实时或历史性能数据，默认查询最新的CPU、内存、磁盘和网络使用情况",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "性能"
+                ],
+                "summary": "查询虚拟机性能数据",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "虚拟机ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "指标名称，多个使用逗号分隔，格式为group.name.rollup，如: cpu.usage.average，为空时查询默认指标",
+                        "name": "counters",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "结束时间，RFC3339格式",
+                        "name": "endTime",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "实例，为空表示汇总数据，*表示所有实例(如每个CPU、每块磁盘)",
+                        "name": "instance",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "采样间隔(s)，20为实时数据，其他为VC中开启的历史数据间隔，如: 300、1800、7200、86400",
+                        "name": "interval",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "最多返回的样本数，没有指定开始时间时默认为1，最大为1440",
+                        "name": "maxSample",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "指标名称省略rollup时使用的汇总方式，默认为average",
+                        "name": "rollup",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "开始时间，RFC3339格式",
+                        "name": "startTime",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/e.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/protocol.PerformanceInfo"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "{\"code\":\"400x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"code\":\"401x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"code\":\"500x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/virtual_machines/{id}/relocate": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "虚拟机迁移",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "虚拟机"
+                ],
+                "summary": "虚拟机迁移",
+                "parameters": [
+                    {
+                        "description": "虚拟机迁移参数",
+                        "name": "c",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.OperationReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/e.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/v1.OperationRes"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "{\"code\":\"400x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"code\":\"401x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "{\"code\":\"429x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"code\":\"500x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/virtual_machines/{id}/snapshots": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "创建快照",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "快照"
+                ],
+                "summary": "创建快照",
+                "parameters": [
+                    {
+                        "description": "创建参数",
+                        "name": "c",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.OperationReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/e.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/v1.OperationRes"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "{\"code\":\"400x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"code\":\"401x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"code\":\"500x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "删除快照",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "快照"
+                ],
+                "summary": "删除快照",
+                "parameters": [
+                    {
+                        "description": "创建参数",
+                        "name": "c",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.OperationReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/e.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/v1.OperationRes"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "{\"code\":\"400x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"code\":\"401x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"code\":\"500x\",\"message\":\"失败\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "audit.Entry": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "object"
+                },
+                "endpoint": {
+                    "type": "string"
+                },
+                "event": {
+                    "type": "string"
+                },
+                "httpRequestId": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "method": {
+                    "type": "string"
+                },
+                "objectIds": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "outcome": {
+                    "type": "string"
+                },
+                "requestId": {
+                    "type": "string"
+                },
+                "sourceIp": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer"
+                },
+                "time": {
+                    "type": "string"
+                },
+                "user": {
+                    "type": "string"
+                },
+                "vcAddress": {
+                    "type": "string"
+                },
+                "vcid": {
+                    "type": "string"
+                }
+            }
+        },
+        "audit.Result": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/audit.Entry"
+                    }
+                },
+                "next": {
+                    "type": "string"
+                }
+            }
+        },
+        "e.Response": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "data": {},
+                "freshness": {
+                    "description": "Freshness 查询类接口返回数据的新鲜度",
+                    "$ref": "#/definitions/protocol.Freshness"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "page": {
+                    "description": "Page 分页信息，只在列表查询接口中返回",
+                    "$ref": "#/definitions/protocol.Page"
+                }
+            }
+        },
+        "identity.APIKey": {
+            "type": "object",
+            "properties": {
+                "accountId": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "disabled": {
+                    "type": "boolean"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "hash": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "scope": {
+                    "$ref": "#/definitions/vsphere.Scope"
+                },
+                "tenant": {
+                    "type": "string"
+                }
+            }
+        },
+        "identity.Account": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "jwt.Pair": {
+            "type": "object",
+            "properties": {
+                "expiresAt": {
+                    "description": "ExpiresAt 访问令牌的过期时间",
+                    "type": "string"
+                },
+                "refreshToken": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "protocol.BootInfo": {
+            "type": "object",
+            "properties": {
+                "bootDelay": {
+                    "type": "integer"
+                },
+                "bootOrder": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "enterSetup": {
+                    "type": "boolean"
+                },
+                "firmware": {
+                    "type": "string"
+                },
+                "secureBootEnabled": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "protocol.CallbackReq": {
+            "type": "object",
+            "properties": {
+                "httpPost": {
+                    "$ref": "#/definitions/protocol.Http"
+                }
+            }
+        },
+        "protocol.CallbackRes": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "data": {},
+                "message": {
+                    "type": "string"
+                },
+                "requestId": {
+                    "type": "string"
+                }
+            }
+        },
+        "protocol.CdromInfo": {
+            "type": "object",
+            "properties": {
+                "backingType": {
+                    "type": "string"
+                },
+                "connected": {
+                    "type": "boolean"
+                },
+                "controllerKey": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "isoPath": {
+                    "type": "string"
+                },
+                "key": {
+                    "type": "integer"
+                },
+                "startConnected": {
+                    "type": "boolean"
+                },
+                "unitNumber": {
+                    "type": "integer"
+                }
+            }
+        },
+        "protocol.ClusterInfo": {
+            "type": "object",
+            "properties": {
+                "datacenterId": {
+                    "type": "string"
+                },
+                "drsEnabled": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "resourcePoolId": {
+                    "type": "string"
+                }
+            }
+        },
+        "protocol.ControllerInfo": {
+            "type": "object",
+            "properties": {
+                "busNumber": {
+                    "type": "integer"
+                },
+                "deviceCount": {
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "protocol.CustomAttributeInfo": {
+            "type": "object",
+            "properties": {
+                "key": {
+                    "type": "integer"
+                },
+                "managedObjectType": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "protocol.DatacenterInfo": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "protocol.DatastoreInfo": {
+            "type": "object",
+            "properties": {
+                "accessible": {
+                    "type": "boolean"
+                },
+                "capacity": {
+                    "type": "integer"
+                },
+                "datacenterId": {
+                    "type": "string"
+                },
+                "freeSpace": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "supportDiskType": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "type": {
+                    "type": "string"
+                },
+                "uncommitted": {
+                    "type": "integer"
+                }
+            }
+        },
+        "protocol.DatastoreName": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "protocol.DiskInfo": {
+            "type": "object",
+            "properties": {
+                "busNumber": {
+                    "type": "integer"
+                },
+                "controllerKey": {
+                    "type": "integer"
+                },
+                "controllerType": {
+                    "type": "string"
+                },
+                "datastoreId": {
+                    "type": "string"
+                },
+                "format": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "key": {
+                    "type": "integer"
+                },
+                "mode": {
+                    "type": "string"
+                },
+                "sharing": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "unitNumber": {
+                    "type": "integer"
+                }
+            }
+        },
+        "protocol.ExtraConfigInfo": {
+            "type": "object",
+            "properties": {
+                "key": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "protocol.FolderInfo": {
+            "type": "object",
+            "properties": {
+                "datacenterID": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parentId": {
+                    "type": "string"
+                }
+            }
+        },
+        "protocol.Freshness": {
+            "type": "object",
+            "properties": {
+                "source": {
+                    "description": "Source cache: 来自缓存, vcenter: 实时查询VC",
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "description": "UpdatedAt 缓存最后更新的时间，实时查询时为查询时间",
+                    "type": "string"
+                },
+                "watching": {
+                    "description": "Watching 是否在监听清单变化，监听中的缓存会随VC的变化增量更新",
+                    "type": "boolean"
+                }
+            }
+        },
+        "protocol.Http": {
+            "type": "object",
+            "properties": {
+                "headers": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "protocol.IpInfo": {
+            "type": "object",
+            "properties": {
+                "ipAddress": {
+                    "type": "string"
+                },
+                "state": {
+                    "type": "string"
+                }
+            }
+        },
+        "protocol.NetworkInfo": {
+            "type": "object",
+            "properties": {
+                "accessible": {
+                    "type": "boolean"
+                },
+                "datacenterId": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "protocol.NetworkInterfaceInfo": {
+            "type": "object",
+            "properties": {
+                "adapterType": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "ipInfo": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/protocol.IpInfo"
+                    }
+                },
+                "key": {
+                    "type": "integer"
+                },
+                "macAddress": {
+                    "type": "string"
+                },
+                "networkId": {
+                    "type": "string"
+                }
+            }
+        },
+        "protocol.OSFamilyInfo": {
+            "type": "object",
+            "properties": {
+                "family": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "supportedCPUs": {
+                    "type": "integer"
+                },
+                "supportedMaxMen": {
+                    "type": "integer"
+                },
+                "supportedMinMen": {
+                    "type": "integer"
+                }
+            }
+        },
+        "protocol.ObjectRef": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "protocol.Page": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "protocol.PerformanceCounterInfo": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "key": {
+                    "type": "integer"
+                },
+                "level": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "statsType": {
+                    "type": "string"
+                },
+                "unit": {
+                    "type": "string"
+                }
+            }
+        },
+        "protocol.PerformanceInfo": {
+            "type": "object",
+            "properties": {
+                "entityId": {
+                    "type": "string"
+                },
+                "entityType": {
+                    "type": "string"
+                },
+                "interval": {
+                    "type": "integer"
+                },
+                "series": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/protocol.PerformanceSeries"
+                    }
+                },
+                "timestamps": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "protocol.PerformanceSeries": {
+            "type": "object",
+            "properties": {
+                "counter": {
+                    "type": "string"
+                },
+                "instance": {
+                    "type": "string"
+                },
+                "unit": {
+                    "type": "string"
+                },
+                "values": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                }
+            }
+        },
+        "protocol.ResourceAllocationInfo": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer"
+                },
+                "reservation": {
+                    "type": "integer"
+                },
+                "shares": {
+                    "type": "integer"
+                },
+                "sharesLevel": {
+                    "type": "string"
+                }
+            }
+        },
+        "protocol.ResourcePoolInfo": {
+            "type": "object",
+            "properties": {
+                "availableCpu": {
+                    "type": "integer"
+                },
+                "availableMemory": {
+                    "type": "integer"
+                },
+                "clusterId": {
+                    "type": "string"
+                },
+                "datacenterId": {
+                    "type": "string"
+                },
+                "hostId": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parentId": {
+                    "type": "string"
+                },
+                "parentType": {
+                    "type": "string"
+                }
+            }
+        },
+        "protocol.SnapshotInfo": {
             "type": "object",
             "properties": {
-                "code": {
+                "createTime": {
                     "type": "string"
                 },
-                "data": {},
-                "message": {
+                "id": {
+                    "type": "string"
+                },
+                "name": {
                     "type": "string"
                 }
             }
         },
-        "protocol.CallbackReq": {
+        "protocol.SnapshotSummaryInfo": {
             "type": "object",
             "properties": {
-                "httpPost": {
-                    "$ref": "#/definitions/protocol.Http"
+                "count": {
+                    "type": "integer"
+                },
+                "current": {
+                    "$ref": "#/definitions/protocol.SnapshotInfo"
+                },
+                "latest": {
+                    "$ref": "#/definitions/protocol.SnapshotInfo"
                 }
             }
         },
-        "protocol.CallbackRes": {
+        "protocol.StoragePolicyInfo": {
             "type": "object",
             "properties": {
-                "code": {
+                "description": {
                     "type": "string"
                 },
-                "data": {},
-                "message": {
+                "id": {
                     "type": "string"
                 },
-                "requestId": {
+                "name": {
                     "type": "string"
                 }
             }
         },
-        "protocol.ClusterInfo": {
+        "protocol.TagCategoryInfo": {
             "type": "object",
             "properties": {
-                "datacenterId": {
+                "associableTypes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "cardinality": {
                     "type": "string"
                 },
-                "drsEnabled": {
-                    "type": "boolean"
+                "description": {
+                    "type": "string"
                 },
                 "id": {
                     "type": "string"
                 },
                 "name": {
                     "type": "string"
-                },
-                "resourcePoolId": {
-                    "type": "string"
                 }
             }
         },
-        "protocol.DatacenterInfo": {
+        "protocol.TagInfo": {
             "type": "object",
             "properties": {
+                "categoryId": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "string"
                 },
@@ -2067,230 +5302,233 @@ const docTemplate = `{
                 }
             }
         },
-        "protocol.DatastoreInfo": {
+        "protocol.TemplateInfo": {
             "type": "object",
             "properties": {
-                "accessible": {
-                    "type": "boolean"
-                },
-                "capacity": {
-                    "type": "integer"
-                },
-                "datacenterId": {
+                "IPAddress": {
                     "type": "string"
                 },
-                "freeSpace": {
-                    "type": "integer"
-                },
-                "id": {
+                "clusterId": {
                     "type": "string"
                 },
-                "name": {
+                "createDate": {
                     "type": "string"
                 },
-                "supportDiskType": {
+                "dataDisks": {
                     "type": "array",
                     "items": {
-                        "type": "string"
+                        "$ref": "#/definitions/protocol.DiskInfo"
                     }
                 },
-                "type": {
+                "datacenterId": {
                     "type": "string"
                 },
-                "uncommitted": {
-                    "type": "integer"
-                }
-            }
-        },
-        "protocol.DiskInfo": {
-            "type": "object",
-            "properties": {
-                "datastoreId": {
+                "description": {
                     "type": "string"
                 },
-                "format": {
+                "folderId": {
+                    "type": "string"
+                },
+                "hostId": {
                     "type": "string"
                 },
                 "id": {
                     "type": "string"
                 },
-                "key": {
+                "instanceUUID": {
+                    "type": "string"
+                },
+                "memoryMB": {
                     "type": "integer"
                 },
-                "mode": {
+                "name": {
                     "type": "string"
                 },
-                "sharing": {
-                    "type": "string"
+                "networkInterfaces": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/protocol.NetworkInterfaceInfo"
+                    }
                 },
-                "size": {
+                "numCPU": {
                     "type": "integer"
-                }
-            }
-        },
-        "protocol.FolderInfo": {
-            "type": "object",
-            "properties": {
-                "datacenterID": {
-                    "type": "string"
                 },
-                "id": {
+                "numCoresPerSocket": {
+                    "type": "integer"
+                },
+                "osFamily": {
                     "type": "string"
                 },
-                "name": {
+                "osName": {
                     "type": "string"
                 },
-                "parentId": {
+                "resourcePoolId": {
                     "type": "string"
-                }
-            }
-        },
-        "protocol.Http": {
-            "type": "object",
-            "properties": {
-                "headers": {
-                    "type": "object",
-                    "additionalProperties": {
-                        "type": "string"
-                    }
                 },
-                "url": {
+                "sysDisk": {
+                    "$ref": "#/definitions/protocol.DiskInfo"
+                },
+                "toolsHasInstalled": {
+                    "type": "boolean"
+                },
+                "uuid": {
                     "type": "string"
                 }
             }
         },
-        "protocol.IpInfo": {
+        "protocol.VirtualMachineDetail": {
             "type": "object",
             "properties": {
-                "ipAddress": {
+                "IPAddress": {
                     "type": "string"
                 },
-                "state": {
-                    "type": "string"
-                }
-            }
-        },
-        "protocol.NetworkInfo": {
-            "type": "object",
-            "properties": {
-                "accessible": {
-                    "type": "boolean"
+                "boot": {
+                    "$ref": "#/definitions/protocol.BootInfo"
                 },
-                "datacenterId": {
+                "bootTime": {
                     "type": "string"
                 },
-                "id": {
-                    "type": "string"
+                "cdroms": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/protocol.CdromInfo"
+                    }
                 },
-                "name": {
+                "clusterId": {
                     "type": "string"
                 },
-                "type": {
+                "controllers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/protocol.ControllerInfo"
+                    }
+                },
+                "cpuAllocation": {
+                    "$ref": "#/definitions/protocol.ResourceAllocationInfo"
+                },
+                "createDate": {
                     "type": "string"
-                }
-            }
-        },
-        "protocol.NetworkInterfaceInfo": {
-            "type": "object",
-            "properties": {
-                "adapterType": {
+                },
+                "dataDisks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/protocol.DiskInfo"
+                    }
+                },
+                "datacenterId": {
                     "type": "string"
                 },
-                "id": {
+                "datastores": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/protocol.DatastoreName"
+                    }
+                },
+                "description": {
                     "type": "string"
                 },
-                "ipInfo": {
+                "extraConfig": {
+                    "description": "ExtraConfig 只包含配置中允许管理的高级参数",
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/protocol.IpInfo"
+                        "$ref": "#/definitions/protocol.ExtraConfigInfo"
                     }
                 },
-                "key": {
-                    "type": "integer"
+                "folderId": {
+                    "type": "string"
                 },
-                "macAddress": {
+                "guestFullName": {
                     "type": "string"
                 },
-                "networkId": {
+                "hostId": {
                     "type": "string"
-                }
-            }
-        },
-        "protocol.OSFamilyInfo": {
-            "type": "object",
-            "properties": {
-                "family": {
+                },
+                "hostName": {
+                    "type": "string"
+                },
+                "hostname": {
                     "type": "string"
                 },
                 "id": {
                     "type": "string"
                 },
-                "name": {
+                "instanceUUID": {
                     "type": "string"
                 },
-                "supportedCPUs": {
-                    "type": "integer"
+                "ip_address": {
+                    "type": "string"
                 },
-                "supportedMaxMen": {
-                    "type": "integer"
+                "memoryAllocation": {
+                    "$ref": "#/definitions/protocol.ResourceAllocationInfo"
                 },
-                "supportedMinMen": {
+                "memoryMB": {
                     "type": "integer"
-                }
-            }
-        },
-        "protocol.ResourcePoolInfo": {
-            "type": "object",
-            "properties": {
-                "availableCpu": {
+                },
+                "name": {
+                    "type": "string"
+                },
+                "networkInterfaces": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/protocol.NetworkInterfaceInfo"
+                    }
+                },
+                "numCPU": {
                     "type": "integer"
                 },
-                "availableMemory": {
+                "numCoresPerSocket": {
                     "type": "integer"
                 },
-                "clusterId": {
+                "osFamily": {
                     "type": "string"
                 },
-                "datacenterId": {
+                "osName": {
                     "type": "string"
                 },
-                "hostId": {
-                    "type": "string"
+                "power_state": {
+                    "type": "string"
+                },
+                "resourcePoolId": {
+                    "type": "string"
+                },
+                "snapshots": {
+                    "$ref": "#/definitions/protocol.SnapshotSummaryInfo"
+                },
+                "sysDisk": {
+                    "$ref": "#/definitions/protocol.DiskInfo"
+                },
+                "toolsHasInstalled": {
+                    "type": "boolean"
                 },
-                "id": {
+                "toolsRunningStatus": {
                     "type": "string"
                 },
-                "name": {
+                "toolsVersion": {
                     "type": "string"
                 },
-                "parentId": {
+                "toolsVersionStatus": {
                     "type": "string"
                 },
-                "parentType": {
-                    "type": "string"
-                }
-            }
-        },
-        "protocol.StoragePolicyInfo": {
-            "type": "object",
-            "properties": {
-                "description": {
+                "tools_status": {
                     "type": "string"
                 },
-                "id": {
-                    "type": "string"
+                "uptimeSeconds": {
+                    "type": "integer"
                 },
-                "name": {
+                "uuid": {
                     "type": "string"
                 }
             }
         },
-        "protocol.TemplateInfo": {
+        "protocol.VirtualMachineInfo": {
             "type": "object",
             "properties": {
                 "IPAddress": {
                     "type": "string"
                 },
+                "boot": {
+                    "$ref": "#/definitions/protocol.BootInfo"
+                },
                 "clusterId": {
                     "type": "string"
                 },
@@ -2315,12 +5553,18 @@ const docTemplate = `{
                 "hostId": {
                     "type": "string"
                 },
+                "hostname": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "string"
                 },
                 "instanceUUID": {
                     "type": "string"
                 },
+                "ip_address": {
+                    "type": "string"
+                },
                 "memoryMB": {
                     "type": "integer"
                 },
@@ -2345,6 +5589,9 @@ const docTemplate = `{
                 "osName": {
                     "type": "string"
                 },
+                "power_state": {
+                    "type": "string"
+                },
                 "resourcePoolId": {
                     "type": "string"
                 },
@@ -2354,93 +5601,145 @@ const docTemplate = `{
                 "toolsHasInstalled": {
                     "type": "boolean"
                 },
+                "tools_status": {
+                    "type": "string"
+                },
                 "uuid": {
                     "type": "string"
                 }
             }
         },
-        "protocol.VirtualMachineInfo": {
+        "security.RefreshReq": {
             "type": "object",
             "properties": {
-                "IPAddress": {
+                "refreshToken": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.APIKeyReq": {
+            "type": "object",
+            "properties": {
+                "accountId": {
+                    "description": "关联的VC账号ID，为空时只能访问管理接口",
                     "type": "string"
                 },
-                "clusterId": {
+                "expiresAt": {
+                    "description": "过期时间，RFC3339格式，为空时不过期",
                     "type": "string"
                 },
-                "createDate": {
+                "name": {
+                    "description": "名称，用于区分调用方",
                     "type": "string"
                 },
-                "dataDisks": {
+                "roles": {
+                    "description": "角色: viewer, operator, deployer, admin，高级角色包含低级角色的权限",
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/protocol.DiskInfo"
+                        "type": "string"
                     }
                 },
-                "datacenterId": {
-                    "type": "string"
+                "scope": {
+                    "description": "允许访问的清单范围，为空时不限制",
+                    "$ref": "#/definitions/vsphere.Scope"
                 },
-                "description": {
+                "tenant": {
+                    "description": "资源配额所属的租户，对应配置vsphere.quota.tenants中的name，为空时不限制",
                     "type": "string"
-                },
-                "folderId": {
+                }
+            }
+        },
+        "v1.APIKeyResp": {
+            "type": "object",
+            "properties": {
+                "accountId": {
                     "type": "string"
                 },
-                "hostId": {
+                "createdAt": {
                     "type": "string"
                 },
-                "hostname": {
-                    "type": "string"
+                "disabled": {
+                    "type": "boolean"
                 },
-                "id": {
+                "expiresAt": {
                     "type": "string"
                 },
-                "instanceUUID": {
+                "hash": {
                     "type": "string"
                 },
-                "ip_address": {
+                "id": {
                     "type": "string"
                 },
-                "memoryMB": {
-                    "type": "integer"
+                "key": {
+                    "type": "string"
                 },
                 "name": {
                     "type": "string"
                 },
-                "networkInterfaces": {
+                "roles": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/protocol.NetworkInterfaceInfo"
+                        "type": "string"
                     }
                 },
-                "numCPU": {
-                    "type": "integer"
+                "scope": {
+                    "$ref": "#/definitions/vsphere.Scope"
                 },
-                "numCoresPerSocket": {
-                    "type": "integer"
+                "tenant": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.AccountReq": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
                 },
-                "osFamily": {
+                "name": {
+                    "description": "账号名称",
                     "type": "string"
                 },
-                "osName": {
+                "password": {
                     "type": "string"
                 },
-                "power_state": {
+                "username": {
                     "type": "string"
+                }
+            }
+        },
+        "v1.BatchPerformanceReq": {
+            "type": "object",
+            "properties": {
+                "counters": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "resourcePoolId": {
+                "endTime": {
                     "type": "string"
                 },
-                "sysDisk": {
-                    "$ref": "#/definitions/protocol.DiskInfo"
+                "ids": {
+                    "description": "虚拟机ID，一次最多100个",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "toolsHasInstalled": {
-                    "type": "boolean"
+                "instance": {
+                    "type": "string"
                 },
-                "tools_status": {
+                "interval": {
+                    "type": "integer"
+                },
+                "maxSample": {
+                    "type": "integer"
+                },
+                "rollup": {
                     "type": "string"
                 },
-                "uuid": {
+                "startTime": {
                     "type": "string"
                 }
             }
@@ -2456,6 +5755,20 @@ const docTemplate = `{
                 }
             }
         },
+        "v1.CustomAttributeReq": {
+            "type": "object",
+            "properties": {
+                "object": {
+                    "$ref": "#/definitions/protocol.ObjectRef"
+                },
+                "values": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
         "v1.DeployReq": {
             "type": "object",
             "properties": {
@@ -2497,6 +5810,14 @@ const docTemplate = `{
                     "items": {
                         "type": "object",
                         "properties": {
+                            "busNumber": {
+                                "description": "BusNumber 控制器总线号，为空时自动选择",
+                                "type": "integer"
+                            },
+                            "controllerType": {
+                                "description": "ControllerType 控制器类型，为空时使用虚拟机已有的硬盘控制器",
+                                "type": "string"
+                            },
                             "datastoreId": {
                                 "type": "string"
                             },
@@ -2668,9 +5989,21 @@ const docTemplate = `{
                 "MemoryMB": {
                     "type": "integer"
                 },
+                "boot": {
+                    "$ref": "#/definitions/virtualmachinereconfig.BootParameter"
+                },
                 "callBack": {
                     "$ref": "#/definitions/protocol.CallbackReq"
                 },
+                "encryption": {
+                    "$ref": "#/definitions/virtualmachinereconfig.EncryptionParameter"
+                },
+                "extraConfig": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
                 "id": {
                     "type": "string"
                 },
@@ -2679,6 +6012,9 @@ const docTemplate = `{
                 },
                 "numCoresPerSocket": {
                     "type": "integer"
+                },
+                "vtpm": {
+                    "$ref": "#/definitions/virtualmachinereconfig.VTpmParameter"
                 }
             }
         },
@@ -2693,6 +6029,23 @@ const docTemplate = `{
                 }
             }
         },
+        "v1.TagAssociationReq": {
+            "type": "object",
+            "properties": {
+                "objects": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/protocol.ObjectRef"
+                    }
+                },
+                "tagIds": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
         "v1.TemplateQuery": {
             "type": "object",
             "properties": {
@@ -2730,6 +6083,12 @@ const docTemplate = `{
                     "items": {
                         "type": "string"
                     }
+                },
+                "tagIds": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 }
             }
         },
@@ -2918,6 +6277,34 @@ const docTemplate = `{
                 }
             }
         },
+        "virtualmachinereconfig.BootParameter": {
+            "type": "object",
+            "properties": {
+                "bootDelay": {
+                    "description": "BootDelay 引导延迟(毫秒)，0为取消延迟",
+                    "type": "integer"
+                },
+                "bootOrder": {
+                    "description": "BootOrder 引导顺序：disk、nic、cdrom，空数组为清除引导顺序，序列化时保留空数组，保存的任务重新执行时不会丢失",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "enterSetup": {
+                    "description": "EnterSetup 下次引导时进入BIOS/EFI设置",
+                    "type": "boolean"
+                },
+                "firmware": {
+                    "description": "Firmware 固件类型：bios、efi",
+                    "type": "string"
+                },
+                "secureBootEnabled": {
+                    "description": "SecureBootEnabled 安全引导，仅efi固件可用",
+                    "type": "boolean"
+                }
+            }
+        },
         "virtualmachinereconfig.CpuParameter": {
             "type": "object",
             "properties": {
@@ -2958,6 +6345,15 @@ const docTemplate = `{
                 }
             }
         },
+        "virtualmachinereconfig.EncryptionParameter": {
+            "type": "object",
+            "properties": {
+                "storagePolicyId": {
+                    "description": "StoragePolicyID 加密存储策略，同时应用到虚拟机主目录和所有硬盘",
+                    "type": "string"
+                }
+            }
+        },
         "virtualmachinereconfig.MemoryParameter": {
             "type": "object",
             "properties": {
@@ -2989,6 +6385,15 @@ const docTemplate = `{
                 }
             }
         },
+        "virtualmachinereconfig.VTpmParameter": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "description": "Enabled true添加vTPM设备，false移除vTPM设备",
+                    "type": "boolean"
+                }
+            }
+        },
         "vsphere.Auth": {
             "type": "object",
             "properties": {
@@ -3003,6 +6408,71 @@ const docTemplate = `{
                 }
             }
         },
+        "vsphere.Scope": {
+            "type": "object",
+            "properties": {
+                "datacenters": {
+                    "description": "数据中心ID",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "folders": {
+                    "description": "虚拟机文件夹ID，包含子文件夹",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "resourcePools": {
+                    "description": "资源池ID，包含子资源池",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "tags": {
+                    "description": "标签ID，虚拟机需要有其中一个标签",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "vsphere.VCenterStatus": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "alias": {
+                    "type": "string"
+                },
+                "circuitOpen": {
+                    "description": "CircuitOpen 连续连接失败熔断中",
+                    "type": "boolean"
+                },
+                "connected": {
+                    "description": "Connected 有可用的缓存会话",
+                    "type": "boolean"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "description": "ID VC实例ID，没有缓存的会话时为空",
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string"
+                },
+                "watching": {
+                    "type": "boolean"
+                }
+            }
+        },
         "workerpool.DataDisk": {
             "type": "object",
             "properties": {
@@ -3026,15 +6496,33 @@ const docTemplate = `{
         "workerpool.DeployParameter": {
             "type": "object",
             "properties": {
+                "boot": {
+                    "$ref": "#/definitions/virtualmachinereconfig.BootParameter"
+                },
                 "cpu": {
                     "$ref": "#/definitions/virtualmachinereconfig.CpuParameter"
                 },
+                "customAttributes": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
                 "dataDisks": {
                     "type": "array",
                     "items": {
                         "$ref": "#/definitions/workerpool.DataDisk"
                     }
                 },
+                "encryption": {
+                    "$ref": "#/definitions/virtualmachinereconfig.EncryptionParameter"
+                },
+                "extraConfig": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
                 "flag": {
                     "$ref": "#/definitions/workerpool.Flag"
                 },
@@ -3062,9 +6550,18 @@ const docTemplate = `{
                 "powerOn": {
                     "type": "boolean"
                 },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "template": {
                     "$ref": "#/definitions/workerpool.Template"
                 },
+                "vtpm": {
+                    "$ref": "#/definitions/virtualmachinereconfig.VTpmParameter"
+                },
                 "waitForIp": {
                     "type": "string"
                 }
@@ -4,15 +4,26 @@ import (
 	"context"
 	"fmt"
 	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/soap"
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 	"vsphere-facade/app/logging"
+	"vsphere-facade/app/metrics"
+	"vsphere-facade/app/tracing"
 	"vsphere-facade/config"
 )
 
 var apiCache = make(map[string]*API)
+var apiCacheMu sync.Mutex
+
+// connectLocks 按VC地址和用户名加锁，避免并发请求同时建立多个连接
+var connectLocks = &keyedLocks{locks: make(map[string]*keyedLock)}
 var APITimeout time.Duration
 
 type API struct {
@@ -20,6 +31,16 @@ type API struct {
 	Type    string
 	version *APIVersion
 	Client  *govmomi.Client
+
+	userinfo *url.Userinfo
+	restMu   sync.Mutex
+	rest     *rest.Client
+	// loginGen 每次重新登录加1，用于判断其他请求是否已经重新登录
+	loginMu       sync.Mutex
+	loginGen      uint64
+	stopKeepAlive func()
+	// base 链路追踪使用的副本指向原连接，共用vAPI会话
+	base *API
 }
 
 type APIVersion struct {
@@ -29,39 +50,166 @@ type APIVersion struct {
 	V     int
 }
 
+func init() {
+	metrics.RegisterGauges("vcenter_sessions", "缓存的VC会话数", nil, func() []metrics.Gauge {
+		return []metrics.Gauge{{Value: float64(len(APIs()))}}
+	})
+}
+
 func Setup() {
 	APITimeout = time.Duration(config.G.Vsphere.Timeout.Api) * time.Minute
 }
 
-func Init(address, username, password string) *API {
+// Init 获取VC连接，优先使用缓存的会话，连接失败或熔断中时返回错误
+func Init(address, username, password string) (*API, error) {
+	return InitContext(context.Background(), address, username, password)
+}
+
+// InitContext 与Init相同，等待和建立连接的时间不超过ctx
+func InitContext(ctx context.Context, address, username, password string) (*API, error) {
 	logging.L().Debug("初始化VC连接")
 	n := time.Now()
-	// 先从缓存里拿
+	// 先从缓存里拿，缓存的会话失效时由sessionRoundTripper重新登录，不经过熔断
 	k := cacheKey(address, username, password)
-	cacheApi := getFromCache(k)
-	if cacheApi != nil {
+	if cacheApi := getFromCache(k); cacheApi != nil {
+		logging.L().Debug("获取到缓存连接")
+		return cacheApi, nil
+	}
+	b := breakerOf(address)
+	if err := b.allow(address, n); err != nil {
+		return nil, err
+	}
+
+	// 同一个账号同时只建立一个连接，等待期间其他请求可能已经建立
+	unlock, err := connectLocks.lock(ctx, address+"@"+username)
+	if err != nil {
+		b.cancelProbe()
+		return nil, err
+	}
+	defer unlock()
+	if cacheApi := getFromCache(k); cacheApi != nil {
 		logging.L().Debug("获取到缓存连接")
-		return cacheApi
+		// 没有建立新连接，半开状态下让下一个请求尝试
+		b.cancelProbe()
+		return cacheApi, nil
 	}
 
 	// 新建
-	newApi := API{}
 	u, err := url.Parse(address + "/sdk")
 	if err != nil {
-		logging.L().Panic("连接测试时发生错误", err)
+		return nil, fmt.Errorf("VC地址[%s]不正确: %v", address, err)
 	}
 	u.User = url.UserPassword(username, password)
-	ctx := context.Background()
-	client, err := govmomi.NewClient(ctx, u, true)
+	ctx, cancel := connectContext(ctx)
+	defer cancel()
+	newApi, err := connect(ctx, u)
 	if err != nil {
-		logging.L().Panic(err)
+		// 账号密码错误等VC返回的错误说明VC可以连接，不计入熔断
+		if soap.IsSoapFault(err) {
+			b.success()
+		} else if b.failure(time.Now()) {
+			logging.L().Errorf("VC[%s]连续连接失败，开始熔断", address)
+		}
+		return nil, err
 	}
-	newApi.Client = client
-	newApi.parseVer()
-	newApi.checkVer()
-	cache(k, &newApi)
+	b.success()
+	bindVCenter(newApi.ID, address)
+	cache(k, newApi)
 	logging.L().Debug("初始化VC连接完成，耗时: ", time.Since(n))
-	return &newApi
+	return newApi, nil
+}
+
+// connectContext 连接超时使用API超时时间，没有配置时不超时
+func connectContext(parent context.Context) (context.Context, context.CancelFunc) {
+	if APITimeout > 0 {
+		return context.WithTimeout(parent, APITimeout)
+	}
+	return context.WithCancel(parent)
+}
+
+// connect 与govmomi.NewClient相同，按配置校验VC的证书，登录前安装会话保活
+func connect(ctx context.Context, u *url.URL) (*API, error) {
+	soapClient, err := newSoapClient(u)
+	if err != nil {
+		return nil, err
+	}
+	vimClient, err := vim25.NewClient(ctx, soapClient)
+	if err != nil {
+		return nil, err
+	}
+	client := &govmomi.Client{
+		Client:         vimClient,
+		SessionManager: session.NewManager(vimClient),
+	}
+	newApi := NewAPI(client, u.User)
+	newApi.withSession()
+	err = client.Login(ctx, u.User)
+	if err != nil {
+		return nil, err
+	}
+	newApi.parseVer()
+	if err = newApi.checkVer(); err != nil {
+		_ = client.Logout(ctx)
+		return nil, err
+	}
+	return newApi, nil
+}
+
+func NewAPI(client *govmomi.Client, userinfo *url.Userinfo) *API {
+	return &API{
+		Client:   client,
+		userinfo: userinfo,
+	}
+}
+
+// RestClient
+// vAPI(REST)客户端，使用与SOAP相同的账号登录，会话失效后重新登录
+func (a *API) RestClient(ctx context.Context) (*rest.Client, error) {
+	if a.base != nil {
+		return a.base.RestClient(ctx)
+	}
+	a.restMu.Lock()
+	defer a.restMu.Unlock()
+
+	if a.rest != nil {
+		session, err := a.rest.Session(ctx)
+		if err == nil && session != nil {
+			return a.rest, nil
+		}
+	}
+
+	rc := rest.NewClient(a.Client.Client)
+	err := rc.Login(ctx, a.userinfo)
+	if err != nil {
+		return nil, fmt.Errorf("vAPI登录失败: %v", err)
+	}
+	a.rest = rc
+	return rc, nil
+}
+
+// Traced
+// 返回记录链路的连接副本，通过副本发起的SOAP调用都会在scope当前的步骤下创建span，没有开启链路追踪时返回自身
+func (a *API) Traced(scope *tracing.Scope) *API {
+	if !tracing.Enabled() || a.Client == nil {
+		return a
+	}
+	base := a
+	if a.base != nil {
+		base = a.base
+	}
+	vimClient := *base.Client.Client
+	vimClient.RoundTripper = tracing.RoundTripper(base.Client.Client.RoundTripper, scope)
+	return &API{
+		ID:      base.ID,
+		Type:    base.Type,
+		version: base.version,
+		Client: &govmomi.Client{
+			Client:         &vimClient,
+			SessionManager: base.Client.SessionManager,
+		},
+		userinfo: base.userinfo,
+		base:     base,
+	}
 }
 
 func (a *API) Newer(major, minor, patch int) bool {
@@ -82,6 +230,10 @@ func (a *API) parseVer() {
 	about := a.Client.ServiceContent.About
 	ver := about.ApiVersion
 	s := strings.Split(ver, ".")
+	// 版本号可能只有两段，如6.5
+	for len(s) < 3 {
+		s = append(s, "0")
+	}
 	major, _ := strconv.Atoi(s[0])
 	minor, _ := strconv.Atoi(s[1])
 	patch, _ := strconv.Atoi(s[2])
@@ -95,33 +247,129 @@ func (a *API) parseVer() {
 	a.ID = about.InstanceUuid
 }
 
-func (a *API) checkVer() {
+func (a *API) checkVer() error {
 	if !a.Newer(5, 5, 0) {
 		ver := a.version
 		current := fmt.Sprintf("%d.%d.%d", ver.Major, ver.Minor, ver.Patch)
-		logging.L().Panicf("支持的最小版本为[5.5.0], 当前版本为[%s]", current)
+		return fmt.Errorf("支持的最小版本为[5.5.0], 当前版本为[%s]", current)
 	}
+	return nil
 }
 
 func version(major, minor, patch int) int {
 	return major*1000 + minor*100 + patch
 }
 
+// CheckSession 检查会话是否有效，用于健康检查。
+// 查询当前会话不需要Sessions.ValidateSession权限，只读账号也可以检查
+func (a *API) CheckSession(ctx context.Context) error {
+	if a.Client == nil || !a.Client.Valid() {
+		return fmt.Errorf("VC连接无效")
+	}
+	userSession, err := a.Client.SessionManager.UserSession(ctx)
+	if err != nil {
+		return fmt.Errorf("检查会话时发生错误: %v", err)
+	}
+	if userSession == nil {
+		return fmt.Errorf("会话已失效")
+	}
+	return nil
+}
+
+// Address VC地址和登录用户，不包含密码
+func (a *API) Address() string {
+	if a.Client == nil {
+		return ""
+	}
+	host := a.Client.URL().Host
+	if a.userinfo != nil {
+		return a.userinfo.Username() + "@" + host
+	}
+	return host
+}
+
+// APIs 缓存的所有VC连接
+func APIs() []*API {
+	apiCacheMu.Lock()
+	defer apiCacheMu.Unlock()
+	var apis []*API
+	for _, a := range apiCache {
+		apis = append(apis, a)
+	}
+	return apis
+}
+
+// CachedAPI 缓存的VC连接，没有缓存时返回nil，不会建立连接
+func CachedAPI(address, username, password string) *API {
+	return getFromCache(cacheKey(address, username, password))
+}
+
+// getFromCache 只检查连接是否有效，不请求VC
 func getFromCache(k string) *API {
+	apiCacheMu.Lock()
 	a := apiCache[k]
+	apiCacheMu.Unlock()
 	if a != nil && a.Client != nil && a.Client.Valid() {
-		ctx, cancel := context.WithTimeout(context.Background(), APITimeout)
-		defer cancel()
-		ok, _ := a.Client.SessionManager.SessionIsActive(ctx)
-		if ok {
-			return a
-		}
+		return a
 	}
 	return nil
 }
 
+// cache 替换缓存后停止旧会话的保活并退出旧会话，不在持有apiCacheMu时请求VC
 func cache(k string, a *API) {
+	apiCacheMu.Lock()
+	old := apiCache[k]
 	apiCache[k] = a
+	apiCacheMu.Unlock()
+	if old == nil || old == a {
+		return
+	}
+	if old.stopKeepAlive != nil {
+		old.stopKeepAlive()
+	}
+	go old.logout()
+}
+
+type keyedLock struct {
+	ch      chan struct{}
+	waiters int
+}
+
+// keyedLocks 按key加锁，没有等待的请求时删除key对应的锁
+type keyedLocks struct {
+	mu    sync.Mutex
+	locks map[string]*keyedLock
+}
+
+// lock 返回解锁函数，ctx结束前没有拿到锁时返回错误
+func (l *keyedLocks) lock(ctx context.Context, key string) (func(), error) {
+	l.mu.Lock()
+	k := l.locks[key]
+	if k == nil {
+		k = &keyedLock{ch: make(chan struct{}, 1)}
+		l.locks[key] = k
+	}
+	k.waiters++
+	l.mu.Unlock()
+
+	release := func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		k.waiters--
+		if k.waiters == 0 {
+			delete(l.locks, key)
+		}
+	}
+	select {
+	case k.ch <- struct{}{}:
+		return func() {
+			<-k.ch
+			release()
+		}, nil
+	case <-ctx.Done():
+		release()
+		return nil, ctx.Err()
+	}
 }
 
 func cacheKey(address, username, password string) string {
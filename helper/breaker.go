@@ -0,0 +1,120 @@
+package helper
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"vsphere-facade/config"
+)
+
+var ErrCircuitOpen = errors.New("VC连接已熔断")
+
+// CircuitOpenError 熔断期间连接VC时返回，errors.Is(err, ErrCircuitOpen)为true
+type CircuitOpenError struct {
+	Address    string
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("VC[%s]连续连接失败，%d秒后重试", e.Address, int(e.RetryAfter.Seconds()+0.5))
+}
+
+func (e *CircuitOpenError) Unwrap() error {
+	return ErrCircuitOpen
+}
+
+// breaker 按VC地址熔断，连续失败达到阈值后在熔断时间内不再尝试连接。
+// 熔断时间结束后进入半开状态，只允许一个请求尝试连接，成功后关闭熔断，失败后重新熔断
+type breaker struct {
+	mu       sync.Mutex
+	failures int
+	// openUntil 熔断结束时间，不为零时表示熔断中或半开状态
+	openUntil time.Time
+	// probeUntil 半开状态下尝试连接的请求的超时时间，请求没有返回结果时超时后允许下一个请求尝试
+	probeUntil time.Time
+}
+
+var breakers sync.Map
+
+func breakerOf(address string) *breaker {
+	b, _ := breakers.LoadOrStore(strings.ToLower(addressHost(address)), &breaker{})
+	return b.(*breaker)
+}
+
+// allow 半开状态下允许的请求需要调用success或failure报告结果
+func (b *breaker) allow(address string, now time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if retryAfter := b.retryAfter(now); retryAfter > 0 {
+		return &CircuitOpenError{Address: address, RetryAfter: retryAfter}
+	}
+	if !b.openUntil.IsZero() {
+		_, openTimeout := breakerSettings()
+		b.probeUntil = now.Add(openTimeout)
+	}
+	return nil
+}
+
+// retryAfter 熔断中或半开状态下已经有请求在尝试连接时返回需要等待的时间
+func (b *breaker) retryAfter(now time.Time) time.Duration {
+	if now.Before(b.openUntil) {
+		return b.openUntil.Sub(now)
+	}
+	if now.Before(b.probeUntil) {
+		return b.probeUntil.Sub(now)
+	}
+	return 0
+}
+
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.openUntil = time.Time{}
+	b.probeUntil = time.Time{}
+}
+
+// cancelProbe 允许的请求没有尝试连接时调用
+func (b *breaker) cancelProbe() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probeUntil = time.Time{}
+}
+
+// failure 返回是否进入熔断，半开状态下失败立即重新熔断
+func (b *breaker) failure(now time.Time) bool {
+	threshold, openTimeout := breakerSettings()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if b.failures < threshold && b.openUntil.IsZero() {
+		return false
+	}
+	b.failures = 0
+	b.openUntil = now.Add(openTimeout)
+	b.probeUntil = time.Time{}
+	return true
+}
+
+// breakerSettings 默认连续失败3次熔断30秒
+func breakerSettings() (int, time.Duration) {
+	conf := config.G.Vsphere.Session
+	threshold, openTimeout := conf.FailureThreshold, time.Duration(conf.OpenTimeout)*time.Second
+	if threshold <= 0 {
+		threshold = 3
+	}
+	if openTimeout <= 0 {
+		openTimeout = 30 * time.Second
+	}
+	return threshold, openTimeout
+}
+
+// CircuitOpen VC当前是否处于熔断中，不占用半开状态下的尝试
+func CircuitOpen(address string) bool {
+	b := breakerOf(address)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.retryAfter(time.Now()) > 0
+}
@@ -0,0 +1,88 @@
+package customfield
+
+import (
+	"context"
+	"fmt"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+	"sort"
+	"vsphere-facade/app/logging"
+	"vsphere-facade/helper"
+)
+
+func GetManager(api *helper.API) (*object.CustomFieldsManager, error) {
+	return object.GetCustomFieldsManager(api.Client.Client)
+}
+
+func GetFields(api *helper.API) []types.CustomFieldDef {
+	logging.L().Debug("查询自定义属性")
+	m, err := GetManager(api)
+	if err != nil {
+		logging.L().Error("查询自定义属性时发生错误", err)
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), helper.APITimeout)
+	defer cancel()
+	fields, err := m.Field(ctx)
+	if err != nil {
+		logging.L().Error("查询自定义属性时发生错误", err)
+		return nil
+	}
+	return fields
+}
+
+// Set
+// 按名称设置自定义属性的值，属性需要事先在VC中定义
+func Set(api *helper.API, ref types.ManagedObjectReference, values map[string]string) error {
+	if len(values) == 0 {
+		return nil
+	}
+	logging.L().Debug(fmt.Sprintf("设置对象[%s:%s]的自定义属性", ref.Type, ref.Value))
+	m, err := GetManager(api)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), helper.APITimeout)
+	defer cancel()
+	fields, err := m.Field(ctx)
+	if err != nil {
+		return fmt.Errorf("查询自定义属性时发生错误: %v", err)
+	}
+
+	var names []string
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	keys := make(map[string]int32)
+	for _, name := range names {
+		key, ok := findKey(fields, name, ref.Type)
+		if !ok {
+			return fmt.Errorf("自定义属性[%s]不存在或不适用于[%s]", name, ref.Type)
+		}
+		keys[name] = key
+	}
+
+	for _, name := range names {
+		err = m.Set(ctx, ref, keys[name], values[name])
+		if err != nil {
+			return fmt.Errorf("设置对象[%s:%s]的自定义属性[%s]失败: %v", ref.Type, ref.Value, name, err)
+		}
+	}
+	return nil
+}
+
+// findKey 属性的ManagedObjectType为空时适用于所有类型
+func findKey(fields []types.CustomFieldDef, name, moType string) (int32, bool) {
+	for _, f := range fields {
+		if f.Name != name {
+			continue
+		}
+		if f.ManagedObjectType == "" || f.ManagedObjectType == moType {
+			return f.Key, true
+		}
+	}
+	return 0, false
+}
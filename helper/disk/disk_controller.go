@@ -0,0 +1,76 @@
+package disk
+
+import (
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+const (
+	ControllerTypeLsiLogic    = "lsilogic"
+	ControllerTypeLsiLogicSAS = "lsilogic-sas"
+	ControllerTypeBusLogic    = "buslogic"
+	ControllerTypePVSCSI      = "pvscsi"
+	ControllerTypeNVMe        = "nvme"
+	ControllerTypeSATA        = "sata"
+	ControllerTypeIDE         = "ide"
+)
+
+// ControllerMaxCount 每种类型的控制器最多4个(总线号0-3)，所有SCSI类型的控制器共用总线号0-3
+const ControllerMaxCount = 4
+
+// SCSIControllerUnitNumber SCSI控制器自身占用的单元号
+const SCSIControllerUnitNumber = 7
+
+// ControllerTypes 允许新增的硬盘控制器类型
+var ControllerTypes = []string{
+	ControllerTypeLsiLogic,
+	ControllerTypeLsiLogicSAS,
+	ControllerTypeBusLogic,
+	ControllerTypePVSCSI,
+	ControllerTypeNVMe,
+	ControllerTypeSATA,
+}
+
+// GetControllerType 返回控制器类型，不是硬盘控制器时返回空
+func GetControllerType(device types.BaseVirtualDevice) string {
+	switch device.(type) {
+	case *types.VirtualLsiLogicController:
+		return ControllerTypeLsiLogic
+	case *types.VirtualLsiLogicSASController:
+		return ControllerTypeLsiLogicSAS
+	case *types.VirtualBusLogicController:
+		return ControllerTypeBusLogic
+	case *types.ParaVirtualSCSIController:
+		return ControllerTypePVSCSI
+	case *types.VirtualNVMEController:
+		return ControllerTypeNVMe
+	case *types.VirtualAHCIController, *types.VirtualSATAController:
+		return ControllerTypeSATA
+	case *types.VirtualIDEController:
+		return ControllerTypeIDE
+	}
+	return ""
+}
+
+// IsSCSIController 是否为SCSI类型的控制器
+func IsSCSIController(controllerType string) bool {
+	switch controllerType {
+	case ControllerTypeLsiLogic, ControllerTypeLsiLogicSAS, ControllerTypeBusLogic, ControllerTypePVSCSI:
+		return true
+	}
+	return false
+}
+
+// GetControllerUnitCount 控制器可挂载设备的单元号数量
+func GetControllerUnitCount(controllerType string) int32 {
+	switch {
+	case IsSCSIController(controllerType):
+		return 16
+	case controllerType == ControllerTypeNVMe:
+		return 15
+	case controllerType == ControllerTypeSATA:
+		return 30
+	case controllerType == ControllerTypeIDE:
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,171 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	"sort"
+	"vsphere-facade/app/logging"
+	"vsphere-facade/helper"
+)
+
+// Watch
+// 使用PropertyCollector(WaitForUpdatesEx)监听RootFolder下指定类型对象的属性变化，
+// props的key为对象类型，value为需要监听的属性。第一次回调会包含所有对象的当前属性(enter)，
+// 之后只包含发生变化的对象。直到ctx取消或发生错误才返回
+func Watch(ctx context.Context, api *helper.API, props map[string][]string, onUpdates func([]types.ObjectUpdate)) error {
+	c := api.Client.Client
+	var kinds []string
+	for kind := range props {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	m := view.NewManager(c)
+	vctx, vcancel := context.WithTimeout(ctx, helper.APITimeout)
+	defer vcancel()
+	v, err := m.CreateContainerView(vctx, c.ServiceContent.RootFolder, kinds, true)
+	if err != nil {
+		return fmt.Errorf("创建清单监听视图时发生错误: %v", err)
+	}
+
+	defer func() {
+		dctx, dcancel := context.WithTimeout(context.Background(), helper.APITimeout)
+		defer dcancel()
+		v.Destroy(dctx)
+	}()
+
+	filter := new(property.WaitFilter)
+	filter.Spec.ObjectSet = []types.ObjectSpec{
+		{
+			Obj:  v.Reference(),
+			Skip: types.NewBool(true),
+			SelectSet: []types.BaseSelectionSpec{
+				&types.TraversalSpec{
+					Type: v.Reference().Type,
+					Path: "view",
+				},
+			},
+		},
+	}
+	for _, kind := range kinds {
+		filter.Spec.PropSet = append(filter.Spec.PropSet, types.PropertySpec{
+			Type:    kind,
+			PathSet: props[kind],
+		})
+	}
+
+	logging.L().Debug(fmt.Sprintf("开始监听VC[%s]清单变化%s", api.ID, kinds))
+	return property.WaitForUpdates(ctx, property.DefaultCollector(c), filter, func(updates []types.ObjectUpdate) bool {
+		onUpdates(updates)
+		return false
+	})
+}
+
+// Retrieve
+// 查询单个对象的属性，以属性变化的形式返回，便于和Watch的结果使用同样的方式处理
+func Retrieve(api *helper.API, ref types.ManagedObjectReference, props []string) ([]types.PropertyChange, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), helper.APITimeout)
+	defer cancel()
+
+	var content []types.ObjectContent
+	err := property.DefaultCollector(api.Client.Client).Retrieve(ctx, []types.ManagedObjectReference{ref}, props, &content)
+	if err != nil {
+		return nil, fmt.Errorf("查询对象[%s:%s]属性时发生错误: %v", ref.Type, ref.Value, err)
+	}
+
+	var changes []types.PropertyChange
+	for _, c := range content {
+		for _, p := range c.PropSet {
+			changes = append(changes, types.PropertyChange{
+				Name: p.Name,
+				Op:   types.PropertyChangeOpAssign,
+				Val:  p.Val,
+			})
+		}
+	}
+	return changes, nil
+}
+
+// NewObject 创建对应类型的空对象，用于承接属性变化
+func NewObject(ref types.ManagedObjectReference) (mo.Entity, error) {
+	v, err := mo.ObjectContentToType(types.ObjectContent{Obj: ref}, true)
+	if err != nil {
+		return nil, err
+	}
+	entity, ok := v.(mo.Entity)
+	if !ok {
+		return nil, fmt.Errorf("对象[%s:%s]不是清单对象", ref.Type, ref.Value)
+	}
+	return entity, nil
+}
+
+// GetNames
+// 批量查询对象名称，返回对象ID到名称的映射
+func GetNames(api *helper.API, refs []types.ManagedObjectReference) (map[string]string, error) {
+	names := make(map[string]string)
+	if len(refs) == 0 {
+		return names, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), helper.APITimeout)
+	defer cancel()
+
+	var entities []mo.ManagedEntity
+	err := property.DefaultCollector(api.Client.Client).Retrieve(ctx, refs, []string{"name"}, &entities)
+	if err != nil {
+		return nil, fmt.Errorf("查询对象名称时发生错误: %v", err)
+	}
+	for _, entity := range entities {
+		names[entity.Reference().Value] = entity.Name
+	}
+	return names, nil
+}
+
+// Placement 对象在清单中的位置，ResourcePool只有虚拟机有
+type Placement struct {
+	Type         string
+	Parent       *types.ManagedObjectReference
+	ResourcePool *types.ManagedObjectReference
+}
+
+// GetPlacements
+// 一次查询RootFolder下所有清单对象的上级对象和虚拟机所在的资源池，返回对象ID到位置的映射，
+// 用于判断对象是否在指定的数据中心、文件夹或资源池下
+func GetPlacements(api *helper.API) (map[string]Placement, error) {
+	c := api.Client.Client
+	ctx, cancel := context.WithTimeout(context.Background(), helper.APITimeout)
+	defer cancel()
+
+	m := view.NewManager(c)
+	v, err := m.CreateContainerView(ctx, c.ServiceContent.RootFolder, []string{"ManagedEntity"}, true)
+	if err != nil {
+		return nil, fmt.Errorf("创建清单视图时发生错误: %v", err)
+	}
+	defer v.Destroy(ctx)
+
+	var entities []mo.ManagedEntity
+	err = v.Retrieve(ctx, []string{"ManagedEntity"}, []string{"parent"}, &entities)
+	if err != nil {
+		return nil, fmt.Errorf("查询清单对象位置时发生错误: %v", err)
+	}
+	placements := make(map[string]Placement, len(entities))
+	for _, entity := range entities {
+		ref := entity.Reference()
+		placements[ref.Value] = Placement{Type: ref.Type, Parent: entity.Parent}
+	}
+
+	var vms []mo.VirtualMachine
+	err = v.Retrieve(ctx, []string{"VirtualMachine"}, []string{"resourcePool"}, &vms)
+	if err != nil {
+		return nil, fmt.Errorf("查询虚拟机资源池时发生错误: %v", err)
+	}
+	for _, vm := range vms {
+		p := placements[vm.Reference().Value]
+		p.ResourcePool = vm.ResourcePool
+		placements[vm.Reference().Value] = p
+	}
+	return placements, nil
+}
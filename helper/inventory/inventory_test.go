@@ -0,0 +1,203 @@
+package inventory
+
+import (
+	"context"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	"os"
+	"testing"
+	"time"
+	"vsphere-facade/app/logging"
+	"vsphere-facade/config"
+	"vsphere-facade/helper"
+	"vsphere-facade/helper/folder"
+)
+
+func setup(c *vim25.Client) *helper.API {
+	config.G.Server.Log.Path = os.TempDir()
+	config.G.Server.Log.Level = "error"
+	logging.Setup()
+	helper.APITimeout = time.Minute
+
+	client := &govmomi.Client{
+		Client:         c,
+		SessionManager: session.NewManager(c),
+	}
+	return helper.NewAPI(client, simulator.DefaultLogin)
+}
+
+func TestWatch(t *testing.T) {
+	simulator.Test(func(ctx context.Context, c *vim25.Client) {
+		api := setup(c)
+
+		dc, err := find.NewFinder(c).DefaultDatacenter(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dcFolders, err := dc.Folders(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		updates := make(chan types.ObjectUpdate, 1024)
+		wctx, wcancel := context.WithCancel(ctx)
+		done := make(chan error, 1)
+		go func() {
+			done <- Watch(wctx, api, map[string][]string{folder.Type: {"name", "parent"}}, func(u []types.ObjectUpdate) {
+				for _, update := range u {
+					updates <- update
+				}
+			})
+		}()
+
+		objects := make(map[types.ManagedObjectReference]mo.Entity)
+		// wait 等待指定对象的指定类型变化，同时把变化合并到objects中
+		wait := func(ref types.ManagedObjectReference, kind types.ObjectUpdateKind) {
+			timeout := time.After(10 * time.Second)
+			for {
+				select {
+				case update := <-updates:
+					if update.Kind == types.ObjectUpdateKindLeave {
+						delete(objects, update.Obj)
+					} else {
+						obj, exists := objects[update.Obj]
+						if !exists {
+							obj, err = NewObject(update.Obj)
+							if err != nil {
+								t.Fatal(err)
+							}
+							objects[update.Obj] = obj
+						}
+						mo.ApplyPropertyChange(obj, update.ChangeSet)
+					}
+					if update.Obj == ref && update.Kind == kind {
+						return
+					}
+				case <-timeout:
+					t.Fatalf("等待对象[%s]的%s变化超时", ref.Value, kind)
+				}
+			}
+		}
+
+		wait(dcFolders.VmFolder.Reference(), types.ObjectUpdateKindEnter)
+		if objects[dcFolders.VmFolder.Reference()].Entity().Parent.Value != dc.Reference().Value {
+			t.Fatal("虚拟机文件夹的parent错误")
+		}
+
+		created, err := dcFolders.VmFolder.CreateFolder(ctx, "watched")
+		if err != nil {
+			t.Fatal(err)
+		}
+		wait(created.Reference(), types.ObjectUpdateKindEnter)
+		if objects[created.Reference()].(*mo.Folder).Name != "watched" {
+			t.Fatal("新建文件夹的名称错误")
+		}
+
+		task, err := created.Rename(ctx, "renamed")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = task.Wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+		wait(created.Reference(), types.ObjectUpdateKindModify)
+		if objects[created.Reference()].(*mo.Folder).Name != "renamed" {
+			t.Fatal("文件夹重命名后名称没有更新")
+		}
+
+		task, err = created.Destroy(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = task.Wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+		wait(created.Reference(), types.ObjectUpdateKindLeave)
+		if _, exists := objects[created.Reference()]; exists {
+			t.Fatal("删除的文件夹没有移除")
+		}
+
+		changes, err := Retrieve(api, dcFolders.VmFolder.Reference(), []string{"name"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(changes) != 1 || changes[0].Name != "name" || changes[0].Val != "vm" {
+			t.Fatalf("查询属性结果错误: %v", changes)
+		}
+
+		wcancel()
+		<-done
+	})
+}
+
+func TestGetNames(t *testing.T) {
+	simulator.Test(func(ctx context.Context, c *vim25.Client) {
+		api := setup(c)
+
+		finder := find.NewFinder(c)
+		dc, err := finder.DefaultDatacenter(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		finder.SetDatacenter(dc)
+		hosts, err := finder.HostSystemList(ctx, "*")
+		if err != nil || len(hosts) == 0 {
+			t.Fatal("模拟环境中没有主机", err)
+		}
+		datastores, err := finder.DatastoreList(ctx, "*")
+		if err != nil || len(datastores) == 0 {
+			t.Fatal("模拟环境中没有存储", err)
+		}
+
+		refs := []types.ManagedObjectReference{hosts[0].Reference(), datastores[0].Reference()}
+		names, err := GetNames(api, refs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if names[refs[0].Value] != hosts[0].Name() || names[refs[1].Value] != datastores[0].Name() {
+			t.Fatalf("查询对象名称结果错误: %v", names)
+		}
+	})
+}
+
+func TestGetPlacements(t *testing.T) {
+	simulator.Test(func(ctx context.Context, c *vim25.Client) {
+		api := setup(c)
+
+		finder := find.NewFinder(c)
+		dc, err := finder.DefaultDatacenter(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		finder.SetDatacenter(dc)
+		vms, err := finder.VirtualMachineList(ctx, "*")
+		if err != nil || len(vms) == 0 {
+			t.Fatal("模拟环境中没有虚拟机", err)
+		}
+		dcFolders, err := dc.Folders(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		placements, err := GetPlacements(api)
+		if err != nil {
+			t.Fatal(err)
+		}
+		vm := placements[vms[0].Reference().Value]
+		if vm.Type != "VirtualMachine" || vm.Parent == nil || vm.Parent.Value != dcFolders.VmFolder.Reference().Value {
+			t.Fatalf("虚拟机的上级对象错误: %+v", vm)
+		}
+		if vm.ResourcePool == nil {
+			t.Fatal("虚拟机的资源池为空")
+		}
+		folder := placements[dcFolders.VmFolder.Reference().Value]
+		if folder.Parent == nil || folder.Parent.Value != dc.Reference().Value {
+			t.Fatalf("虚拟机文件夹的上级对象错误: %+v", folder)
+		}
+	})
+}
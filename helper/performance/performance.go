@@ -0,0 +1,228 @@
+package performance
+
+import (
+	"context"
+	"fmt"
+	"github.com/vmware/govmomi/performance"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/types"
+	"sort"
+	"strings"
+	"time"
+	"vsphere-facade/app/logging"
+	"vsphere-facade/app/utils"
+	"vsphere-facade/helper"
+)
+
+// RealtimeInterval 实时数据的采样间隔(s)，只有虚拟机和主机支持实时数据
+const RealtimeInterval = 20
+
+// MaxSampleLimit 一次查询每个对象最多返回的样本数
+const MaxSampleLimit = 1440
+
+// MaxEntities 一次查询最多的对象数量
+const MaxEntities = 100
+
+const (
+	RollupAverage   = "average"
+	RollupMinimum   = "minimum"
+	RollupMaximum   = "maximum"
+	RollupLatest    = "latest"
+	RollupSummation = "summation"
+	RollupNone      = "none"
+)
+
+var rollups = []string{RollupAverage, RollupMinimum, RollupMaximum, RollupLatest, RollupSummation, RollupNone}
+
+// Spec 性能数据查询条件
+type Spec struct {
+	// Counters 指标名称，格式为group.name.rollup，省略rollup时使用Rollup
+	Counters []string
+	Rollup   string
+	Interval int32
+	// Instance 为空表示汇总数据，*表示所有实例
+	Instance  string
+	StartTime *time.Time
+	EndTime   *time.Time
+	// MaxSample 没有指定开始时间时默认为1，即只查询最新的数据，最大为MaxSampleLimit
+	MaxSample int32
+}
+
+type Series struct {
+	Counter  string
+	Instance string
+	Unit     string
+	Values   []int64
+}
+
+type EntityMetric struct {
+	Entity     types.ManagedObjectReference
+	Timestamps []time.Time
+	Interval   int32
+	Series     []Series
+}
+
+// Intervals 查询VC支持的采样间隔，包括实时数据的20s
+func Intervals(api *helper.API) ([]int32, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), helper.APITimeout)
+	defer cancel()
+	historical, err := performance.NewManager(api.Client.Client).HistoricalInterval(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("查询性能数据采样间隔时发生错误: %v", err)
+	}
+
+	intervals := []int32{RealtimeInterval}
+	for _, i := range historical {
+		if i.Enabled {
+			intervals = append(intervals, i.SamplingPeriod)
+		}
+	}
+	sort.Slice(intervals, func(i, j int) bool {
+		return intervals[i] < intervals[j]
+	})
+	return intervals, nil
+}
+
+// GetCounters 查询VC支持的所有性能指标，按名称排序
+func GetCounters(api *helper.API) ([]types.PerfCounterInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), helper.APITimeout)
+	defer cancel()
+	counters, err := performance.NewManager(api.Client.Client).CounterInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("查询性能指标时发生错误: %v", err)
+	}
+	sort.Slice(counters, func(i, j int) bool {
+		return counters[i].Name() < counters[j].Name()
+	})
+	return counters, nil
+}
+
+// CounterName 补全指标名称中的rollup
+func CounterName(counter, rollup string) string {
+	if strings.Count(counter, ".") >= 2 {
+		return counter
+	}
+	if rollup == "" {
+		rollup = RollupAverage
+	}
+	return counter + "." + rollup
+}
+
+// Sample 查询多个对象的性能数据
+func Sample(api *helper.API, spec Spec, refs []types.ManagedObjectReference) ([]EntityMetric, error) {
+	if spec.Rollup != "" && !utils.SliceContain(rollups, spec.Rollup) {
+		return nil, fmt.Errorf("不支持的汇总方式[%s]，支持%v", spec.Rollup, rollups)
+	}
+	if len(spec.Counters) == 0 {
+		return nil, fmt.Errorf("没有指定性能指标")
+	}
+	if spec.StartTime != nil && spec.EndTime != nil && !spec.StartTime.Before(*spec.EndTime) {
+		return nil, fmt.Errorf("开始时间必须早于结束时间")
+	}
+	if spec.MaxSample < 0 || spec.MaxSample > MaxSampleLimit {
+		return nil, fmt.Errorf("样本数必须在0到%d之间", MaxSampleLimit)
+	}
+	if len(refs) > MaxEntities {
+		return nil, fmt.Errorf("一次最多查询%d个对象的性能数据", MaxEntities)
+	}
+
+	intervals, err := Intervals(api)
+	if err != nil {
+		return nil, err
+	}
+	if !utils.SliceContain(intervals, spec.Interval) {
+		return nil, fmt.Errorf("不支持的采样间隔[%d]，支持%v", spec.Interval, intervals)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), helper.APITimeout)
+	defer cancel()
+	m := performance.NewManager(api.Client.Client)
+	counters, err := m.CounterInfoByName(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("查询性能指标时发生错误: %v", err)
+	}
+
+	var ids []types.PerfMetricId
+	units := make(map[int32]string)
+	names := make(map[int32]string)
+	for _, c := range spec.Counters {
+		name := CounterName(c, spec.Rollup)
+		counter, ok := counters[name]
+		if !ok {
+			return nil, fmt.Errorf("性能指标[%s]不存在", name)
+		}
+		ids = append(ids, types.PerfMetricId{CounterId: counter.Key, Instance: spec.Instance})
+		units[counter.Key] = counter.UnitInfo.GetElementDescription().Key
+		names[counter.Key] = name
+	}
+
+	querySpec := types.PerfQuerySpec{
+		MetricId:   ids,
+		IntervalId: spec.Interval,
+		StartTime:  spec.StartTime,
+		EndTime:    spec.EndTime,
+		MaxSample:  spec.MaxSample,
+		Format:     string(types.PerfFormatNormal),
+	}
+	// 历史数据必须指定开始时间，往前多取一倍再截断，避免VC还没有汇总最新的数据时样本不足
+	truncate := false
+	if querySpec.StartTime == nil {
+		if querySpec.MaxSample == 0 {
+			querySpec.MaxSample = 1
+		}
+		if querySpec.IntervalId > RealtimeInterval {
+			now, err := methods.GetCurrentTime(ctx, api.Client.Client)
+			if err != nil {
+				return nil, fmt.Errorf("查询VC当前时间时发生错误: %v", err)
+			}
+			startTime := now.Add(-time.Duration(int64(querySpec.IntervalId)*int64(querySpec.MaxSample)*2) * time.Second)
+			querySpec.StartTime = &startTime
+			truncate = true
+		}
+	}
+
+	var query []types.PerfQuerySpec
+	for _, ref := range refs {
+		querySpec.Entity = ref
+		query = append(query, querySpec)
+	}
+	logging.L().Debug(fmt.Sprintf("查询%d个对象的性能数据%v，采样间隔%ds", len(refs), spec.Counters, spec.Interval))
+	result, err := m.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("查询性能数据时发生错误: %v", err)
+	}
+
+	var metrics []EntityMetric
+	for _, r := range result {
+		em, ok := r.(*types.PerfEntityMetric)
+		if !ok {
+			continue
+		}
+		sampleInfo := em.SampleInfo
+		if truncate && len(sampleInfo) > int(querySpec.MaxSample) {
+			sampleInfo = sampleInfo[len(sampleInfo)-int(querySpec.MaxSample):]
+		}
+		metric := EntityMetric{Entity: em.Entity, Interval: spec.Interval}
+		for _, info := range sampleInfo {
+			metric.Timestamps = append(metric.Timestamps, info.Timestamp)
+		}
+		for _, v := range em.Value {
+			series, ok := v.(*types.PerfMetricIntSeries)
+			if !ok {
+				continue
+			}
+			values := series.Value
+			if truncate && len(values) > int(querySpec.MaxSample) {
+				values = values[len(values)-int(querySpec.MaxSample):]
+			}
+			metric.Series = append(metric.Series, Series{
+				Counter:  names[series.Id.CounterId],
+				Instance: series.Id.Instance,
+				Unit:     units[series.Id.CounterId],
+				Values:   values,
+			})
+		}
+		metrics = append(metrics, metric)
+	}
+	return metrics, nil
+}
@@ -0,0 +1,78 @@
+package performance
+
+import (
+	"context"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+	"os"
+	"testing"
+	"time"
+	"vsphere-facade/app/logging"
+	"vsphere-facade/config"
+	"vsphere-facade/helper"
+)
+
+func setup(c *vim25.Client) *helper.API {
+	config.G.Server.Log.Path = os.TempDir()
+	config.G.Server.Log.Level = "error"
+	logging.Setup()
+	helper.APITimeout = time.Minute
+
+	client := &govmomi.Client{
+		Client:         c,
+		SessionManager: session.NewManager(c),
+	}
+	return helper.NewAPI(client, simulator.DefaultLogin)
+}
+
+func TestSample(t *testing.T) {
+	simulator.Test(func(ctx context.Context, c *vim25.Client) {
+		api := setup(c)
+
+		vms, err := find.NewFinder(c).VirtualMachineList(ctx, "*")
+		if err != nil || len(vms) < 2 {
+			t.Fatal("模拟环境中的虚拟机不足", err)
+		}
+		refs := []types.ManagedObjectReference{vms[0].Reference(), vms[1].Reference()}
+
+		metrics, err := Sample(api, Spec{
+			Counters:  []string{"cpu.usagemhz", "mem.usage.average"},
+			Interval:  RealtimeInterval,
+			MaxSample: 3,
+		}, refs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(metrics) != 2 || metrics[0].Entity != refs[0] || metrics[1].Entity != refs[1] {
+			t.Fatalf("性能数据对象错误: %v", metrics)
+		}
+		for _, metric := range metrics {
+			if len(metric.Timestamps) != 3 || len(metric.Series) != 2 {
+				t.Fatalf("性能数据样本数量错误: %v", metric)
+			}
+			if metric.Series[0].Counter != "cpu.usagemhz.average" || metric.Series[1].Unit != string(types.PerformanceManagerUnitPercent) {
+				t.Fatalf("性能数据指标错误: %v", metric.Series)
+			}
+		}
+
+		if _, err = Sample(api, Spec{Counters: []string{"cpu.nope"}, Interval: RealtimeInterval}, refs); err == nil {
+			t.Fatal("不存在的指标应该返回错误")
+		}
+		if _, err = Sample(api, Spec{Counters: []string{"cpu.usage"}, Interval: 21}, refs); err == nil {
+			t.Fatal("不支持的采样间隔应该返回错误")
+		}
+		if _, err = Sample(api, Spec{Counters: []string{"cpu.usage"}, Rollup: "median", Interval: RealtimeInterval}, refs); err == nil {
+			t.Fatal("不支持的汇总方式应该返回错误")
+		}
+		if _, err = Sample(api, Spec{Counters: []string{"cpu.usage"}, Interval: RealtimeInterval, MaxSample: MaxSampleLimit + 1}, refs); err == nil {
+			t.Fatal("超过上限的样本数应该返回错误")
+		}
+		if _, err = Sample(api, Spec{Counters: []string{"cpu.usage"}, Interval: RealtimeInterval}, make([]types.ManagedObjectReference, MaxEntities+1)); err == nil {
+			t.Fatal("超过上限的对象数量应该返回错误")
+		}
+	})
+}
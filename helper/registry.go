@@ -0,0 +1,68 @@
+package helper
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+	"vsphere-facade/config"
+)
+
+// registryIDs VC实例ID对应的登记地址，连接过之后才能按实例ID查找
+var registryIDs sync.Map
+
+// FindVCenter 按别名、实例ID或地址查找登记的VC
+func FindVCenter(key string) *config.VCenterConfig {
+	if key == "" {
+		return nil
+	}
+	if address, ok := registryIDs.Load(key); ok {
+		key = address.(string)
+	}
+	vcenters := config.G.Vsphere.VCenters
+	for i, v := range vcenters {
+		if v.Alias == key || sameAddress(v.Address, key) {
+			return &vcenters[i]
+		}
+	}
+	return nil
+}
+
+// RegisteredVCenter 按实例ID查找登记的VC，没有登记或还没有连接过时返回nil
+func RegisteredVCenter(VCID string) *config.VCenterConfig {
+	if _, ok := registryIDs.Load(VCID); !ok {
+		return nil
+	}
+	return FindVCenter(VCID)
+}
+
+func bindVCenter(VCID, address string) {
+	for _, v := range config.G.Vsphere.VCenters {
+		if sameAddress(v.Address, address) {
+			registryIDs.Store(VCID, v.Address)
+			return
+		}
+	}
+}
+
+// Connected 登记的VC是否有可用的缓存会话，不会建立新连接
+func Connected(address string) bool {
+	for _, a := range APIs() {
+		if a.Client != nil && a.Client.Valid() && sameAddress(a.Client.URL().Host, address) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameAddress 忽略协议、大小写和结尾的/比较VC地址
+func sameAddress(a, b string) bool {
+	return a != "" && b != "" && strings.EqualFold(addressHost(a), addressHost(b))
+}
+
+func addressHost(address string) string {
+	u, err := url.Parse(address)
+	if err == nil && u.Host != "" {
+		return u.Host
+	}
+	return strings.TrimSuffix(address, "/")
+}
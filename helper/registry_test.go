@@ -0,0 +1,33 @@
+package helper
+
+import (
+	"testing"
+	"vsphere-facade/config"
+)
+
+func TestFindVCenter(t *testing.T) {
+	saved := config.G.Vsphere.VCenters
+	defer func() { config.G.Vsphere.VCenters = saved }()
+	config.G.Vsphere.VCenters = []config.VCenterConfig{
+		{Alias: "vc1", Address: "https://vc1.example.com"},
+		{Alias: "vc2", Address: "https://vc2.example.com:8443/"},
+	}
+
+	if v := FindVCenter("vc2"); v == nil || v.Address != "https://vc2.example.com:8443/" {
+		t.Error("应该按别名找到VC", v)
+	}
+	if v := FindVCenter("HTTPS://VC1.example.com/"); v == nil || v.Alias != "vc1" {
+		t.Error("应该按地址找到VC", v)
+	}
+	if RegisteredVCenter("uuid-1") != nil {
+		t.Error("没有连接过的VC不能按实例ID找到")
+	}
+	bindVCenter("uuid-1", "https://vc1.example.com/")
+	bindVCenter("uuid-3", "https://vc3.example.com")
+	if v := RegisteredVCenter("uuid-1"); v == nil || v.Alias != "vc1" {
+		t.Error("连接过的VC应该可以按实例ID找到", v)
+	}
+	if RegisteredVCenter("uuid-3") != nil || FindVCenter("vc3") != nil {
+		t.Error("没有登记的VC不应该找到")
+	}
+}
@@ -0,0 +1,134 @@
+package helper
+
+import (
+	"context"
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+	"reflect"
+	"time"
+	"vsphere-facade/app/logging"
+	"vsphere-facade/config"
+)
+
+// keepAliveIdle 会话空闲多久后发送保活请求，默认5分钟
+func keepAliveIdle() time.Duration {
+	if m := config.G.Vsphere.Session.KeepAlive; m > 0 {
+		return time.Duration(m) * time.Minute
+	}
+	return 5 * time.Minute
+}
+
+// sessionRoundTripper 会话失效(NotAuthenticated)时重新登录并重试一次
+type sessionRoundTripper struct {
+	soap.RoundTripper
+	api *API
+}
+
+func (s *sessionRoundTripper) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	// 重新登录时持有loginMu，登录请求不能再获取
+	if isLogin(req) {
+		return s.RoundTripper.RoundTrip(ctx, req, res)
+	}
+	generation := s.api.loginGeneration()
+	err := s.RoundTripper.RoundTrip(ctx, req, res)
+	if !isNotAuthenticated(err) {
+		return err
+	}
+	if err := s.api.relogin(ctx, generation); err != nil {
+		return err
+	}
+	// 清除第一次请求返回的错误再重试
+	v := reflect.ValueOf(res).Elem()
+	v.Set(reflect.Zero(v.Type()))
+	return s.RoundTripper.RoundTrip(ctx, req, res)
+}
+
+// withSession 在登录前安装保活和重新登录，保活在登录成功后开始
+func (a *API) withSession() {
+	vimClient := a.Client.Client
+	keepAlive := session.KeepAliveHandler(vimClient.RoundTripper, keepAliveIdle(), a.keepAlive)
+	if h, ok := keepAlive.(interface{ Stop() }); ok {
+		a.stopKeepAlive = h.Stop
+	}
+	vimClient.RoundTripper = &sessionRoundTripper{RoundTripper: keepAlive, api: a}
+}
+
+// keepAlive 保活请求失败时不停止保活，只有重新登录失败时停止，下次登录成功后重新开始
+func (a *API) keepAlive(rt soap.RoundTripper) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	generation := a.loginGeneration()
+	_, err := methods.GetCurrentTime(ctx, rt)
+	if err == nil {
+		return nil
+	}
+	if !isNotAuthenticated(err) {
+		logging.L().Warnf("VC[%s]会话保活失败: %v", a.Address(), err)
+		return nil
+	}
+	return a.relogin(ctx, generation)
+}
+
+func (a *API) loginGeneration() uint64 {
+	a.loginMu.Lock()
+	defer a.loginMu.Unlock()
+	return a.loginGen
+}
+
+// relogin 使用原账号重新登录，generation与当前不一致时说明其他请求已经重新登录过
+func (a *API) relogin(ctx context.Context, generation uint64) error {
+	a.loginMu.Lock()
+	defer a.loginMu.Unlock()
+	if a.loginGen != generation {
+		return nil
+	}
+	logging.L().Warnf("VC[%s]会话已失效，重新登录", a.Address())
+	err := a.Client.SessionManager.Login(ctx, a.userinfo)
+	if err != nil {
+		logging.L().Errorf("VC[%s]重新登录失败: %v", a.Address(), err)
+		if !soap.IsSoapFault(err) {
+			breakerOf(a.Client.URL().Host).failure(time.Now())
+		}
+		return err
+	}
+	a.loginGen++
+	return nil
+}
+
+// logout 退出被替换的会话，会话已经失效时不重新登录
+func (a *API) logout() {
+	if a.Client == nil || !a.Client.Valid() {
+		return
+	}
+	ctx, cancel := connectContext(context.Background())
+	defer cancel()
+	var rt soap.RoundTripper = a.Client.Client.RoundTripper
+	if s, ok := rt.(*sessionRoundTripper); ok {
+		rt = s.RoundTripper
+	}
+	req := types.Logout{This: *a.Client.ServiceContent.SessionManager}
+	if _, err := methods.Logout(ctx, rt, &req); err != nil && !isNotAuthenticated(err) {
+		logging.L().Warnf("退出VC[%s]被替换的会话失败: %v", a.Address(), err)
+	}
+}
+
+func isNotAuthenticated(err error) bool {
+	if err == nil || !soap.IsSoapFault(err) {
+		return false
+	}
+	switch soap.ToSoapFault(err).VimFault().(type) {
+	case types.NotAuthenticated, *types.NotAuthenticated:
+		return true
+	}
+	return false
+}
+
+func isLogin(req soap.HasFault) bool {
+	switch req.(type) {
+	case *methods.LoginBody, *methods.LoginExtensionByCertificateBody, *methods.LoginByTokenBody:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,207 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25/mo"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+	"vsphere-facade/app/logging"
+	"vsphere-facade/config"
+)
+
+func simulatorServer(t *testing.T) *simulator.Server {
+	config.G.Server.Log.Path = os.TempDir()
+	config.G.Server.Log.Level = "error"
+	logging.Setup()
+	config.G.Vsphere.TLS.Insecure = true
+	t.Cleanup(func() { config.G.Vsphere.TLS.Insecure = false })
+
+	model := simulator.VPX()
+	t.Cleanup(model.Remove)
+	if err := model.Create(); err != nil {
+		t.Fatal(err)
+	}
+	// 只允许指定的账号登录
+	model.Service.Listen = &url.URL{User: url.UserPassword("user", "pass")}
+	s := model.Service.NewServer()
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestRelogin(t *testing.T) {
+	s := simulatorServer(t)
+	password, _ := s.URL.User.Password()
+	api, err := Init(s.URL.Scheme+"://"+s.URL.Host, s.URL.User.Username(), password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if err = api.Client.SessionManager.Logout(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = object.NewSearchIndex(api.Client.Client).FindByInventoryPath(ctx, "/DC0"); err != nil {
+		t.Fatal("会话失效后应该自动重新登录", err)
+	}
+	if api.loginGeneration() != 1 {
+		t.Error("应该重新登录一次", api.loginGeneration())
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	s := simulatorServer(t)
+	saved := config.G.Vsphere.Session
+	defer func() { config.G.Vsphere.Session = saved }()
+	config.G.Vsphere.Session.FailureThreshold = 2
+	config.G.Vsphere.Session.OpenTimeout = 60
+
+	// 账号密码错误不熔断
+	address := s.URL.Scheme + "://" + s.URL.Host
+	for i := 0; i < 3; i++ {
+		if _, err := Init(address, "wrong", "wrong"); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatal("账号错误应该返回登录失败", err)
+		}
+	}
+
+	unreachable := "https://127.0.0.1:1"
+	for i := 0; i < 2; i++ {
+		if _, err := Init(unreachable, "u", "p"); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatal("无法连接时应该返回连接错误", err)
+		}
+	}
+	_, err := Init(unreachable, "u", "p")
+	var circuitErr *CircuitOpenError
+	if !errors.As(err, &circuitErr) || circuitErr.RetryAfter <= 0 || !CircuitOpen(unreachable) {
+		t.Fatal("连续失败后应该熔断", err)
+	}
+	if CircuitOpen(address) {
+		t.Error("其他VC不应该熔断")
+	}
+}
+
+func TestBreakerHalfOpen(t *testing.T) {
+	saved := config.G.Vsphere.Session
+	defer func() { config.G.Vsphere.Session = saved }()
+	config.G.Vsphere.Session.FailureThreshold = 2
+	config.G.Vsphere.Session.OpenTimeout = 30
+
+	b := &breaker{}
+	now := time.Now()
+	if b.failure(now) || !b.failure(now) {
+		t.Fatal("连续失败2次后熔断")
+	}
+	if b.allow("vc", now.Add(10*time.Second)) == nil {
+		t.Error("熔断期间不能连接")
+	}
+
+	now = now.Add(31 * time.Second)
+	if err := b.allow("vc", now); err != nil {
+		t.Fatal("熔断结束后允许一个请求尝试", err)
+	}
+	if b.allow("vc", now) == nil {
+		t.Error("半开状态下只允许一个请求尝试")
+	}
+	if !b.failure(now) || b.allow("vc", now.Add(time.Second)) == nil {
+		t.Error("半开状态下失败应该立即重新熔断")
+	}
+
+	now = now.Add(31 * time.Second)
+	if err := b.allow("vc", now); err != nil {
+		t.Fatal(err)
+	}
+	b.cancelProbe()
+	if err := b.allow("vc", now); err != nil {
+		t.Fatal("没有尝试连接时应该允许下一个请求尝试", err)
+	}
+	if b.allow("vc", now.Add(31*time.Second)) != nil {
+		t.Error("尝试的请求没有返回结果时，超时后允许下一个请求尝试")
+	}
+	b.success()
+	for i := 0; i < 3; i++ {
+		if err := b.allow("vc", now); err != nil {
+			t.Error("连接成功后关闭熔断", err)
+		}
+	}
+	if b.failure(now) {
+		t.Error("关闭熔断后重新计算失败次数")
+	}
+}
+
+func TestConnectLocks(t *testing.T) {
+	unlock, _ := connectLocks.lock(context.Background(), "vc@user")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := connectLocks.lock(ctx, "vc@user"); err == nil {
+		t.Fatal("ctx结束前没有拿到锁时应该返回错误")
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		second, _ := connectLocks.lock(context.Background(), "vc@user")
+		second()
+	}()
+	select {
+	case <-done:
+		t.Fatal("同一个账号同时只能有一个请求建立连接")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlock()
+	<-done
+
+	connectLocks.mu.Lock()
+	defer connectLocks.mu.Unlock()
+	if len(connectLocks.locks) != 0 {
+		t.Error("没有等待的请求时应该删除锁", connectLocks.locks)
+	}
+}
+
+func TestCache(t *testing.T) {
+	s := simulatorServer(t)
+	address := s.URL.Scheme + "://" + s.URL.Host
+	password, _ := s.URL.User.Password()
+	api, err := Init(address, s.URL.User.Username(), password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 缓存的会话不受熔断影响
+	b := breakerOf(address)
+	b.mu.Lock()
+	b.openUntil = time.Now().Add(time.Minute)
+	b.mu.Unlock()
+	cached, err := Init(address, s.URL.User.Username(), password)
+	b.success()
+	if err != nil || cached != api {
+		t.Fatal("熔断中应该返回缓存的连接", err)
+	}
+
+	ctx := context.Background()
+	u, _ := url.Parse(address + "/sdk")
+	u.User = s.URL.User
+	newApi, err := connect(ctx, u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache(cacheKey(address, s.URL.User.Username(), password), newApi)
+	if CachedAPI(address, s.URL.User.Username(), password) != newApi {
+		t.Fatal("应该替换缓存的连接")
+	}
+
+	// 被替换的会话应该退出，直接请求VC，不经过重新登录
+	rt := api.Client.Client.RoundTripper.(*sessionRoundTripper).RoundTripper
+	var sm mo.SessionManager
+	for i := 0; i < 50; i++ {
+		sm = mo.SessionManager{}
+		err = mo.RetrieveProperties(ctx, rt, api.Client.ServiceContent.PropertyCollector, *api.Client.ServiceContent.SessionManager, &sm)
+		if err != nil || sm.CurrentSession == nil {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Error("被替换的会话应该退出", err, sm.CurrentSession)
+}
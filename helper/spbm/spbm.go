@@ -9,6 +9,9 @@ import (
 	"vsphere-facade/helper"
 )
 
+// EncryptionNamespace 虚拟机加密规则的命名空间
+const EncryptionNamespace = "vmwarevmcrypt"
+
 func GetPolicies(c *pbm.Client) *[]types.PbmProfile {
 	ctx, cancel := context.WithTimeout(context.Background(), helper.APITimeout)
 	defer cancel()
@@ -56,3 +59,40 @@ func GetPolicy(c *pbm.Client, id string) *types.PbmProfile {
 //go get -u github.com/swaggo/gin-swagger
 //go get -u github.com/swaggo/files
 //go get -u github.com/alecthomas/template
+
+// IsEncryptionPolicy
+// 存储策略中包含vmwarevmcrypt规则时为加密策略
+func IsEncryptionPolicy(c *pbm.Client, id string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), helper.APITimeout)
+	defer cancel()
+
+	profileId := []types.PbmProfileId{
+		{
+			UniqueId: id,
+		},
+	}
+	profiles, err := c.RetrieveContent(ctx, profileId)
+	if err != nil {
+		return false, fmt.Errorf("使用ID[%s]查询存储策略时发生错误: %v", id, err)
+	}
+	if len(profiles) == 0 {
+		return false, fmt.Errorf("存储策略[%s]不存在", id)
+	}
+
+	profile, ok := profiles[0].(*types.PbmCapabilityProfile)
+	if !ok {
+		return false, nil
+	}
+	constraints, ok := profile.Constraints.(*types.PbmCapabilitySubProfileConstraints)
+	if !ok {
+		return false, nil
+	}
+	for _, sub := range constraints.SubProfiles {
+		for _, capability := range sub.Capability {
+			if capability.Id.Namespace == EncryptionNamespace {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,173 @@
+package tag
+
+import (
+	"context"
+	"fmt"
+	"github.com/vmware/govmomi/vapi/tags"
+	"github.com/vmware/govmomi/vim25/types"
+	"vsphere-facade/app/logging"
+	"vsphere-facade/app/utils"
+	"vsphere-facade/helper"
+)
+
+func GetManager(api *helper.API, ctx context.Context) (*tags.Manager, error) {
+	rc, err := api.RestClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return tags.NewManager(rc), nil
+}
+
+func GetCategories(api *helper.API) []tags.Category {
+	logging.L().Debug("查询标签分类")
+	ctx, cancel := context.WithTimeout(context.Background(), helper.APITimeout)
+	defer cancel()
+	m, err := GetManager(api, ctx)
+	if err != nil {
+		logging.L().Error("查询标签分类时发生错误", err)
+		return nil
+	}
+	categories, err := m.GetCategories(ctx)
+	if err != nil {
+		logging.L().Error("查询标签分类时发生错误", err)
+		return nil
+	}
+	return categories
+}
+
+// GetTags
+// categoryID为空时查询所有标签
+func GetTags(api *helper.API, categoryID string) []tags.Tag {
+	logging.L().Debug(fmt.Sprintf("查询分类[%s]下的标签", categoryID))
+	ctx, cancel := context.WithTimeout(context.Background(), helper.APITimeout)
+	defer cancel()
+	m, err := GetManager(api, ctx)
+	if err != nil {
+		logging.L().Error("查询标签时发生错误", err)
+		return nil
+	}
+
+	var tagList []tags.Tag
+	if categoryID == "" {
+		tagList, err = m.GetTags(ctx)
+	} else {
+		tagList, err = m.GetTagsForCategory(ctx, categoryID)
+	}
+	if err != nil {
+		logging.L().Error(fmt.Sprintf("查询分类[%s]下的标签时发生错误", categoryID), err)
+		return nil
+	}
+	return tagList
+}
+
+func Attach(api *helper.API, tagIDs []string, ref types.ManagedObjectReference) error {
+	if len(tagIDs) == 0 {
+		return nil
+	}
+	logging.L().Debug(fmt.Sprintf("为对象[%s:%s]添加标签%s", ref.Type, ref.Value, tagIDs))
+	ctx, cancel := context.WithTimeout(context.Background(), helper.APITimeout)
+	defer cancel()
+	m, err := GetManager(api, ctx)
+	if err != nil {
+		return err
+	}
+	err = m.AttachMultipleTagsToObject(ctx, tagIDs, ref)
+	if err != nil {
+		return fmt.Errorf("为对象[%s:%s]添加标签失败: %v", ref.Type, ref.Value, err)
+	}
+	return nil
+}
+
+func Detach(api *helper.API, tagIDs []string, ref types.ManagedObjectReference) error {
+	if len(tagIDs) == 0 {
+		return nil
+	}
+	logging.L().Debug(fmt.Sprintf("移除对象[%s:%s]的标签%s", ref.Type, ref.Value, tagIDs))
+	ctx, cancel := context.WithTimeout(context.Background(), helper.APITimeout)
+	defer cancel()
+	m, err := GetManager(api, ctx)
+	if err != nil {
+		return err
+	}
+	err = m.DetachMultipleTagsFromObject(ctx, tagIDs, ref)
+	if err != nil {
+		return fmt.Errorf("移除对象[%s:%s]的标签失败: %v", ref.Type, ref.Value, err)
+	}
+	return nil
+}
+
+// GetAttachedTagIDs 对象上已添加的标签ID
+func GetAttachedTagIDs(api *helper.API, ref types.ManagedObjectReference) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), helper.APITimeout)
+	defer cancel()
+	m, err := GetManager(api, ctx)
+	if err != nil {
+		return nil, err
+	}
+	return m.ListAttachedTags(ctx, ref)
+}
+
+// GetObjectsWithAllTags
+// 返回同时添加了所有标签的对象，key为对象ID
+func GetObjectsWithAllTags(api *helper.API, tagIDs []string, objectType string) (map[string]bool, error) {
+	counter, tagCount, err := countTaggedObjects(api, tagIDs, objectType)
+	if err != nil {
+		return nil, err
+	}
+	objects := make(map[string]bool)
+	for ID, count := range counter {
+		if count == tagCount {
+			objects[ID] = true
+		}
+	}
+	return objects, nil
+}
+
+// GetObjectsWithAnyTags
+// 返回添加了其中一个标签的对象，key为对象ID
+func GetObjectsWithAnyTags(api *helper.API, tagIDs []string, objectType string) (map[string]bool, error) {
+	counter, _, err := countTaggedObjects(api, tagIDs, objectType)
+	if err != nil {
+		return nil, err
+	}
+	objects := make(map[string]bool, len(counter))
+	for ID := range counter {
+		objects[ID] = true
+	}
+	return objects, nil
+}
+
+// countTaggedObjects 一次查询所有标签关联的对象，返回每个对象添加了其中几个标签和去重后的标签数量
+func countTaggedObjects(api *helper.API, tagIDs []string, objectType string) (map[string]int, int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), helper.APITimeout)
+	defer cancel()
+	m, err := GetManager(api, ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	var uniqueTagIDs []string
+	for _, ID := range tagIDs {
+		if !utils.SliceContain(uniqueTagIDs, ID) {
+			uniqueTagIDs = append(uniqueTagIDs, ID)
+		}
+	}
+	attached, err := m.ListAttachedObjectsOnTags(ctx, uniqueTagIDs)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询标签%s关联的对象时发生错误: %v", tagIDs, err)
+	}
+
+	counter := make(map[string]int)
+	for _, a := range attached {
+		// 同一标签下的对象只计数一次
+		seen := make(map[string]bool)
+		for _, o := range a.ObjectIDs {
+			ref := o.Reference()
+			if ref.Type != objectType || seen[ref.Value] {
+				continue
+			}
+			seen[ref.Value] = true
+			counter[ref.Value]++
+		}
+	}
+	return counter, len(uniqueTagIDs), nil
+}
@@ -0,0 +1,143 @@
+package tag
+
+import (
+	"context"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/simulator"
+	_ "github.com/vmware/govmomi/vapi/simulator"
+	"github.com/vmware/govmomi/vapi/tags"
+	"github.com/vmware/govmomi/vim25"
+	"os"
+	"testing"
+	"time"
+	"vsphere-facade/app/logging"
+	"vsphere-facade/config"
+	"vsphere-facade/helper"
+	"vsphere-facade/helper/customfield"
+	"vsphere-facade/helper/virtualmachine"
+)
+
+func setup(c *vim25.Client) *helper.API {
+	config.G.Server.Log.Path = os.TempDir()
+	config.G.Server.Log.Level = "error"
+	logging.Setup()
+	helper.APITimeout = time.Minute
+
+	client := &govmomi.Client{
+		Client:         c,
+		SessionManager: session.NewManager(c),
+	}
+	return helper.NewAPI(client, simulator.DefaultLogin)
+}
+
+func TestTags(t *testing.T) {
+	simulator.Test(func(ctx context.Context, c *vim25.Client) {
+		api := setup(c)
+
+		m, err := GetManager(api, ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		categoryID, err := m.CreateCategory(ctx, &tags.Category{
+			Name:            "owner",
+			Cardinality:     "MULTIPLE",
+			AssociableTypes: []string{virtualmachine.Type},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		teamA, err := m.CreateTag(ctx, &tags.Tag{Name: "team-a", CategoryID: categoryID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		teamB, err := m.CreateTag(ctx, &tags.Tag{Name: "team-b", CategoryID: categoryID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if categories := GetCategories(api); len(categories) != 1 {
+			t.Fatalf("期望1个标签分类，实际%d个", len(categories))
+		}
+		if tagList := GetTags(api, categoryID); len(tagList) != 2 {
+			t.Fatalf("期望2个标签，实际%d个", len(tagList))
+		}
+
+		vms, err := find.NewFinder(c).VirtualMachineList(ctx, "*")
+		if err != nil || len(vms) < 2 {
+			t.Fatal("模拟环境中的虚拟机不足", err)
+		}
+		vm0 := vms[0].Reference()
+		vm1 := vms[1].Reference()
+
+		if err = Attach(api, []string{teamA, teamB}, vm0); err != nil {
+			t.Fatal(err)
+		}
+		if err = Attach(api, []string{teamA}, vm1); err != nil {
+			t.Fatal(err)
+		}
+
+		objects, err := GetObjectsWithAllTags(api, []string{teamA, teamB}, virtualmachine.Type)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(objects) != 1 || !objects[vm0.Value] {
+			t.Fatalf("按标签过滤结果错误: %v", objects)
+		}
+
+		objects, err = GetObjectsWithAllTags(api, []string{teamA, teamA}, virtualmachine.Type)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(objects) != 2 {
+			t.Fatalf("按标签过滤结果错误: %v", objects)
+		}
+
+		if err = Detach(api, []string{teamB}, vm0); err != nil {
+			t.Fatal(err)
+		}
+		attached, err := GetAttachedTagIDs(api, vm0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(attached) != 1 || attached[0] != teamA {
+			t.Fatalf("移除标签后剩余标签错误: %v", attached)
+		}
+	})
+}
+
+func TestCustomFields(t *testing.T) {
+	simulator.Test(func(ctx context.Context, c *vim25.Client) {
+		api := setup(c)
+
+		m, err := customfield.GetManager(api)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = m.Add(ctx, "cost-center", virtualmachine.Type, nil, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		vms, err := find.NewFinder(c).VirtualMachineList(ctx, "*")
+		if err != nil || len(vms) == 0 {
+			t.Fatal("模拟环境中没有虚拟机", err)
+		}
+		ref := vms[0].Reference()
+
+		if err = customfield.Set(api, ref, map[string]string{"cost-center": "cc-001"}); err != nil {
+			t.Fatal(err)
+		}
+		if err = customfield.Set(api, ref, map[string]string{"not-defined": "x"}); err == nil {
+			t.Fatal("未定义的自定义属性应该返回错误")
+		}
+
+		moVM := virtualmachine.FindProps(vms[0], "customValue")
+		if moVM == nil || len(moVM.CustomValue) != 1 {
+			t.Fatal("自定义属性设置失败")
+		}
+		if len(customfield.GetFields(api)) != 1 {
+			t.Fatal("自定义属性定义查询结果错误")
+		}
+	})
+}
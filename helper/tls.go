@@ -0,0 +1,76 @@
+package helper
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"github.com/vmware/govmomi/vim25/soap"
+	"net/url"
+	"strings"
+	"vsphere-facade/app/logging"
+	"vsphere-facade/config"
+)
+
+// TLSConfig 返回VC地址对应的证书校验配置，hosts中没有匹配的地址时使用默认配置
+func TLSConfig(host string) config.VCenterTLS {
+	conf := config.G.Vsphere.TLS
+	for _, h := range conf.Hosts {
+		if strings.EqualFold(h.Host, host) {
+			return h
+		}
+	}
+	return conf.VCenterTLS
+}
+
+// newSoapClient 按配置校验VC的证书，登记的VC配置了tls时优先使用
+func newSoapClient(u *url.URL) (*soap.Client, error) {
+	t := TLSConfig(u.Hostname())
+	if v := FindVCenter(u.Host); v != nil && v.TLS != (config.VCenterTLS{}) {
+		t = v.TLS
+	}
+	if t.Thumbprint != "" {
+		// 只校验指纹，跳过证书链校验
+		sc := soap.NewClient(u, true)
+		sc.DefaultTransport().TLSClientConfig.VerifyPeerCertificate = verifyThumbprint(t.Thumbprint)
+		return sc, nil
+	}
+	if t.Insecure {
+		logging.L().Warnf("连接VC[%s]时不校验证书", u.Host)
+		return soap.NewClient(u, true), nil
+	}
+	sc := soap.NewClient(u, false)
+	if t.CAFile != "" {
+		if err := sc.SetRootCAs(t.CAFile); err != nil {
+			return nil, fmt.Errorf("读取VC[%s]的CA证书失败: %v", u.Host, err)
+		}
+	}
+	return sc, nil
+}
+
+// verifyThumbprint 按指纹长度区分SHA-1和SHA-256，忽略大小写和冒号
+func verifyThumbprint(thumbprint string) func([][]byte, [][]*x509.Certificate) error {
+	expected := normalizeThumbprint(thumbprint)
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return fmt.Errorf("VC没有提供证书")
+		}
+		var actual string
+		if len(expected) == sha1.Size*2 {
+			sum := sha1.Sum(rawCerts[0])
+			actual = hex.EncodeToString(sum[:])
+		} else {
+			sum := sha256.Sum256(rawCerts[0])
+			actual = hex.EncodeToString(sum[:])
+		}
+		if actual != expected {
+			return fmt.Errorf("VC证书指纹[%s]与配置的指纹不一致", actual)
+		}
+		return nil
+	}
+}
+
+func normalizeThumbprint(thumbprint string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(thumbprint), ":", ""))
+}
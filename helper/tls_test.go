@@ -0,0 +1,55 @@
+package helper
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"vsphere-facade/config"
+)
+
+func TestVerifyThumbprint(t *testing.T) {
+	s := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer s.Close()
+	raw := s.Certificate().Raw
+
+	colon := func(sum []byte) string {
+		var parts []string
+		for _, b := range sum {
+			parts = append(parts, fmt.Sprintf("%02X", b))
+		}
+		return strings.Join(parts, ":")
+	}
+	sha1Sum := sha1.Sum(raw)
+	sha256Sum := sha256.Sum256(raw)
+	for name, c := range map[string]struct {
+		thumbprint string
+		ok         bool
+	}{
+		"SHA-1":   {colon(sha1Sum[:]), true},
+		"SHA-256": {fmt.Sprintf("%x", sha256Sum), true},
+		"不一致":     {colon(sha256Sum[:len(sha256Sum)-1]) + ":00", false},
+	} {
+		err := verifyThumbprint(c.thumbprint)([][]byte{raw}, nil)
+		if (err == nil) != c.ok {
+			t.Errorf("%s: 校验结果不正确 %v", name, err)
+		}
+	}
+}
+
+func TestTLSConfig(t *testing.T) {
+	saved := config.G.Vsphere.TLS
+	defer func() { config.G.Vsphere.TLS = saved }()
+	config.G.Vsphere.TLS.Insecure = true
+	config.G.Vsphere.TLS.Hosts = []config.VCenterTLS{{Host: "vc1.example.com", Thumbprint: "AB"}}
+
+	if c := TLSConfig("VC1.example.com"); c.Thumbprint != "AB" || c.Insecure {
+		t.Error("应该使用地址匹配的配置", c)
+	}
+	if c := TLSConfig("vc2.example.com"); !c.Insecure {
+		t.Error("没有匹配的地址时应该使用默认配置", c)
+	}
+}
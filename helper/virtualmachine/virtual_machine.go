@@ -18,6 +18,7 @@ import (
 	"vsphere-facade/helper/clustercomputerresource"
 	"vsphere-facade/helper/computerresource"
 	"vsphere-facade/helper/datacenter"
+	"vsphere-facade/helper/disk"
 	"vsphere-facade/helper/folder"
 	"vsphere-facade/helper/hostsystem"
 	"vsphere-facade/helper/vsphere"
@@ -64,12 +65,19 @@ var virtualMachineProps = []string{
 	"config.tools.toolsInstallType",
 	"config.guestId",
 	"config.guestFullName",
+	"config.firmware",
+	"config.bootOptions",
 	"runtime.host",
 	"runtime.powerState",
 	"resourcePool",
 	"guest",
 }
 
+// InfoProps 构建虚拟机/模板信息时用到的属性
+func InfoProps() []string {
+	return append([]string(nil), virtualMachineProps...)
+}
+
 type OSInfo struct {
 	GuestID     string
 	GuestName   string
@@ -155,11 +163,37 @@ func GetDevices(oVM *object.VirtualMachine) object.VirtualDeviceList {
 
 func GetSysDisk(oVM *object.VirtualMachine) *types.VirtualDisk {
 	logging.L().Debug(fmt.Sprintf("获取虚拟机[%s(%s)]的系统盘信息", oVM.Name(), oVM.Reference().Value))
-	disks := GetDisks(oVM)
-	for _, disk := range disks {
-		d := disk.GetVirtualDevice()
-		if *d.UnitNumber == 0 {
-			return disk.(*types.VirtualDisk)
+	return FindSysDisk(GetDevices(oVM))
+}
+
+// FindSysDisk
+// 设备列表中第一个单元号为0的硬盘为系统盘，其他控制器上单元号为0的硬盘为数据盘
+func FindSysDisk(devices object.VirtualDeviceList) *types.VirtualDisk {
+	disks := devices.SelectByType((*types.VirtualDisk)(nil))
+	for _, d := range disks {
+		vd := d.(*types.VirtualDisk)
+		if vd.UnitNumber != nil && *vd.UnitNumber == 0 {
+			return vd
+		}
+	}
+	return nil
+}
+
+// FindDisk
+// 根据控制器类型、总线号和单元号查找硬盘
+func FindDisk(devices object.VirtualDeviceList, controllerType string, busNumber int32, unitNumber int32) *types.VirtualDisk {
+	disks := devices.SelectByType((*types.VirtualDisk)(nil))
+	for _, d := range disks {
+		vd := d.(*types.VirtualDisk)
+		if vd.UnitNumber == nil || *vd.UnitNumber != unitNumber {
+			continue
+		}
+		ctrl := devices.FindByKey(vd.ControllerKey)
+		if ctrl == nil || disk.GetControllerType(ctrl) != controllerType {
+			continue
+		}
+		if c, ok := ctrl.(types.BaseVirtualController); ok && c.GetVirtualController().BusNumber == busNumber {
+			return vd
 		}
 	}
 	return nil
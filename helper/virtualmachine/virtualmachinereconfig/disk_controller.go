@@ -0,0 +1,218 @@
+package virtualmachinereconfig
+
+import (
+	"fmt"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+	"sort"
+	"vsphere-facade/app/logging"
+	"vsphere-facade/app/utils"
+	"vsphere-facade/helper"
+	"vsphere-facade/helper/disk"
+)
+
+// controllerAllocator
+// 为新增硬盘分配控制器和单元号，已有控制器没有可用单元号时新增控制器
+type controllerAllocator struct {
+	api     *helper.API
+	devices object.VirtualDeviceList
+	change  *[]types.BaseVirtualDeviceConfigSpec
+}
+
+func newControllerAllocator(api *helper.API, devices object.VirtualDeviceList, change *[]types.BaseVirtualDeviceConfigSpec) *controllerAllocator {
+	// 复制一份设备列表，新增的控制器和硬盘只记录在分配器中
+	var copied object.VirtualDeviceList
+	copied = append(copied, devices...)
+	return &controllerAllocator{
+		api:     api,
+		devices: copied,
+		change:  change,
+	}
+}
+
+// assign
+// controllerType为空时使用虚拟机已有的硬盘控制器类型，busNumber为空时按总线号顺序查找可用的控制器
+func (a *controllerAllocator) assign(vd *types.VirtualDisk, controllerType *string, busNumber *int32) (types.BaseVirtualController, error) {
+	t, err := a.resolveType(controllerType)
+	if err != nil {
+		return nil, err
+	}
+
+	ctrl, unitNumber := a.find(t, busNumber)
+	if ctrl == nil {
+		ctrl, err = a.create(t, busNumber)
+		if err != nil {
+			return nil, err
+		}
+		unitNumber = a.freeUnitNumber(ctrl)
+	}
+	if unitNumber < 0 {
+		return nil, fmt.Errorf("%s控制器[总线%d]没有可用的单元号", t, ctrl.GetVirtualController().BusNumber)
+	}
+
+	d := vd.GetVirtualDevice()
+	d.ControllerKey = ctrl.GetVirtualController().Key
+	d.UnitNumber = &unitNumber
+	if d.Key == 0 {
+		d.Key = a.devices.NewKey()
+	}
+	a.devices = append(a.devices, vd)
+	return ctrl, nil
+}
+
+func (a *controllerAllocator) resolveType(controllerType *string) (string, error) {
+	if controllerType == nil || *controllerType == "" {
+		existing := a.controllers("")
+		if len(existing) > 0 {
+			return disk.GetControllerType(existing[0].(types.BaseVirtualDevice)), nil
+		}
+		return disk.ControllerTypeLsiLogic, nil
+	}
+
+	t := *controllerType
+	if !utils.SliceContain(disk.ControllerTypes, t) {
+		return "", fmt.Errorf("不支持的控制器类型[%s]，可选类型%v", t, disk.ControllerTypes)
+	}
+	if t == disk.ControllerTypeNVMe && !a.api.Newer(6, 5, 0) {
+		return "", fmt.Errorf("VC版本低于6.5.0，不支持%s控制器", t)
+	}
+	return t, nil
+}
+
+// controllers 按总线号排序的指定类型硬盘控制器，类型为空时返回所有SCSI、NVMe、SATA控制器
+func (a *controllerAllocator) controllers(controllerType string) []types.BaseVirtualController {
+	var controllers []types.BaseVirtualController
+	for _, device := range a.devices {
+		t := disk.GetControllerType(device)
+		if t == "" || t == disk.ControllerTypeIDE {
+			continue
+		}
+		if controllerType != "" && t != controllerType {
+			continue
+		}
+		controllers = append(controllers, device.(types.BaseVirtualController))
+	}
+	sort.SliceStable(controllers, func(i, j int) bool {
+		return controllers[i].GetVirtualController().BusNumber < controllers[j].GetVirtualController().BusNumber
+	})
+	return controllers
+}
+
+func (a *controllerAllocator) find(controllerType string, busNumber *int32) (types.BaseVirtualController, int32) {
+	for _, ctrl := range a.controllers(controllerType) {
+		if busNumber != nil && ctrl.GetVirtualController().BusNumber != *busNumber {
+			continue
+		}
+		unitNumber := a.freeUnitNumber(ctrl)
+		if unitNumber >= 0 {
+			return ctrl, unitNumber
+		}
+		if busNumber != nil {
+			// 指定的控制器已满，不再新建
+			return ctrl, -1
+		}
+	}
+	return nil, -1
+}
+
+func (a *controllerAllocator) freeUnitNumber(ctrl types.BaseVirtualController) int32 {
+	t := disk.GetControllerType(ctrl.(types.BaseVirtualDevice))
+	key := ctrl.GetVirtualController().Key
+
+	var used []int32
+	if disk.IsSCSIController(t) {
+		used = append(used, disk.SCSIControllerUnitNumber)
+	}
+	for _, device := range a.devices {
+		d := device.GetVirtualDevice()
+		if d.ControllerKey == key && d.UnitNumber != nil {
+			used = append(used, *d.UnitNumber)
+		}
+	}
+
+	for unitNumber := int32(0); unitNumber < disk.GetControllerUnitCount(t); unitNumber++ {
+		if !utils.SliceContain(used, unitNumber) {
+			return unitNumber
+		}
+	}
+	return -1
+}
+
+// busFamily 占用同一组总线号的控制器，SCSI类型的控制器共用总线号，其他类型按类型区分
+func (a *controllerAllocator) busFamily(controllerType string) []types.BaseVirtualController {
+	if !disk.IsSCSIController(controllerType) {
+		return a.controllers(controllerType)
+	}
+	var controllers []types.BaseVirtualController
+	for _, ctrl := range a.controllers("") {
+		if disk.IsSCSIController(disk.GetControllerType(ctrl.(types.BaseVirtualDevice))) {
+			controllers = append(controllers, ctrl)
+		}
+	}
+	return controllers
+}
+
+func (a *controllerAllocator) create(controllerType string, busNumber *int32) (types.BaseVirtualController, error) {
+	family := "SCSI"
+	if !disk.IsSCSIController(controllerType) {
+		family = controllerType
+	}
+	var usedBus []int32
+	for _, ctrl := range a.busFamily(controllerType) {
+		usedBus = append(usedBus, ctrl.GetVirtualController().BusNumber)
+	}
+	if len(usedBus) >= disk.ControllerMaxCount {
+		return nil, fmt.Errorf("%s控制器数量已达上限[%d]，无法新增硬盘", family, disk.ControllerMaxCount)
+	}
+
+	var bus int32 = -1
+	if busNumber != nil {
+		if *busNumber < 0 || *busNumber >= disk.ControllerMaxCount {
+			return nil, fmt.Errorf("无效的控制器总线号[%d]", *busNumber)
+		}
+		if utils.SliceContain(usedBus, *busNumber) {
+			return nil, fmt.Errorf("%s总线[%d]已被其他类型的控制器使用", family, *busNumber)
+		}
+		bus = *busNumber
+	} else {
+		for b := int32(0); b < disk.ControllerMaxCount; b++ {
+			if !utils.SliceContain(usedBus, b) {
+				bus = b
+				break
+			}
+		}
+	}
+
+	var device types.BaseVirtualDevice
+	switch {
+	case disk.IsSCSIController(controllerType):
+		scsi, err := a.devices.CreateSCSIController(controllerType)
+		if err != nil {
+			return nil, err
+		}
+		device = scsi
+	case controllerType == disk.ControllerTypeNVMe:
+		nvme, err := a.devices.CreateNVMEController()
+		if err != nil {
+			return nil, err
+		}
+		device = nvme
+	case controllerType == disk.ControllerTypeSATA:
+		sata := &types.VirtualAHCIController{}
+		sata.Key = a.devices.NewKey()
+		device = sata
+	default:
+		return nil, fmt.Errorf("不支持新增%s控制器", controllerType)
+	}
+
+	ctrl := device.(types.BaseVirtualController)
+	ctrl.GetVirtualController().BusNumber = bus
+	logging.L().Debugf("硬盘控制器没有可用的单元号，新增%s控制器[总线%d]", controllerType, bus)
+
+	*a.change = append(*a.change, &types.VirtualDeviceConfigSpec{
+		Device:    device,
+		Operation: types.VirtualDeviceConfigSpecOperationAdd,
+	})
+	a.devices = append(a.devices, device)
+	return ctrl, nil
+}
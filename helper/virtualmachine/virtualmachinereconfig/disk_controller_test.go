@@ -0,0 +1,77 @@
+package virtualmachinereconfig
+
+import (
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+	"os"
+	"testing"
+	"vsphere-facade/app/logging"
+	"vsphere-facade/config"
+	"vsphere-facade/helper/disk"
+)
+
+func scsiController(device types.BaseVirtualDevice, key, bus int32) types.BaseVirtualDevice {
+	ctrl := device.(types.BaseVirtualController).GetVirtualController()
+	ctrl.Key = key
+	ctrl.BusNumber = bus
+	return device
+}
+
+func TestControllerAllocator(t *testing.T) {
+	config.G.Server.Log.Path = os.TempDir()
+	config.G.Server.Log.Level = "error"
+	logging.Setup()
+
+	pvscsi := disk.ControllerTypePVSCSI
+	lsiLogic := disk.ControllerTypeLsiLogic
+	bus0, bus1 := int32(0), int32(1)
+
+	var change []types.BaseVirtualDeviceConfigSpec
+	devices := object.VirtualDeviceList{scsiController(&types.VirtualLsiLogicController{}, 1000, 0)}
+	a := newControllerAllocator(nil, devices, &change)
+
+	ctrl, err := a.assign(&types.VirtualDisk{}, nil, nil)
+	if err != nil || ctrl.GetVirtualController().Key != 1000 || len(change) != 0 {
+		t.Fatal("没有指定类型时应该使用已有的控制器", ctrl, err)
+	}
+
+	ctrl, err = a.assign(&types.VirtualDisk{}, &pvscsi, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ctrl.GetVirtualController().BusNumber != 1 || len(change) != 1 {
+		t.Error("不同类型的SCSI控制器不能使用同一个总线号", ctrl.GetVirtualController().BusNumber)
+	}
+	if _, err = a.assign(&types.VirtualDisk{}, &pvscsi, &bus0); err == nil {
+		t.Error("总线0已被LSI Logic控制器使用，不能新增PVSCSI控制器")
+	}
+	if ctrl, err = a.assign(&types.VirtualDisk{}, &pvscsi, &bus1); err != nil || ctrl.GetVirtualController().BusNumber != 1 {
+		t.Error("应该使用总线1上已有的PVSCSI控制器", err)
+	}
+
+	for _, bus := range []int32{2, 3} {
+		b := bus
+		if _, err = a.assign(&types.VirtualDisk{}, &lsiLogic, &b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	busLogic := disk.ControllerTypeBusLogic
+	if _, err = a.assign(&types.VirtualDisk{}, &busLogic, nil); err == nil {
+		t.Error("SCSI控制器总数不能超过4个")
+	}
+
+	full := object.VirtualDeviceList{
+		scsiController(&types.VirtualLsiLogicController{}, 1000, 0),
+		scsiController(&types.ParaVirtualSCSIController{}, 1001, 1),
+		scsiController(&types.VirtualLsiLogicSASController{}, 1002, 2),
+		scsiController(&types.ParaVirtualSCSIController{}, 1003, 3),
+	}
+	a = newControllerAllocator(nil, full, &change)
+	if _, err = a.create(busLogic, nil); err == nil {
+		t.Error("SCSI控制器总数不能超过4个")
+	}
+	nvme := disk.ControllerTypeNVMe
+	if _, err = a.create(nvme, nil); err != nil {
+		t.Error("NVMe控制器不受SCSI控制器数量限制", err)
+	}
+}
@@ -0,0 +1,144 @@
+package virtualmachinereconfig
+
+import (
+	"fmt"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+	"vsphere-facade/app/logging"
+	"vsphere-facade/app/utils"
+	"vsphere-facade/helper"
+	"vsphere-facade/helper/virtualmachine"
+)
+
+const (
+	FirmwareBIOS = string(types.GuestOsDescriptorFirmwareTypeBios)
+	FirmwareEFI  = string(types.GuestOsDescriptorFirmwareTypeEfi)
+)
+
+const (
+	BootDeviceDisk  = "disk"
+	BootDeviceNic   = "nic"
+	BootDeviceCdrom = "cdrom"
+)
+
+// bootDelayKey 引导延迟对应的高级参数
+const bootDelayKey = "bios.bootDelay"
+
+var Firmwares = []string{FirmwareBIOS, FirmwareEFI}
+
+var BootDevices = []string{BootDeviceDisk, BootDeviceNic, BootDeviceCdrom}
+
+type BootParameter struct {
+	// Firmware 固件类型：bios、efi
+	Firmware *string `json:"firmware,omitempty"`
+	// SecureBootEnabled 安全引导，仅efi固件可用
+	SecureBootEnabled *bool `json:"secureBootEnabled,omitempty"`
+	// BootDelay 引导延迟(毫秒)，0为取消延迟
+	BootDelay *int64 `json:"bootDelay,omitempty"`
+	// BootOrder 引导顺序：disk、nic、cdrom，空数组为清除引导顺序，序列化时保留空数组，保存的任务重新执行时不会丢失
+	BootOrder []string `json:"bootOrder"`
+	// EnterSetup 下次引导时进入BIOS/EFI设置
+	EnterSetup *bool `json:"enterSetup,omitempty"`
+}
+
+func parseReconfigureBoot(api *helper.API, oVM *object.VirtualMachine, p ReconfigureParameter, devices object.VirtualDeviceList, spec *types.VirtualMachineConfigSpec) error {
+	boot := p.Boot
+	if boot == nil {
+		return nil
+	}
+
+	firmware := ""
+	if boot.Firmware != nil {
+		if !utils.SliceContain(Firmwares, *boot.Firmware) {
+			return fmt.Errorf("不支持的固件类型[%s]，可选类型%v", *boot.Firmware, Firmwares)
+		}
+		firmware = *boot.Firmware
+		spec.Firmware = firmware
+	}
+
+	changed := false
+	bootOptions := types.VirtualMachineBootOptions{}
+	if boot.SecureBootEnabled != nil {
+		if !api.Newer(6, 5, 0) {
+			logging.L().Debug("跳过[安全引导]设置")
+		} else {
+			if *boot.SecureBootEnabled {
+				if firmware == "" {
+					moVM := virtualmachine.FindProps(oVM, "config.firmware")
+					if moVM != nil && moVM.Config != nil {
+						firmware = moVM.Config.Firmware
+					}
+				}
+				if firmware != FirmwareEFI {
+					return fmt.Errorf("安全引导仅支持%s固件", FirmwareEFI)
+				}
+			}
+			bootOptions.EfiSecureBootEnabled = boot.SecureBootEnabled
+			changed = true
+		}
+	}
+	if boot.BootDelay != nil {
+		if *boot.BootDelay < 0 {
+			return fmt.Errorf("无效的引导延迟[%d]", *boot.BootDelay)
+		}
+		if *boot.BootDelay == 0 {
+			// bootDelay为0时不会序列化到请求中，通过对应的高级参数清除
+			spec.ExtraConfig = append(spec.ExtraConfig, &types.OptionValue{Key: bootDelayKey, Value: "0"})
+		} else {
+			bootOptions.BootDelay = *boot.BootDelay
+			changed = true
+		}
+	}
+	if boot.EnterSetup != nil {
+		bootOptions.EnterBIOSSetup = boot.EnterSetup
+		changed = true
+	}
+	if boot.BootOrder != nil {
+		bootOrder, err := buildBootOrder(boot.BootOrder, devices)
+		if err != nil {
+			return err
+		}
+		bootOptions.BootOrder = bootOrder
+		changed = true
+	}
+
+	if changed {
+		spec.BootOptions = &bootOptions
+	}
+	return nil
+}
+
+// buildBootOrder
+// disk为系统盘，nic为所有网卡，cdrom为所有光驱。
+// order为空时返回一个空的引导设备，空数组不会序列化到请求中，VC收到空的引导设备时清除引导顺序
+func buildBootOrder(order []string, devices object.VirtualDeviceList) ([]types.BaseVirtualMachineBootOptionsBootableDevice, error) {
+	if len(order) == 0 {
+		return []types.BaseVirtualMachineBootOptionsBootableDevice{&types.VirtualMachineBootOptionsBootableDevice{}}, nil
+	}
+	var bootOrder []types.BaseVirtualMachineBootOptionsBootableDevice
+	for _, o := range order {
+		switch o {
+		case BootDeviceDisk:
+			sysDisk := virtualmachine.FindSysDisk(devices)
+			if sysDisk == nil {
+				return nil, fmt.Errorf("未找到系统盘，无法设置硬盘引导")
+			}
+			bootOrder = append(bootOrder, &types.VirtualMachineBootOptionsBootableDiskDevice{
+				DeviceKey: sysDisk.Key,
+			})
+		case BootDeviceNic:
+			for _, nic := range devices.SelectByType((*types.VirtualEthernetCard)(nil)) {
+				bootOrder = append(bootOrder, &types.VirtualMachineBootOptionsBootableEthernetDevice{
+					DeviceKey: nic.GetVirtualDevice().Key,
+				})
+			}
+		case BootDeviceCdrom:
+			if len(devices.SelectByType((*types.VirtualCdrom)(nil))) > 0 {
+				bootOrder = append(bootOrder, &types.VirtualMachineBootOptionsBootableCdromDevice{})
+			}
+		default:
+			return nil, fmt.Errorf("不支持的引导设备[%s]，可选设备%v", o, BootDevices)
+		}
+	}
+	return bootOrder, nil
+}
@@ -0,0 +1,137 @@
+package virtualmachinereconfig
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+	"os"
+	"testing"
+	"time"
+	"vsphere-facade/app/logging"
+	"vsphere-facade/config"
+	"vsphere-facade/helper"
+)
+
+// simulatorAPI apiVersion为模拟的VC版本
+func simulatorAPI(c *vim25.Client, apiVersion string) *helper.API {
+	config.G.Server.Log.Path = os.TempDir()
+	config.G.Server.Log.Level = "error"
+	logging.Setup()
+	helper.APITimeout = time.Minute
+
+	c.ServiceContent.About.ApiVersion = apiVersion
+	client := &govmomi.Client{
+		Client:         c,
+		SessionManager: session.NewManager(c),
+	}
+	return helper.NewAPI(client, simulator.DefaultLogin)
+}
+
+func bootDevices() object.VirtualDeviceList {
+	unit := int32(0)
+	disk := &types.VirtualDisk{}
+	disk.Key, disk.UnitNumber = 2000, &unit
+	nic := &types.VirtualE1000{}
+	nic.Key = 4000
+	cdrom := &types.VirtualCdrom{}
+	cdrom.Key = 3000
+	return object.VirtualDeviceList{disk, nic, cdrom}
+}
+
+func TestParseReconfigureBoot(t *testing.T) {
+	simulator.Test(func(ctx context.Context, c *vim25.Client) {
+		api := simulatorAPI(c, "7.0.3")
+		vms, err := find.NewFinder(c).VirtualMachineList(ctx, "*")
+		if err != nil {
+			t.Fatal(err)
+		}
+		oVM := vms[0]
+		devices := bootDevices()
+		parse := func(boot *BootParameter) (types.VirtualMachineConfigSpec, error) {
+			spec := types.VirtualMachineConfigSpec{}
+			err := parseReconfigureBoot(api, oVM, ReconfigureParameter{Boot: boot}, devices, &spec)
+			return spec, err
+		}
+
+		if spec, err := parse(nil); err != nil || spec.BootOptions != nil || spec.Firmware != "" {
+			t.Error("没有引导参数时不修改", spec, err)
+		}
+
+		unknown, efi, enabled := "uefi", FirmwareEFI, true
+		if _, err = parse(&BootParameter{Firmware: &unknown}); err == nil {
+			t.Error("不支持的固件类型应该返回错误")
+		}
+		if _, err = parse(&BootParameter{SecureBootEnabled: &enabled}); err == nil {
+			t.Error("虚拟机不是efi固件时不能开启安全引导")
+		}
+		spec, err := parse(&BootParameter{Firmware: &efi, SecureBootEnabled: &enabled})
+		if err != nil || spec.Firmware != FirmwareEFI || spec.BootOptions == nil || !*spec.BootOptions.EfiSecureBootEnabled {
+			t.Error("同时修改为efi固件时可以开启安全引导", spec, err)
+		}
+
+		negative, zero, delay := int64(-1), int64(0), int64(3000)
+		if _, err = parse(&BootParameter{BootDelay: &negative}); err == nil {
+			t.Error("引导延迟小于0应该返回错误")
+		}
+		spec, err = parse(&BootParameter{BootDelay: &delay})
+		if err != nil || spec.BootOptions == nil || spec.BootOptions.BootDelay != delay {
+			t.Error(spec, err)
+		}
+		spec, err = parse(&BootParameter{BootDelay: &zero})
+		if err != nil || len(spec.ExtraConfig) != 1 || spec.ExtraConfig[0].GetOptionValue().Key != bootDelayKey || spec.ExtraConfig[0].GetOptionValue().Value != "0" {
+			t.Error("引导延迟为0时通过高级参数清除", spec.ExtraConfig, err)
+		}
+
+		spec, err = parse(&BootParameter{BootOrder: []string{BootDeviceCdrom, BootDeviceNic, BootDeviceDisk}})
+		if err != nil || spec.BootOptions == nil || len(spec.BootOptions.BootOrder) != 3 {
+			t.Fatal(spec, err)
+		}
+		if _, ok := spec.BootOptions.BootOrder[0].(*types.VirtualMachineBootOptionsBootableCdromDevice); !ok {
+			t.Error("按指定的顺序引导", spec.BootOptions.BootOrder)
+		}
+		if disk, ok := spec.BootOptions.BootOrder[2].(*types.VirtualMachineBootOptionsBootableDiskDevice); !ok || disk.DeviceKey != 2000 {
+			t.Error("硬盘引导使用系统盘", spec.BootOptions.BootOrder)
+		}
+		spec, err = parse(&BootParameter{BootOrder: []string{}})
+		if err != nil || spec.BootOptions == nil || len(spec.BootOptions.BootOrder) != 1 {
+			t.Fatal("空的引导顺序应该清除引导顺序", spec, err)
+		}
+		if _, ok := spec.BootOptions.BootOrder[0].(*types.VirtualMachineBootOptionsBootableDevice); !ok {
+			t.Error(spec.BootOptions.BootOrder)
+		}
+		if _, err = parse(&BootParameter{BootOrder: []string{"floppy"}}); err == nil {
+			t.Error("不支持的引导设备应该返回错误")
+		}
+		devices = devices[1:]
+		if _, err = parse(&BootParameter{BootOrder: []string{BootDeviceDisk}}); err == nil {
+			t.Error("没有系统盘时不能设置硬盘引导")
+		}
+
+		api = simulatorAPI(c, "6.0")
+		if spec, err = parse(&BootParameter{SecureBootEnabled: &enabled}); err != nil || spec.BootOptions != nil {
+			t.Error("VC版本低于6.5时跳过安全引导", spec, err)
+		}
+	})
+}
+
+func TestBootParameterJSON(t *testing.T) {
+	// 任务参数保存后重新执行时，空的引导顺序仍然清除引导顺序
+	data, err := json.Marshal(BootParameter{BootOrder: []string{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	boot := BootParameter{}
+	if err = json.Unmarshal(data, &boot); err != nil || boot.BootOrder == nil {
+		t.Fatal("空的引导顺序序列化后应该保留", string(data), err)
+	}
+	boot = BootParameter{}
+	if err = json.Unmarshal([]byte(`{}`), &boot); err != nil || boot.BootOrder != nil {
+		t.Error("没有指定引导顺序时不修改", err)
+	}
+}
@@ -0,0 +1,82 @@
+package virtualmachinereconfig
+
+import (
+	"fmt"
+	"github.com/vmware/govmomi/vim25/types"
+	"sort"
+	"strings"
+	"vsphere-facade/config"
+)
+
+// CheckExtraConfig
+// 按配置中的allow/deny列表校验key，deny优先，allow为空时允许所有未被deny的key
+func CheckExtraConfig(extraConfig map[string]string) error {
+	var denied []string
+	for key := range extraConfig {
+		if !ExtraConfigAllowed(key) {
+			denied = append(denied, key)
+		}
+	}
+	if len(denied) > 0 {
+		sort.Strings(denied)
+		return fmt.Errorf("不允许设置的高级参数%v", denied)
+	}
+	return nil
+}
+
+// ExtraConfigAllowed
+// 读取和修改高级参数都使用该规则。deny优先；allow为空时不限制，允许所有未被deny的key，需要限制时应该配置allow
+func ExtraConfigAllowed(key string) bool {
+	if key == "" {
+		return false
+	}
+	c := config.G.Vsphere.ExtraConfig
+	if matchExtraConfigKey(c.Deny, key) {
+		return false
+	}
+	if len(c.Allow) == 0 {
+		return true
+	}
+	return matchExtraConfigKey(c.Allow, key)
+}
+
+// matchExtraConfigKey 以*结尾的规则按前缀匹配，其他规则完全匹配，VC的高级参数不区分大小写
+func matchExtraConfigKey(patterns []string, key string) bool {
+	key = strings.ToLower(key)
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		if strings.HasSuffix(pattern, "*") {
+			if strings.HasPrefix(key, strings.TrimSuffix(pattern, "*")) {
+				return true
+			}
+		} else if pattern == key {
+			return true
+		}
+	}
+	return false
+}
+
+// parseExtraConfig
+// value为空时VC会删除该参数
+func parseExtraConfig(p ReconfigureParameter, spec *types.VirtualMachineConfigSpec) error {
+	if len(p.ExtraConfig) == 0 {
+		return nil
+	}
+	err := CheckExtraConfig(p.ExtraConfig)
+	if err != nil {
+		return err
+	}
+
+	var keys []string
+	for key := range p.ExtraConfig {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		spec.ExtraConfig = append(spec.ExtraConfig, &types.OptionValue{
+			Key:   key,
+			Value: p.ExtraConfig[key],
+		})
+	}
+	return nil
+}
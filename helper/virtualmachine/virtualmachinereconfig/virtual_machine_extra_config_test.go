@@ -0,0 +1,34 @@
+package virtualmachinereconfig
+
+import (
+	"testing"
+	"vsphere-facade/config"
+)
+
+func TestExtraConfigAllowed(t *testing.T) {
+	saved := config.G.Vsphere.ExtraConfig
+	defer func() { config.G.Vsphere.ExtraConfig = saved }()
+	config.G.Vsphere.ExtraConfig.Allow = []string{"disk.EnableUUID", "guestinfo.*"}
+	config.G.Vsphere.ExtraConfig.Deny = []string{"guestinfo.Secret*"}
+
+	for key, allowed := range map[string]bool{
+		"disk.EnableUUID":    true,
+		"DISK.enableuuid":    true,
+		"guestinfo.metadata": true,
+		"GuestInfo.userdata": true,
+		"guestinfo.secret":   false,
+		"GUESTINFO.SECRETS":  false,
+		"isolation.tools":    false,
+		"disk.EnableUUID2":   false,
+		"":                   false,
+	} {
+		if ExtraConfigAllowed(key) != allowed {
+			t.Errorf("高级参数[%s]应该%v", key, allowed)
+		}
+	}
+
+	config.G.Vsphere.ExtraConfig.Allow = nil
+	if !ExtraConfigAllowed("isolation.tools") || ExtraConfigAllowed("GuestInfo.SecretKey") {
+		t.Error("allow为空时允许所有未被deny的key")
+	}
+}
@@ -25,6 +25,12 @@ type ReconfigureParameter struct {
 	Disk   *DiskParameter   `json:"disk,omitempty"`
 	Nic    *NicParameter    `json:"nic,omitempty"`
 	Flag   *FlagParameter   `json:"flag,omitempty"`
+	Boot   *BootParameter   `json:"boot,omitempty"`
+
+	VTpm       *VTpmParameter       `json:"vtpm,omitempty"`
+	Encryption *EncryptionParameter `json:"encryption,omitempty"`
+
+	ExtraConfig map[string]string `json:"extraConfig,omitempty"`
 
 	reboot bool
 }
@@ -70,6 +76,10 @@ type AddDiskParameter struct {
 	Mode            *string
 	StoragePolicyID *string
 	Sharing         *string
+	// ControllerType 控制器类型，为空时使用虚拟机已有的硬盘控制器
+	ControllerType *string
+	// BusNumber 控制器总线号，为空时自动选择
+	BusNumber *int32
 
 	UnitNumber *int32 `json:"-"`
 }
@@ -157,6 +167,11 @@ func Reconfigure(api *helper.API, ID string, p *ReconfigureParameter) (*object.V
 		return nil, fmt.Errorf(fmt.Sprintf("修改虚拟机[%s]配置失败: %s", ID, err))
 	}
 
+	err = parseExtraConfig(*p, &spec)
+	if err != nil {
+		return nil, fmt.Errorf(fmt.Sprintf("修改虚拟机[%s]配置失败: %s", ID, err))
+	}
+
 	devices := virtualmachine.GetDevices(oVM)
 	if devices == nil {
 		return nil, fmt.Errorf(fmt.Sprintf("未获取到虚拟机[%s]的设备列表", ID))
@@ -172,9 +187,24 @@ func Reconfigure(api *helper.API, ID string, p *ReconfigureParameter) (*object.V
 		return nil, fmt.Errorf(fmt.Sprintf("修改虚拟机[%s]配置失败: %s", ID, err))
 	}
 
+	err = parseReconfigureBoot(api, oVM, *p, devices, &spec)
+	if err != nil {
+		return nil, fmt.Errorf(fmt.Sprintf("修改虚拟机[%s]配置失败: %s", ID, err))
+	}
+
+	err = parseReconfigureVTpm(api, oVM, *p, devices, &deviceChange)
+	if err != nil {
+		return nil, fmt.Errorf(fmt.Sprintf("修改虚拟机[%s]配置失败: %s", ID, err))
+	}
+
 	if len(deviceChange) > 0 {
 		spec.DeviceChange = deviceChange
 	}
+
+	err = parseReconfigureEncryption(api, *p, devices, &spec)
+	if err != nil {
+		return nil, fmt.Errorf(fmt.Sprintf("修改虚拟机[%s]配置失败: %s", ID, err))
+	}
 	err = waitReconfigureTask(oVM, spec)
 	if err != nil {
 		return nil, fmt.Errorf(fmt.Sprintf("修改虚拟机[%s]配置失败: %s", ID, err))
@@ -351,9 +381,10 @@ func removeDisk(removeDisks []int32, devices object.VirtualDeviceList, change *[
 		removeKeys = append(removeKeys, key)
 	}
 
+	sysDisk := virtualmachine.FindSysDisk(devices)
 	disks := devices.SelectByType((*types.VirtualDisk)(nil))
 	for _, d := range disks {
-		if *d.GetVirtualDevice().UnitNumber == int32(0) {
+		if sysDisk != nil && d.GetVirtualDevice().Key == sysDisk.Key {
 			// 系统盘不可移除，跳过
 			continue
 		}
@@ -373,13 +404,8 @@ func addDisk(api *helper.API, newDisks []*AddDiskParameter, devices object.Virtu
 	if len(newDisks) == 0 {
 		return nil
 	}
-	ctrl, err := devices.FindDiskController("")
-	if err != nil {
-		return fmt.Errorf("获取控制器失败: %v", err)
-	}
-
-	newUnitNums := generateUnitNum(devices, "disk", len(newDisks))
-	for i, nd := range newDisks {
+	allocator := newControllerAllocator(api, devices, change)
+	for _, nd := range newDisks {
 		vd := types.VirtualDisk{}
 		size := int64(nd.Size) * int64(1024) * int64(1024)
 		vd.CapacityInKB = size
@@ -400,7 +426,15 @@ func addDisk(api *helper.API, newDisks []*AddDiskParameter, devices object.Virtu
 			logging.L().Debug("跳过[Sharing]设置")
 		}
 		vd.Backing = &backing
-		vd.UnitNumber = &newUnitNums[i]
+		ctrl, err := allocator.assign(&vd, nd.ControllerType, nd.BusNumber)
+		if err != nil {
+			return fmt.Errorf("分配硬盘控制器失败: %v", err)
+		}
+		// 回填实际使用的控制器，便于修改完成后定位新增的硬盘
+		controllerType := disk.GetControllerType(ctrl.(types.BaseVirtualDevice))
+		busNumber := ctrl.GetVirtualController().BusNumber
+		nd.ControllerType = &controllerType
+		nd.BusNumber = &busNumber
 		nd.UnitNumber = vd.UnitNumber
 		configSpec := &types.VirtualDeviceConfigSpec{
 			Device:        &vd,
@@ -414,7 +448,6 @@ func addDisk(api *helper.API, newDisks []*AddDiskParameter, devices object.Virtu
 				},
 			}
 		}
-		devices.AssignController(&vd, ctrl)
 		*change = append(*change, configSpec)
 	}
 	return nil
@@ -744,10 +777,6 @@ func generateUnitNum(devices object.VirtualDeviceList, deviceType string, cnt in
 
 	var startNn int32
 	switch deviceType {
-	case "disk":
-		startNn = 1
-		// 保留7
-		usedNu = append(usedNu, 7)
 	case "nic":
 		startNn = 7
 	}
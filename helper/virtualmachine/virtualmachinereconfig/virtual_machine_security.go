@@ -0,0 +1,146 @@
+package virtualmachinereconfig
+
+import (
+	"context"
+	"fmt"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+	"vsphere-facade/app/logging"
+	"vsphere-facade/helper"
+	"vsphere-facade/helper/spbm"
+	"vsphere-facade/helper/virtualmachine"
+	"vsphere-facade/helper/vsphere"
+)
+
+type VTpmParameter struct {
+	// Enabled true添加vTPM设备，false移除vTPM设备
+	Enabled bool `json:"enabled"`
+}
+
+type EncryptionParameter struct {
+	// StoragePolicyID 加密存储策略，同时应用到虚拟机主目录和所有硬盘
+	StoragePolicyID string `json:"storagePolicyId" valid:"Required"`
+}
+
+func parseReconfigureVTpm(api *helper.API, oVM *object.VirtualMachine, p ReconfigureParameter, devices object.VirtualDeviceList, change *[]types.BaseVirtualDeviceConfigSpec) error {
+	if p.VTpm == nil {
+		return nil
+	}
+
+	tpms := devices.SelectByType((*types.VirtualTPM)(nil))
+	if !p.VTpm.Enabled {
+		for _, tpm := range tpms {
+			*change = append(*change, &types.VirtualDeviceConfigSpec{
+				Device:    tpm,
+				Operation: types.VirtualDeviceConfigSpecOperationRemove,
+			})
+		}
+		return nil
+	}
+
+	if len(tpms) > 0 {
+		logging.L().Debug("虚拟机已存在vTPM设备，跳过添加")
+		return nil
+	}
+	if !api.Newer(6, 7, 0) {
+		return fmt.Errorf("VC版本低于6.7.0，不支持vTPM设备")
+	}
+
+	firmware := ""
+	if p.Boot != nil && p.Boot.Firmware != nil {
+		firmware = *p.Boot.Firmware
+	} else {
+		moVM := virtualmachine.FindProps(oVM, "config.firmware")
+		if moVM != nil && moVM.Config != nil {
+			firmware = moVM.Config.Firmware
+		}
+	}
+	if firmware != FirmwareEFI {
+		return fmt.Errorf("vTPM设备仅支持%s固件", FirmwareEFI)
+	}
+
+	err := checkKeyProvider(api)
+	if err != nil {
+		return fmt.Errorf("无法添加vTPM设备: %v", err)
+	}
+
+	tpm := &types.VirtualTPM{}
+	tpm.Key = devices.NewKey()
+	*change = append(*change, &types.VirtualDeviceConfigSpec{
+		Device:    tpm,
+		Operation: types.VirtualDeviceConfigSpecOperationAdd,
+	})
+	return nil
+}
+
+// parseReconfigureEncryption
+// 加密策略应用到虚拟机主目录和所有硬盘，已在本次修改中的硬盘直接替换存储策略
+func parseReconfigureEncryption(api *helper.API, p ReconfigureParameter, devices object.VirtualDeviceList, spec *types.VirtualMachineConfigSpec) error {
+	if p.Encryption == nil {
+		return nil
+	}
+	if !api.Newer(6, 5, 0) {
+		return fmt.Errorf("VC版本低于6.5.0，不支持虚拟机加密")
+	}
+
+	err := checkKeyProvider(api)
+	if err != nil {
+		return fmt.Errorf("无法加密虚拟机: %v", err)
+	}
+
+	policyID := p.Encryption.StoragePolicyID
+	ctx, cancel := context.WithTimeout(context.Background(), helper.APITimeout)
+	defer cancel()
+	pc := vsphere.GetPbmClient(api, ctx)
+	if pc == nil {
+		return fmt.Errorf("创建pbm客户端失败")
+	}
+	isEncryption, err := spbm.IsEncryptionPolicy(pc, policyID)
+	if err != nil {
+		return err
+	}
+	if !isEncryption {
+		return fmt.Errorf("存储策略[%s]不是加密策略", policyID)
+	}
+
+	profile := []types.BaseVirtualMachineProfileSpec{
+		&types.VirtualMachineDefinedProfileSpec{
+			ProfileId: policyID,
+		},
+	}
+	spec.VmProfile = profile
+
+	changed := make(map[int32]bool)
+	for _, c := range spec.DeviceChange {
+		deviceSpec := c.GetVirtualDeviceConfigSpec()
+		if _, ok := deviceSpec.Device.(*types.VirtualDisk); !ok {
+			continue
+		}
+		changed[deviceSpec.Device.GetVirtualDevice().Key] = true
+		if deviceSpec.Operation != types.VirtualDeviceConfigSpecOperationRemove {
+			deviceSpec.Profile = profile
+		}
+	}
+	for _, d := range devices.SelectByType((*types.VirtualDisk)(nil)) {
+		if changed[d.GetVirtualDevice().Key] {
+			continue
+		}
+		spec.DeviceChange = append(spec.DeviceChange, &types.VirtualDeviceConfigSpec{
+			Device:    d,
+			Operation: types.VirtualDeviceConfigSpecOperationEdit,
+			Profile:   profile,
+		})
+	}
+	return nil
+}
+
+func checkKeyProvider(api *helper.API) error {
+	providers, err := vsphere.GetKeyProviders(api)
+	if err != nil {
+		return err
+	}
+	if len(providers) == 0 {
+		return fmt.Errorf("VC未配置密钥提供程序")
+	}
+	return nil
+}
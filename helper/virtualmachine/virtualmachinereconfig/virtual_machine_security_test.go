@@ -0,0 +1,76 @@
+package virtualmachinereconfig
+
+import (
+	"context"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+	"strings"
+	"testing"
+)
+
+func TestParseReconfigureVTpm(t *testing.T) {
+	simulator.Test(func(ctx context.Context, c *vim25.Client) {
+		api := simulatorAPI(c, "7.0.3")
+		vms, err := find.NewFinder(c).VirtualMachineList(ctx, "*")
+		if err != nil {
+			t.Fatal(err)
+		}
+		oVM := vms[0]
+		tpm := &types.VirtualTPM{}
+		tpm.Key = 11000
+		parse := func(p ReconfigureParameter, devices object.VirtualDeviceList) ([]types.BaseVirtualDeviceConfigSpec, error) {
+			var change []types.BaseVirtualDeviceConfigSpec
+			err := parseReconfigureVTpm(api, oVM, p, devices, &change)
+			return change, err
+		}
+
+		if change, err := parse(ReconfigureParameter{}, nil); err != nil || len(change) != 0 {
+			t.Error("没有vTPM参数时不修改", change, err)
+		}
+		change, err := parse(ReconfigureParameter{VTpm: &VTpmParameter{Enabled: false}}, object.VirtualDeviceList{tpm})
+		if err != nil || len(change) != 1 || change[0].GetVirtualDeviceConfigSpec().Operation != types.VirtualDeviceConfigSpecOperationRemove {
+			t.Error("移除已有的vTPM设备", change, err)
+		}
+		if change, err = parse(ReconfigureParameter{VTpm: &VTpmParameter{Enabled: true}}, object.VirtualDeviceList{tpm}); err != nil || len(change) != 0 {
+			t.Error("已有vTPM设备时跳过添加", change, err)
+		}
+
+		bios, efi := FirmwareBIOS, FirmwareEFI
+		if _, err = parse(ReconfigureParameter{VTpm: &VTpmParameter{Enabled: true}, Boot: &BootParameter{Firmware: &bios}}, nil); err == nil {
+			t.Error("bios固件不能添加vTPM设备")
+		}
+		if _, err = parse(ReconfigureParameter{VTpm: &VTpmParameter{Enabled: true}}, nil); err == nil {
+			t.Error("虚拟机不是efi固件时不能添加vTPM设备")
+		}
+		if _, err = parse(ReconfigureParameter{VTpm: &VTpmParameter{Enabled: true}, Boot: &BootParameter{Firmware: &efi}}, nil); err == nil || !strings.HasPrefix(err.Error(), "无法添加vTPM设备") {
+			t.Error("VC没有配置密钥提供程序时不能添加vTPM设备", err)
+		}
+
+		api = simulatorAPI(c, "6.5")
+		if _, err = parse(ReconfigureParameter{VTpm: &VTpmParameter{Enabled: true}, Boot: &BootParameter{Firmware: &efi}}, nil); err == nil {
+			t.Error("VC版本低于6.7时不支持vTPM设备")
+		}
+	})
+}
+
+func TestParseReconfigureEncryption(t *testing.T) {
+	simulator.Test(func(ctx context.Context, c *vim25.Client) {
+		spec := types.VirtualMachineConfigSpec{}
+		api := simulatorAPI(c, "7.0.3")
+		if err := parseReconfigureEncryption(api, ReconfigureParameter{}, nil, &spec); err != nil || spec.VmProfile != nil {
+			t.Error("没有加密参数时不修改", spec, err)
+		}
+
+		p := ReconfigureParameter{Encryption: &EncryptionParameter{StoragePolicyID: "policy-1"}}
+		if err := parseReconfigureEncryption(api, p, nil, &spec); err == nil || !strings.HasPrefix(err.Error(), "无法加密虚拟机") || spec.VmProfile != nil {
+			t.Error("VC没有配置密钥提供程序时不能加密", err)
+		}
+		api = simulatorAPI(c, "6.0")
+		if err := parseReconfigureEncryption(api, p, nil, &spec); err == nil {
+			t.Error("VC版本低于6.5时不支持加密")
+		}
+	})
+}
@@ -77,3 +77,21 @@ func FindParentPathByType(api *helper.API, id, oType, parentType string) []types
 	parents = append(parents, findParents...)
 	return parents
 }
+
+// GetKeyProviders
+// 查询VC中配置的密钥提供程序，VC不支持加密时返回空
+func GetKeyProviders(api *helper.API) ([]types.KmipClusterInfo, error) {
+	ref := api.Client.ServiceContent.CryptoManager
+	if ref == nil {
+		return nil, nil
+	}
+
+	var m mo.CryptoManagerKmip
+	ctx, cancel := context.WithTimeout(context.Background(), helper.APITimeout)
+	defer cancel()
+	err := property.DefaultCollector(api.Client.Client).RetrieveOne(ctx, *ref, []string{"kmipServers"}, &m)
+	if err != nil {
+		return nil, fmt.Errorf("查询密钥提供程序时发生错误: %v", err)
+	}
+	return m.KmipServers, nil
+}
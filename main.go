@@ -1,27 +1,42 @@
 package main
 
 import (
-	"fmt"
+	"flag"
 	"github.com/gin-gonic/gin"
+	"vsphere-facade/api/audit"
 	"vsphere-facade/api/router"
 	"vsphere-facade/api/security"
+	"vsphere-facade/api/security/identity"
 	"vsphere-facade/app/cache"
 	"vsphere-facade/app/logging"
+	"vsphere-facade/app/tracing"
+	"vsphere-facade/app/utils"
 	"vsphere-facade/config"
 	"vsphere-facade/db"
 	"vsphere-facade/helper"
 	"vsphere-facade/startup"
+	"vsphere-facade/vsphere"
 	vCache "vsphere-facade/vsphere/cache"
 )
 
 func init() {
 	config.Setup()
 	logging.Setup()
+	if flag.Arg(0) == rotateKeyCommand {
+		rotateKey()
+	}
+	utils.SetupKeys()
+	tracing.Setup()
 	security.Setup()
 	helper.Setup()
 	cache.Setup()
 	vCache.Setup()
 	db.Setup()
+	identity.CheckKeys()
+	audit.Setup()
+	if config.G.Server.Metrics.Enable && config.G.Server.Metrics.Inventory {
+		vsphere.RegisterInventoryMetrics()
+	}
 }
 
 // @title        vSphere Facade
@@ -36,11 +51,14 @@ func init() {
 // @name                        token
 func main() {
 	defer logging.Sync()
+	defer tracing.Shutdown()
 	gin.SetMode(config.G.Server.Mode)
 	r := router.InitRouter()
 	initSwagger(r)
 	go startup.Run()
-	_ = r.Run(fmt.Sprintf(":%d", config.G.Server.Port))
+	if err := serve(r); err != nil {
+		logging.L().Fatal("启动服务失败: ", err)
+	}
 }
 
 var swagHandler gin.HandlerFunc
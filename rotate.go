@@ -0,0 +1,84 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"vsphere-facade/api/security/identity"
+	"vsphere-facade/app/logging"
+	"vsphere-facade/app/utils"
+	"vsphere-facade/config"
+	"vsphere-facade/db"
+	"vsphere-facade/db/badgerdb"
+)
+
+// rotateKeyCommand 轮换加密密钥: vsphere-facade -config <配置目录> rotate-key [reencrypt]
+const rotateKeyCommand = "rotate-key"
+
+// reencryptCommand 密钥来自环境变量或配置时，更新配置后使用当前密钥重新加密保存的账号密码
+const reencryptCommand = "reencrypt"
+
+// rotateKey
+// 生成新的当前密钥，原来的密钥保留用于解密已经发放的令牌，并使用新密钥重新加密保存的账号密码。
+// 配置了密钥文件并且没有使用环境变量时写入文件，确认写入成功后再重新加密。
+// 否则只输出新的密钥列表，不重新加密，避免新密钥没有保存时账号密码无法解密，
+// 需要手动更新配置或环境变量后执行rotate-key reencrypt。
+// badger DB不能同时被多个进程打开，需要先停止服务
+func rotateKey() {
+	defer logging.Sync()
+	loaded, source, err := utils.LoadKeys()
+	if err != nil {
+		logging.L().Fatal("读取加密密钥失败", err)
+	}
+	if flag.Arg(1) == reencryptCommand {
+		if len(loaded) == 0 {
+			logging.L().Fatal("没有配置加密密钥，不能重新加密")
+		}
+		reencrypt(loaded)
+		return
+	}
+
+	key, err := utils.NewKey()
+	if err != nil {
+		logging.L().Fatal("生成加密密钥失败", err)
+	}
+	keys := append([]utils.Key{key}, loaded...)
+
+	file := config.G.App.Crypto.KeyFile
+	if file == "" || os.Getenv(utils.EnvKeys) != "" {
+		logging.L().Warnf("原密钥来自%s，请将以下密钥按顺序更新到配置或环境变量%s，然后执行%s %s重新加密保存的账号密码",
+			source, utils.EnvKeys, rotateKeyCommand, reencryptCommand)
+		for _, k := range keys {
+			fmt.Println(k.String())
+		}
+		os.Exit(0)
+	}
+
+	err = utils.WriteKeyFile(file, keys)
+	if err != nil {
+		logging.L().Fatal("写入密钥文件失败", err)
+	}
+	saved, err := utils.ReadKeyFile(file)
+	if err != nil || len(saved) == 0 || saved[0].ID != key.ID {
+		logging.L().Fatal("确认密钥文件失败，没有重新加密账号密码", err)
+	}
+	logging.L().Infof("新的加密密钥[%s]已写入%s", key.ID, file)
+	reencrypt(saved)
+}
+
+// reencrypt 使用keys中的第一个密钥重新加密保存的账号密码
+func reencrypt(keys []utils.Key) {
+	err := utils.SetKeys(keys)
+	if err != nil {
+		logging.L().Fatal("加密密钥无效", err)
+	}
+	db.Setup()
+	if badgerdb.Enabled() {
+		n, err := identity.Reencrypt()
+		if err != nil {
+			logging.L().Fatal("重新加密账号密码失败", err)
+		}
+		logging.L().Infof("已使用密钥[%s]重新加密%d个账号密码", keys[0].ID, n)
+	}
+	os.Exit(0)
+}
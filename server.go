@@ -0,0 +1,82 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"os"
+	"vsphere-facade/app/logging"
+	"vsphere-facade/config"
+)
+
+// serve 开启TLS时使用HTTPS，否则使用HTTP
+func serve(r *gin.Engine) error {
+	addr := fmt.Sprintf(":%d", config.G.Server.Port)
+	if !config.G.Server.TLS.Enable {
+		return r.Run(addr)
+	}
+	tlsConfig, err := serverTLSConfig()
+	if err != nil {
+		return err
+	}
+	server := &http.Server{
+		Addr:      addr,
+		Handler:   r,
+		TLSConfig: tlsConfig,
+	}
+	logging.L().Infof("HTTPS服务监听%s", addr)
+	return server.ListenAndServeTLS("", "")
+}
+
+// serverTLSConfig API服务的TLS配置，配置了clientCaFile时校验客户端证书
+func serverTLSConfig() (*tls.Config, error) {
+	conf := config.G.Server.TLS
+	cert, err := tls.LoadX509KeyPair(conf.CertFile, conf.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("读取服务端证书失败: %v", err)
+	}
+	tlsConfig := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+	}
+
+	clientAuth := conf.ClientAuth
+	if clientAuth == "" && conf.ClientCAFile != "" {
+		clientAuth = "verify"
+	}
+	switch clientAuth {
+	case "", "none":
+		tlsConfig.ClientAuth = tls.NoClientCert
+	case "request":
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	case "verify":
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return nil, fmt.Errorf("不支持的客户端认证方式[%s]", clientAuth)
+	}
+	if tlsConfig.ClientAuth == tls.VerifyClientCertIfGiven || tlsConfig.ClientAuth == tls.RequireAndVerifyClientCert {
+		if conf.ClientCAFile == "" {
+			return nil, fmt.Errorf("校验客户端证书需要配置clientCaFile")
+		}
+		pool, err := loadCertPool(conf.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.ClientCAs = pool
+	}
+	return tlsConfig, nil
+}
+
+func loadCertPool(file string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("读取CA证书[%s]失败: %v", file, err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("CA证书[%s]中没有有效的证书", file)
+	}
+	return pool, nil
+}
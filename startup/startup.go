@@ -3,7 +3,9 @@ package startup
 import (
 	"encoding/json"
 	"errors"
+	"vsphere-facade/api/audit"
 	"vsphere-facade/app/logging"
+	"vsphere-facade/vsphere"
 	"vsphere-facade/vsphere/callback"
 	"vsphere-facade/vsphere/protocol"
 	"vsphere-facade/vsphere/workerpool/taskreceiver"
@@ -11,6 +13,7 @@ import (
 
 func Run() {
 	interruptTaskCallback()
+	vsphere.WarmUp()
 }
 
 // interruptTaskCallback
@@ -42,5 +45,6 @@ func interruptTaskCallback() {
 
 		callback.NewCallbacker(callbackReq).CallbackErr(requestID, nil, errors.New("系统中断，任务失去控制"))
 		taskreceiver.Cancel(requestID, "系统中断，任务失去控制")
+		audit.Complete(requestID, audit.OutcomeFailure, nil, "系统中断，任务失去控制")
 	}
 }
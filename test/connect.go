@@ -35,5 +35,9 @@ func init() {
 	helper.Setup()
 	cache.Setup()
 	vCache.Setup()
-	vc = vsphere.Get(vc30)
+	var err error
+	vc, err = vsphere.Get(vc30)
+	if err != nil {
+		logging.L().Panic(err)
+	}
 }
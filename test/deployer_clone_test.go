@@ -1,6 +1,7 @@
 package test
 
 import (
+	"context"
 	"github.com/stretchr/testify/assert"
 	"testing"
 	"vsphere-facade/helper/virtualmachine"
@@ -51,7 +52,7 @@ func Test_clone_default_value(t *testing.T) {
 			Size:        20,
 		}},
 	}
-	err := d.Deploy()
+	err := d.Deploy(context.Background())
 
 	assert.NoError(t, err)
 	if err != nil {
@@ -28,3 +28,21 @@ func (c VCCache) GetClusters() []protocol.ClusterInfo {
 	}
 	return v.([]protocol.ClusterInfo)
 }
+
+// UpdateClusters 新增或更新缓存中的集群
+func (c VCCache) UpdateClusters(infos []protocol.ClusterInfo) {
+	c.upsert(computerresource.Type, infos, clusterID)
+}
+
+// ReplaceClusters 使用全量数据覆盖缓存
+func (c VCCache) ReplaceClusters(infos []protocol.ClusterInfo) {
+	c.replace(computerresource.Type, infos)
+}
+
+func (c VCCache) RemoveClusters(IDs []string) {
+	c.remove(computerresource.Type, IDs, clusterID)
+}
+
+func clusterID(info interface{}) string {
+	return info.(protocol.ClusterInfo).ID
+}
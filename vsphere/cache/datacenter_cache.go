@@ -28,3 +28,21 @@ func (c VCCache) GetDatacenters() []protocol.DatacenterInfo {
 	}
 	return v.([]protocol.DatacenterInfo)
 }
+
+// UpdateDatacenters 新增或更新缓存中的数据中心
+func (c VCCache) UpdateDatacenters(infos []protocol.DatacenterInfo) {
+	c.upsert(datacenter.Type, infos, datacenterID)
+}
+
+// ReplaceDatacenters 使用全量数据覆盖缓存
+func (c VCCache) ReplaceDatacenters(infos []protocol.DatacenterInfo) {
+	c.replace(datacenter.Type, infos)
+}
+
+func (c VCCache) RemoveDatacenters(IDs []string) {
+	c.remove(datacenter.Type, IDs, datacenterID)
+}
+
+func datacenterID(info interface{}) string {
+	return info.(protocol.DatacenterInfo).ID
+}
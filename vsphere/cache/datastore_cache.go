@@ -28,3 +28,21 @@ func (c VCCache) GetDatastores() []protocol.DatastoreInfo {
 	}
 	return v.([]protocol.DatastoreInfo)
 }
+
+// UpdateDatastores 新增或更新缓存中的存储
+func (c VCCache) UpdateDatastores(infos []protocol.DatastoreInfo) {
+	c.upsert(datastore.Type, infos, datastoreID)
+}
+
+// ReplaceDatastores 使用全量数据覆盖缓存
+func (c VCCache) ReplaceDatastores(infos []protocol.DatastoreInfo) {
+	c.replace(datastore.Type, infos)
+}
+
+func (c VCCache) RemoveDatastores(IDs []string) {
+	c.remove(datastore.Type, IDs, datastoreID)
+}
+
+func datastoreID(info interface{}) string {
+	return info.(protocol.DatastoreInfo).ID
+}
@@ -28,3 +28,21 @@ func (c VCCache) GetFolders() []protocol.FolderInfo {
 	}
 	return v.([]protocol.FolderInfo)
 }
+
+// UpdateFolders 新增或更新缓存中的文件夹
+func (c VCCache) UpdateFolders(infos []protocol.FolderInfo) {
+	c.upsert(folder.Type, infos, folderID)
+}
+
+// ReplaceFolders 使用全量数据覆盖缓存
+func (c VCCache) ReplaceFolders(infos []protocol.FolderInfo) {
+	c.replace(folder.Type, infos)
+}
+
+func (c VCCache) RemoveFolders(IDs []string) {
+	c.remove(folder.Type, IDs, folderID)
+}
+
+func folderID(info interface{}) string {
+	return info.(protocol.FolderInfo).ID
+}
@@ -28,3 +28,21 @@ func (c VCCache) GetHosts() []protocol.HostInfo {
 	}
 	return v.([]protocol.HostInfo)
 }
+
+// UpdateHosts 新增或更新缓存中的主机
+func (c VCCache) UpdateHosts(infos []protocol.HostInfo) {
+	c.upsert(hostsystem.Type, infos, hostID)
+}
+
+// ReplaceHosts 使用全量数据覆盖缓存
+func (c VCCache) ReplaceHosts(infos []protocol.HostInfo) {
+	c.replace(hostsystem.Type, infos)
+}
+
+func (c VCCache) RemoveHosts(IDs []string) {
+	c.remove(hostsystem.Type, IDs, hostID)
+}
+
+func hostID(info interface{}) string {
+	return info.(protocol.HostInfo).ID
+}
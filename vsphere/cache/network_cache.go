@@ -28,3 +28,21 @@ func (c VCCache) GetNetworks() []protocol.NetworkInfo {
 	}
 	return v.([]protocol.NetworkInfo)
 }
+
+// UpdateNetworks 新增或更新缓存中的网络
+func (c VCCache) UpdateNetworks(infos []protocol.NetworkInfo) {
+	c.upsert(network.Type, infos, networkID)
+}
+
+// ReplaceNetworks 使用全量数据覆盖缓存
+func (c VCCache) ReplaceNetworks(infos []protocol.NetworkInfo) {
+	c.replace(network.Type, infos)
+}
+
+func (c VCCache) RemoveNetworks(IDs []string) {
+	c.remove(network.Type, IDs, networkID)
+}
+
+func networkID(info interface{}) string {
+	return info.(protocol.NetworkInfo).ID
+}
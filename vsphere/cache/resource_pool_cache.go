@@ -29,3 +29,21 @@ func (c VCCache) GetResourcePools() []protocol.ResourcePoolInfo {
 	}
 	return v.([]protocol.ResourcePoolInfo)
 }
+
+// UpdateResourcePools 新增或更新缓存中的资源池
+func (c VCCache) UpdateResourcePools(infos []protocol.ResourcePoolInfo) {
+	c.upsert(key(c.VCID, resourcepool.Type), infos, resourcePoolID)
+}
+
+// ReplaceResourcePools 使用全量数据覆盖缓存
+func (c VCCache) ReplaceResourcePools(infos []protocol.ResourcePoolInfo) {
+	c.replace(key(c.VCID, resourcepool.Type), infos)
+}
+
+func (c VCCache) RemoveResourcePools(IDs []string) {
+	c.remove(key(c.VCID, resourcepool.Type), IDs, resourcePoolID)
+}
+
+func resourcePoolID(info interface{}) string {
+	return info.(protocol.ResourcePoolInfo).ID
+}
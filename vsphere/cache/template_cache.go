@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"vsphere-facade/vsphere/protocol"
+)
+
+const TemplateCacheKey = "Template"
+
+func (c VCCache) CacheTemplates(v []protocol.TemplateInfo) {
+	c.Set(TemplateCacheKey, v)
+}
+
+func (c VCCache) GetTemplate(ID string) *protocol.TemplateInfo {
+	templates := c.GetTemplates()
+	if templates != nil {
+		for _, t := range templates {
+			if t.ID == ID {
+				return &t
+			}
+		}
+	}
+	return nil
+}
+
+func (c VCCache) GetTemplates() []protocol.TemplateInfo {
+	v, b := c.Get(TemplateCacheKey)
+	if !b {
+		return nil
+	}
+	return v.([]protocol.TemplateInfo)
+}
+
+// UpdateTemplates 新增或更新缓存中的模板
+func (c VCCache) UpdateTemplates(infos []protocol.TemplateInfo) {
+	c.upsert(TemplateCacheKey, infos, templateID)
+}
+
+// ReplaceTemplates 使用全量数据覆盖缓存
+func (c VCCache) ReplaceTemplates(infos []protocol.TemplateInfo) {
+	c.replace(TemplateCacheKey, infos)
+}
+
+func (c VCCache) RemoveTemplates(IDs []string) {
+	c.remove(TemplateCacheKey, IDs, templateID)
+}
+
+func templateID(info interface{}) string {
+	return info.(protocol.TemplateInfo).ID
+}
@@ -2,16 +2,23 @@ package cache
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
+	"sync"
+	"time"
 	"vsphere-facade/app/cache"
 	"vsphere-facade/app/logging"
 	"vsphere-facade/config"
 )
 
-const RefreshTicker = "RefreshTicker"
+// updatedAtSuffix 记录缓存最后写入时间的key后缀
+const updatedAtSuffix = "@UpdatedAt"
 
 var ignorePrefixes []string
 
+// updateMu 保证增量更新时读取-修改-写回的完整性
+var updateMu sync.Mutex
+
 type VCCache struct {
 	VCID string
 }
@@ -46,15 +53,114 @@ func (c VCCache) Set(k string, v interface{}) {
 			_, b := cache.INST.Get(ck)
 			if !b {
 				cache.INST.Set(ck, v, -1)
+				touch(ck)
 			}
 		}
 	}
 }
 
+// update
+// 缓存存在时使用fn的返回值覆盖，缓存不存在时跳过，避免未预热的缓存中只有部分数据被当成全量数据使用。
+// fn需要返回新的对象，不能修改原有的数据，查询方可能正在使用
+func (c VCCache) update(k string, fn func(v interface{}) interface{}) {
+	if !config.G.Vsphere.Cache.Enable {
+		return
+	}
+	updateMu.Lock()
+	defer updateMu.Unlock()
+	ck := key(c.VCID, k)
+	v, b := cache.INST.Get(ck)
+	if !b {
+		return
+	}
+	cache.INST.Set(ck, fn(v), -1)
+	touch(ck)
+}
+
+// replace 缓存不存在时也写入，只用于全量数据，v为nil切片时写入空切片
+func (c VCCache) replace(k string, v interface{}) {
+	if !config.G.Vsphere.Cache.Enable {
+		return
+	}
+	ck := key(c.VCID, k)
+	if ignore(ck) {
+		return
+	}
+	if rv := reflect.ValueOf(v); rv.IsNil() {
+		v = reflect.MakeSlice(rv.Type(), 0, 0).Interface()
+	}
+	updateMu.Lock()
+	defer updateMu.Unlock()
+	cache.INST.Set(ck, v, -1)
+	touch(ck)
+}
+
+// upsert 按ID新增或更新缓存k中的对象，infos为对象切片，id返回对象的ID，已有的对象保持原来的顺序
+func (c VCCache) upsert(k string, infos interface{}, id func(info interface{}) string) {
+	added := reflect.ValueOf(infos)
+	if added.Len() == 0 {
+		return
+	}
+	c.update(k, func(v interface{}) interface{} {
+		old := reflect.ValueOf(v)
+		index := make(map[string]int, added.Len())
+		for i := 0; i < added.Len(); i++ {
+			index[id(added.Index(i).Interface())] = i
+		}
+		result := reflect.MakeSlice(old.Type(), 0, old.Len()+added.Len())
+		for i := 0; i < old.Len(); i++ {
+			item := old.Index(i)
+			ID := id(item.Interface())
+			if j, ok := index[ID]; ok {
+				delete(index, ID)
+				item = added.Index(j)
+			}
+			result = reflect.Append(result, item)
+		}
+		for i := 0; i < added.Len(); i++ {
+			if _, ok := index[id(added.Index(i).Interface())]; ok {
+				result = reflect.Append(result, added.Index(i))
+			}
+		}
+		return result.Interface()
+	})
+}
+
+// remove 按ID删除缓存k中的对象
+func (c VCCache) remove(k string, IDs []string, id func(info interface{}) string) {
+	if len(IDs) == 0 {
+		return
+	}
+	c.update(k, func(v interface{}) interface{} {
+		old := reflect.ValueOf(v)
+		removed := make(map[string]bool, len(IDs))
+		for _, ID := range IDs {
+			removed[ID] = true
+		}
+		result := reflect.MakeSlice(old.Type(), 0, old.Len())
+		for i := 0; i < old.Len(); i++ {
+			if !removed[id(old.Index(i).Interface())] {
+				result = reflect.Append(result, old.Index(i))
+			}
+		}
+		return result.Interface()
+	})
+}
+
+// UpdatedAt 缓存最后一次写入的时间，缓存不存在时返回nil
+func (c VCCache) UpdatedAt(k string) *time.Time {
+	v, b := c.Get(k + updatedAtSuffix)
+	if !b {
+		return nil
+	}
+	t := v.(time.Time)
+	return &t
+}
+
 func (c VCCache) CleanAll() {
 	logging.L().Debug(fmt.Sprintf("清除VCenter[%s]下所有缓存数据", c.VCID))
 	for k := range cache.INST.Items() {
-		if strings.HasPrefix(k, c.VCID) && !strings.HasSuffix(k, RefreshTicker) {
+		if strings.HasPrefix(k, c.VCID) {
 			cache.INST.Delete(k)
 		}
 	}
@@ -65,6 +171,7 @@ func (c VCCache) Clean(keys ...string) {
 		logging.L().Debug(fmt.Sprintf("清除VCenter[%s]缓存数据: [%s]", c.VCID, keys))
 		for _, k := range keys {
 			cache.INST.Delete(key(c.VCID, k))
+			cache.INST.Delete(key(c.VCID, k) + updatedAtSuffix)
 		}
 	}
 }
@@ -73,6 +180,10 @@ func key(VCID, t string) string {
 	return fmt.Sprintf("%s::%s", VCID, t)
 }
 
+func touch(ck string) {
+	cache.INST.Set(ck+updatedAtSuffix, time.Now(), -1)
+}
+
 func ignore(k string) bool {
 	for _, prefix := range ignorePrefixes {
 		if strings.HasPrefix(k, prefix) {
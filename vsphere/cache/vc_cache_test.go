@@ -0,0 +1,37 @@
+package cache
+
+import (
+	"reflect"
+	"testing"
+	"vsphere-facade/app/cache"
+	"vsphere-facade/config"
+	"vsphere-facade/vsphere/protocol"
+)
+
+func TestUpsertAndRemove(t *testing.T) {
+	config.G.Vsphere.Cache.Enable = true
+	defer func() { config.G.Vsphere.Cache.Enable = false }()
+	cache.Setup()
+	c := VCCache{VCID: "vc-1"}
+
+	c.UpdateClusters([]protocol.ClusterInfo{{ID: "c1"}})
+	if c.GetClusters() != nil {
+		t.Fatal("缓存不存在时不能只写入部分数据")
+	}
+
+	old := []protocol.ClusterInfo{{ID: "c1", Name: "a"}, {ID: "c2", Name: "b"}}
+	c.CacheClusters(old)
+	c.UpdateClusters([]protocol.ClusterInfo{{ID: "c3", Name: "c"}, {ID: "c1", Name: "a2"}})
+	want := []protocol.ClusterInfo{{ID: "c1", Name: "a2"}, {ID: "c2", Name: "b"}, {ID: "c3", Name: "c"}}
+	if clusters := c.GetClusters(); !reflect.DeepEqual(clusters, want) {
+		t.Error("已有的对象保持原来的顺序，新对象追加到最后", clusters)
+	}
+	if old[0].Name != "a" {
+		t.Error("不能修改原来的数据", old)
+	}
+
+	c.RemoveClusters([]string{"c2", "unknown"})
+	if clusters := c.GetClusters(); !reflect.DeepEqual(clusters, []protocol.ClusterInfo{want[0], want[2]}) {
+		t.Error(clusters)
+	}
+}
@@ -0,0 +1,48 @@
+package cache
+
+import (
+	"vsphere-facade/helper/virtualmachine"
+	"vsphere-facade/vsphere/protocol"
+)
+
+func (c VCCache) CacheVirtualMachines(v []protocol.VirtualMachineInfo) {
+	c.Set(virtualmachine.Type, v)
+}
+
+func (c VCCache) GetVirtualMachine(ID string) *protocol.VirtualMachineInfo {
+	vms := c.GetVirtualMachines()
+	if vms != nil {
+		for _, vm := range vms {
+			if vm.ID == ID {
+				return &vm
+			}
+		}
+	}
+	return nil
+}
+
+func (c VCCache) GetVirtualMachines() []protocol.VirtualMachineInfo {
+	v, b := c.Get(virtualmachine.Type)
+	if !b {
+		return nil
+	}
+	return v.([]protocol.VirtualMachineInfo)
+}
+
+// UpdateVirtualMachines 新增或更新缓存中的虚拟机
+func (c VCCache) UpdateVirtualMachines(infos []protocol.VirtualMachineInfo) {
+	c.upsert(virtualmachine.Type, infos, virtualMachineID)
+}
+
+// ReplaceVirtualMachines 使用全量数据覆盖缓存
+func (c VCCache) ReplaceVirtualMachines(infos []protocol.VirtualMachineInfo) {
+	c.replace(virtualmachine.Type, infos)
+}
+
+func (c VCCache) RemoveVirtualMachines(IDs []string) {
+	c.remove(virtualmachine.Type, IDs, virtualMachineID)
+}
+
+func virtualMachineID(info interface{}) string {
+	return info.(protocol.VirtualMachineInfo).ID
+}
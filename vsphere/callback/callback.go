@@ -1,12 +1,16 @@
 package callback
 
 import (
+	"context"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"strings"
 	"time"
 	"vsphere-facade/api/e"
 	"vsphere-facade/app/logging"
+	"vsphere-facade/app/metrics"
+	"vsphere-facade/app/tracing"
 	"vsphere-facade/app/utils"
 	"vsphere-facade/config"
 	"vsphere-facade/vsphere/protocol"
@@ -14,6 +18,7 @@ import (
 
 type Callbacker struct {
 	Req protocol.CallbackReq
+	ctx context.Context
 }
 
 func NewCallbacker(req protocol.CallbackReq) *Callbacker {
@@ -22,6 +27,12 @@ func NewCallbacker(req protocol.CallbackReq) *Callbacker {
 	}
 }
 
+// WithContext 回调时在请求头中传递ctx中的链路信息(traceparent)
+func (c *Callbacker) WithContext(ctx context.Context) *Callbacker {
+	c.ctx = ctx
+	return c
+}
+
 func (c *Callbacker) CallbackObj(requestID string, data interface{}) {
 	if data == nil {
 		data = e.EmptyObject()
@@ -55,18 +66,24 @@ func (c *Callbacker) callback(requestID string, data interface{}, err error) {
 	_ = c.sendByHttp(res)
 }
 
-func (c *Callbacker) sendByHttp(res protocol.CallbackRes) error {
+func (c *Callbacker) sendByHttp(res protocol.CallbackRes) (err error) {
 	cb := utils.NilNext(c.Req.HttpPost, config.G.Vsphere.Default.Callback.HttpPost)
 	httpCB := cb.(*protocol.Http)
 	if httpCB == nil {
 		return nil
 	}
+	ctx, span := tracing.Start(c.ctx, "callback")
+	defer func() {
+		metrics.Callback(err)
+		tracing.End(span, err)
+	}()
 
 	body := utils.ToJson(res)
-	logging.L().Debugf("http回调：\nPOST %s \nHeaders: %s \nPayload: %s", httpCB.URL, httpCB.Headers, body)
+	log := logging.C(ctx)
+	log.Debugf("http回调：\nPOST %s \nHeaders: %s \nPayload: %s", httpCB.URL, logging.RedactHeaders(httpCB.Headers), body)
 	post, err := http.NewRequest("POST", httpCB.URL, strings.NewReader(body))
 	if err != nil {
-		logging.L().Error("http post回调时，创建http客户端失败", err)
+		log.Error("http post回调时，创建http客户端失败", err)
 		return err
 	}
 
@@ -75,27 +92,29 @@ func (c *Callbacker) sendByHttp(res protocol.CallbackRes) error {
 	for k, v := range httpCB.Headers {
 		post.Header.Add(k, v)
 	}
+	tracing.Inject(ctx, post.Header)
 	// Send
 	client := &http.Client{
 		Timeout: 30 * time.Second,
 	}
 	resp, err := client.Do(post)
 	if err != nil {
-		logging.L().Errorf("http post回调时，请求回调URL失败\n: POST %s\n %s\n %v", httpCB.URL, body, err)
+		log.Errorf("http post回调时，请求回调URL失败\n: POST %s\n %s\n %v", httpCB.URL, body, err)
 		return err
 	}
 
 	if logging.IsDebug() {
 		defer resp.Body.Close()
 		rbody, _ := ioutil.ReadAll(resp.Body)
-		logging.L().Debugf("响应状态: %d\n响应内容: %s", resp.StatusCode, string(rbody))
+		log.Debugf("响应状态: %d\n响应内容: %s", resp.StatusCode, string(rbody))
 	}
 
 	if resp.StatusCode > 399 {
 		defer resp.Body.Close()
 		rbody, _ := ioutil.ReadAll(resp.Body)
-		logging.L().Errorf("http post回调后，后响应错误\n: POST %s\n %s\n响应状态: %d\n响应内容: %s",
+		log.Errorf("http post回调后，后响应错误\n: POST %s\n %s\n响应状态: %d\n响应内容: %s",
 			httpCB.URL, body, resp.StatusCode, string(rbody))
+		return fmt.Errorf("回调响应状态[%d]", resp.StatusCode)
 	}
 	return nil
 }
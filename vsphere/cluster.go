@@ -3,13 +3,23 @@ package vsphere
 import (
 	"github.com/vmware/govmomi/vim25/mo"
 	"vsphere-facade/app/logging"
+	"vsphere-facade/app/metrics"
 	"vsphere-facade/app/utils"
 	"vsphere-facade/helper/clustercomputerresource"
 	"vsphere-facade/vsphere/protocol"
 )
 
-func (vc *VCenter) QueryClusters(q protocol.ClusterQuery) []protocol.ClusterInfo {
+func (vc *VCenter) QueryClusters(q protocol.ClusterQuery) ([]protocol.ClusterInfo, error) {
+	items, err := vc.filterScope(vc.queryClusters(q), clustercomputerresource.Type)
+	if err != nil {
+		return nil, err
+	}
+	return items.([]protocol.ClusterInfo), nil
+}
+
+func (vc *VCenter) queryClusters(q protocol.ClusterQuery) []protocol.ClusterInfo {
 	clusters := vc.queryClustersFromCache(q)
+	metrics.CacheQuery(vc.Cache.VCID, clustercomputerresource.Type, clusters != nil)
 	if clusters != nil {
 		logging.L().Debug("本次查询使用了缓存")
 		return clusters
@@ -3,13 +3,23 @@ package vsphere
 import (
 	"github.com/vmware/govmomi/object"
 	"vsphere-facade/app/logging"
+	"vsphere-facade/app/metrics"
 	"vsphere-facade/app/utils"
 	"vsphere-facade/helper/datacenter"
 	"vsphere-facade/vsphere/protocol"
 )
 
-func (vc *VCenter) QueryDatacenters(q protocol.DatacenterQuery) []protocol.DatacenterInfo {
+func (vc *VCenter) QueryDatacenters(q protocol.DatacenterQuery) ([]protocol.DatacenterInfo, error) {
+	items, err := vc.filterScope(vc.queryDatacenters(q), datacenter.Type)
+	if err != nil {
+		return nil, err
+	}
+	return items.([]protocol.DatacenterInfo), nil
+}
+
+func (vc *VCenter) queryDatacenters(q protocol.DatacenterQuery) []protocol.DatacenterInfo {
 	dcs := vc.queryDatacentersFromCache(q)
+	metrics.CacheQuery(vc.Cache.VCID, datacenter.Type, dcs != nil)
 	if dcs != nil {
 		logging.L().Debug("本次查询使用了缓存")
 		return dcs
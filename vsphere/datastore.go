@@ -3,14 +3,24 @@ package vsphere
 import (
 	"github.com/vmware/govmomi/vim25/mo"
 	"vsphere-facade/app/logging"
+	"vsphere-facade/app/metrics"
 	"vsphere-facade/app/utils"
 	"vsphere-facade/helper/datastore"
 	"vsphere-facade/helper/disk"
 	"vsphere-facade/vsphere/protocol"
 )
 
-func (vc *VCenter) QueryDatastores(q protocol.DatastoreQuery) []protocol.DatastoreInfo {
+func (vc *VCenter) QueryDatastores(q protocol.DatastoreQuery) ([]protocol.DatastoreInfo, error) {
+	items, err := vc.filterScope(vc.queryDatastores(q), datastore.Type)
+	if err != nil {
+		return nil, err
+	}
+	return items.([]protocol.DatastoreInfo), nil
+}
+
+func (vc *VCenter) queryDatastores(q protocol.DatastoreQuery) []protocol.DatastoreInfo {
 	datastores := vc.queryDatastoresFromCache(q)
+	metrics.CacheQuery(vc.Cache.VCID, datastore.Type, datastores != nil)
 	if datastores != nil {
 		logging.L().Debug("本次查询使用了缓存")
 		return datastores
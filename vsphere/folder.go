@@ -3,13 +3,23 @@ package vsphere
 import (
 	"github.com/vmware/govmomi/vim25/mo"
 	"vsphere-facade/app/logging"
+	"vsphere-facade/app/metrics"
 	"vsphere-facade/app/utils"
 	"vsphere-facade/helper/folder"
 	"vsphere-facade/vsphere/protocol"
 )
 
-func (vc *VCenter) QueryFolders(q protocol.FolderQuery) []protocol.FolderInfo {
+func (vc *VCenter) QueryFolders(q protocol.FolderQuery) ([]protocol.FolderInfo, error) {
+	items, err := vc.filterScope(vc.queryFolders(q), folder.Type)
+	if err != nil {
+		return nil, err
+	}
+	return items.([]protocol.FolderInfo), nil
+}
+
+func (vc *VCenter) queryFolders(q protocol.FolderQuery) []protocol.FolderInfo {
 	folders := vc.queryFoldersFromCache(q)
+	metrics.CacheQuery(vc.Cache.VCID, folder.Type, folders != nil)
 	if folders != nil {
 		logging.L().Debug("本次查询使用了缓存")
 		return folders
@@ -3,6 +3,7 @@ package vsphere
 import (
 	"github.com/vmware/govmomi/vim25/mo"
 	"vsphere-facade/app/logging"
+	"vsphere-facade/app/metrics"
 	"vsphere-facade/app/utils"
 	"vsphere-facade/helper/clustercomputerresource"
 	"vsphere-facade/helper/computerresource"
@@ -10,8 +11,17 @@ import (
 	"vsphere-facade/vsphere/protocol"
 )
 
-func (vc *VCenter) QueryHosts(q protocol.HostQuery) []protocol.HostInfo {
+func (vc *VCenter) QueryHosts(q protocol.HostQuery) ([]protocol.HostInfo, error) {
+	items, err := vc.filterScope(vc.queryHosts(q), hostsystem.Type)
+	if err != nil {
+		return nil, err
+	}
+	return items.([]protocol.HostInfo), nil
+}
+
+func (vc *VCenter) queryHosts(q protocol.HostQuery) []protocol.HostInfo {
 	hosts := vc.queryHostsFromCache(q)
+	metrics.CacheQuery(vc.Cache.VCID, hostsystem.Type, hosts != nil)
 	if hosts != nil {
 		logging.L().Debug("本次查询使用了缓存")
 		return hosts
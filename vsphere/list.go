@@ -0,0 +1,284 @@
+package vsphere
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"vsphere-facade/vsphere/protocol"
+)
+
+// jsonFieldsCache 结构体类型对应的json字段
+var jsonFieldsCache sync.Map
+
+var timeType = reflect.TypeOf(time.Time{})
+
+type sortField struct {
+	index []int
+	desc  bool
+}
+
+// ApplyListOptions
+// 对查询结果进行过滤、排序、分页和字段裁剪，缓存和实时查询的结果都使用这里处理。
+// items需要是结构体切片，排序和裁剪的字段使用json名称，指定fields时返回[]map[string]interface{}
+func ApplyListOptions(items interface{}, opts protocol.ListOptions) (interface{}, protocol.Page, error) {
+	page := protocol.Page{
+		Offset: opts.Offset,
+		Limit:  opts.Limit,
+	}
+	if opts.Limit < 0 || opts.Offset < 0 {
+		return nil, page, fmt.Errorf("limit和offset不能小于0")
+	}
+
+	v := reflect.ValueOf(items)
+	if v.Kind() != reflect.Slice || v.Type().Elem().Kind() != reflect.Struct {
+		return nil, page, fmt.Errorf("查询结果不支持分页")
+	}
+	elemType := v.Type().Elem()
+	fields := jsonFields(elemType)
+
+	match, err := listFilter(elemType, opts)
+	if err != nil {
+		return nil, page, err
+	}
+	var indexes []int
+	for i := 0; i < v.Len(); i++ {
+		if match(v.Index(i)) {
+			indexes = append(indexes, i)
+		}
+	}
+
+	sortFields, err := parseSort(elemType, fields, opts.Sort)
+	if err != nil {
+		return nil, page, err
+	}
+	if len(sortFields) > 0 {
+		sort.SliceStable(indexes, func(i, j int) bool {
+			a := v.Index(indexes[i])
+			b := v.Index(indexes[j])
+			for _, f := range sortFields {
+				c := compareValue(a.FieldByIndex(f.index), b.FieldByIndex(f.index))
+				if c == 0 {
+					continue
+				}
+				if f.desc {
+					return c > 0
+				}
+				return c < 0
+			}
+			return false
+		})
+	}
+
+	page.Total = len(indexes)
+	start := opts.Offset
+	if start > len(indexes) {
+		start = len(indexes)
+	}
+	end := len(indexes)
+	if opts.Limit > 0 && opts.Limit < end-start {
+		end = start + opts.Limit
+	}
+	indexes = indexes[start:end]
+
+	if len(opts.Fields) > 0 {
+		for _, name := range opts.Fields {
+			if _, ok := fields[name]; !ok {
+				return nil, page, fmt.Errorf("不支持的字段[%s]", name)
+			}
+		}
+		projected := make([]map[string]interface{}, 0, len(indexes))
+		for _, i := range indexes {
+			item := make(map[string]interface{}, len(opts.Fields))
+			for _, name := range opts.Fields {
+				item[name] = v.Index(i).FieldByIndex(fields[name]).Interface()
+			}
+			projected = append(projected, item)
+		}
+		return projected, page, nil
+	}
+
+	result := reflect.MakeSlice(v.Type(), 0, len(indexes))
+	for _, i := range indexes {
+		result = reflect.Append(result, v.Index(i))
+	}
+	return result.Interface(), page, nil
+}
+
+func listFilter(elemType reflect.Type, opts protocol.ListOptions) (func(reflect.Value) bool, error) {
+	var filters []func(reflect.Value) bool
+
+	if opts.Name != "" || opts.NameRegex != "" {
+		f, ok := elemType.FieldByName("Name")
+		if !ok || f.Type.Kind() != reflect.String {
+			return nil, fmt.Errorf("查询结果不支持按名称过滤")
+		}
+		if opts.Name != "" {
+			name := strings.ToLower(opts.Name)
+			filters = append(filters, func(v reflect.Value) bool {
+				return strings.Contains(strings.ToLower(v.FieldByIndex(f.Index).String()), name)
+			})
+		}
+		if opts.NameRegex != "" {
+			re, err := regexp.Compile(opts.NameRegex)
+			if err != nil {
+				return nil, fmt.Errorf("名称正则表达式[%s]错误: %v", opts.NameRegex, err)
+			}
+			filters = append(filters, func(v reflect.Value) bool {
+				return re.MatchString(v.FieldByIndex(f.Index).String())
+			})
+		}
+	}
+
+	if opts.PowerState != "" {
+		f, ok := elemType.FieldByName("PowerState")
+		if !ok || f.Type.Kind() != reflect.String {
+			return nil, fmt.Errorf("查询结果不支持按电源状态过滤")
+		}
+		filters = append(filters, func(v reflect.Value) bool {
+			return strings.EqualFold(v.FieldByIndex(f.Index).String(), opts.PowerState)
+		})
+	}
+
+	return func(v reflect.Value) bool {
+		for _, filter := range filters {
+			if !filter(v) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+func parseSort(elemType reflect.Type, fields map[string][]int, sorts []string) ([]sortField, error) {
+	var sortFields []sortField
+	for _, s := range sorts {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		f := sortField{}
+		if strings.HasPrefix(s, "-") {
+			f.desc = true
+			s = s[1:]
+		}
+		index, ok := fields[s]
+		if !ok {
+			return nil, fmt.Errorf("不支持的排序字段[%s]", s)
+		}
+		t := elemType.FieldByIndex(index).Type
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if !sortable(t) {
+			return nil, fmt.Errorf("字段[%s]不支持排序", s)
+		}
+		f.index = index
+		sortFields = append(sortFields, f)
+	}
+	return sortFields, nil
+}
+
+func sortable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return t == timeType
+}
+
+// compareValue 空指针排在最前面
+func compareValue(a, b reflect.Value) int {
+	if a.Kind() == reflect.Ptr {
+		if a.IsNil() || b.IsNil() {
+			switch {
+			case a.IsNil() && b.IsNil():
+				return 0
+			case a.IsNil():
+				return -1
+			default:
+				return 1
+			}
+		}
+		a = a.Elem()
+		b = b.Elem()
+	}
+
+	switch a.Kind() {
+	case reflect.String:
+		return strings.Compare(a.String(), b.String())
+	case reflect.Bool:
+		if a.Bool() == b.Bool() {
+			return 0
+		} else if a.Bool() {
+			return 1
+		}
+		return -1
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return compareOrdered(a.Int() < b.Int(), a.Int() > b.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return compareOrdered(a.Uint() < b.Uint(), a.Uint() > b.Uint())
+	case reflect.Float32, reflect.Float64:
+		return compareOrdered(a.Float() < b.Float(), a.Float() > b.Float())
+	}
+	if a.Type() == timeType {
+		at := a.Interface().(time.Time)
+		bt := b.Interface().(time.Time)
+		return compareOrdered(at.Before(bt), at.After(bt))
+	}
+	return 0
+}
+
+func compareOrdered(less, greater bool) int {
+	if less {
+		return -1
+	}
+	if greater {
+		return 1
+	}
+	return 0
+}
+
+// jsonFields
+// 结构体的json字段名称和字段位置，匿名嵌入的结构体字段展开，没有json标签时使用字段名称
+func jsonFields(t reflect.Type) map[string][]int {
+	if fields, ok := jsonFieldsCache.Load(t); ok {
+		return fields.(map[string][]int)
+	}
+	fields := make(map[string][]int)
+	collectJsonFields(t, nil, fields)
+	jsonFieldsCache.Store(t, fields)
+	return fields
+}
+
+func collectJsonFields(t reflect.Type, parent []int, fields map[string][]int) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		index := append(append([]int(nil), parent...), i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			collectJsonFields(f.Type, index, fields)
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		// 外层字段优先
+		if _, exists := fields[name]; !exists || len(fields[name]) > len(index) {
+			fields[name] = index
+		}
+	}
+}
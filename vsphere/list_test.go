@@ -0,0 +1,92 @@
+package vsphere
+
+import (
+	"reflect"
+	"testing"
+	"vsphere-facade/vsphere/protocol"
+)
+
+type listItem struct {
+	Name       string `json:"name"`
+	PowerState string `json:"powerState"`
+	NumCPU     int32  `json:"numCPU"`
+	ClusterID  *string
+}
+
+func listNames(t *testing.T, result interface{}) []string {
+	items, ok := result.([]listItem)
+	if !ok {
+		t.Fatalf("返回结果类型错误%T", result)
+	}
+	names := make([]string, 0, len(items))
+	for _, item := range items {
+		names = append(names, item.Name)
+	}
+	return names
+}
+
+func TestApplyListOptions(t *testing.T) {
+	cluster := "domain-c1"
+	items := []listItem{
+		{Name: "web-01", PowerState: "poweredOn", NumCPU: 4, ClusterID: &cluster},
+		{Name: "web-02", PowerState: "poweredOff", NumCPU: 2},
+		{Name: "db-01", PowerState: "poweredOn", NumCPU: 8},
+		{Name: "DB-02", PowerState: "poweredOn", NumCPU: 2, ClusterID: &cluster},
+	}
+
+	tests := []struct {
+		name  string
+		opts  protocol.ListOptions
+		names []string
+		total int
+	}{
+		{"不分页", protocol.ListOptions{}, []string{"web-01", "web-02", "db-01", "DB-02"}, 4},
+		{"分页", protocol.ListOptions{Offset: 1, Limit: 2}, []string{"web-02", "db-01"}, 4},
+		{"最后一页不足", protocol.ListOptions{Offset: 3, Limit: 2}, []string{"DB-02"}, 4},
+		{"offset超出总数", protocol.ListOptions{Offset: 10, Limit: 2}, []string{}, 4},
+		{"limit很大时不溢出", protocol.ListOptions{Offset: 1, Limit: int(^uint(0) >> 1)}, []string{"web-02", "db-01", "DB-02"}, 4},
+		{"按名称过滤不区分大小写", protocol.ListOptions{Name: "db"}, []string{"db-01", "DB-02"}, 2},
+		{"名称正则", protocol.ListOptions{NameRegex: "^web-0[2-9]$"}, []string{"web-02"}, 1},
+		{"电源状态", protocol.ListOptions{PowerState: "POWEREDOFF"}, []string{"web-02"}, 1},
+		{"多字段排序", protocol.ListOptions{Sort: []string{"numCPU", "-name"}}, []string{"web-02", "DB-02", "web-01", "db-01"}, 4},
+		{"空指针排在最前面", protocol.ListOptions{Sort: []string{"ClusterID", "name"}}, []string{"db-01", "web-02", "DB-02", "web-01"}, 4},
+		{"过滤排序后分页", protocol.ListOptions{PowerState: "poweredOn", Sort: []string{"-numCPU"}, Limit: 2}, []string{"db-01", "web-01"}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, page, err := ApplyListOptions(items, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if names := listNames(t, result); !reflect.DeepEqual(names, tt.names) {
+				t.Error(names)
+			}
+			if page.Total != tt.total || page.Offset != tt.opts.Offset || page.Limit != tt.opts.Limit {
+				t.Error(page)
+			}
+		})
+	}
+
+	result, _, err := ApplyListOptions(items, protocol.ListOptions{Fields: []string{"name"}, Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if projected := result.([]map[string]interface{}); len(projected) != 1 || len(projected[0]) != 1 || projected[0]["name"] != "web-01" {
+		t.Error("只返回指定的字段", projected)
+	}
+
+	for name, opts := range map[string]protocol.ListOptions{
+		"limit小于0":  {Limit: -1},
+		"offset小于0": {Offset: -1},
+		"不支持的排序字段":  {Sort: []string{"unknown"}},
+		"不支持的字段":    {Fields: []string{"unknown"}},
+		"正则错误":      {NameRegex: "("},
+	} {
+		if _, _, err = ApplyListOptions(items, opts); err == nil {
+			t.Error(name, "应该返回错误")
+		}
+	}
+	if _, _, err = ApplyListOptions([]string{"a"}, protocol.ListOptions{}); err == nil {
+		t.Error("不是结构体切片时应该返回错误")
+	}
+}
@@ -0,0 +1,93 @@
+package vsphere
+
+import (
+	"vsphere-facade/app/metrics"
+	"vsphere-facade/config"
+	"vsphere-facade/helper"
+	"vsphere-facade/vsphere/cache"
+)
+
+// RegisterInventoryMetrics
+// 从缓存中统计清单指标，抓取时不会查询VC，没有创建缓存的VC不统计
+func RegisterInventoryMetrics() {
+	metrics.RegisterGauges("registered_vcenter_up", "登记的VC是否有可用的会话", []string{"alias"}, func() []metrics.Gauge {
+		var gauges []metrics.Gauge
+		for _, v := range config.G.Vsphere.VCenters {
+			up := 0.0
+			if helper.Connected(v.Address) && !helper.CircuitOpen(v.Address) {
+				up = 1
+			}
+			gauges = append(gauges, metrics.Gauge{Labels: []string{v.Alias}, Value: up})
+		}
+		return gauges
+	})
+	metrics.RegisterGauges("inventory_virtual_machines", "虚拟机数量", []string{"vcid", "power_state"}, func() []metrics.Gauge {
+		var gauges []metrics.Gauge
+		for _, c := range vcCaches() {
+			vms := c.GetVirtualMachines()
+			if vms == nil {
+				continue
+			}
+			counts := make(map[string]int)
+			for _, vm := range vms {
+				counts[vm.PowerState]++
+			}
+			for powerState, count := range counts {
+				gauges = append(gauges, metrics.Gauge{Labels: []string{c.VCID, powerState}, Value: float64(count)})
+			}
+		}
+		return gauges
+	})
+	metrics.RegisterGauges("inventory_templates", "模板数量", []string{"vcid"}, func() []metrics.Gauge {
+		var gauges []metrics.Gauge
+		for _, c := range vcCaches() {
+			if templates := c.GetTemplates(); templates != nil {
+				gauges = append(gauges, metrics.Gauge{Labels: []string{c.VCID}, Value: float64(len(templates))})
+			}
+		}
+		return gauges
+	})
+	metrics.RegisterGauges("inventory_hosts", "主机数量", []string{"vcid"}, func() []metrics.Gauge {
+		var gauges []metrics.Gauge
+		for _, c := range vcCaches() {
+			if hosts := c.GetHosts(); hosts != nil {
+				gauges = append(gauges, metrics.Gauge{Labels: []string{c.VCID}, Value: float64(len(hosts))})
+			}
+		}
+		return gauges
+	})
+
+	datastoreLabels := []string{"vcid", "datastore_id", "datastore"}
+	metrics.RegisterGauges("inventory_datastore_free_bytes", "存储剩余空间", datastoreLabels, func() []metrics.Gauge {
+		var gauges []metrics.Gauge
+		for _, c := range vcCaches() {
+			for _, ds := range c.GetDatastores() {
+				gauges = append(gauges, metrics.Gauge{Labels: []string{c.VCID, ds.ID, ds.Name}, Value: float64(ds.FreeSpace)})
+			}
+		}
+		return gauges
+	})
+	metrics.RegisterGauges("inventory_datastore_capacity_bytes", "存储总容量", datastoreLabels, func() []metrics.Gauge {
+		var gauges []metrics.Gauge
+		for _, c := range vcCaches() {
+			for _, ds := range c.GetDatastores() {
+				gauges = append(gauges, metrics.Gauge{Labels: []string{c.VCID, ds.ID, ds.Name}, Value: float64(ds.Capacity)})
+			}
+		}
+		return gauges
+	})
+}
+
+// vcCaches 同一个VC可能使用不同的账号连接，按VC去重
+func vcCaches() []cache.VCCache {
+	var caches []cache.VCCache
+	seen := make(map[string]bool)
+	for _, api := range helper.APIs() {
+		if api.ID == "" || seen[api.ID] {
+			continue
+		}
+		seen[api.ID] = true
+		caches = append(caches, cache.VCCache{VCID: api.ID})
+	}
+	return caches
+}
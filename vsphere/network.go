@@ -3,6 +3,7 @@ package vsphere
 import (
 	"github.com/vmware/govmomi/vim25/mo"
 	"vsphere-facade/app/logging"
+	"vsphere-facade/app/metrics"
 	"vsphere-facade/app/utils"
 	"vsphere-facade/helper/network"
 	"vsphere-facade/vsphere/protocol"
@@ -14,8 +15,17 @@ const (
 	NetworkTypeOpaqueNetwork = "OPAQUE_NETWORK"
 )
 
-func (vc *VCenter) QueryNetworks(q protocol.NetworkQuery) []protocol.NetworkInfo {
+func (vc *VCenter) QueryNetworks(q protocol.NetworkQuery) ([]protocol.NetworkInfo, error) {
+	items, err := vc.filterScope(vc.queryNetworks(q), network.Type)
+	if err != nil {
+		return nil, err
+	}
+	return items.([]protocol.NetworkInfo), nil
+}
+
+func (vc *VCenter) queryNetworks(q protocol.NetworkQuery) []protocol.NetworkInfo {
 	networks := vc.queryNetworksFromCache(q)
+	metrics.CacheQuery(vc.Cache.VCID, network.Type, networks != nil)
 	if networks != nil {
 		logging.L().Debug("本次查询使用了缓存")
 		return networks
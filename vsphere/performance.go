@@ -0,0 +1,118 @@
+package vsphere
+
+import (
+	"github.com/vmware/govmomi/vim25/types"
+	"vsphere-facade/helper/clustercomputerresource"
+	"vsphere-facade/helper/datastore"
+	"vsphere-facade/helper/hostsystem"
+	"vsphere-facade/helper/performance"
+	"vsphere-facade/helper/virtualmachine"
+	"vsphere-facade/vsphere/protocol"
+)
+
+// defaultCounters 没有指定指标时查询CPU、内存、磁盘和网络使用情况
+var defaultCounters = map[string][]string{
+	virtualmachine.Type: {
+		"cpu.usage.average", "cpu.usagemhz.average", "mem.usage.average", "mem.consumed.average",
+		"disk.usage.average", "net.usage.average",
+	},
+	hostsystem.Type: {
+		"cpu.usage.average", "cpu.usagemhz.average", "mem.usage.average", "mem.consumed.average",
+		"disk.usage.average", "net.usage.average",
+	},
+	clustercomputerresource.Type: {
+		"cpu.usage.average", "cpu.usagemhz.average", "mem.usage.average", "mem.consumed.average",
+	},
+	datastore.Type: {
+		"disk.capacity.latest", "disk.used.latest", "disk.provisioned.latest",
+	},
+}
+
+// defaultIntervals 集群和存储没有实时数据，默认使用历史数据
+var defaultIntervals = map[string]int32{
+	virtualmachine.Type:          performance.RealtimeInterval,
+	hostsystem.Type:              performance.RealtimeInterval,
+	clustercomputerresource.Type: 300,
+	datastore.Type:               1800,
+}
+
+func (vc *VCenter) QueryPerformance(q protocol.PerformanceQuery) ([]protocol.PerformanceInfo, error) {
+	spec := performance.Spec{
+		Counters:  q.Counters,
+		Rollup:    q.Rollup,
+		Interval:  q.Interval,
+		Instance:  q.Instance,
+		StartTime: q.StartTime,
+		EndTime:   q.EndTime,
+		MaxSample: q.MaxSample,
+	}
+	if len(spec.Counters) == 0 {
+		spec.Counters = defaultCounters[q.EntityType]
+	}
+	if spec.Interval == 0 {
+		spec.Interval = defaultIntervals[q.EntityType]
+	}
+
+	var refs []types.ManagedObjectReference
+	for _, ID := range q.IDs {
+		refs = append(refs, types.ManagedObjectReference{Type: q.EntityType, Value: ID})
+	}
+	err := vc.checkObjects(refs)
+	if err != nil {
+		return nil, err
+	}
+	metrics, err := performance.Sample(vc.Api, spec, refs)
+	if err != nil {
+		return nil, err
+	}
+
+	performanceInfos := make([]protocol.PerformanceInfo, 0, len(metrics))
+	for _, metric := range metrics {
+		info := protocol.PerformanceInfo{
+			EntityID:   metric.Entity.Value,
+			EntityType: metric.Entity.Type,
+			Interval:   metric.Interval,
+			Timestamps: metric.Timestamps,
+		}
+		for _, s := range metric.Series {
+			series := protocol.PerformanceSeries{
+				Counter:  s.Counter,
+				Instance: s.Instance,
+				Unit:     s.Unit,
+			}
+			for _, v := range s.Values {
+				value := float64(v)
+				// 百分比的单位是0.01%
+				if s.Unit == string(types.PerformanceManagerUnitPercent) && v >= 0 {
+					value = value / 100
+				}
+				series.Values = append(series.Values, value)
+			}
+			info.Series = append(info.Series, series)
+		}
+		performanceInfos = append(performanceInfos, info)
+	}
+	return performanceInfos, nil
+}
+
+func (vc *VCenter) QueryPerformanceCounters() ([]protocol.PerformanceCounterInfo, error) {
+	counters, err := performance.GetCounters(vc.Api)
+	if err != nil {
+		return nil, err
+	}
+	counterInfos := make([]protocol.PerformanceCounterInfo, 0, len(counters))
+	for _, c := range counters {
+		info := protocol.PerformanceCounterInfo{
+			Key:       c.Key,
+			Name:      c.Name(),
+			Unit:      c.UnitInfo.GetElementDescription().Key,
+			Level:     c.Level,
+			StatsType: string(c.StatsType),
+		}
+		if c.NameInfo != nil {
+			info.Description = c.NameInfo.GetElementDescription().Summary
+		}
+		counterInfos = append(counterInfos, info)
+	}
+	return counterInfos, nil
+}
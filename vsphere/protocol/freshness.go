@@ -0,0 +1,20 @@
+package protocol
+
+import (
+	"time"
+)
+
+const (
+	FreshnessSourceCache   = "cache"
+	FreshnessSourceVCenter = "vcenter"
+)
+
+// Freshness 查询结果的新鲜度
+type Freshness struct {
+	// Source cache: 来自缓存, vcenter: 实时查询VC
+	Source string `json:"source"`
+	// UpdatedAt 缓存最后更新的时间，实时查询时为查询时间
+	UpdatedAt time.Time `json:"updatedAt"`
+	// Watching 是否在监听清单变化，监听中的缓存会随VC的变化增量更新
+	Watching bool `json:"watching"`
+}
@@ -124,3 +124,33 @@ type StoragePolicyInfo struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
 }
+
+type TagCategoryInfo struct {
+	ID              string   `json:"id"`
+	Name            string   `json:"name"`
+	Description     string   `json:"description"`
+	Cardinality     string   `json:"cardinality"`
+	AssociableTypes []string `json:"associableTypes,omitempty"`
+}
+
+type TagInfo struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	CategoryID  string `json:"categoryId"`
+}
+
+type TagQuery struct {
+	CategoryID string
+}
+
+type CustomAttributeInfo struct {
+	Key               int32  `json:"key"`
+	Name              string `json:"name"`
+	ManagedObjectType string `json:"managedObjectType"`
+}
+
+type ObjectRef struct {
+	Type string `json:"type" valid:"Required"`
+	ID   string `json:"id" valid:"Required"`
+}
@@ -0,0 +1,24 @@
+package protocol
+
+// ListOptions 查询结果的过滤、排序、分页和字段裁剪参数
+type ListOptions struct {
+	// Limit 为0时不分页
+	Limit  int
+	Offset int
+	// Sort 按字段排序，字段使用返回结果中的名称，-开头为倒序
+	Sort []string
+	// Name 名称包含，不区分大小写
+	Name string
+	// NameRegex 名称正则匹配
+	NameRegex string
+	// PowerState 电源状态，只适用于虚拟机
+	PowerState string
+	// Fields 只返回指定的字段
+	Fields []string
+}
+
+type Page struct {
+	Total  int `json:"total"`
+	Offset int `json:"offset"`
+	Limit  int `json:"limit"`
+}
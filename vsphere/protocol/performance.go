@@ -0,0 +1,41 @@
+package protocol
+
+import "time"
+
+type PerformanceQuery struct {
+	EntityType string
+	IDs        []string
+	Counters   []string
+	Rollup     string
+	Interval   int32
+	Instance   string
+	StartTime  *time.Time
+	EndTime    *time.Time
+	MaxSample  int32
+}
+
+type PerformanceInfo struct {
+	EntityID   string              `json:"entityId"`
+	EntityType string              `json:"entityType"`
+	Interval   int32               `json:"interval"`
+	Timestamps []time.Time         `json:"timestamps"`
+	Series     []PerformanceSeries `json:"series"`
+}
+
+// PerformanceSeries
+// Values与Timestamps一一对应，百分比已经换算为0-100，-1表示没有数据
+type PerformanceSeries struct {
+	Counter  string    `json:"counter"`
+	Instance string    `json:"instance"`
+	Unit     string    `json:"unit"`
+	Values   []float64 `json:"values"`
+}
+
+type PerformanceCounterInfo struct {
+	Key         int32  `json:"key"`
+	Name        string `json:"name"`
+	Unit        string `json:"unit"`
+	Level       int32  `json:"level"`
+	StatsType   string `json:"statsType"`
+	Description string `json:"description"`
+}
@@ -8,6 +8,8 @@ type VirtualMachineQuery struct {
 	ClusterID    string
 	HostID       string
 	IDs          []string
+	// TagIDs 同时添加了所有标签的虚拟机
+	TagIDs []string
 }
 
 type VirtualMachineInfo struct {
@@ -17,6 +19,16 @@ type VirtualMachineInfo struct {
 	Hostname    string `json:"hostname"`
 	PowerState  string `json:"power_state"`
 	ToolsStatus string `json:"tools_status"`
+
+	Boot *BootInfo `json:"boot,omitempty"`
+}
+
+type BootInfo struct {
+	Firmware          string   `json:"firmware"`
+	SecureBootEnabled bool     `json:"secureBootEnabled"`
+	BootDelay         int64    `json:"bootDelay"`
+	BootOrder         []string `json:"bootOrder,omitempty"`
+	EnterSetup        bool     `json:"enterSetup"`
 }
 
 type TemplateQuery struct {
@@ -60,6 +72,11 @@ type DiskInfo struct {
 	Format      *string `json:"format"`
 	Sharing     *string `json:"sharing"`
 	DatastoreID *string `json:"datastoreId"`
+
+	ControllerKey  int32  `json:"controllerKey"`
+	ControllerType string `json:"controllerType"`
+	BusNumber      int32  `json:"busNumber"`
+	UnitNumber     int32  `json:"unitNumber"`
 }
 
 type NetworkInterfaceInfo struct {
@@ -75,3 +92,77 @@ type IpInfo struct {
 	IpAddress string `json:"ipAddress"`
 	State     string `json:"state"`
 }
+
+type ExtraConfigInfo struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// VirtualMachineDetail 单个虚拟机的详细信息
+type VirtualMachineDetail struct {
+	VirtualMachineInfo
+
+	GuestFullName      string     `json:"guestFullName"`
+	ToolsVersion       string     `json:"toolsVersion"`
+	ToolsVersionStatus string     `json:"toolsVersionStatus"`
+	ToolsRunningStatus string     `json:"toolsRunningStatus"`
+	BootTime           *time.Time `json:"bootTime,omitempty"`
+	UptimeSeconds      int32      `json:"uptimeSeconds"`
+
+	HostName   string          `json:"hostName"`
+	Datastores []DatastoreName `json:"datastores,omitempty"`
+
+	CPUAllocation    *ResourceAllocationInfo `json:"cpuAllocation,omitempty"`
+	MemoryAllocation *ResourceAllocationInfo `json:"memoryAllocation,omitempty"`
+
+	Controllers []ControllerInfo     `json:"controllers,omitempty"`
+	Cdroms      []CdromInfo          `json:"cdroms,omitempty"`
+	Snapshots   *SnapshotSummaryInfo `json:"snapshots,omitempty"`
+
+	// ExtraConfig 只包含配置中允许管理的高级参数
+	ExtraConfig []ExtraConfigInfo `json:"extraConfig,omitempty"`
+}
+
+type DatastoreName struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// ResourceAllocationInfo
+// CPU单位为MHz，内存单位为MB，Limit为-1表示不限制
+type ResourceAllocationInfo struct {
+	Reservation int64  `json:"reservation"`
+	Limit       int64  `json:"limit"`
+	Shares      int32  `json:"shares"`
+	SharesLevel string `json:"sharesLevel"`
+}
+
+type ControllerInfo struct {
+	Key         int32  `json:"key"`
+	Type        string `json:"type"`
+	BusNumber   int32  `json:"busNumber"`
+	DeviceCount int    `json:"deviceCount"`
+}
+
+type CdromInfo struct {
+	ID             string `json:"id"`
+	Key            int32  `json:"key"`
+	ControllerKey  int32  `json:"controllerKey"`
+	UnitNumber     int32  `json:"unitNumber"`
+	BackingType    string `json:"backingType"`
+	ISOPath        string `json:"isoPath,omitempty"`
+	Connected      bool   `json:"connected"`
+	StartConnected bool   `json:"startConnected"`
+}
+
+type SnapshotSummaryInfo struct {
+	Count   int           `json:"count"`
+	Current *SnapshotInfo `json:"current,omitempty"`
+	Latest  *SnapshotInfo `json:"latest,omitempty"`
+}
+
+type SnapshotInfo struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	CreateTime time.Time `json:"createTime"`
+}
@@ -0,0 +1,462 @@
+package vsphere
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"vsphere-facade/app/logging"
+	"vsphere-facade/app/utils"
+	"vsphere-facade/config"
+	"vsphere-facade/helper/tag"
+	"vsphere-facade/helper/virtualmachine"
+	"vsphere-facade/vsphere/protocol"
+	"vsphere-facade/vsphere/workerpool"
+)
+
+var ErrQuotaExceeded = errors.New("超出租户资源配额")
+
+// QuotaUsage 资源使用量，内存单位为MB，硬盘单位为GB
+type QuotaUsage struct {
+	CPU      int64 `json:"cpu"`
+	MemoryMB int64 `json:"memoryMB"`
+	DiskGB   int64 `json:"diskGb"`
+	VMs      int64 `json:"vms"`
+}
+
+func (u *QuotaUsage) add(o QuotaUsage) {
+	u.CPU += o.CPU
+	u.MemoryMB += o.MemoryMB
+	u.DiskGB += o.DiskGB
+	u.VMs += o.VMs
+}
+
+// TenantUsage 租户的资源使用量，同时按集群和数据存储统计
+type TenantUsage struct {
+	QuotaUsage
+	Clusters   map[string]*QuotaUsage `json:"clusters,omitempty"`
+	Datastores map[string]*QuotaUsage `json:"datastores,omitempty"`
+}
+
+func newTenantUsage() *TenantUsage {
+	return &TenantUsage{Clusters: make(map[string]*QuotaUsage), Datastores: make(map[string]*QuotaUsage)}
+}
+
+// addCompute 统计CPU、内存和虚拟机数量，clusterID为空时只统计总量
+func (t *TenantUsage) addCompute(clusterID string, u QuotaUsage) {
+	t.QuotaUsage.add(u)
+	if clusterID == "" {
+		return
+	}
+	if t.Clusters[clusterID] == nil {
+		t.Clusters[clusterID] = &QuotaUsage{}
+	}
+	t.Clusters[clusterID].add(u)
+}
+
+// addDisk 硬盘同时计入集群和数据存储
+func (t *TenantUsage) addDisk(clusterID, datastoreID string, sizeGB int64) {
+	t.addCompute(clusterID, QuotaUsage{DiskGB: sizeGB})
+	if datastoreID == "" {
+		return
+	}
+	if t.Datastores[datastoreID] == nil {
+		t.Datastores[datastoreID] = &QuotaUsage{}
+	}
+	t.Datastores[datastoreID].DiskGB += sizeGB
+}
+
+// merge 累加另一个使用量，集群和数据存储分别累加
+func (t *TenantUsage) merge(o *TenantUsage) {
+	t.QuotaUsage.add(o.QuotaUsage)
+	for ID, u := range o.Clusters {
+		if t.Clusters[ID] == nil {
+			t.Clusters[ID] = &QuotaUsage{}
+		}
+		t.Clusters[ID].add(*u)
+	}
+	for ID, u := range o.Datastores {
+		if t.Datastores[ID] == nil {
+			t.Datastores[ID] = &QuotaUsage{}
+		}
+		t.Datastores[ID].add(*u)
+	}
+}
+
+// QuotaReservation 任务接受时预留的资源，任务结束或失败时释放，避免任务执行期间其他请求重复使用配额
+type QuotaReservation struct {
+	key    string
+	demand *TenantUsage
+	once   sync.Once
+}
+
+// Release 释放预留的资源，可以重复调用
+func (r *QuotaReservation) Release() {
+	if r == nil {
+		return
+	}
+	r.once.Do(func() { quotaReservations.remove(r) })
+}
+
+// reservations 按VC ID和租户记录预留的资源
+type reservations struct {
+	mu    sync.Mutex
+	items map[string]map[*QuotaReservation]bool
+}
+
+var (
+	quotaReservations = &reservations{items: make(map[string]map[*QuotaReservation]bool)}
+	quotaLocks        sync.Map
+)
+
+func (r *reservations) add(key string, demand *TenantUsage) *QuotaReservation {
+	reservation := &QuotaReservation{key: key, demand: demand}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.items[key] == nil {
+		r.items[key] = make(map[*QuotaReservation]bool)
+	}
+	r.items[key][reservation] = true
+	return reservation
+}
+
+func (r *reservations) remove(reservation *QuotaReservation) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.items[reservation.key], reservation)
+	if len(r.items[reservation.key]) == 0 {
+		delete(r.items, reservation.key)
+	}
+}
+
+// reserved 租户所有预留资源的合计
+func (r *reservations) reserved(key string) *TenantUsage {
+	usage := newTenantUsage()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for reservation := range r.items[key] {
+		usage.merge(reservation.demand)
+	}
+	return usage
+}
+
+// QuotaViolation 超出配额的资源
+type QuotaViolation struct {
+	// Object 超出配额的对象，租户总量为空，否则为集群或数据存储ID
+	Object    string `json:"object,omitempty"`
+	Resource  string `json:"resource"`
+	Used      int64  `json:"used"`
+	Requested int64  `json:"requested"`
+	Limit     int64  `json:"limit"`
+}
+
+func (v QuotaViolation) String() string {
+	object := "总量"
+	if v.Object != "" {
+		object = v.Object
+	}
+	return fmt.Sprintf("%s的%s已使用%d，申请%d，配额%d", object, v.Resource, v.Used, v.Requested, v.Limit)
+}
+
+// QuotaError 租户超出配额时返回，errors.Is(err, ErrQuotaExceeded)为true
+type QuotaError struct {
+	Tenant     string
+	Violations []QuotaViolation
+}
+
+func (e *QuotaError) Error() string {
+	var messages []string
+	for _, v := range e.Violations {
+		messages = append(messages, v.String())
+	}
+	return fmt.Sprintf("租户[%s]超出资源配额: %s", e.Tenant, strings.Join(messages, "; "))
+}
+
+func (e *QuotaError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
+// TenantQuota 租户在当前VC的配额，没有开启配额或没有租户时返回nil
+func (vc *VCenter) TenantQuota(tenant string) (*config.TenantQuota, error) {
+	if !config.G.Vsphere.Quota.Enable || tenant == "" {
+		return nil, nil
+	}
+	for i, q := range config.G.Vsphere.Quota.Tenants {
+		if q.Name == tenant && (q.VCID == "" || q.VCID == vc.Api.ID) {
+			return &config.G.Vsphere.Quota.Tenants[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: 租户[%s]没有配置资源配额", ErrQuotaExceeded, tenant)
+}
+
+// TenantUsage 按标签或文件夹统计租户的虚拟机，满足其中一个条件即属于租户，同时计入执行中的任务预留的资源
+func (vc *VCenter) TenantUsage(q config.TenantQuota) (*TenantUsage, error) {
+	var tagged map[string]bool
+	if q.TagID != "" {
+		var err error
+		tagged, err = tag.GetObjectsWithAllTags(vc.Api, []string{q.TagID}, virtualmachine.Type)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var index *scopeIndex
+	if q.FolderID != "" {
+		placements, err := vc.placements()
+		if err != nil {
+			return nil, err
+		}
+		index = &scopeIndex{placements: placements}
+	}
+
+	usage := newTenantUsage()
+	virtualMachines, _, err := vc.queryVirtualMachines(protocol.VirtualMachineQuery{})
+	if err != nil {
+		return nil, err
+	}
+	for _, vm := range virtualMachines {
+		owned := tagged[vm.ID]
+		if !owned && index != nil {
+			p, ok := index.placements[vm.ID]
+			owned = ok && p.Parent != nil && index.within(p.Parent.Value, []string{q.FolderID})
+		}
+		if owned {
+			addVirtualMachine(usage, vm.TemplateInfo)
+		}
+	}
+	usage.merge(quotaReservations.reserved(vc.Api.ID + "/" + q.Name))
+	return usage, nil
+}
+
+func addVirtualMachine(usage *TenantUsage, vm protocol.TemplateInfo) {
+	clusterID := ""
+	if vm.ClusterID != nil {
+		clusterID = *vm.ClusterID
+	}
+	usage.addCompute(clusterID, QuotaUsage{CPU: int64(vm.NumCPU), MemoryMB: int64(vm.MemoryMB), VMs: 1})
+	for _, d := range append([]protocol.DiskInfo{vm.SysDisk}, vm.DataDisks...) {
+		datastoreID := ""
+		if d.DatastoreID != nil {
+			datastoreID = *d.DatastoreID
+		}
+		usage.addDisk(clusterID, datastoreID, int64(d.Size))
+	}
+}
+
+// CheckDeploymentQuota
+// 按模板和部署参数估算新虚拟机使用的资源。租户按标签归属时自动添加标签，按文件夹归属时需要部署到该文件夹下。
+// 没有超出配额时预留申请的资源，任务结束后调用Release释放
+func (vc *VCenter) CheckDeploymentQuota(tenant string, p *workerpool.DeployParameter) (*QuotaReservation, error) {
+	q, err := vc.TenantQuota(tenant)
+	if q == nil || err != nil {
+		return nil, err
+	}
+	if q.TagID != "" && !utils.SliceContain(p.Tags, q.TagID) {
+		p.Tags = append(p.Tags, q.TagID)
+	}
+	if q.TagID == "" && q.FolderID != "" {
+		placements, err := vc.placements()
+		if err != nil {
+			return nil, err
+		}
+		index := &scopeIndex{placements: placements}
+		if p.Location.FolderID == nil || !index.within(*p.Location.FolderID, []string{q.FolderID}) {
+			return nil, fmt.Errorf("%w: 租户[%s]的虚拟机需要部署到文件夹[%s]下", ErrQuotaExceeded, tenant, q.FolderID)
+		}
+	}
+
+	moTemplate := virtualmachine.GetMObject(vc.Api, p.Template.ID)
+	if moTemplate == nil {
+		return nil, fmt.Errorf("模板[%s]不存在", p.Template.ID)
+	}
+	clusterID := stringValue(p.Location.ClusterID)
+	if clusterID == "" && p.Location.HostId != nil {
+		clusterID = stringValue(vc.hostCluster(*p.Location.HostId))
+	}
+	return vc.reserveQuota(*q, deploymentDemand(vc.buildTemplateInfo(*moTemplate, ""), clusterID, p))
+}
+
+// deploymentDemand 新虚拟机的资源，系统盘取模板和参数中较大的值，硬盘没有指定数据存储时使用部署位置的数据存储
+func deploymentDemand(template protocol.TemplateInfo, clusterID string, p *workerpool.DeployParameter) *TenantUsage {
+	datastoreID := func(IDs ...*string) string {
+		for _, ID := range IDs {
+			if ID != nil && *ID != "" {
+				return *ID
+			}
+		}
+		return ""
+	}
+
+	demand := newTenantUsage()
+	compute := QuotaUsage{CPU: int64(template.NumCPU), MemoryMB: int64(template.MemoryMB), VMs: 1}
+	if p.Cpu != nil && p.Cpu.NumCPU != nil {
+		compute.CPU = int64(*p.Cpu.NumCPU)
+	}
+	if p.Memory != nil && p.Memory.MemoryMB != nil {
+		compute.MemoryMB = int64(*p.Memory.MemoryMB)
+	}
+	demand.addCompute(clusterID, compute)
+
+	sysDisk := template.SysDisk
+	sysSize := int64(sysDisk.Size)
+	var sysDatastore *string
+	if p.Template.SysDisk != nil {
+		sysDatastore = p.Template.SysDisk.DatastoreId
+		if p.Template.SysDisk.Size != nil && int64(*p.Template.SysDisk.Size) > sysSize {
+			sysSize = int64(*p.Template.SysDisk.Size)
+		}
+	}
+	demand.addDisk(clusterID, datastoreID(sysDatastore, p.Location.DatastoreID, sysDisk.DatastoreID), sysSize)
+	for _, d := range template.DataDisks {
+		demand.addDisk(clusterID, datastoreID(p.Location.DatastoreID, d.DatastoreID), int64(d.Size))
+	}
+	for _, d := range p.DataDisks {
+		demand.addDisk(clusterID, datastoreID(&d.DatastoreId, p.Location.DatastoreID), int64(d.Size))
+	}
+	return demand
+}
+
+// CheckReconfigureQuota 只统计增加的CPU和内存
+func (vc *VCenter) CheckReconfigureQuota(tenant, ID string, p workerpool.ReconfigureParameter) (*QuotaReservation, error) {
+	q, err := vc.TenantQuota(tenant)
+	if q == nil || err != nil {
+		return nil, err
+	}
+	vm := vc.currentVirtualMachine(ID)
+	if vm == nil {
+		return nil, nil
+	}
+	return vc.reserveQuota(*q, reconfigureDemand(*vm, p))
+}
+
+func reconfigureDemand(vm protocol.VirtualMachineInfo, p workerpool.ReconfigureParameter) *TenantUsage {
+	compute := QuotaUsage{}
+	if p.NumCPU > vm.NumCPU {
+		compute.CPU = int64(p.NumCPU - vm.NumCPU)
+	}
+	if p.MemoryMB > vm.MemoryMB {
+		compute.MemoryMB = int64(p.MemoryMB - vm.MemoryMB)
+	}
+	demand := newTenantUsage()
+	demand.addCompute(stringValue(vm.ClusterID), compute)
+	return demand
+}
+
+// CheckDiskQuota 统计新增的硬盘和扩容的大小
+func (vc *VCenter) CheckDiskQuota(tenant, ID string, p workerpool.ReconfigureDiskParameter) (*QuotaReservation, error) {
+	q, err := vc.TenantQuota(tenant)
+	if q == nil || err != nil {
+		return nil, err
+	}
+	vm := vc.currentVirtualMachine(ID)
+	if vm == nil {
+		return nil, nil
+	}
+	return vc.reserveQuota(*q, diskDemand(*vm, p))
+}
+
+// diskDemand 没有指定数据存储的新硬盘按系统盘所在的数据存储统计
+func diskDemand(vm protocol.VirtualMachineInfo, p workerpool.ReconfigureDiskParameter) *TenantUsage {
+	clusterID := stringValue(vm.ClusterID)
+	demand := newTenantUsage()
+	for _, a := range p.Add {
+		datastoreID := a.DatastoreID
+		if datastoreID == "" {
+			datastoreID = stringValue(vm.SysDisk.DatastoreID)
+		}
+		demand.addDisk(clusterID, datastoreID, int64(a.Size))
+	}
+	for _, ed := range p.Edit {
+		if ed.Size == nil {
+			continue
+		}
+		for _, d := range append([]protocol.DiskInfo{vm.SysDisk}, vm.DataDisks...) {
+			if d.Key == ed.Key && *ed.Size > d.Size {
+				demand.addDisk(clusterID, stringValue(d.DatastoreID), int64(*ed.Size-d.Size))
+			}
+		}
+	}
+	return demand
+}
+
+func (vc *VCenter) currentVirtualMachine(ID string) *protocol.VirtualMachineInfo {
+	moVM := virtualmachine.GetMObject(vc.Api, ID)
+	if moVM == nil {
+		return nil
+	}
+	info := vc.buildVirtualMachineInfo(*moVM, "")
+	return &info
+}
+
+// reserveQuota 使用量(包括其他任务预留的资源)加上申请量超过配额时返回QuotaError，否则预留申请的资源。
+// 同一个租户的检查和预留串行执行，避免并发的任务同时通过检查
+func (vc *VCenter) reserveQuota(q config.TenantQuota, demand *TenantUsage) (*QuotaReservation, error) {
+	key := vc.Api.ID + "/" + q.Name
+	mu, _ := quotaLocks.LoadOrStore(key, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	defer mu.(*sync.Mutex).Unlock()
+
+	used, err := vc.TenantUsage(q)
+	if err != nil {
+		logging.L().Error("统计租户资源使用量失败", err)
+		return nil, err
+	}
+	if violations := checkQuota(q, used, demand); len(violations) > 0 {
+		return nil, &QuotaError{Tenant: q.Name, Violations: violations}
+	}
+	return quotaReservations.add(key, demand), nil
+}
+
+// checkQuota 返回超出租户总量、集群和数据存储配额的资源
+func checkQuota(q config.TenantQuota, used, demand *TenantUsage) []QuotaViolation {
+	violations := exceeded("", q.QuotaLimit, used.QuotaUsage, demand.QuotaUsage)
+	for _, c := range q.Clusters {
+		if d, ok := demand.Clusters[c.ID]; ok {
+			violations = append(violations, exceeded(c.ID, c.QuotaLimit, usageOf(used.Clusters, c.ID), *d)...)
+		}
+	}
+	for _, ds := range q.Datastores {
+		if d, ok := demand.Datastores[ds.ID]; ok {
+			limit := config.QuotaLimit{DiskGB: ds.DiskGB}
+			violations = append(violations, exceeded(ds.ID, limit, usageOf(used.Datastores, ds.ID), *d)...)
+		}
+	}
+	return violations
+}
+
+func exceeded(object string, limit config.QuotaLimit, used, demand QuotaUsage) []QuotaViolation {
+	var violations []QuotaViolation
+	for _, r := range []struct {
+		name                   string
+		limit, used, requested int64
+	}{
+		{"cpu", limit.CPU, used.CPU, demand.CPU},
+		{"memoryGb", limit.MemoryGB * 1024, used.MemoryMB, demand.MemoryMB},
+		{"diskGb", limit.DiskGB, used.DiskGB, demand.DiskGB},
+		{"vms", limit.VMs, used.VMs, demand.VMs},
+	} {
+		if r.limit <= 0 || r.requested <= 0 || r.used+r.requested <= r.limit {
+			continue
+		}
+		v := QuotaViolation{Object: object, Resource: r.name, Used: r.used, Requested: r.requested, Limit: r.limit}
+		if r.name == "memoryGb" {
+			// 内存配额按GB配置，向上取整展示
+			v.Used, v.Requested, v.Limit = (r.used+1023)/1024, (r.requested+1023)/1024, limit.MemoryGB
+		}
+		violations = append(violations, v)
+	}
+	return violations
+}
+
+func usageOf(usages map[string]*QuotaUsage, ID string) QuotaUsage {
+	if u, ok := usages[ID]; ok {
+		return *u
+	}
+	return QuotaUsage{}
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
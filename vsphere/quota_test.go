@@ -0,0 +1,149 @@
+package vsphere
+
+import (
+	"testing"
+	"vsphere-facade/config"
+	"vsphere-facade/helper/virtualmachine/virtualmachinereconfig"
+	"vsphere-facade/vsphere/protocol"
+	"vsphere-facade/vsphere/workerpool"
+)
+
+func TestExceeded(t *testing.T) {
+	limit := config.QuotaLimit{CPU: 8, MemoryGB: 16, DiskGB: 100, VMs: 2}
+	tests := []struct {
+		name      string
+		limit     config.QuotaLimit
+		used      QuotaUsage
+		demand    QuotaUsage
+		resources []string
+	}{
+		{"没有超出", limit, QuotaUsage{CPU: 4, MemoryMB: 8192, DiskGB: 50, VMs: 1}, QuotaUsage{CPU: 4, MemoryMB: 8192, DiskGB: 50, VMs: 1}, nil},
+		{"CPU和虚拟机数量超出", limit, QuotaUsage{CPU: 6, VMs: 2}, QuotaUsage{CPU: 4, VMs: 1}, []string{"cpu", "vms"}},
+		{"内存超出", limit, QuotaUsage{MemoryMB: 16384}, QuotaUsage{MemoryMB: 1}, []string{"memoryGb"}},
+		{"硬盘超出", limit, QuotaUsage{DiskGB: 99}, QuotaUsage{DiskGB: 2}, []string{"diskGb"}},
+		{"已经超出但没有申请", limit, QuotaUsage{CPU: 10}, QuotaUsage{MemoryMB: 1024}, nil},
+		{"0为不限制", config.QuotaLimit{}, QuotaUsage{CPU: 100}, QuotaUsage{CPU: 100}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := exceeded("", tt.limit, tt.used, tt.demand)
+			if len(violations) != len(tt.resources) {
+				t.Fatal(violations)
+			}
+			for i, v := range violations {
+				if v.Resource != tt.resources[i] {
+					t.Error(v)
+				}
+			}
+		})
+	}
+
+	v := exceeded("cluster-1", limit, QuotaUsage{MemoryMB: 16000}, QuotaUsage{MemoryMB: 1025})
+	if len(v) != 1 || v[0].Object != "cluster-1" || v[0].Used != 16 || v[0].Requested != 2 || v[0].Limit != 16 {
+		t.Error("内存按GB向上取整", v)
+	}
+}
+
+func TestDeploymentDemand(t *testing.T) {
+	ds1, ds2, ds3 := "datastore-1", "datastore-2", "datastore-3"
+	template := protocol.TemplateInfo{
+		NumCPU:    2,
+		MemoryMB:  4096,
+		SysDisk:   protocol.DiskInfo{Size: 40, DatastoreID: &ds1},
+		DataDisks: []protocol.DiskInfo{{Size: 10, DatastoreID: &ds1}},
+	}
+
+	demand := deploymentDemand(template, "cluster-1", &workerpool.DeployParameter{})
+	if demand.QuotaUsage != (QuotaUsage{CPU: 2, MemoryMB: 4096, DiskGB: 50, VMs: 1}) {
+		t.Error("没有参数时使用模板的配置", demand.QuotaUsage)
+	}
+	if *demand.Clusters["cluster-1"] != demand.QuotaUsage || demand.Datastores[ds1].DiskGB != 50 {
+		t.Error(demand.Clusters, demand.Datastores)
+	}
+
+	numCPU, memoryMB, smaller, larger := int32(4), int32(8192), int32(20), int32(60)
+	p := &workerpool.DeployParameter{
+		Template: workerpool.Template{SysDisk: &workerpool.SysDisk{Size: &smaller}},
+		Cpu:      &virtualmachinereconfig.CpuParameter{NumCPU: &numCPU},
+		Memory:   &virtualmachinereconfig.MemoryParameter{MemoryMB: &memoryMB},
+		DataDisks: []*workerpool.DataDisk{
+			{Size: 100, DatastoreId: ds3},
+			{Size: 5},
+		},
+	}
+	p.Location.DatastoreID = &ds2
+	demand = deploymentDemand(template, "", p)
+	if demand.QuotaUsage != (QuotaUsage{CPU: 4, MemoryMB: 8192, DiskGB: 155, VMs: 1}) {
+		t.Error("系统盘不能小于模板", demand.QuotaUsage)
+	}
+	if len(demand.Clusters) != 0 {
+		t.Error("没有集群时只统计总量", demand.Clusters)
+	}
+	if demand.Datastores[ds2].DiskGB != 55 || demand.Datastores[ds3].DiskGB != 100 || demand.Datastores[ds1] != nil {
+		t.Error("硬盘没有指定数据存储时使用部署位置的数据存储", demand.Datastores)
+	}
+
+	p.Template.SysDisk = &workerpool.SysDisk{Size: &larger, DatastoreId: &ds3}
+	demand = deploymentDemand(template, "", p)
+	if demand.DiskGB != 175 || demand.Datastores[ds3].DiskGB != 160 {
+		t.Error("系统盘扩容并指定数据存储", demand.Datastores)
+	}
+}
+
+func TestReconfigureDemand(t *testing.T) {
+	cluster := "cluster-1"
+	vm := protocol.VirtualMachineInfo{TemplateInfo: protocol.TemplateInfo{NumCPU: 4, MemoryMB: 8192, ClusterID: &cluster}}
+
+	demand := reconfigureDemand(vm, workerpool.ReconfigureParameter{NumCPU: 8, MemoryMB: 4096})
+	if demand.QuotaUsage != (QuotaUsage{CPU: 4}) || *demand.Clusters[cluster] != demand.QuotaUsage {
+		t.Error("只统计增加的资源", demand.QuotaUsage)
+	}
+	demand = reconfigureDemand(vm, workerpool.ReconfigureParameter{})
+	if demand.QuotaUsage != (QuotaUsage{}) {
+		t.Error("没有修改时不申请资源", demand.QuotaUsage)
+	}
+}
+
+func TestDiskDemand(t *testing.T) {
+	ds1, ds2 := "datastore-1", "datastore-2"
+	vm := protocol.VirtualMachineInfo{TemplateInfo: protocol.TemplateInfo{
+		SysDisk:   protocol.DiskInfo{Key: 2000, Size: 40, DatastoreID: &ds1},
+		DataDisks: []protocol.DiskInfo{{Key: 2001, Size: 100, DatastoreID: &ds2}},
+	}}
+	p := workerpool.ReconfigureDiskParameter{}
+	p.Add = append(p.Add, struct {
+		DatastoreID string `json:"datastoreId"`
+		virtualmachinereconfig.AddDiskParameter
+	}{AddDiskParameter: virtualmachinereconfig.AddDiskParameter{Size: 10}})
+	grow, shrink := int32(60), int32(50)
+	p.Edit = []virtualmachinereconfig.EditDiskParameter{{Key: 2000, Size: &grow}, {Key: 2001, Size: &shrink}}
+
+	demand := diskDemand(vm, p)
+	if demand.DiskGB != 30 || demand.Datastores[ds1].DiskGB != 30 || demand.Datastores[ds2] != nil {
+		t.Error("新硬盘使用系统盘的数据存储，只统计扩容的大小", demand.DiskGB, demand.Datastores)
+	}
+}
+
+func TestQuotaReservation(t *testing.T) {
+	key := "vc/tenant"
+	demand := newTenantUsage()
+	demand.addDisk("cluster-1", "datastore-1", 10)
+	r1 := quotaReservations.add(key, demand)
+	r2 := quotaReservations.add(key, demand)
+
+	reserved := quotaReservations.reserved(key)
+	if reserved.DiskGB != 20 || reserved.Clusters["cluster-1"].DiskGB != 20 || reserved.Datastores["datastore-1"].DiskGB != 20 {
+		t.Error("应该统计所有预留的资源", reserved)
+	}
+	r1.Release()
+	r1.Release()
+	if reserved = quotaReservations.reserved(key); reserved.DiskGB != 10 {
+		t.Error("重复释放只释放一次", reserved.DiskGB)
+	}
+	r2.Release()
+	if _, ok := quotaReservations.items[key]; ok {
+		t.Error("全部释放后应该删除租户的记录")
+	}
+	var r3 *QuotaReservation
+	r3.Release()
+}
@@ -0,0 +1,110 @@
+package vsphere
+
+import (
+	"fmt"
+	"vsphere-facade/app/logging"
+	"vsphere-facade/app/utils"
+	"vsphere-facade/config"
+	"vsphere-facade/helper"
+)
+
+// VCenterStatus 登记的VC及其缓存的服务账号会话的状态
+type VCenterStatus struct {
+	Alias   string `json:"alias"`
+	Address string `json:"address"`
+	// ID VC实例ID，没有缓存的会话时为空
+	ID      string `json:"id,omitempty"`
+	Version string `json:"version,omitempty"`
+	// Connected 有可用的缓存会话
+	Connected bool   `json:"connected"`
+	Error     string `json:"error,omitempty"`
+	Watching  bool   `json:"watching"`
+	// CircuitOpen 连续连接失败熔断中
+	CircuitOpen bool `json:"circuitOpen"`
+}
+
+// RegisteredAuth 登记的VC的服务账号，密码可以是密文，解密失败时返回错误
+func RegisteredAuth(v config.VCenterConfig) (Auth, error) {
+	password := v.Password
+	if utils.KeyIDOf(password) != "" {
+		password = utils.AesDecrypt(password)
+		if password == "" {
+			return Auth{}, fmt.Errorf("VC[%s]的服务账号密码解密失败", v.Alias)
+		}
+	}
+	return Auth{Address: v.Address, Username: v.Username, Password: password}, nil
+}
+
+// GetRegistered 按别名、实例ID或地址使用服务账号连接登记的VC
+func GetRegistered(key string) (*VCenter, error) {
+	v := helper.FindVCenter(key)
+	if v == nil {
+		return nil, fmt.Errorf("VC[%s]没有登记", key)
+	}
+	auth, err := RegisteredAuth(*v)
+	if err != nil {
+		return nil, err
+	}
+	return Get(auth)
+}
+
+// VCenterStatuses 所有登记的VC的状态，只查看缓存的会话，不会登录VC
+func VCenterStatuses() []VCenterStatus {
+	vcenters := config.G.Vsphere.VCenters
+	statuses := make([]VCenterStatus, 0, len(vcenters))
+	for _, v := range vcenters {
+		statuses = append(statuses, vcenterStatus(v))
+	}
+	return statuses
+}
+
+func vcenterStatus(v config.VCenterConfig) VCenterStatus {
+	status := VCenterStatus{
+		Alias:       v.Alias,
+		Address:     v.Address,
+		CircuitOpen: helper.CircuitOpen(v.Address),
+	}
+	auth, err := RegisteredAuth(v)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	api := helper.CachedAPI(auth.Address, auth.Username, auth.Password)
+	if api == nil {
+		return status
+	}
+	vc := VCenter{Api: api}
+	status.ID = api.ID
+	status.Version = api.Client.ServiceContent.About.Version
+	status.Connected = true
+	status.Watching = vc.IsWatching()
+	return status
+}
+
+// WarmUp 为开启了warmUp的登记VC创建缓存，没有开启缓存时不处理。
+// 开启了清单指标时为所有登记的VC创建缓存，不需要等调用方的令牌连接后才有指标
+func WarmUp() {
+	if !config.G.Vsphere.Cache.Enable {
+		return
+	}
+	inventory := config.G.Server.Metrics.Enable && config.G.Server.Metrics.Inventory
+	for _, v := range config.G.Vsphere.VCenters {
+		if !v.WarmUp && !inventory {
+			continue
+		}
+		go func(v config.VCenterConfig) {
+			auth, err := RegisteredAuth(v)
+			if err != nil {
+				logging.L().Error("缓存预热失败: ", err)
+				return
+			}
+			vc, err := Get(auth)
+			if err != nil {
+				logging.L().Error("缓存预热失败: ", err)
+				return
+			}
+			logging.L().Infof("为登记的VC[%s]预热缓存", v.Alias)
+			vc.CreateCache()
+		}(v)
+	}
+}
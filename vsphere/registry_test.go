@@ -0,0 +1,59 @@
+package vsphere
+
+import (
+	"github.com/vmware/govmomi/simulator"
+	"os"
+	"testing"
+	"time"
+	"vsphere-facade/app/logging"
+	"vsphere-facade/config"
+	"vsphere-facade/helper"
+)
+
+func TestVCenterStatuses(t *testing.T) {
+	config.G.Server.Log.Path = os.TempDir()
+	config.G.Server.Log.Level = "error"
+	logging.Setup()
+	helper.APITimeout = time.Minute
+	config.G.Vsphere.TLS.Insecure = true
+
+	model := simulator.VPX()
+	defer model.Remove()
+	if err := model.Create(); err != nil {
+		t.Fatal(err)
+	}
+	s := model.Service.NewServer()
+	defer s.Close()
+
+	password, _ := s.URL.User.Password()
+	saved := config.G.Vsphere.VCenters
+	defer func() {
+		config.G.Vsphere.VCenters = saved
+		config.G.Vsphere.TLS.Insecure = false
+	}()
+	config.G.Vsphere.VCenters = []config.VCenterConfig{
+		{Alias: "sim", Address: s.URL.Scheme + "://" + s.URL.Host, Username: s.URL.User.Username(), Password: password},
+		{Alias: "broken", Address: "https://127.0.0.1:1", Username: "u", Password: "v2:missing:AAAA"},
+	}
+
+	if _, err := RegisteredAuth(config.G.Vsphere.VCenters[1]); err == nil {
+		t.Fatal("密码解密失败时应该返回错误")
+	}
+
+	statuses := VCenterStatuses()
+	if len(statuses) != 2 || statuses[0].Connected || statuses[0].ID != "" {
+		t.Fatal("没有缓存的会话时不应该登录VC", statuses)
+	}
+	if statuses[1].Error == "" {
+		t.Error("密码解密失败时应该返回错误", statuses[1])
+	}
+
+	vc, err := GetRegistered("sim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses = VCenterStatuses()
+	if !statuses[0].Connected || statuses[0].ID != vc.Api.ID || statuses[0].Version == "" {
+		t.Error("有缓存的会话时应该返回VC状态", statuses[0])
+	}
+}
@@ -3,6 +3,7 @@ package vsphere
 import (
 	"github.com/vmware/govmomi/vim25/mo"
 	"vsphere-facade/app/logging"
+	"vsphere-facade/app/metrics"
 	"vsphere-facade/app/utils"
 	"vsphere-facade/helper/resourcepool"
 	"vsphere-facade/vsphere/protocol"
@@ -15,8 +16,17 @@ const (
 )
 
 // QueryResourcePools 查询集群和主机下的资源池，virtual app的资源池在virtual app中
-func (vc *VCenter) QueryResourcePools(q protocol.ResourcePoolQuery) []protocol.ResourcePoolInfo {
+func (vc *VCenter) QueryResourcePools(q protocol.ResourcePoolQuery) ([]protocol.ResourcePoolInfo, error) {
+	items, err := vc.filterScope(vc.queryResourcePools(q), resourcepool.Type)
+	if err != nil {
+		return nil, err
+	}
+	return items.([]protocol.ResourcePoolInfo), nil
+}
+
+func (vc *VCenter) queryResourcePools(q protocol.ResourcePoolQuery) []protocol.ResourcePoolInfo {
 	resourcePools := vc.queryResourcePoolsFromCache(q)
+	metrics.CacheQuery(vc.Cache.VCID, resourcepool.Type, resourcePools != nil)
 	if resourcePools != nil {
 		logging.L().Debug("本次查询使用了缓存")
 		return resourcePools
@@ -0,0 +1,259 @@
+package vsphere
+
+import (
+	"errors"
+	"fmt"
+	"github.com/vmware/govmomi/vim25/types"
+	"reflect"
+	"vsphere-facade/app/logging"
+	"vsphere-facade/app/utils"
+	"vsphere-facade/helper/datacenter"
+	"vsphere-facade/helper/folder"
+	"vsphere-facade/helper/inventory"
+	"vsphere-facade/helper/resourcepool"
+	"vsphere-facade/helper/tag"
+	"vsphere-facade/helper/virtualmachine"
+	"vsphere-facade/vsphere/protocol"
+	"vsphere-facade/vsphere/workerpool"
+)
+
+var ErrOutOfScope = errors.New("超出允许访问的清单范围")
+
+// Scope 调用者允许访问的清单范围，为空的条件不限制，多个条件需要同时满足。
+// 数据中心限制所有对象，文件夹、资源池和标签只限制虚拟机，模板只受数据中心限制
+type Scope struct {
+	// 数据中心ID
+	Datacenters []string `json:"datacenters,omitempty"`
+	// 虚拟机文件夹ID，包含子文件夹
+	Folders []string `json:"folders,omitempty"`
+	// 资源池ID，包含子资源池
+	ResourcePools []string `json:"resourcePools,omitempty"`
+	// 标签ID，虚拟机需要有其中一个标签
+	Tags []string `json:"tags,omitempty"`
+}
+
+func (s *Scope) unrestricted() bool {
+	return s == nil || (len(s.Datacenters) == 0 && len(s.Folders) == 0 && len(s.ResourcePools) == 0 && len(s.Tags) == 0)
+}
+
+// Scoped 返回限制了清单范围的VCenter，查询结果只包含范围内的对象，操作范围外的对象返回ErrOutOfScope
+func (vc *VCenter) Scoped(s *Scope) *VCenter {
+	if s.unrestricted() {
+		return vc
+	}
+	scoped := *vc
+	scoped.scope = s
+	return &scoped
+}
+
+// scopeIndex 一次请求内判断对象是否在范围内，清单对象的位置只查询一次
+type scopeIndex struct {
+	scope      *Scope
+	placements map[string]inventory.Placement
+	tagged     map[string]bool
+}
+
+func (vc *VCenter) scopeIndex() (*scopeIndex, error) {
+	placements, err := vc.placements()
+	if err != nil {
+		return nil, err
+	}
+	index := &scopeIndex{scope: vc.scope, placements: placements}
+	if len(vc.scope.Tags) > 0 {
+		index.tagged, err = tag.GetObjectsWithAnyTags(vc.Api, vc.scope.Tags, virtualmachine.Type)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return index, nil
+}
+
+// placements 清单变化监听运行中时使用监听到的位置，否则查询VC
+func (vc *VCenter) placements() (map[string]inventory.Placement, error) {
+	if placements := vc.watchedPlacements(); placements != nil {
+		return placements, nil
+	}
+	return inventory.GetPlacements(vc.Api)
+}
+
+// datacenter 对象所在的数据中心
+func (x *scopeIndex) datacenter(ID string) string {
+	for i := 0; ID != "" && i < 64; i++ {
+		p, ok := x.placements[ID]
+		if !ok {
+			return ""
+		}
+		if p.Type == datacenter.Type {
+			return ID
+		}
+		if p.Parent == nil {
+			return ""
+		}
+		ID = p.Parent.Value
+	}
+	return ""
+}
+
+// within 对象自身或上级对象在ancestors中
+func (x *scopeIndex) within(ID string, ancestors []string) bool {
+	for i := 0; ID != "" && i < 64; i++ {
+		if utils.SliceContain(ancestors, ID) {
+			return true
+		}
+		p, ok := x.placements[ID]
+		if !ok || p.Parent == nil {
+			return false
+		}
+		ID = p.Parent.Value
+	}
+	return false
+}
+
+func (x *scopeIndex) allowDatacenter(ID string) bool {
+	return len(x.scope.Datacenters) == 0 || utils.SliceContain(x.scope.Datacenters, ID)
+}
+
+func (x *scopeIndex) allowVirtualMachine(ID string) bool {
+	p, ok := x.placements[ID]
+	if !ok || p.Type != virtualmachine.Type {
+		return false
+	}
+	if !x.allowDatacenter(x.datacenter(ID)) {
+		return false
+	}
+	if len(x.scope.Folders) > 0 && (p.Parent == nil || !x.within(p.Parent.Value, x.scope.Folders)) {
+		return false
+	}
+	if len(x.scope.ResourcePools) > 0 && (p.ResourcePool == nil || !x.within(p.ResourcePool.Value, x.scope.ResourcePools)) {
+		return false
+	}
+	if len(x.scope.Tags) > 0 && !x.tagged[ID] {
+		return false
+	}
+	return true
+}
+
+// allowObject 虚拟机按全部条件判断，文件夹和资源池还需要在范围内的文件夹和资源池下，其他对象只判断数据中心
+func (x *scopeIndex) allowObject(ref types.ManagedObjectReference) bool {
+	switch ref.Type {
+	case virtualmachine.Type:
+		return x.allowVirtualMachine(ref.Value)
+	case datacenter.Type:
+		return x.allowDatacenter(ref.Value)
+	case folder.Type:
+		if len(x.scope.Folders) > 0 && !x.within(ref.Value, x.scope.Folders) {
+			return false
+		}
+	case resourcepool.Type:
+		if len(x.scope.ResourcePools) > 0 && !x.within(ref.Value, x.scope.ResourcePools) {
+			return false
+		}
+	}
+	return x.allowDatacenter(x.datacenter(ref.Value))
+}
+
+// CheckScope 虚拟机都在允许访问的范围内时返回nil，不存在的虚拟机也视为超出范围
+func (vc *VCenter) CheckScope(IDs ...string) error {
+	var refs []types.ManagedObjectReference
+	for _, ID := range IDs {
+		refs = append(refs, types.ManagedObjectReference{Type: virtualmachine.Type, Value: ID})
+	}
+	return vc.checkObjects(refs)
+}
+
+func (vc *VCenter) checkObjects(refs []types.ManagedObjectReference) error {
+	if vc.scope.unrestricted() || len(refs) == 0 {
+		return nil
+	}
+	index, err := vc.scopeIndex()
+	if err != nil {
+		return err
+	}
+	var denied []string
+	for _, ref := range refs {
+		if !index.allowObject(ref) {
+			denied = append(denied, ref.Value)
+		}
+	}
+	if len(denied) > 0 {
+		return fmt.Errorf("%w: %v", ErrOutOfScope, denied)
+	}
+	return nil
+}
+
+// CheckDeployment 新虚拟机的模板、数据中心、文件夹和资源池需要在范围内，
+// 限制了文件夹、资源池或标签时必须指定对应的位置或添加其中一个标签，保证创建的虚拟机仍在范围内
+func (vc *VCenter) CheckDeployment(p workerpool.DeployParameter) error {
+	if vc.scope.unrestricted() {
+		return nil
+	}
+	index, err := vc.scopeIndex()
+	if err != nil {
+		return err
+	}
+	location := p.Location
+	if !index.allowDatacenter(location.DatacenterID) {
+		return fmt.Errorf("%w: 数据中心[%s]", ErrOutOfScope, location.DatacenterID)
+	}
+	if !index.allowDatacenter(index.datacenter(p.Template.ID)) {
+		return fmt.Errorf("%w: 模板[%s]", ErrOutOfScope, p.Template.ID)
+	}
+	if len(vc.scope.Folders) > 0 && (location.FolderID == nil || !index.within(*location.FolderID, vc.scope.Folders)) {
+		return fmt.Errorf("%w: 需要指定允许的文件夹", ErrOutOfScope)
+	}
+	if len(vc.scope.ResourcePools) > 0 && (location.ResourcePoolID == nil || !index.within(*location.ResourcePoolID, vc.scope.ResourcePools)) {
+		return fmt.Errorf("%w: 需要指定允许的资源池", ErrOutOfScope)
+	}
+	if len(vc.scope.Tags) > 0 {
+		tagged := false
+		for _, tagID := range p.Tags {
+			if utils.SliceContain(vc.scope.Tags, tagID) {
+				tagged = true
+				break
+			}
+		}
+		if !tagged {
+			return fmt.Errorf("%w: 需要添加允许的标签", ErrOutOfScope)
+		}
+	}
+	return nil
+}
+
+// templateType 模板也是虚拟机，只按数据中心过滤
+const templateType = "Template"
+
+// filterScope 按元素的ID字段过滤查询结果，返回同类型的切片
+func (vc *VCenter) filterScope(items interface{}, objectType string) (interface{}, error) {
+	v := reflect.ValueOf(items)
+	if vc.scope.unrestricted() || v.Len() == 0 {
+		return items, nil
+	}
+	index, err := vc.scopeIndex()
+	if err != nil {
+		logging.L().Error("查询清单范围时发生错误", err)
+		return nil, err
+	}
+
+	filtered := reflect.MakeSlice(v.Type(), 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		ID := v.Index(i).FieldByName("ID").String()
+		var allowed bool
+		if objectType == templateType {
+			allowed = index.allowDatacenter(index.datacenter(ID))
+		} else {
+			allowed = index.allowObject(types.ManagedObjectReference{Type: objectType, Value: ID})
+		}
+		if allowed {
+			filtered = reflect.Append(filtered, v.Index(i))
+		}
+	}
+	return filtered.Interface(), nil
+}
+
+func objectRefs(objects []protocol.ObjectRef) []types.ManagedObjectReference {
+	var refs []types.ManagedObjectReference
+	for _, o := range objects {
+		refs = append(refs, objectReference(o))
+	}
+	return refs
+}
@@ -0,0 +1,55 @@
+package vsphere
+
+import (
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	"testing"
+	"vsphere-facade/helper/inventory"
+)
+
+func TestUpdatePlacements(t *testing.T) {
+	dc := types.ManagedObjectReference{Type: "Datacenter", Value: "datacenter-1"}
+	f1 := types.ManagedObjectReference{Type: "Folder", Value: "group-v1"}
+	f2 := types.ManagedObjectReference{Type: "Folder", Value: "group-v2"}
+	pool := types.ManagedObjectReference{Type: "ResourcePool", Value: "resgroup-1"}
+	vmRef := types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-1"}
+
+	vm := &mo.VirtualMachine{ResourcePool: &pool}
+	vm.Self, vm.Parent = vmRef, &f1
+	folder1, folder2 := &mo.Folder{}, &mo.Folder{}
+	folder1.Self, folder1.Parent = f1, &dc
+	folder2.Self, folder2.Parent = f2, &dc
+	w := &watcher{objects: map[types.ManagedObjectReference]mo.Entity{
+		dc: &mo.Datacenter{}, f1: folder1, f2: folder2, vmRef: vm,
+	}}
+
+	w.updatePlacements(nil, nil)
+	first := w.placements.Load().(map[string]inventory.Placement)
+	index := &scopeIndex{scope: &Scope{Folders: []string{f1.Value}}, placements: first}
+	if len(first) != 4 || !index.allowVirtualMachine(vmRef.Value) || first[vmRef.Value].ResourcePool.Value != pool.Value {
+		t.Fatal("第一批对象应该重建所有对象的位置", first)
+	}
+
+	// 位置没有变化时不替换
+	w.updatePlacements([]types.ManagedObjectReference{vmRef}, nil)
+	if second := w.placements.Load().(map[string]inventory.Placement); len(second) != 4 {
+		t.Fatal(second)
+	}
+
+	vm.Parent = &f2
+	w.updatePlacements([]types.ManagedObjectReference{vmRef}, nil)
+	moved := w.placements.Load().(map[string]inventory.Placement)
+	if moved[vmRef.Value].Parent.Value != f2.Value || first[vmRef.Value].Parent.Value != f1.Value {
+		t.Error("位置变化时复制后替换，不能修改读取方正在使用的数据")
+	}
+	index.placements = moved
+	if index.allowVirtualMachine(vmRef.Value) {
+		t.Error("虚拟机移动到范围外的文件夹后不允许访问")
+	}
+
+	delete(w.objects, vmRef)
+	w.updatePlacements(nil, []types.ManagedObjectReference{vmRef})
+	if _, ok := w.placements.Load().(map[string]inventory.Placement)[vmRef.Value]; ok {
+		t.Error("删除的对象应该从位置中移除")
+	}
+}
@@ -4,14 +4,17 @@ import (
 	"context"
 	"github.com/vmware/govmomi/pbm/types"
 	"vsphere-facade/app/logging"
+	"vsphere-facade/app/metrics"
 	"vsphere-facade/helper"
 	"vsphere-facade/helper/spbm"
 	"vsphere-facade/helper/vsphere"
+	vCache "vsphere-facade/vsphere/cache"
 	"vsphere-facade/vsphere/protocol"
 )
 
 func (vc *VCenter) QueryStoragePolicies(q protocol.StoragePolicyQuery) []protocol.StoragePolicyInfo {
 	cache := vc.Cache.GetStoragePolicies()
+	metrics.CacheQuery(vc.Cache.VCID, vCache.StoragePolicyCacheKey, cache != nil)
 	if cache != nil {
 		logging.L().Debug("本次查询使用了缓存")
 		return cache
@@ -0,0 +1,102 @@
+package vsphere
+
+import (
+	"github.com/vmware/govmomi/vim25/types"
+	"vsphere-facade/helper/customfield"
+	"vsphere-facade/helper/tag"
+	"vsphere-facade/vsphere/protocol"
+)
+
+func (vc *VCenter) QueryTagCategories() []protocol.TagCategoryInfo {
+	categories := tag.GetCategories(vc.Api)
+	if categories == nil {
+		return nil
+	}
+	var categoryInfos []protocol.TagCategoryInfo
+	for _, c := range categories {
+		categoryInfos = append(categoryInfos, protocol.TagCategoryInfo{
+			ID:              c.ID,
+			Name:            c.Name,
+			Description:     c.Description,
+			Cardinality:     c.Cardinality,
+			AssociableTypes: c.AssociableTypes,
+		})
+	}
+	return categoryInfos
+}
+
+func (vc *VCenter) QueryTags(q protocol.TagQuery) []protocol.TagInfo {
+	tags := tag.GetTags(vc.Api, q.CategoryID)
+	if tags == nil {
+		return nil
+	}
+	var tagInfos []protocol.TagInfo
+	for _, t := range tags {
+		tagInfos = append(tagInfos, protocol.TagInfo{
+			ID:          t.ID,
+			Name:        t.Name,
+			Description: t.Description,
+			CategoryID:  t.CategoryID,
+		})
+	}
+	return tagInfos
+}
+
+func (vc *VCenter) AttachTags(tagIDs []string, objects []protocol.ObjectRef) error {
+	err := vc.checkObjects(objectRefs(objects))
+	if err != nil {
+		return err
+	}
+	for _, o := range objects {
+		err := tag.Attach(vc.Api, tagIDs, objectReference(o))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (vc *VCenter) DetachTags(tagIDs []string, objects []protocol.ObjectRef) error {
+	err := vc.checkObjects(objectRefs(objects))
+	if err != nil {
+		return err
+	}
+	for _, o := range objects {
+		err := tag.Detach(vc.Api, tagIDs, objectReference(o))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (vc *VCenter) QueryCustomAttributes() []protocol.CustomAttributeInfo {
+	fields := customfield.GetFields(vc.Api)
+	if fields == nil {
+		return nil
+	}
+	var customAttributeInfos []protocol.CustomAttributeInfo
+	for _, f := range fields {
+		customAttributeInfos = append(customAttributeInfos, protocol.CustomAttributeInfo{
+			Key:               f.Key,
+			Name:              f.Name,
+			ManagedObjectType: f.ManagedObjectType,
+		})
+	}
+	return customAttributeInfos
+}
+
+func (vc *VCenter) SetCustomAttributes(object protocol.ObjectRef, values map[string]string) error {
+	err := vc.checkObjects([]types.ManagedObjectReference{objectReference(object)})
+	if err != nil {
+		return err
+	}
+	return customfield.Set(vc.Api, objectReference(object), values)
+}
+
+func objectReference(o protocol.ObjectRef) types.ManagedObjectReference {
+	return types.ManagedObjectReference{
+		Type:  o.Type,
+		Value: o.ID,
+	}
+}
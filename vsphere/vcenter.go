@@ -1,6 +1,7 @@
 package vsphere
 
 import (
+	"context"
 	"fmt"
 	"time"
 	"vsphere-facade/app/logging"
@@ -20,23 +21,34 @@ type Auth struct {
 type VCenter struct {
 	Api   *helper.API
 	Cache *cache.VCCache
+	// scope 调用者允许访问的清单范围，nil为不限制
+	scope *Scope
 }
 
-func Get(a Auth) *VCenter {
+// Get 连接VC，连接失败或熔断中时返回错误
+func Get(a Auth) (*VCenter, error) {
+	return GetContext(context.Background(), a)
+}
+
+// GetContext 与Get相同，建立连接的时间不超过ctx
+func GetContext(ctx context.Context, a Auth) (*VCenter, error) {
+	api, err := helper.InitContext(ctx, a.Address, a.Username, a.Password)
+	if err != nil {
+		return nil, err
+	}
 	vc := VCenter{}
-	api := helper.Init(a.Address, a.Username, a.Password)
 	vc.Api = api
 	vc.Cache = &cache.VCCache{
 		VCID: api.ID,
 	}
-	return &vc
+	return &vc, nil
 }
 
 func (vc *VCenter) CreateCache() {
 	if config.G.Vsphere.Cache.Enable {
 		logging.L().Debug(fmt.Sprintf("为VCenter[%s]创建缓存数据开始", vc.Cache.VCID))
 		vc.createCache()
-		vc.CreateCacheTicker()
+		vc.StartWatcher()
 		logging.L().Debug(fmt.Sprintf("为VCenter[%s]创建缓存数据完成", vc.Cache.VCID))
 	} else {
 		logging.L().Debug("未开启缓存配置")
@@ -52,38 +64,31 @@ func (vc *VCenter) createCache() {
 	vc.getClusters()
 	vc.getHosts()
 	vc.getNetworks()
+	vc.getAllVirtualMachines()
+	vc.getAllTemplates()
 	vc.QueryStoragePolicies(protocol.StoragePolicyQuery{})
 	vc.getOSFamilies()
 }
 
-func (vc *VCenter) AddTask(t workerpool.WorkerType, task func()) error {
-	return workerpool.AddTask(vc.Api.ID, t, task)
+func (vc *VCenter) cacheFreshness(k string) protocol.Freshness {
+	freshness := protocol.Freshness{
+		Source:   protocol.FreshnessSourceCache,
+		Watching: vc.IsWatching(),
+	}
+	updatedAt := vc.Cache.UpdatedAt(k)
+	if updatedAt != nil {
+		freshness.UpdatedAt = *updatedAt
+	}
+	return freshness
 }
 
-func (vc *VCenter) CreateCacheTicker() {
-	refreshDuration := config.G.Vsphere.Cache.RefreshDuration
-	if refreshDuration > 0 {
-		logging.L().Debugf("为VC[%s]创建缓存刷新定时器，刷新间隔设置为: %dm", vc.Api.ID, refreshDuration)
-		_, exists := vc.Cache.Get(cache.RefreshTicker)
-		if exists {
-			logging.L().Debug("定时器已经存在，跳过")
-			return
-		}
-		ticker := time.NewTicker(time.Minute * time.Duration(refreshDuration))
-		vc.Cache.Set(cache.RefreshTicker, ticker)
-	} else {
-		logging.L().Debugf("[refreshDuration: %d]刷新间隔设置小于0，跳过缓存刷新定时器创建", refreshDuration)
+func (vc *VCenter) liveFreshness() protocol.Freshness {
+	return protocol.Freshness{
+		Source:    protocol.FreshnessSourceVCenter,
+		UpdatedAt: time.Now(),
 	}
 }
 
-func (vc *VCenter) StopTicker() {
-	logging.L().Debugf("停止VC[%s]缓存刷新定时器", vc.Api.ID)
-	ticker, exists := vc.Cache.Get(cache.RefreshTicker)
-	if exists {
-		ticker.(*time.Ticker).Stop()
-		vc.Cache.Clean(cache.RefreshTicker)
-		logging.L().Debugf("VC[%s]缓存刷新定时器已停止", vc.Api.ID)
-	} else {
-		logging.L().Debug("定时器不存在，跳过")
-	}
+func (vc *VCenter) AddTask(ctx context.Context, t workerpool.WorkerType, task func(ctx context.Context)) error {
+	return workerpool.AddTask(ctx, vc.Api.ID, t, task)
 }
@@ -1,3 +1,8 @@
+//go:build live
+// +build live
+
+// 连接真实VC的测试，使用go test -tags live运行
+
 package vsphere
 
 import (
@@ -50,31 +55,35 @@ func init() {
 	helper.Setup()
 	cache.Setup()
 	vCache.Setup()
-	vc = Get(vc30)
+	var err error
+	vc, err = Get(vc30)
+	if err != nil {
+		logging.L().Panic(err)
+	}
 }
 
 func TestVCenter_QueryDatacenters(t *testing.T) {
-	l := vc.QueryDatacenters(protocol.DatacenterQuery{})
+	l, _ := vc.QueryDatacenters(protocol.DatacenterQuery{})
 	fmt.Println(utils.ToJson(l))
 }
 
 func TestVCenter_QueryClusters(t *testing.T) {
-	clusters := vc.QueryClusters(protocol.ClusterQuery{DatacenterID: ""})
+	clusters, _ := vc.QueryClusters(protocol.ClusterQuery{DatacenterID: ""})
 	fmt.Println(utils.ToJson(clusters))
 }
 
 func TestVCenter_QueryHosts(t *testing.T) {
-	hosts := vc.QueryHosts(protocol.HostQuery{})
+	hosts, _ := vc.QueryHosts(protocol.HostQuery{})
 	fmt.Println(hosts)
 }
 
 func TestVCenter_QueryNetworks(t *testing.T) {
-	networks := vc.QueryNetworks(protocol.NetworkQuery{})
+	networks, _ := vc.QueryNetworks(protocol.NetworkQuery{})
 	fmt.Println(networks)
 }
 
 func TestVCenter_QueryDatastores(t *testing.T) {
-	datastores := vc.QueryDatastores(protocol.DatastoreQuery{})
+	datastores, _ := vc.QueryDatastores(protocol.DatastoreQuery{})
 	fmt.Println(utils.ToJson(datastores))
 }
 
@@ -85,7 +94,7 @@ func TestVCenter_QueryResourcePools(t *testing.T) {
 		HostID:       "",
 		IDs:          nil,
 	}
-	list := vc.QueryResourcePools(q)
+	list, _ := vc.QueryResourcePools(q)
 	if list != nil {
 		for _, info := range list {
 			fmt.Println(info)
@@ -280,6 +289,13 @@ func TestReconfigure(t *testing.T) {
 		//StoragePolicyID: &storagePolicyID2,
 		Sharing: &sharing2,
 	})
+	// 添加硬盘到PVSCSI控制器，控制器不存在时自动新增
+	controllerType := disk.ControllerTypePVSCSI
+	diskParameter.Add = append(diskParameter.Add, &virtualmachinereconfig.AddDiskParameter{
+		Size:           size,
+		Format:         disk.FormatThin,
+		ControllerType: &controllerType,
+	})
 	rp.Disk = &diskParameter
 
 	oVM, err := virtualmachinereconfig.Reconfigure(vc.Api, oVM.Reference().Value, &rp)
@@ -345,12 +361,12 @@ func TestDestroy(t *testing.T) {
 }
 
 func TestVCenter_QueryTemplates(t *testing.T) {
-	list := vc.QueryTemplates(protocol.TemplateQuery{})
+	list, _, _ := vc.QueryTemplates(protocol.TemplateQuery{})
 	fmt.Println(utils.ToJson(list))
 }
 
 func TestVCenter_QueryFolders(t *testing.T) {
-	l := vc.QueryFolders(protocol.FolderQuery{})
+	l, _ := vc.QueryFolders(protocol.FolderQuery{})
 	fmt.Println(utils.ToJson(l))
 }
 
@@ -389,7 +405,7 @@ func TestWorkPool(t *testing.T) {
 
 	for i := 0; i < 200; i++ {
 		nd := i
-		err := workerpool.AddTask(vc.Api.ID, workerpool.WorkerTypeOperation, func() {
+		err := workerpool.AddTask(context.Background(), vc.Api.ID, workerpool.WorkerTypeOperation, func(ctx context.Context) {
 			time.Sleep(time.Millisecond * 500)
 			logging.L().Warn("Test: ", nd)
 		})
@@ -5,27 +5,118 @@ import (
 	"github.com/vmware/govmomi/object"
 	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
+	"sort"
 	"strings"
 	"vsphere-facade/app/logging"
+	"vsphere-facade/app/metrics"
+	"vsphere-facade/app/utils"
+	"vsphere-facade/helper/datacenter"
 	"vsphere-facade/helper/disk"
 	"vsphere-facade/helper/hostsystem"
+	"vsphere-facade/helper/inventory"
+	"vsphere-facade/helper/tag"
 	"vsphere-facade/helper/virtualmachine"
+	"vsphere-facade/helper/virtualmachine/virtualmachinereconfig"
+	helperVsphere "vsphere-facade/helper/vsphere"
+	"vsphere-facade/vsphere/cache"
 	"vsphere-facade/vsphere/protocol"
+	"vsphere-facade/vsphere/workerpool"
 )
 
-func (vc *VCenter) QueryVirtualMachines(q protocol.VirtualMachineQuery) []protocol.VirtualMachineInfo {
-	if q.DatacenterID != "" {
-		return vc.getVirtualMachinesByDatacenterID(q.DatacenterID)
-	} else if q.FolderID != "" {
-		return vc.getVirtualMachinesByFolderID(q.FolderID)
-	} else if len(q.IDs) > 0 {
-		return vc.getVirtualMachinesIDs(q.IDs)
+func (vc *VCenter) QueryVirtualMachines(q protocol.VirtualMachineQuery) ([]protocol.VirtualMachineInfo, protocol.Freshness, error) {
+	virtualMachineInfos, freshness, err := vc.queryVirtualMachines(q)
+	if err != nil {
+		return nil, freshness, err
+	}
+	items, err := vc.filterScope(virtualMachineInfos, virtualmachine.Type)
+	if err != nil {
+		return nil, freshness, err
+	}
+	return items.([]protocol.VirtualMachineInfo), freshness, nil
+}
+
+func (vc *VCenter) queryVirtualMachines(q protocol.VirtualMachineQuery) ([]protocol.VirtualMachineInfo, protocol.Freshness, error) {
+	virtualMachineInfos := vc.queryVirtualMachinesFromCache(q)
+	metrics.CacheQuery(vc.Cache.VCID, virtualmachine.Type, virtualMachineInfos != nil)
+	freshness := vc.cacheFreshness(virtualmachine.Type)
+	if virtualMachineInfos != nil {
+		logging.L().Debug("本次查询使用了缓存")
 	} else {
-		return vc.getAllVirtualMachines()
+		freshness = vc.liveFreshness()
+		if q.DatacenterID != "" {
+			virtualMachineInfos = vc.getVirtualMachinesByDatacenterID(q.DatacenterID)
+		} else if q.FolderID != "" {
+			virtualMachineInfos = vc.getVirtualMachinesByFolderID(q.FolderID)
+		} else if len(q.IDs) > 0 {
+			virtualMachineInfos = vc.getVirtualMachinesIDs(q.IDs)
+		} else {
+			virtualMachineInfos = vc.getAllVirtualMachines()
+		}
+	}
+
+	if len(q.TagIDs) > 0 {
+		var err error
+		virtualMachineInfos, err = vc.filterVirtualMachinesByTags(virtualMachineInfos, q.TagIDs)
+		if err != nil {
+			return nil, freshness, err
+		}
 	}
+	return virtualMachineInfos, freshness, nil
+}
+
+// queryVirtualMachinesFromCache
+// 缓存中的虚拟机没有记录所在文件夹，按文件夹查询时直接查询VC
+func (vc *VCenter) queryVirtualMachinesFromCache(q protocol.VirtualMachineQuery) []protocol.VirtualMachineInfo {
+	if q.FolderID != "" {
+		return nil
+	}
+	cache := vc.Cache.GetVirtualMachines()
+	if cache == nil {
+		return nil
+	}
+
+	virtualMachines := make([]protocol.VirtualMachineInfo, 0)
+	for _, vm := range cache {
+		if len(q.IDs) > 0 && !utils.SliceContain(q.IDs, vm.ID) {
+			continue
+		}
+		if q.DatacenterID != "" && vm.DatacenterID != q.DatacenterID {
+			continue
+		}
+		if q.ClusterID != "" && (vm.ClusterID == nil || *vm.ClusterID != q.ClusterID) {
+			continue
+		}
+		if q.HostID != "" && vm.HostID != q.HostID {
+			continue
+		}
+		virtualMachines = append(virtualMachines, vm)
+	}
+	return virtualMachines
+}
+
+func (vc *VCenter) filterVirtualMachinesByTags(virtualMachineInfos []protocol.VirtualMachineInfo, tagIDs []string) ([]protocol.VirtualMachineInfo, error) {
+	if len(virtualMachineInfos) == 0 {
+		return virtualMachineInfos, nil
+	}
+	tagged, err := tag.GetObjectsWithAllTags(vc.Api, tagIDs, virtualmachine.Type)
+	if err != nil {
+		logging.L().Error("按标签过滤虚拟机时发生错误", err)
+		return nil, fmt.Errorf("按标签过滤虚拟机失败: %v", err)
+	}
+
+	var filtered []protocol.VirtualMachineInfo
+	for _, info := range virtualMachineInfos {
+		if tagged[info.ID] {
+			filtered = append(filtered, info)
+		}
+	}
+	return filtered, nil
 }
 
 func (vc *VCenter) GetVirtualMachine(ID string) *protocol.VirtualMachineInfo {
+	if vc.CheckScope(ID) != nil {
+		return nil
+	}
 	moVM := virtualmachine.GetMObject(vc.Api, ID)
 	if moVM == nil {
 		return nil
@@ -34,11 +125,278 @@ func (vc *VCenter) GetVirtualMachine(ID string) *protocol.VirtualMachineInfo {
 	return &info
 }
 
+// GetVirtualMachineOperator
+// 虚拟机不在允许访问的范围内时返回ErrOutOfScope，不存在时返回nil
+func (vc *VCenter) GetVirtualMachineOperator(ID string) (*workerpool.VirtualMachineOperator, error) {
+	machines, err := vc.GetVirtualMachineOperators(ID)
+	if err != nil {
+		return nil, err
+	}
+	return machines[ID], nil
+}
+
+// GetVirtualMachineOperators
+// 有虚拟机不在允许访问的范围内时返回ErrOutOfScope，不存在的虚拟机不在返回结果中
+func (vc *VCenter) GetVirtualMachineOperators(IDs ...string) (map[string]*workerpool.VirtualMachineOperator, error) {
+	if err := vc.CheckScope(IDs...); err != nil {
+		return nil, err
+	}
+	machines := make(map[string]*workerpool.VirtualMachineOperator, len(IDs))
+	for _, ID := range IDs {
+		if machine := workerpool.GetVirtualMachineOperator(vc.Api, ID); machine != nil {
+			machines[ID] = machine
+		}
+	}
+	return machines, nil
+}
+
+// GetVirtualMachineDetail
+// 虚拟机不存在或不在允许访问的范围内时返回nil
+func (vc *VCenter) GetVirtualMachineDetail(ID string) *protocol.VirtualMachineDetail {
+	if vc.CheckScope(ID) != nil {
+		return nil
+	}
+	moVM := virtualmachine.GetMObject(vc.Api, ID)
+	if moVM == nil || moVM.Config == nil {
+		return nil
+	}
+
+	var datacenterID string
+	parentPath := helperVsphere.FindParentPathByType(vc.Api, ID, virtualmachine.Type, datacenter.Type)
+	if len(parentPath) > 0 {
+		datacenterID = parentPath[len(parentPath)-1].Value
+	}
+
+	detail := protocol.VirtualMachineDetail{}
+	detail.VirtualMachineInfo = vc.buildVirtualMachineInfo(*moVM, datacenterID)
+	if moVM.Guest != nil {
+		detail.GuestFullName = moVM.Guest.GuestFullName
+		detail.ToolsVersion = moVM.Guest.ToolsVersion
+		detail.ToolsVersionStatus = moVM.Guest.ToolsVersionStatus2
+		detail.ToolsRunningStatus = moVM.Guest.ToolsRunningStatus
+	}
+	detail.BootTime = moVM.Runtime.BootTime
+	detail.UptimeSeconds = moVM.Summary.QuickStats.UptimeSeconds
+
+	detail.CPUAllocation = vc.buildResourceAllocationInfo(moVM.Config.CpuAllocation)
+	detail.MemoryAllocation = vc.buildResourceAllocationInfo(moVM.Config.MemoryAllocation)
+	detail.Controllers = vc.findControllers(*moVM)
+	detail.Cdroms = vc.findCdroms(*moVM)
+	detail.Snapshots = vc.buildSnapshotSummaryInfo(moVM.Snapshot)
+	for _, o := range moVM.Config.ExtraConfig {
+		option := o.GetOptionValue()
+		if !virtualmachinereconfig.ExtraConfigAllowed(option.Key) {
+			continue
+		}
+		detail.ExtraConfig = append(detail.ExtraConfig, protocol.ExtraConfigInfo{
+			Key:   option.Key,
+			Value: fmt.Sprintf("%v", option.Value),
+		})
+	}
+	sort.Slice(detail.ExtraConfig, func(i, j int) bool {
+		return detail.ExtraConfig[i].Key < detail.ExtraConfig[j].Key
+	})
+
+	var refs []types.ManagedObjectReference
+	if moVM.Runtime.Host != nil {
+		refs = append(refs, *moVM.Runtime.Host)
+	}
+	refs = append(refs, moVM.Datastore...)
+	names, err := inventory.GetNames(vc.Api, refs)
+	if err != nil {
+		logging.L().Error(fmt.Sprintf("查询虚拟机[%s]所在主机和存储名称时发生错误", ID), err)
+		names = map[string]string{}
+	}
+	if moVM.Runtime.Host != nil {
+		detail.HostName = names[moVM.Runtime.Host.Value]
+	}
+	for _, ds := range moVM.Datastore {
+		detail.Datastores = append(detail.Datastores, protocol.DatastoreName{
+			ID:   ds.Value,
+			Name: names[ds.Value],
+		})
+	}
+	return &detail
+}
+
+func (vc *VCenter) buildResourceAllocationInfo(allocation *types.ResourceAllocationInfo) *protocol.ResourceAllocationInfo {
+	if allocation == nil {
+		return nil
+	}
+	info := protocol.ResourceAllocationInfo{Limit: -1}
+	if allocation.Reservation != nil {
+		info.Reservation = *allocation.Reservation
+	}
+	if allocation.Limit != nil {
+		info.Limit = *allocation.Limit
+	}
+	if allocation.Shares != nil {
+		info.Shares = allocation.Shares.Shares
+		info.SharesLevel = string(allocation.Shares.Level)
+	}
+	return &info
+}
+
+func (vc *VCenter) findControllers(moVM mo.VirtualMachine) []protocol.ControllerInfo {
+	var controllers []protocol.ControllerInfo
+	devices := object.VirtualDeviceList(moVM.Config.Hardware.Device)
+	for _, device := range devices {
+		c, ok := device.(types.BaseVirtualController)
+		if !ok {
+			continue
+		}
+		controller := c.GetVirtualController()
+		controllerType := disk.GetControllerType(device)
+		if controllerType == "" {
+			controllerType = devices.Type(device)
+		}
+		controllers = append(controllers, protocol.ControllerInfo{
+			Key:         controller.Key,
+			Type:        controllerType,
+			BusNumber:   controller.BusNumber,
+			DeviceCount: len(controller.Device),
+		})
+	}
+	return controllers
+}
+
+func (vc *VCenter) findCdroms(moVM mo.VirtualMachine) []protocol.CdromInfo {
+	var cdroms []protocol.CdromInfo
+	vmID := moVM.Reference().Value
+	devices := object.VirtualDeviceList(moVM.Config.Hardware.Device)
+	for _, device := range devices.SelectByType((*types.VirtualCdrom)(nil)) {
+		d := device.GetVirtualDevice()
+		info := protocol.CdromInfo{
+			ID:            vc.buildDeviceId(vmID, d.Key),
+			Key:           d.Key,
+			ControllerKey: d.ControllerKey,
+		}
+		if d.UnitNumber != nil {
+			info.UnitNumber = *d.UnitNumber
+		}
+		if d.Connectable != nil {
+			info.Connected = d.Connectable.Connected
+			info.StartConnected = d.Connectable.StartConnected
+		}
+		switch backing := d.Backing.(type) {
+		case *types.VirtualCdromIsoBackingInfo:
+			info.BackingType = "iso"
+			info.ISOPath = backing.FileName
+		case *types.VirtualCdromAtapiBackingInfo, *types.VirtualCdromPassthroughBackingInfo:
+			info.BackingType = "host"
+		case *types.VirtualCdromRemoteAtapiBackingInfo, *types.VirtualCdromRemotePassthroughBackingInfo:
+			info.BackingType = "client"
+		}
+		cdroms = append(cdroms, info)
+	}
+	return cdroms
+}
+
+func (vc *VCenter) buildSnapshotSummaryInfo(snapshot *types.VirtualMachineSnapshotInfo) *protocol.SnapshotSummaryInfo {
+	summary := protocol.SnapshotSummaryInfo{}
+	if snapshot == nil {
+		return &summary
+	}
+	var walk func(trees []types.VirtualMachineSnapshotTree)
+	walk = func(trees []types.VirtualMachineSnapshotTree) {
+		for _, tree := range trees {
+			summary.Count++
+			info := &protocol.SnapshotInfo{
+				ID:         tree.Snapshot.Value,
+				Name:       tree.Name,
+				CreateTime: tree.CreateTime,
+			}
+			if snapshot.CurrentSnapshot != nil && tree.Snapshot.Value == snapshot.CurrentSnapshot.Value {
+				summary.Current = info
+			}
+			if summary.Latest == nil || tree.CreateTime.After(summary.Latest.CreateTime) {
+				summary.Latest = info
+			}
+			walk(tree.ChildSnapshotList)
+		}
+	}
+	walk(snapshot.RootSnapshotList)
+	return &summary
+}
+
+// GetVirtualMachineExtraConfig
+// 只返回允许管理的高级参数，虚拟机不存在或不在允许访问的范围内时返回nil
+func (vc *VCenter) GetVirtualMachineExtraConfig(ID string) []protocol.ExtraConfigInfo {
+	if vc.CheckScope(ID) != nil {
+		return nil
+	}
+	oVM := virtualmachine.GetObject(vc.Api, ID)
+	if oVM == nil {
+		return nil
+	}
+	moVM := virtualmachine.FindProps(oVM, "config.extraConfig")
+	if moVM == nil {
+		return nil
+	}
+
+	extraConfigInfos := []protocol.ExtraConfigInfo{}
+	if moVM.Config == nil {
+		return extraConfigInfos
+	}
+	for _, o := range moVM.Config.ExtraConfig {
+		option := o.GetOptionValue()
+		if !virtualmachinereconfig.ExtraConfigAllowed(option.Key) {
+			continue
+		}
+		extraConfigInfos = append(extraConfigInfos, protocol.ExtraConfigInfo{
+			Key:   option.Key,
+			Value: fmt.Sprintf("%v", option.Value),
+		})
+	}
+	sort.Slice(extraConfigInfos, func(i, j int) bool {
+		return extraConfigInfos[i].Key < extraConfigInfos[j].Key
+	})
+	return extraConfigInfos
+}
+
+// RefreshVirtualMachineCache
+// 虚拟机操作完成后重新查询虚拟机并更新缓存，虚拟机已经不存在时从缓存中移除。
+// 缓存由清单变化监听增量更新，这里主动刷新是为了操作返回后立即查询时能拿到最新数据
+func (vc *VCenter) RefreshVirtualMachineCache(IDs ...string) {
+	if vc.Cache.GetVirtualMachines() == nil && vc.Cache.GetTemplates() == nil {
+		return
+	}
+	var virtualMachines []protocol.VirtualMachineInfo
+	var templates []protocol.TemplateInfo
+	var removedVirtualMachines, removedTemplates []string
+	for _, ID := range IDs {
+		logging.L().Debug(fmt.Sprintf("刷新虚拟机[%s]缓存", ID))
+		moVM := virtualmachine.GetMObject(vc.Api, ID)
+		if moVM == nil || moVM.Config == nil {
+			removedVirtualMachines = append(removedVirtualMachines, ID)
+			removedTemplates = append(removedTemplates, ID)
+			continue
+		}
+
+		var datacenterID string
+		parentPath := helperVsphere.FindParentPathByType(vc.Api, ID, virtualmachine.Type, datacenter.Type)
+		if len(parentPath) > 0 {
+			datacenterID = parentPath[len(parentPath)-1].Value
+		}
+		if moVM.Config.Template {
+			removedVirtualMachines = append(removedVirtualMachines, ID)
+			templates = append(templates, vc.buildTemplateInfo(*moVM, datacenterID))
+		} else {
+			removedTemplates = append(removedTemplates, ID)
+			virtualMachines = append(virtualMachines, vc.buildVirtualMachineInfo(*moVM, datacenterID))
+		}
+	}
+	vc.Cache.UpdateVirtualMachines(virtualMachines)
+	vc.Cache.UpdateTemplates(templates)
+	vc.Cache.RemoveVirtualMachines(removedVirtualMachines)
+	vc.Cache.RemoveTemplates(removedTemplates)
+}
+
 func (vc *VCenter) getVirtualMachinesByDatacenterID(datacenterID string) []protocol.VirtualMachineInfo {
 	var virtualMachineInfos []protocol.VirtualMachineInfo
 	moVMs := virtualmachine.GetVirtualMachinesByDatacenterID(vc.Api, datacenterID)
 	for _, vm := range moVMs {
-		info := vc.buildVirtualMachineInfo(vm, "")
+		info := vc.buildVirtualMachineInfo(vm, datacenterID)
 		virtualMachineInfos = append(virtualMachineInfos, info)
 	}
 	return virtualMachineInfos
@@ -83,6 +441,8 @@ func (vc *VCenter) getAllVirtualMachines() []protocol.VirtualMachineInfo {
 			virtualMachineInfos = append(virtualMachineInfos, info)
 		}
 	}
+
+	vc.Cache.CacheVirtualMachines(virtualMachineInfos)
 	return virtualMachineInfos
 }
 
@@ -121,26 +481,102 @@ func (vc *VCenter) buildVirtualMachineInfo(moVM mo.VirtualMachine, datacenterID
 	info.SysDisk = sysDisk
 	info.DataDisks = dataDisks
 	info.NetworkInterfaces = vc.findNetworkInterfaces(moVM)
+	info.Boot = vc.buildBootInfo(moVM)
 	return info
 }
 
-func (vc *VCenter) QueryTemplates(q protocol.TemplateQuery) []protocol.TemplateInfo {
+func (vc *VCenter) buildBootInfo(moVM mo.VirtualMachine) *protocol.BootInfo {
+	if moVM.Config == nil {
+		return nil
+	}
+	bootInfo := protocol.BootInfo{}
+	bootInfo.Firmware = moVM.Config.Firmware
+	bootOptions := moVM.Config.BootOptions
+	if bootOptions != nil {
+		bootInfo.BootDelay = bootOptions.BootDelay
+		if bootOptions.EfiSecureBootEnabled != nil {
+			bootInfo.SecureBootEnabled = *bootOptions.EfiSecureBootEnabled
+		}
+		if bootOptions.EnterBIOSSetup != nil {
+			bootInfo.EnterSetup = *bootOptions.EnterBIOSSetup
+		}
+		for _, device := range bootOptions.BootOrder {
+			var bootDevice string
+			switch device.(type) {
+			case *types.VirtualMachineBootOptionsBootableDiskDevice:
+				bootDevice = virtualmachinereconfig.BootDeviceDisk
+			case *types.VirtualMachineBootOptionsBootableEthernetDevice:
+				bootDevice = virtualmachinereconfig.BootDeviceNic
+			case *types.VirtualMachineBootOptionsBootableCdromDevice:
+				bootDevice = virtualmachinereconfig.BootDeviceCdrom
+			default:
+				continue
+			}
+			// 多个网卡只记录一次
+			if !utils.SliceContain(bootInfo.BootOrder, bootDevice) {
+				bootInfo.BootOrder = append(bootInfo.BootOrder, bootDevice)
+			}
+		}
+	}
+	return &bootInfo
+}
+
+func (vc *VCenter) QueryTemplates(q protocol.TemplateQuery) ([]protocol.TemplateInfo, protocol.Freshness, error) {
+	templateInfos, freshness := vc.queryTemplates(q)
+	items, err := vc.filterScope(templateInfos, templateType)
+	if err != nil {
+		return nil, freshness, err
+	}
+	return items.([]protocol.TemplateInfo), freshness, nil
+}
+
+func (vc *VCenter) queryTemplates(q protocol.TemplateQuery) ([]protocol.TemplateInfo, protocol.Freshness) {
+	templateInfos := vc.queryTemplatesFromCache(q)
+	metrics.CacheQuery(vc.Cache.VCID, cache.TemplateCacheKey, templateInfos != nil)
+	if templateInfos != nil {
+		logging.L().Debug("本次查询使用了缓存")
+		return templateInfos, vc.cacheFreshness(cache.TemplateCacheKey)
+	}
+
+	freshness := vc.liveFreshness()
 	if q.DatacenterID != "" {
-		return vc.getTemplatesByDatacenterID(q.DatacenterID)
+		return vc.getTemplatesByDatacenterID(q.DatacenterID), freshness
 	} else if q.FolderID != "" {
-		return vc.getTemplatesByFolderID(q.FolderID)
+		return vc.getTemplatesByFolderID(q.FolderID), freshness
 	} else if len(q.IDs) > 0 {
-		return vc.getTemplatesIDs(q.IDs)
+		return vc.getTemplatesIDs(q.IDs), freshness
 	} else {
-		return vc.getAllTemplates()
+		return vc.getAllTemplates(), freshness
 	}
 }
 
+func (vc *VCenter) queryTemplatesFromCache(q protocol.TemplateQuery) []protocol.TemplateInfo {
+	if q.FolderID != "" {
+		return nil
+	}
+	cache := vc.Cache.GetTemplates()
+	if cache == nil {
+		return nil
+	}
+
+	templates := make([]protocol.TemplateInfo, 0)
+	for _, t := range cache {
+		if len(q.IDs) > 0 && !utils.SliceContain(q.IDs, t.ID) {
+			continue
+		}
+		if q.DatacenterID != "" && t.DatacenterID != q.DatacenterID {
+			continue
+		}
+		templates = append(templates, t)
+	}
+	return templates
+}
+
 func (vc *VCenter) getTemplatesByDatacenterID(datacenterID string) []protocol.TemplateInfo {
 	var templateInfos []protocol.TemplateInfo
 	moVMs := virtualmachine.GetTemplatesByDatacenterID(vc.Api, datacenterID)
 	for _, vm := range moVMs {
-		templateInfo := vc.buildTemplateInfo(vm, "")
+		templateInfo := vc.buildTemplateInfo(vm, datacenterID)
 		templateInfos = append(templateInfos, templateInfo)
 	}
 	return templateInfos
@@ -185,6 +621,8 @@ func (vc *VCenter) getAllTemplates() []protocol.TemplateInfo {
 			templateInfos = append(templateInfos, info)
 		}
 	}
+
+	vc.Cache.CacheTemplates(templateInfos)
 	return templateInfos
 }
 
@@ -233,7 +671,11 @@ func (vc *VCenter) findFolder(moVM mo.VirtualMachine) *string {
 }
 
 func (vc *VCenter) findCluster(moVM mo.VirtualMachine) *string {
-	hostID := moVM.Runtime.Host.Value
+	return vc.hostCluster(moVM.Runtime.Host.Value)
+}
+
+// hostCluster 主机所在的集群，独立主机返回nil
+func (vc *VCenter) hostCluster(hostID string) *string {
 	host := vc.Cache.GetHost(hostID)
 	if host != nil {
 		return &host.ClusterID
@@ -262,13 +704,17 @@ func (vc *VCenter) findDisks(moVM mo.VirtualMachine) (protocol.DiskInfo, []proto
 	vmID := moVM.Reference().Value
 	device := moVM.Config.Hardware.Device
 	devices := object.VirtualDeviceList(device)
+	sysKey := int32(0)
+	if sd := virtualmachine.FindSysDisk(devices); sd != nil {
+		sysKey = sd.Key
+	}
 	disks := devices.SelectByType((*types.VirtualDisk)(nil))
 	for _, d := range disks {
 		vd := d.(*types.VirtualDisk)
-		if *vd.UnitNumber == 0 {
-			sysDisk = vc.buildDiskInfo(vmID, vd)
+		if vd.Key == sysKey {
+			sysDisk = vc.buildDiskInfo(vmID, vd, devices)
 		} else {
-			dataDisk := vc.buildDiskInfo(vmID, vd)
+			dataDisk := vc.buildDiskInfo(vmID, vd, devices)
 			dataDisks = append(dataDisks, dataDisk)
 		}
 	}
@@ -316,7 +762,7 @@ func (vc *VCenter) buildDeviceId(vmID string, key int32) string {
 	return fmt.Sprintf("%s:%d", vmID, key)
 }
 
-func (vc *VCenter) buildDiskInfo(vmID string, d *types.VirtualDisk) protocol.DiskInfo {
+func (vc *VCenter) buildDiskInfo(vmID string, d *types.VirtualDisk, devices object.VirtualDeviceList) protocol.DiskInfo {
 	var diskInfo protocol.DiskInfo
 	key := d.GetVirtualDevice().Key
 	diskInfo.ID = vc.buildDeviceId(vmID, key)
@@ -327,6 +773,16 @@ func (vc *VCenter) buildDiskInfo(vmID string, d *types.VirtualDisk) protocol.Dis
 	diskInfo.Mode = mode
 	diskInfo.Sharing = sharing
 	diskInfo.DatastoreID = datastoreID
+	diskInfo.ControllerKey = d.ControllerKey
+	if d.UnitNumber != nil {
+		diskInfo.UnitNumber = *d.UnitNumber
+	}
+	if ctrl := devices.FindByKey(d.ControllerKey); ctrl != nil {
+		diskInfo.ControllerType = disk.GetControllerType(ctrl)
+		if c, ok := ctrl.(types.BaseVirtualController); ok {
+			diskInfo.BusNumber = c.GetVirtualController().BusNumber
+		}
+	}
 	return diskInfo
 }
 
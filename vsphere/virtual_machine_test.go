@@ -0,0 +1,68 @@
+package vsphere
+
+import (
+	"context"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25/types"
+	"os"
+	"testing"
+	"time"
+	"vsphere-facade/app/logging"
+	"vsphere-facade/config"
+	"vsphere-facade/helper"
+)
+
+// simulatorVCenter 连接vcsim模拟的VC
+func simulatorVCenter(t *testing.T) *VCenter {
+	config.G.Server.Log.Path = os.TempDir()
+	config.G.Server.Log.Level = "error"
+	logging.Setup()
+	helper.APITimeout = time.Minute
+	config.G.Vsphere.TLS.Insecure = true
+	t.Cleanup(func() { config.G.Vsphere.TLS.Insecure = false })
+
+	model := simulator.VPX()
+	t.Cleanup(model.Remove)
+	if err := model.Create(); err != nil {
+		t.Fatal(err)
+	}
+	s := model.Service.NewServer()
+	t.Cleanup(s.Close)
+	password, _ := s.URL.User.Password()
+	vc, err := Get(Auth{Address: s.URL.Scheme + "://" + s.URL.Host, Username: s.URL.User.Username(), Password: password})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return vc
+}
+
+func TestGetVirtualMachineExtraConfig(t *testing.T) {
+	vc := simulatorVCenter(t)
+	saved := config.G.Vsphere.ExtraConfig
+	defer func() { config.G.Vsphere.ExtraConfig = saved }()
+	config.G.Vsphere.ExtraConfig.Allow = []string{"guestinfo.*"}
+	config.G.Vsphere.ExtraConfig.Deny = []string{"guestinfo.secret*"}
+
+	ctx := context.Background()
+	oVM, err := find.NewFinder(vc.Api.Client.Client, true).VirtualMachine(ctx, "/DC0/vm/DC0_H0_VM0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, err := oVM.Reconfigure(ctx, types.VirtualMachineConfigSpec{ExtraConfig: []types.BaseOptionValue{
+		&types.OptionValue{Key: "guestinfo.metadata", Value: "m"},
+		&types.OptionValue{Key: "guestinfo.secretToken", Value: "s"},
+		&types.OptionValue{Key: "monitor.phys_bits_used", Value: "45"},
+	}})
+	if err == nil {
+		err = task.Wait(ctx)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	infos := vc.GetVirtualMachineExtraConfig(oVM.Reference().Value)
+	if len(infos) != 1 || infos[0].Key != "guestinfo.metadata" {
+		t.Error("只返回允许管理的高级参数", infos)
+	}
+}
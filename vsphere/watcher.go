@@ -0,0 +1,435 @@
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"vsphere-facade/app/logging"
+	"vsphere-facade/config"
+	"vsphere-facade/helper/clustercomputerresource"
+	"vsphere-facade/helper/computerresource"
+	"vsphere-facade/helper/datacenter"
+	"vsphere-facade/helper/datastore"
+	"vsphere-facade/helper/folder"
+	"vsphere-facade/helper/hostsystem"
+	"vsphere-facade/helper/inventory"
+	"vsphere-facade/helper/network"
+	"vsphere-facade/helper/resourcepool"
+	"vsphere-facade/helper/virtualapp"
+	"vsphere-facade/helper/virtualmachine"
+	"vsphere-facade/vsphere/protocol"
+)
+
+const defaultWatchRetryInterval = 30 * time.Second
+
+var watchers = make(map[string]*watcher)
+var watchersMu sync.Mutex
+
+// watchProps 各类型需要监听的属性，需要包含build*Info用到的所有属性，parent用于计算所属的数据中心
+var watchProps = map[string][]string{
+	datacenter.Type:              {"name", "parent"},
+	folder.Type:                  {"name", "parent"},
+	computerresource.Type:        {"name", "parent"},
+	clustercomputerresource.Type: {"name", "parent", "resourcePool", "configuration.drsConfig"},
+	hostsystem.Type:              {"name", "parent", "datastore", "network"},
+	resourcepool.Type:            {"name", "parent", "owner", "runtime.cpu.unreservedForVm", "runtime.memory.unreservedForVm"},
+	datastore.Type:               {"name", "parent", "summary"},
+	network.Type:                 {"name", "parent", "summary"},
+	virtualmachine.Type:          append(virtualmachine.InfoProps(), "parent", "parentVApp"),
+}
+
+// watcher
+// 每个VC一个后台监听，将清单变化增量更新到缓存中，objects保存监听到的对象的最新属性
+type watcher struct {
+	vc      *VCenter
+	cancel  context.CancelFunc
+	objects map[types.ManagedObjectReference]mo.Entity
+	// placements 清单对象的位置(map[string]inventory.Placement)，位置变化时整体替换，读取时不需要加锁
+	placements atomic.Value
+	// synced 重新监听后收到第一批对象时重建placements并覆盖缓存
+	synced bool
+	// watching 收到第一批对象后为1，监听中断后为0
+	watching int32
+}
+
+// StartWatcher 启动VC清单变化监听，已经启动时跳过
+func (vc *VCenter) StartWatcher() {
+	watchersMu.Lock()
+	defer watchersMu.Unlock()
+	if _, exists := watchers[vc.Api.ID]; exists {
+		logging.L().Debugf("VC[%s]清单变化监听已经存在，跳过", vc.Api.ID)
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &watcher{
+		vc:     vc,
+		cancel: cancel,
+	}
+	watchers[vc.Api.ID] = w
+	go w.run(ctx)
+	logging.L().Debugf("VC[%s]清单变化监听已启动", vc.Api.ID)
+}
+
+func (vc *VCenter) StopWatcher() {
+	watchersMu.Lock()
+	defer watchersMu.Unlock()
+	w, exists := watchers[vc.Api.ID]
+	if !exists {
+		logging.L().Debug("清单变化监听不存在，跳过")
+		return
+	}
+	w.cancel()
+	delete(watchers, vc.Api.ID)
+	logging.L().Debugf("VC[%s]清单变化监听已停止", vc.Api.ID)
+}
+
+// IsWatching 监听正常时返回true，监听中断等待重试时返回false
+func (vc *VCenter) IsWatching() bool {
+	watchersMu.Lock()
+	w, exists := watchers[vc.Api.ID]
+	watchersMu.Unlock()
+	return exists && atomic.LoadInt32(&w.watching) == 1
+}
+
+func (w *watcher) run(ctx context.Context) {
+	retryInterval := defaultWatchRetryInterval
+	if config.G.Vsphere.Cache.RetryInterval > 0 {
+		retryInterval = time.Duration(config.G.Vsphere.Cache.RetryInterval) * time.Second
+	}
+	for {
+		// 重新监听时会重新收到所有对象，本地状态从头开始
+		w.objects = make(map[types.ManagedObjectReference]mo.Entity)
+		w.synced = false
+		err := inventory.Watch(ctx, w.vc.Api, watchProps, w.apply)
+		atomic.StoreInt32(&w.watching, 0)
+		if ctx.Err() != nil {
+			return
+		}
+		logging.L().Error(fmt.Sprintf("VC[%s]清单变化监听中断，%s后重新监听", w.vc.Api.ID, retryInterval), err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+// apply
+// 先合并所有对象的变化再更新缓存，同一批次中子对象可能先于父对象到达，合并后才能计算出数据中心。
+// 同一类型的变化合并后一次写入缓存
+func (w *watcher) apply(updates []types.ObjectUpdate) {
+	var changed []types.ManagedObjectReference
+	var removed []types.ManagedObjectReference
+	seen := make(map[types.ManagedObjectReference]bool)
+	for _, update := range updates {
+		ref := update.Obj
+		switch update.Kind {
+		case types.ObjectUpdateKindLeave:
+			delete(w.objects, ref)
+			removed = append(removed, ref)
+		case types.ObjectUpdateKindEnter, types.ObjectUpdateKindModify:
+			if w.merge(ref, update.ChangeSet) && !seen[ref] {
+				seen[ref] = true
+				changed = append(changed, ref)
+			}
+		}
+	}
+
+	b := inventoryBatch{}
+	for _, ref := range changed {
+		w.collect(&b, ref)
+	}
+	for _, ref := range removed {
+		b.remove(ref)
+	}
+	// 第一批包含所有对象，覆盖缓存，清除监听中断期间删除的对象
+	b.flush(w.vc, !w.synced)
+	w.updatePlacements(changed, removed)
+	atomic.StoreInt32(&w.watching, 1)
+}
+
+// updatePlacements 第一批对象包含所有对象，重建placements，之后只在位置变化时复制并替换
+func (w *watcher) updatePlacements(changed, removed []types.ManagedObjectReference) {
+	if !w.synced {
+		placements := make(map[string]inventory.Placement, len(w.objects))
+		for ref, obj := range w.objects {
+			placements[ref.Value] = placementOf(ref, obj)
+		}
+		w.placements.Store(placements)
+		w.synced = true
+		return
+	}
+
+	current := w.placements.Load().(map[string]inventory.Placement)
+	var updated map[string]inventory.Placement
+	modify := func() {
+		if updated == nil {
+			updated = make(map[string]inventory.Placement, len(current))
+			for ID, p := range current {
+				updated[ID] = p
+			}
+		}
+	}
+	for _, ref := range changed {
+		obj, exists := w.objects[ref]
+		if !exists {
+			continue
+		}
+		p := placementOf(ref, obj)
+		if old, ok := current[ref.Value]; !ok || !samePlacement(old, p) {
+			modify()
+			updated[ref.Value] = p
+		}
+	}
+	for _, ref := range removed {
+		if _, ok := current[ref.Value]; ok {
+			modify()
+			delete(updated, ref.Value)
+		}
+	}
+	if updated != nil {
+		w.placements.Store(updated)
+	}
+}
+
+func placementOf(ref types.ManagedObjectReference, obj mo.Entity) inventory.Placement {
+	p := inventory.Placement{Type: ref.Type, Parent: obj.Entity().Parent}
+	if vm, ok := obj.(*mo.VirtualMachine); ok {
+		p.ResourcePool = vm.ResourcePool
+	}
+	return p
+}
+
+func samePlacement(a, b inventory.Placement) bool {
+	sameRef := func(x, y *types.ManagedObjectReference) bool {
+		return (x == nil && y == nil) || (x != nil && y != nil && *x == *y)
+	}
+	return a.Type == b.Type && sameRef(a.Parent, b.Parent) && sameRef(a.ResourcePool, b.ResourcePool)
+}
+
+// watchedPlacements 监听中的VC返回监听到的清单对象位置，没有监听、监听中断或还没有收到第一批对象时返回nil
+func (vc *VCenter) watchedPlacements() map[string]inventory.Placement {
+	watchersMu.Lock()
+	w, exists := watchers[vc.Api.ID]
+	watchersMu.Unlock()
+	if !exists || atomic.LoadInt32(&w.watching) == 0 {
+		return nil
+	}
+	placements, _ := w.placements.Load().(map[string]inventory.Placement)
+	return placements
+}
+
+func (w *watcher) merge(ref types.ManagedObjectReference, changes []types.PropertyChange) bool {
+	obj, exists := w.objects[ref]
+	if !exists {
+		newObj, err := inventory.NewObject(ref)
+		if err != nil {
+			logging.L().Error("处理清单变化时发生错误", err)
+			return false
+		}
+		obj = newObj
+		w.objects[ref] = obj
+	}
+
+	// 数组元素的变化(如config.hardware.device[2000])无法直接合并，重新查询该对象
+	for _, change := range changes {
+		if strings.Contains(change.Name, "[") {
+			props := w.props(ref)
+			fresh, err := inventory.Retrieve(w.vc.Api, ref, props)
+			if err != nil {
+				logging.L().Error("处理清单变化时发生错误", err)
+				return false
+			}
+			newObj, err := inventory.NewObject(ref)
+			if err != nil {
+				logging.L().Error("处理清单变化时发生错误", err)
+				return false
+			}
+			mo.ApplyPropertyChange(newObj, fresh)
+			w.objects[ref] = newObj
+			return true
+		}
+	}
+	mo.ApplyPropertyChange(obj, changes)
+	return true
+}
+
+func (w *watcher) props(ref types.ManagedObjectReference) []string {
+	if props, ok := watchProps[ref.Type]; ok {
+		return props
+	}
+	switch ref.Type {
+	case virtualapp.Type:
+		return watchProps[resourcepool.Type]
+	default:
+		return watchProps[network.Type]
+	}
+}
+
+// datacenterID 沿着parent向上查找所属的数据中心，vApp中的虚拟机parent为空，使用parentVApp
+func (w *watcher) datacenterID(ref types.ManagedObjectReference) string {
+	for ref.Type != datacenter.Type {
+		obj, exists := w.objects[ref]
+		if !exists {
+			return ""
+		}
+		parent := obj.Entity().Parent
+		if vm, ok := obj.(*mo.VirtualMachine); ok && parent == nil {
+			parent = vm.ParentVApp
+		}
+		if parent == nil {
+			return ""
+		}
+		ref = *parent
+	}
+	return ref.Value
+}
+
+func (w *watcher) collect(b *inventoryBatch, ref types.ManagedObjectReference) {
+	obj, exists := w.objects[ref]
+	if !exists {
+		return
+	}
+	vc := w.vc
+	datacenterID := w.datacenterID(ref)
+
+	switch o := obj.(type) {
+	case *mo.Datacenter:
+		b.datacenters = append(b.datacenters, protocol.DatacenterInfo{
+			ID:   o.Self.Value,
+			Name: o.Name,
+		})
+	case *mo.Folder:
+		// 根文件夹不属于任何数据中心
+		if datacenterID != "" {
+			b.folders = append(b.folders, vc.buildFolderInfo(*o, datacenterID))
+		}
+	case *mo.ClusterComputeResource:
+		if o.Configuration.DrsConfig.Enabled != nil {
+			b.clusters = append(b.clusters, vc.buildClusterInfo(*o, datacenterID))
+		}
+	case *mo.HostSystem:
+		if o.Parent != nil {
+			b.hosts = append(b.hosts, vc.buildHostInfo(*o, datacenterID))
+		}
+	case *mo.ResourcePool:
+		// 与helper层保持一致，过滤掉virtualApp下的资源池
+		if o.Parent != nil && o.Parent.Type != virtualapp.Type {
+			b.resourcePools = append(b.resourcePools, vc.buildResourcePoolInfo(*o, datacenterID))
+		}
+	case *mo.Datastore:
+		b.datastores = append(b.datastores, vc.buildDatastoreInfo(*o, datacenterID))
+	case *mo.Network:
+		b.addNetwork(vc, *o, datacenterID)
+	case *mo.DistributedVirtualPortgroup:
+		b.addNetwork(vc, o.Network, datacenterID)
+	case *mo.OpaqueNetwork:
+		b.addNetwork(vc, o.Network, datacenterID)
+	case *mo.VirtualMachine:
+		if o.Config == nil {
+			// 创建中的虚拟机还没有配置信息
+			return
+		}
+		// 虚拟机和模板可以互相转换，需要从另一个缓存中移除
+		if o.Config.Template {
+			b.removedVirtualMachines = append(b.removedVirtualMachines, o.Self.Value)
+			b.templates = append(b.templates, vc.buildTemplateInfo(*o, datacenterID))
+		} else {
+			b.removedTemplates = append(b.removedTemplates, o.Self.Value)
+			b.virtualMachines = append(b.virtualMachines, vc.buildVirtualMachineInfo(*o, datacenterID))
+		}
+	}
+}
+
+type inventoryBatch struct {
+	datacenters     []protocol.DatacenterInfo
+	folders         []protocol.FolderInfo
+	clusters        []protocol.ClusterInfo
+	hosts           []protocol.HostInfo
+	resourcePools   []protocol.ResourcePoolInfo
+	datastores      []protocol.DatastoreInfo
+	networks        []protocol.NetworkInfo
+	virtualMachines []protocol.VirtualMachineInfo
+	templates       []protocol.TemplateInfo
+
+	removedDatacenters     []string
+	removedFolders         []string
+	removedClusters        []string
+	removedHosts           []string
+	removedResourcePools   []string
+	removedDatastores      []string
+	removedNetworks        []string
+	removedVirtualMachines []string
+	removedTemplates       []string
+}
+
+func (b *inventoryBatch) addNetwork(vc *VCenter, moNetwork mo.Network, datacenterID string) {
+	if moNetwork.Summary == nil {
+		return
+	}
+	b.networks = append(b.networks, vc.buildNetworkInfo(moNetwork, datacenterID))
+}
+
+func (b *inventoryBatch) remove(ref types.ManagedObjectReference) {
+	switch ref.Type {
+	case datacenter.Type:
+		b.removedDatacenters = append(b.removedDatacenters, ref.Value)
+	case folder.Type:
+		b.removedFolders = append(b.removedFolders, ref.Value)
+	case clustercomputerresource.Type:
+		b.removedClusters = append(b.removedClusters, ref.Value)
+	case hostsystem.Type:
+		b.removedHosts = append(b.removedHosts, ref.Value)
+	case resourcepool.Type:
+		b.removedResourcePools = append(b.removedResourcePools, ref.Value)
+	case datastore.Type:
+		b.removedDatastores = append(b.removedDatastores, ref.Value)
+	case virtualmachine.Type:
+		b.removedVirtualMachines = append(b.removedVirtualMachines, ref.Value)
+		b.removedTemplates = append(b.removedTemplates, ref.Value)
+	case computerresource.Type, virtualapp.Type:
+	default:
+		b.removedNetworks = append(b.removedNetworks, ref.Value)
+	}
+}
+
+// flush full为true时批次中是所有对象，覆盖缓存
+func (b *inventoryBatch) flush(vc *VCenter, full bool) {
+	c := vc.Cache
+	if full {
+		c.ReplaceDatacenters(b.datacenters)
+		c.ReplaceFolders(b.folders)
+		c.ReplaceClusters(b.clusters)
+		c.ReplaceHosts(b.hosts)
+		c.ReplaceResourcePools(b.resourcePools)
+		c.ReplaceDatastores(b.datastores)
+		c.ReplaceNetworks(b.networks)
+		c.ReplaceVirtualMachines(b.virtualMachines)
+		c.ReplaceTemplates(b.templates)
+		return
+	}
+	c.UpdateDatacenters(b.datacenters)
+	c.UpdateFolders(b.folders)
+	c.UpdateClusters(b.clusters)
+	c.UpdateHosts(b.hosts)
+	c.UpdateResourcePools(b.resourcePools)
+	c.UpdateDatastores(b.datastores)
+	c.UpdateNetworks(b.networks)
+	c.UpdateVirtualMachines(b.virtualMachines)
+	c.UpdateTemplates(b.templates)
+
+	c.RemoveDatacenters(b.removedDatacenters)
+	c.RemoveFolders(b.removedFolders)
+	c.RemoveClusters(b.removedClusters)
+	c.RemoveHosts(b.removedHosts)
+	c.RemoveResourcePools(b.removedResourcePools)
+	c.RemoveDatastores(b.removedDatastores)
+	c.RemoveNetworks(b.removedNetworks)
+	c.RemoveVirtualMachines(b.removedVirtualMachines)
+	c.RemoveTemplates(b.removedTemplates)
+}
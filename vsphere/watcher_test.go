@@ -0,0 +1,64 @@
+package vsphere
+
+import (
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"vsphere-facade/app/cache"
+	"vsphere-facade/config"
+	"vsphere-facade/helper"
+	vCache "vsphere-facade/vsphere/cache"
+	"vsphere-facade/vsphere/protocol"
+)
+
+func TestWatcherApply(t *testing.T) {
+	config.G.Vsphere.Cache.Enable = true
+	defer func() { config.G.Vsphere.Cache.Enable = false }()
+	cache.Setup()
+	vc := &VCenter{Api: &helper.API{ID: "vc-watch"}, Cache: &vCache.VCCache{VCID: "vc-watch"}}
+	vc.Cache.CacheDatacenters([]protocol.DatacenterInfo{{ID: "datacenter-deleted", Name: "deleted"}})
+
+	w := &watcher{vc: vc, objects: make(map[types.ManagedObjectReference]mo.Entity)}
+	watchersMu.Lock()
+	watchers[vc.Api.ID] = w
+	watchersMu.Unlock()
+	defer func() {
+		watchersMu.Lock()
+		delete(watchers, vc.Api.ID)
+		watchersMu.Unlock()
+	}()
+	if vc.IsWatching() {
+		t.Error("还没有收到第一批对象时不是监听中")
+	}
+
+	enter := func(ID, name string) types.ObjectUpdate {
+		return types.ObjectUpdate{
+			Kind:      types.ObjectUpdateKindEnter,
+			Obj:       types.ManagedObjectReference{Type: "Datacenter", Value: ID},
+			ChangeSet: []types.PropertyChange{{Name: "name", Op: types.PropertyChangeOpAssign, Val: name}},
+		}
+	}
+	w.apply([]types.ObjectUpdate{enter("datacenter-1", "DC1")})
+	want := []protocol.DatacenterInfo{{ID: "datacenter-1", Name: "DC1"}}
+	if datacenters := vc.Cache.GetDatacenters(); !reflect.DeepEqual(datacenters, want) {
+		t.Error("第一批对象应该覆盖缓存，移除监听中断期间删除的对象", datacenters)
+	}
+	if !vc.IsWatching() || vc.watchedPlacements() == nil {
+		t.Error("收到第一批对象后是监听中")
+	}
+	if vms := vc.Cache.GetVirtualMachines(); vms == nil || len(vms) != 0 {
+		t.Error("没有对象的类型应该缓存为空", vms)
+	}
+
+	w.apply([]types.ObjectUpdate{enter("datacenter-2", "DC2")})
+	if datacenters := vc.Cache.GetDatacenters(); len(datacenters) != 2 {
+		t.Error("之后的变化合并到缓存中", datacenters)
+	}
+
+	atomic.StoreInt32(&w.watching, 0)
+	if vc.IsWatching() || vc.watchedPlacements() != nil {
+		t.Error("监听中断时不是监听中，也不使用监听到的位置")
+	}
+}
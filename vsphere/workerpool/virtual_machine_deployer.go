@@ -1,14 +1,22 @@
 package workerpool
 
 import (
+	"context"
 	"github.com/google/uuid"
 	"github.com/vmware/govmomi/object"
 	"github.com/vmware/govmomi/vim25/types"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+	"strings"
 	"time"
 	"vsphere-facade/app/logging"
+	"vsphere-facade/app/metrics"
+	"vsphere-facade/app/tracing"
 	"vsphere-facade/config"
 	"vsphere-facade/helper"
+	"vsphere-facade/helper/customfield"
 	"vsphere-facade/helper/datastore"
+	"vsphere-facade/helper/tag"
 	"vsphere-facade/helper/virtualmachine"
 	"vsphere-facade/helper/virtualmachine/virtualmachineclone"
 	"vsphere-facade/helper/virtualmachine/virtualmachinecustomize"
@@ -21,24 +29,32 @@ type VirtualMachineDeployer struct {
 	Parameter      DeployParameter
 
 	api       *helper.API
+	scope     *tracing.Scope
+	log       *zap.SugaredLogger
 	oNewVM    *object.VirtualMachine
 	newVmID   string
 	newVmName string
 }
 
 type DeployParameter struct {
-	Name              string                                   `json:"name" valid:"Required"`
-	Template          Template                                 `json:"template" valid:"Required"`
-	Location          virtualmachineclone.LocationParameter    `json:"location" valid:"Required"`
-	Cpu               *virtualmachinereconfig.CpuParameter     `json:"cpu"`
-	Memory            *virtualmachinereconfig.MemoryParameter  `json:"memory"`
-	NetworkInterfaces []*NetworkInterface                      `json:"networkInterfaces,omitempty"`
-	DataDisks         []*DataDisk                              `json:"dataDisks,omitempty"`
-	OS                *OS                                      `json:"os,omitempty"`
-	GlobalIP          *virtualmachinecustomize.GlobalIPSetting `json:"globalIp,omitempty"`
-	WaitForIP         string                                   `json:"waitForIp,omitempty"`
-	Flag              Flag                                     `json:"flag,omitempty"`
-	PowerOn           *bool                                    `json:"powerOn,omitempty"`
+	Name              string                                      `json:"name" valid:"Required"`
+	Template          Template                                    `json:"template" valid:"Required"`
+	Location          virtualmachineclone.LocationParameter       `json:"location" valid:"Required"`
+	Cpu               *virtualmachinereconfig.CpuParameter        `json:"cpu"`
+	Memory            *virtualmachinereconfig.MemoryParameter     `json:"memory"`
+	NetworkInterfaces []*NetworkInterface                         `json:"networkInterfaces,omitempty"`
+	DataDisks         []*DataDisk                                 `json:"dataDisks,omitempty"`
+	OS                *OS                                         `json:"os,omitempty"`
+	GlobalIP          *virtualmachinecustomize.GlobalIPSetting    `json:"globalIp,omitempty"`
+	WaitForIP         string                                      `json:"waitForIp,omitempty"`
+	Flag              Flag                                        `json:"flag,omitempty"`
+	Boot              *virtualmachinereconfig.BootParameter       `json:"boot,omitempty"`
+	VTpm              *virtualmachinereconfig.VTpmParameter       `json:"vtpm,omitempty"`
+	Encryption        *virtualmachinereconfig.EncryptionParameter `json:"encryption,omitempty"`
+	ExtraConfig       map[string]string                           `json:"extraConfig,omitempty"`
+	Tags              []string                                    `json:"tags,omitempty"`
+	CustomAttributes  map[string]string                           `json:"customAttributes,omitempty"`
+	PowerOn           *bool                                       `json:"powerOn,omitempty"`
 }
 
 type Template struct {
@@ -139,10 +155,25 @@ func NewVirtualMachineDeployer(api *helper.API) *VirtualMachineDeployer {
 	}
 }
 
-func (d *VirtualMachineDeployer) Deploy() error {
+// Deploy
+// 每个步骤记录为一个span，步骤中的vSphere调用记录在步骤下
+func (d *VirtualMachineDeployer) Deploy(ctx context.Context) (err error) {
+	defer metrics.ObserveTask("deploy", time.Now(), &err)
+	ctx, span := tracing.Start(ctx, "deploy",
+		attribute.String("deploy.id", d.DeployID),
+		attribute.String("vm.name", d.Parameter.Name),
+		attribute.String("template.id", d.Parameter.Template.ID))
+	defer func() {
+		tracing.End(span, err)
+	}()
+	d.scope = tracing.NewScope(ctx)
+	d.log = logging.C(ctx)
+	d.api = d.api.Traced(d.scope)
+
 	d.setTimeout()
 	d.setDefault()
 	// 创建机器
+	end := d.scope.Step("deploy.clone")
 	oTempVM := virtualmachine.GetObject(d.api, d.Parameter.Template.ID)
 	var clone = virtualmachineclone.CloneParameter{}
 	clone.ID = d.Parameter.Template.ID
@@ -167,16 +198,21 @@ func (d *VirtualMachineDeployer) Deploy() error {
 		clone.Disks = &[]virtualmachineclone.SelfContainedDiskParameter{sysDisk}
 	}
 	oVM, err := virtualmachineclone.Clone(d.api, clone, d.TimeoutSetting.WaitForClone)
+	end(err)
 	if oVM == nil {
-		logging.L().Error("虚拟机创建失败", err)
+		d.logger().Error("虚拟机创建失败", err)
 		return err
 	}
 	d.setNewVM(oVM)
+	span.SetAttributes(attribute.String("vm.id", d.newVmID))
+	d.log = d.log.With(logging.FieldVMID, d.newVmID)
 
 	// 硬件配置
+	end = d.scope.Step("deploy.reconfigure")
 	reconfig := virtualmachinereconfig.ReconfigureParameter{}
 	createDate := time.Now()
 	reconfig.CreateDate = &createDate
+	reconfig.ExtraConfig = d.Parameter.ExtraConfig
 	cpu := d.Parameter.Cpu
 	if cpu != nil {
 		reconfig.Cpu = &virtualmachinereconfig.CpuParameter{
@@ -218,8 +254,9 @@ func (d *VirtualMachineDeployer) Deploy() error {
 		reconfig.Nic = &nicParameter
 	}
 	oVM, err = virtualmachinereconfig.Reconfigure(d.api, d.newVmID, &reconfig)
+	end(err)
 	if err != nil {
-		logging.L().Error("硬件配置失败", err)
+		d.logger().Error("硬件配置失败", err)
 		d.rollBack()
 		return err
 	}
@@ -249,6 +286,39 @@ func (d *VirtualMachineDeployer) Deploy() error {
 		}
 	}
 
+	// 引导和安全配置，网卡替换完成后再设置，保证引导顺序引用的是新网卡
+	if d.Parameter.Boot != nil || d.Parameter.VTpm != nil || d.Parameter.Encryption != nil {
+		end = d.scope.Step("deploy.boot_security")
+		oVM, err = virtualmachinereconfig.Reconfigure(d.api, d.newVmID, &virtualmachinereconfig.ReconfigureParameter{
+			Boot:       d.Parameter.Boot,
+			VTpm:       d.Parameter.VTpm,
+			Encryption: d.Parameter.Encryption,
+		})
+		end(err)
+		if err != nil {
+			d.logger().Error("引导和安全配置失败", err)
+			d.rollBack()
+			return err
+		}
+	}
+
+	// 标签和自定义属性
+	end = d.scope.Step("deploy.tags")
+	err = tag.Attach(d.api, d.Parameter.Tags, oVM.Reference())
+	if err != nil {
+		end(err)
+		d.logger().Error("添加标签失败", err)
+		d.rollBack()
+		return err
+	}
+	err = customfield.Set(d.api, oVM.Reference(), d.Parameter.CustomAttributes)
+	end(err)
+	if err != nil {
+		d.logger().Error("设置自定义属性失败", err)
+		d.rollBack()
+		return err
+	}
+
 	// 系统配置
 	shouldCustomize := false
 	customize := virtualmachinecustomize.CustomizeParameter{}
@@ -291,38 +361,46 @@ func (d *VirtualMachineDeployer) Deploy() error {
 	}
 
 	if shouldCustomize {
+		end = d.scope.Step("deploy.customize")
 		err = virtualmachinecustomize.Customize(d.api, d.newVmID, &customize)
+		end(err)
 		if err != nil {
-			logging.L().Error("", err)
+			d.logger().Error("", err)
 			d.rollBack()
 			return err
 		}
 	} else {
-		logging.L().Debugf("未设置操作系统参数，跳过系统配置")
+		d.logger().Debugf("未设置操作系统参数，跳过系统配置")
 	}
 
 	// 开机
+	end = d.scope.Step("deploy.power_on")
 	err = virtualmachine.PowerOn(oVM, true, 10)
+	end(err)
 	if err != nil {
-		logging.L().Error("", err)
+		d.logger().Error("", err)
 		return err
 	}
 
 	// 等待IP
 	waitForIPTimeout := d.TimeoutSetting.WaitForIP
 	if waitForIPTimeout != nil && *waitForIPTimeout > 0 {
+		end = d.scope.Step("deploy.wait_for_ip")
 		err = virtualmachine.WaitForGuestIP(d.api, oVM, nil, d.Parameter.WaitForIP, *waitForIPTimeout)
+		end(err)
 		if err != nil {
-			logging.L().Error("", err)
+			d.logger().Error("", err)
 			d.rollBack()
 			return err
 		}
 
 		waitForNetTimeout := d.TimeoutSetting.WaitForNet
 		if waitForNetTimeout != nil && *waitForNetTimeout > 0 {
+			end = d.scope.Step("deploy.wait_for_net")
 			err = virtualmachine.WaitForGuestNet(d.api, oVM, false, nil, *waitForNetTimeout)
+			end(err)
 			if err != nil {
-				logging.L().Error("", err)
+				d.logger().Error("", err)
 				d.rollBack()
 				return err
 			}
@@ -348,7 +426,12 @@ func (d *VirtualMachineDeployer) Verify() []string {
 	// 校验主机是否可用
 	// 校验主机和是否可以访问存储
 	// 校验主机和是否可以访问网络
-	return nil
+	var errs []string
+	err := virtualmachinereconfig.CheckExtraConfig(d.Parameter.ExtraConfig)
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+	return errs
 }
 
 func (d *VirtualMachineDeployer) NewMachineID() string {
@@ -378,6 +461,18 @@ func (d *VirtualMachineDeployer) setTimeout() {
 
 func (d *VirtualMachineDeployer) setDefault() {
 	deployment := config.G.Vsphere.Default.Deployment
+	policies := deployment.StoragePolicies[d.api.ID]
+	if v := helper.RegisteredVCenter(d.api.ID); v != nil {
+		if v.Default.AdapterType != nil {
+			deployment.AdapterType = v.Default.AdapterType
+		}
+		if v.Default.DiskMode != nil {
+			deployment.DiskMode = v.Default.DiskMode
+		}
+		if len(v.Default.StoragePolicies) > 0 {
+			policies = v.Default.StoragePolicies
+		}
+	}
 	p := d.Parameter
 	if p.Flag.EnableLogging == nil {
 		p.Flag.EnableLogging = deployment.Flag.EnableLogging
@@ -400,7 +495,7 @@ func (d *VirtualMachineDeployer) setDefault() {
 			moDatastore := datastore.GetMObject(d.api, *sysDisk.DatastoreId)
 			if moDatastore != nil {
 				datastoreType := moDatastore.Summary.Type
-				storagePolicyID := deployment.StoragePolicies[d.api.ID][datastoreType]
+				storagePolicyID := storagePolicy(policies, datastoreType)
 				if storagePolicyID != "" {
 					sysDisk.StoragePolicyID = &storagePolicyID
 				}
@@ -420,7 +515,7 @@ func (d *VirtualMachineDeployer) setDefault() {
 				moDatastore := datastore.GetMObject(d.api, disk.DatastoreId)
 				if moDatastore != nil {
 					datastoreType := moDatastore.Summary.Type
-					storagePolicyID := deployment.StoragePolicies[d.api.ID][datastoreType]
+					storagePolicyID := storagePolicy(policies, datastoreType)
 					if storagePolicyID != "" {
 						disk.StoragePolicyID = &storagePolicyID
 					}
@@ -439,10 +534,12 @@ func (d *VirtualMachineDeployer) setDefault() {
 
 func (d *VirtualMachineDeployer) rollBack() {
 	if d.oNewVM != nil {
-		logging.L().Debugf("回滚删除创建的虚拟机：%s(%s)", d.oNewVM.Name(), d.oNewVM.Reference().Value)
+		d.logger().Debugf("回滚删除创建的虚拟机：%s(%s)", d.oNewVM.Name(), d.oNewVM.Reference().Value)
+		end := d.scope.Step("deploy.rollback")
 		err := virtualmachine.Destroy(d.api, d.oNewVM.Reference().Value)
+		end(err)
 		if err != nil {
-			logging.L().Errorf("回滚删除创建的虚拟机：%s(%s)发生错误: %v", d.oNewVM.Name(), d.oNewVM.Reference().Value, err)
+			d.logger().Errorf("回滚删除创建的虚拟机：%s(%s)发生错误: %v", d.oNewVM.Name(), d.oNewVM.Reference().Value, err)
 			return
 		}
 	}
@@ -453,3 +550,24 @@ func (d *VirtualMachineDeployer) setNewVM(oVM *object.VirtualMachine) {
 	d.newVmID = oVM.Reference().Value
 	d.newVmName = oVM.Name()
 }
+
+// logger 部署开始后使用带有任务ID、VC ID和虚拟机ID的日志
+func (d *VirtualMachineDeployer) logger() *zap.SugaredLogger {
+	if d.log != nil {
+		return d.log
+	}
+	return logging.L()
+}
+
+// storagePolicy 配置读取后map的key会转为小写，按类型查找时忽略大小写
+func storagePolicy(policies map[string]string, datastoreType string) string {
+	if ID, ok := policies[datastoreType]; ok {
+		return ID
+	}
+	for t, ID := range policies {
+		if strings.EqualFold(t, datastoreType) {
+			return ID
+		}
+	}
+	return ""
+}
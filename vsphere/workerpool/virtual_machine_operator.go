@@ -1,10 +1,16 @@
 package workerpool
 
 import (
+	"context"
 	"fmt"
 	"github.com/vmware/govmomi/object"
 	"github.com/vmware/govmomi/vim25/types"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+	"time"
 	"vsphere-facade/app/logging"
+	"vsphere-facade/app/metrics"
+	"vsphere-facade/app/tracing"
 	"vsphere-facade/config"
 	"vsphere-facade/helper"
 	"vsphere-facade/helper/virtualmachine"
@@ -19,6 +25,8 @@ type VirtualMachineOperator struct {
 	id      string
 	name    string
 	display string
+	scope   *tracing.Scope
+	log     *zap.SugaredLogger
 }
 
 func GetVirtualMachineOperator(api *helper.API, ID string) *VirtualMachineOperator {
@@ -36,44 +44,80 @@ func GetVirtualMachineOperator(api *helper.API, ID string) *VirtualMachineOperat
 	}
 }
 
-func (o VirtualMachineOperator) PowerOn() error {
-	err := virtualmachine.PowerOn(o.oVM, true, 2)
+// WithContext 返回记录链路和日志字段的副本，ctx为工作池任务的context
+func (o VirtualMachineOperator) WithContext(ctx context.Context) *VirtualMachineOperator {
+	o.scope = tracing.NewScope(ctx)
+	o.log = logging.C(ctx).With(logging.FieldVMID, o.id)
+	o.api = o.api.Traced(o.scope)
+	oVM := object.NewVirtualMachine(o.api.Client.Client, o.oVM.Reference())
+	oVM.InventoryPath = o.oVM.InventoryPath
+	o.oVM = oVM
+	return &o
+}
+
+func (o VirtualMachineOperator) logger() *zap.SugaredLogger {
+	if o.log != nil {
+		return o.log
+	}
+	return logging.L()
+}
+
+// track 记录任务耗时和span，使用方式: defer o.track("power_on")(&err)
+func (o VirtualMachineOperator) track(taskType string) func(err *error) {
+	start := time.Now()
+	end := func(error) {}
+	if o.scope != nil {
+		end = o.scope.Step("operator."+taskType, attribute.String("vm.id", o.id))
+	}
+	return func(err *error) {
+		metrics.ObserveTask(taskType, start, err)
+		end(*err)
+	}
+}
+
+func (o VirtualMachineOperator) PowerOn() (err error) {
+	defer o.track("power_on")(&err)
+	err = virtualmachine.PowerOn(o.oVM, true, 2)
 	return err
 }
 
-func (o VirtualMachineOperator) PowerOff() error {
+func (o VirtualMachineOperator) PowerOff() (err error) {
+	defer o.track("power_off")(&err)
 	if config.G.Vsphere.Default.Operation.ShutdownFirst {
 		err := virtualmachine.Shutdown(o.oVM, true, 2)
 		if err == nil {
 			return nil
 		}
 	}
-	err := virtualmachine.PowerOff(o.oVM, true, 2)
+	err = virtualmachine.PowerOff(o.oVM, true, 2)
 	return err
 }
 
-func (o VirtualMachineOperator) Shutdown() error {
-	err := virtualmachine.Shutdown(o.oVM, true, 2)
+func (o VirtualMachineOperator) Shutdown() (err error) {
+	defer o.track("shutdown")(&err)
+	err = virtualmachine.Shutdown(o.oVM, true, 2)
 	return err
 }
 
-func (o VirtualMachineOperator) Restart() error {
-	err := virtualmachine.Shutdown(o.oVM, true, 2)
+func (o VirtualMachineOperator) Restart() (err error) {
+	defer o.track("restart")(&err)
+	err = virtualmachine.Shutdown(o.oVM, true, 2)
 	if err != nil {
-		logging.L().Warn(fmt.Sprintf("关闭虚拟机[%s]操作系统失败，尝试关闭电源", o.display), err)
+		o.logger().Warn(fmt.Sprintf("关闭虚拟机[%s]操作系统失败，尝试关闭电源", o.display), err)
 		err = virtualmachine.PowerOff(o.oVM, true, 2)
 	}
 	err = virtualmachine.PowerOn(o.oVM, true, 2)
 	if err != nil {
-		logging.L().Error(fmt.Sprintf("重启虚拟机[%s]失败", o.display), err)
+		o.logger().Error(fmt.Sprintf("重启虚拟机[%s]失败", o.display), err)
 	}
 	return err
 }
 
-func (o VirtualMachineOperator) Destroy() error {
-	err := virtualmachine.Destroy(o.api, o.id)
+func (o VirtualMachineOperator) Destroy() (err error) {
+	defer o.track("destroy")(&err)
+	err = virtualmachine.Destroy(o.api, o.id)
 	if err != nil {
-		logging.L().Error(fmt.Sprintf("删除虚拟机[%s]失败", o.display))
+		o.logger().Error(fmt.Sprintf("删除虚拟机[%s]失败", o.display))
 	}
 	return err
 }
@@ -83,16 +127,18 @@ func (o VirtualMachineOperator) CreateSnapshot() error {
 	return nil
 }
 
-func (o VirtualMachineOperator) Rename(newName string) error {
+func (o VirtualMachineOperator) Rename(newName string) (err error) {
+	defer o.track("rename")(&err)
 	if o.oVM.Name() == newName {
 		return nil
 	}
 	return virtualmachine.Rename(o.oVM, newName, 10)
 }
 
-func (o VirtualMachineOperator) Descript(annotation string) error {
+func (o VirtualMachineOperator) Descript(annotation string) (err error) {
+	defer o.track("description")(&err)
 	p := &virtualmachinereconfig.ReconfigureParameter{Annotation: &annotation}
-	_, err := virtualmachinereconfig.Reconfigure(o.api, o.id, p)
+	_, err = virtualmachinereconfig.Reconfigure(o.api, o.id, p)
 	return err
 }
 
@@ -101,12 +147,13 @@ type RelocateParameter struct {
 	Storage *virtualmachinerelocate.StorageParameter `json:"storage"`
 }
 
-func (o VirtualMachineOperator) Relocate(p RelocateParameter) error {
+func (o VirtualMachineOperator) Relocate(p RelocateParameter) (err error) {
+	defer o.track("relocate")(&err)
 	parameter := virtualmachinerelocate.RelocateParameter{
 		Compute: p.Compute,
 		Storage: p.Storage,
 	}
-	_, err := virtualmachinerelocate.Relocate(o.api, o.id, parameter, config.G.Vsphere.Timeout.WaitForRelocate)
+	_, err = virtualmachinerelocate.Relocate(o.api, o.id, parameter, config.G.Vsphere.Timeout.WaitForRelocate)
 	return err
 }
 
@@ -114,14 +161,22 @@ type ReconfigureParameter struct {
 	NumCPU            int32 `json:"numCPU"`
 	NumCoresPerSocket int32 `json:"numCoresPerSocket"`
 	MemoryMB          int32 `json:"MemoryMB"`
+
+	Boot       *virtualmachinereconfig.BootParameter       `json:"boot,omitempty"`
+	VTpm       *virtualmachinereconfig.VTpmParameter       `json:"vtpm,omitempty"`
+	Encryption *virtualmachinereconfig.EncryptionParameter `json:"encryption,omitempty"`
+
+	ExtraConfig map[string]string `json:"extraConfig,omitempty"`
 }
 
-func (o VirtualMachineOperator) Reconfigure(p ReconfigureParameter) error {
+func (o VirtualMachineOperator) Reconfigure(p ReconfigureParameter) (err error) {
+	defer o.track("reconfigure")(&err)
 	props := []string{"config.hardware.memoryMB", "config.hardware.numCPU", "config.hardware.numCoresPerSocket",
-		"runtime.powerState", "config.memoryHotAddEnabled", "config.cpuHotAddEnabled", "config.cpuHotRemoveEnabled"}
+		"runtime.powerState", "config.memoryHotAddEnabled", "config.cpuHotAddEnabled", "config.cpuHotRemoveEnabled",
+		"config.firmware", "config.bootOptions"}
 	moVM := virtualmachine.FindProps(o.oVM, props...)
 
-	var cpuChanged, memoryChanged, addCPU, removeCPU, addMemory, removeMemory bool
+	var cpuChanged, memoryChanged, bootChanged, firmwareChanged, secureBootChanged, securityChanged, extraConfigChanged, addCPU, removeCPU, addMemory, removeMemory bool
 	var parameter virtualmachinereconfig.ReconfigureParameter
 	if p.NumCPU > 0 || p.NumCoresPerSocket > 0 {
 		currentNumCPU := moVM.Config.Hardware.NumCPU
@@ -152,8 +207,32 @@ func (o VirtualMachineOperator) Reconfigure(p ReconfigureParameter) error {
 		memoryChanged = true
 	}
 
-	if !cpuChanged && !memoryChanged {
-		logging.L().Warnf("虚拟机[%s]配置未发生改变，配置修改中断", o.display)
+	if p.Boot != nil {
+		parameter.Boot = p.Boot
+		bootChanged = true
+		firmwareChanged = p.Boot.Firmware != nil && *p.Boot.Firmware != moVM.Config.Firmware
+		if p.Boot.SecureBootEnabled != nil {
+			secureBootEnabled := false
+			if moVM.Config.BootOptions != nil && moVM.Config.BootOptions.EfiSecureBootEnabled != nil {
+				secureBootEnabled = *moVM.Config.BootOptions.EfiSecureBootEnabled
+			}
+			secureBootChanged = *p.Boot.SecureBootEnabled != secureBootEnabled
+		}
+	}
+
+	if p.VTpm != nil || p.Encryption != nil {
+		parameter.VTpm = p.VTpm
+		parameter.Encryption = p.Encryption
+		securityChanged = true
+	}
+
+	if len(p.ExtraConfig) > 0 {
+		parameter.ExtraConfig = p.ExtraConfig
+		extraConfigChanged = true
+	}
+
+	if !cpuChanged && !memoryChanged && !bootChanged && !securityChanged && !extraConfigChanged {
+		o.logger().Warnf("虚拟机[%s]配置未发生改变，配置修改中断", o.display)
 		return nil
 	}
 
@@ -172,6 +251,9 @@ func (o VirtualMachineOperator) Reconfigure(p ReconfigureParameter) error {
 			stopFirst = true
 		} else if removeCPU && cpuHotRemoveEnabled != nil && !*cpuHotRemoveEnabled {
 			stopFirst = true
+		} else if firmwareChanged || secureBootChanged || securityChanged {
+			// 固件类型、安全引导、vTPM和加密只能在关机状态下修改
+			stopFirst = true
 		}
 	}
 
@@ -182,7 +264,7 @@ func (o VirtualMachineOperator) Reconfigure(p ReconfigureParameter) error {
 		}
 	}
 
-	_, err := virtualmachinereconfig.Reconfigure(o.api, o.id, &parameter)
+	_, err = virtualmachinereconfig.Reconfigure(o.api, o.id, &parameter)
 	if err != nil {
 		return err
 	}
@@ -190,7 +272,7 @@ func (o VirtualMachineOperator) Reconfigure(p ReconfigureParameter) error {
 	if stopFirst && !isPoweredOn {
 		err := o.PowerOn()
 		if err != nil {
-			logging.L().Errorf("虚拟机[%s]启动失败", o.display)
+			o.logger().Errorf("虚拟机[%s]启动失败", o.display)
 		}
 	}
 	return err
@@ -210,7 +292,8 @@ type ReconfigureDiskParameter struct {
 	Remove []int32
 }
 
-func (o VirtualMachineOperator) ReconfigureDisk(p ReconfigureDiskParameter) error {
+func (o VirtualMachineOperator) ReconfigureDisk(p ReconfigureDiskParameter) (err error) {
+	defer o.track("reconfigure_disk")(&err)
 	reconfigureParameter := virtualmachinereconfig.ReconfigureParameter{}
 	reconfigureParameter.Disk = &virtualmachinereconfig.DiskParameter{
 		Edit:   p.Edit,
@@ -226,6 +309,8 @@ func (o VirtualMachineOperator) ReconfigureDisk(p ReconfigureDiskParameter) erro
 			Mode:            a.Mode,
 			StoragePolicyID: a.StoragePolicyID,
 			Sharing:         a.Sharing,
+			ControllerType:  a.ControllerType,
+			BusNumber:       a.BusNumber,
 		}
 		addDisks = append(addDisks, &addDiskParameter)
 
@@ -245,19 +330,16 @@ func (o VirtualMachineOperator) ReconfigureDisk(p ReconfigureDiskParameter) erro
 	o.oVM = oVM
 
 	if len(mayRelocate) > 0 {
-		disks := virtualmachine.GetDisks(o.oVM)
-
-		diskUmMap := make(map[int32]*types.VirtualDisk)
-		for _, d := range disks {
-			un := *d.GetVirtualDevice().UnitNumber
-			diskUmMap[un] = d.(*types.VirtualDisk)
-		}
+		devices := virtualmachine.GetDevices(o.oVM)
 
 		var relocateDisks []virtualmachinerelocate.DiskStorageParameter
 		for _, i := range mayRelocate {
 			newDisk := addDisks[i]
-			un := *newDisk.UnitNumber
-			disk := diskUmMap[un]
+			disk := virtualmachine.FindDisk(devices, *newDisk.ControllerType, *newDisk.BusNumber, *newDisk.UnitNumber)
+			if disk == nil {
+				o.logger().Warnf("虚拟机[%s]未找到新增的硬盘[%s:%d:%d]，跳过存储迁移", o.display, *newDisk.ControllerType, *newDisk.BusNumber, *newDisk.UnitNumber)
+				continue
+			}
 			datastoreRef := disk.Backing.(*types.VirtualDiskFlatVer2BackingInfo).Datastore
 			if p.Add[i].DatastoreID != datastoreRef.Value {
 				diskStorageParameter := virtualmachinerelocate.DiskStorageParameter{
@@ -310,7 +392,8 @@ type ReconfigureNicParameter struct {
 	Remove []int32
 }
 
-func (o VirtualMachineOperator) ReconfigureNic(p ReconfigureNicParameter) error {
+func (o VirtualMachineOperator) ReconfigureNic(p ReconfigureNicParameter) (err error) {
+	defer o.track("reconfigure_nic")(&err)
 	var addNics []*virtualmachinereconfig.AddNicParameter
 	for _, a := range p.Add {
 		addNicParameter := virtualmachinereconfig.AddNicParameter{
@@ -1,12 +1,19 @@
 package workerpool
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/panjf2000/ants/v2"
+	"go.opentelemetry.io/otel/attribute"
 	"sync"
+	"sync/atomic"
 	"vsphere-facade/app/cache"
 	"vsphere-facade/app/logging"
+	"vsphere-facade/app/metrics"
+	"vsphere-facade/app/tracing"
 	"vsphere-facade/config"
+	"vsphere-facade/helper"
 )
 
 type WorkerType string
@@ -18,10 +25,38 @@ const (
 
 var receiveTaskPool *ants.Pool
 
+// ErrTooManyTasks 调用方或VC排队和执行中的任务数超过限制
+var ErrTooManyTasks = errors.New("排队和执行中的任务过多")
+
+type clientKey struct{}
+
+// inflight 调用方和VC排队和执行中的任务数
+var inflight sync.Map
+
+// pools 使用过的工作池，用于统计监控指标
+var pools sync.Map
+
+type poolInfo struct {
+	VCID   string
+	Type   WorkerType
+	queued int64
+}
+
 func init() {
 	receiveTaskPool, _ = ants.NewPool(10000,
 		ants.WithNonblocking(false),
 		ants.WithMaxBlockingTasks(0))
+
+	labels := []string{"vcid", "type"}
+	metrics.RegisterGauges("worker_pool_running", "工作池中正在执行的任务数", labels, func() []metrics.Gauge {
+		return poolGauges(func(p *ants.Pool, info *poolInfo) float64 { return float64(p.Running()) })
+	})
+	metrics.RegisterGauges("worker_pool_queued", "已接收还没有开始执行的任务数", labels, func() []metrics.Gauge {
+		return poolGauges(func(p *ants.Pool, info *poolInfo) float64 { return float64(atomic.LoadInt64(&info.queued)) })
+	})
+	metrics.RegisterGauges("worker_pool_capacity", "工作池容量", labels, func() []metrics.Gauge {
+		return poolGauges(func(p *ants.Pool, info *poolInfo) float64 { return float64(p.Cap()) })
+	})
 }
 
 func Get(VCID string, t WorkerType) *ants.Pool {
@@ -33,13 +68,82 @@ func Get(VCID string, t WorkerType) *ants.Pool {
 	return newPool(VCID, t)
 }
 
-func AddTask(VCID string, t WorkerType, task func()) error {
-	return receiveTaskPool.Submit(func() {
-		err := Get(VCID, t).Submit(task)
+// WithClient 记录添加任务的调用方，用于限制每个调用方的任务数
+func WithClient(ctx context.Context, client string) context.Context {
+	return context.WithValue(ctx, clientKey{}, client)
+}
+
+// AddTask
+// ctx只用于传递链路信息和日志字段，任务在请求返回后执行，传给task的ctx不会随请求取消。
+// 调用方或VC排队和执行中的任务数超过server.rateLimit的限制时返回ErrTooManyTasks
+func AddTask(ctx context.Context, VCID string, t WorkerType, task func(ctx context.Context)) error {
+	client, _ := ctx.Value(clientKey{}).(string)
+	release, err := acquire(client, VCID)
+	if err != nil {
+		logging.C(ctx).Warnf("调用方[%s]或VC[%s]的任务数超过限制", client, VCID)
+		return err
+	}
+	ctx = logging.Inherit(tracing.Detach(ctx), ctx)
+	attrs := []attribute.KeyValue{attribute.String("vcid", VCID), attribute.String("worker.type", string(t))}
+	_, wait := tracing.Start(ctx, "workerpool.wait", attrs...)
+	queued := queueCounter(VCID, t)
+	atomic.AddInt64(queued, 1)
+	err = receiveTaskPool.Submit(func() {
+		err := Get(VCID, t).Submit(func() {
+			defer release()
+			atomic.AddInt64(queued, -1)
+			wait.End()
+			taskCtx, span := tracing.Start(ctx, "workerpool."+string(t), attrs...)
+			defer span.End()
+			task(taskCtx)
+		})
 		if err != nil {
-			logging.L().Error("添加任务失败： ", err)
+			release()
+			atomic.AddInt64(queued, -1)
+			tracing.End(wait, err)
+			logging.C(ctx).Error("添加任务失败： ", err)
 		}
 	})
+	if err != nil {
+		release()
+		atomic.AddInt64(queued, -1)
+		tracing.End(wait, err)
+	}
+	return err
+}
+
+// acquire 占用调用方和VC的任务数，返回的函数在任务结束时释放
+func acquire(client, VCID string) (func(), error) {
+	conf := config.G.Server.RateLimit
+	if !conf.Enable {
+		return func() {}, nil
+	}
+	var counters []*int64
+	releaseAll := func() {
+		for _, c := range counters {
+			atomic.AddInt64(c, -1)
+		}
+	}
+	for _, limit := range []struct {
+		key string
+		max int
+	}{
+		{"vc::" + VCID, conf.MaxTasksPerVC},
+		{"client::" + client, conf.MaxTasksPerClient},
+	} {
+		if limit.max <= 0 || limit.key == "client::" {
+			continue
+		}
+		v, _ := inflight.LoadOrStore(limit.key, new(int64))
+		counter := v.(*int64)
+		counters = append(counters, counter)
+		if atomic.AddInt64(counter, 1) > int64(limit.max) {
+			releaseAll()
+			return nil, ErrTooManyTasks
+		}
+	}
+	var once sync.Once
+	return func() { once.Do(releaseAll) }, nil
 }
 
 func newPool(VCID string, t WorkerType) *ants.Pool {
@@ -55,7 +159,7 @@ func newPool(VCID string, t WorkerType) *ants.Pool {
 
 	switch t {
 	case WorkerTypeDeployment:
-		pool, err := ants.NewPool(config.G.Vsphere.RoutineCount.Deployment,
+		pool, err := ants.NewPool(poolSize(VCID, t),
 			ants.WithNonblocking(false),
 			ants.WithMaxBlockingTasks(0))
 		if err != nil {
@@ -66,7 +170,7 @@ func newPool(VCID string, t WorkerType) *ants.Pool {
 		cache.INST.Set(k, pool, -1)
 		return pool
 	case WorkerTypeOperation:
-		pool, err := ants.NewPool(config.G.Vsphere.RoutineCount.Operation,
+		pool, err := ants.NewPool(poolSize(VCID, t),
 			ants.WithNonblocking(false),
 			ants.WithMaxBlockingTasks(0))
 		if err != nil {
@@ -81,6 +185,82 @@ func newPool(VCID string, t WorkerType) *ants.Pool {
 	return nil
 }
 
+// poolSize 登记的VC配置了routineCount时优先使用
+func poolSize(VCID string, t WorkerType) int {
+	routineCount := config.G.Vsphere.RoutineCount
+	if v := helper.RegisteredVCenter(VCID); v != nil {
+		if v.RoutineCount.Deployment > 0 {
+			routineCount.Deployment = v.RoutineCount.Deployment
+		}
+		if v.RoutineCount.Operation > 0 {
+			routineCount.Operation = v.RoutineCount.Operation
+		}
+	}
+	if t == WorkerTypeDeployment {
+		return routineCount.Deployment
+	}
+	return routineCount.Operation
+}
+
 func poolKey(VCID string, t WorkerType) string {
 	return fmt.Sprintf("%s::%s", VCID, t)
 }
+
+func getPoolInfo(VCID string, t WorkerType) *poolInfo {
+	info, _ := pools.LoadOrStore(poolKey(VCID, t), &poolInfo{VCID: VCID, Type: t})
+	return info.(*poolInfo)
+}
+
+// queueCounter 工作池还没有创建时也需要计数
+func queueCounter(VCID string, t WorkerType) *int64 {
+	return &getPoolInfo(VCID, t).queued
+}
+
+// poolGauges 工作池还没有创建的跳过
+func poolGauges(value func(p *ants.Pool, info *poolInfo) float64) []metrics.Gauge {
+	var gauges []metrics.Gauge
+	pools.Range(func(k, v interface{}) bool {
+		p, exist := cache.INST.Get(k.(string))
+		if !exist {
+			return true
+		}
+		info := v.(*poolInfo)
+		gauges = append(gauges, metrics.Gauge{
+			Labels: []string{info.VCID, string(info.Type)},
+			Value:  value(p.(*ants.Pool), info),
+		})
+		return true
+	})
+	return gauges
+}
+
+// PoolStatus 工作池使用情况，用于健康检查
+type PoolStatus struct {
+	VCID     string     `json:"vcid"`
+	Type     WorkerType `json:"type"`
+	Running  int        `json:"running"`
+	Capacity int        `json:"capacity"`
+	Queued   int64      `json:"queued"`
+}
+
+// Statuses 已创建的工作池的使用情况
+func Statuses() []PoolStatus {
+	var statuses []PoolStatus
+	pools.Range(func(k, v interface{}) bool {
+		p, exist := cache.INST.Get(k.(string))
+		if !exist {
+			return true
+		}
+		info := v.(*poolInfo)
+		pool := p.(*ants.Pool)
+		statuses = append(statuses, PoolStatus{
+			VCID:     info.VCID,
+			Type:     info.Type,
+			Running:  pool.Running(),
+			Capacity: pool.Cap(),
+			Queued:   atomic.LoadInt64(&info.queued),
+		})
+		return true
+	})
+	return statuses
+}
@@ -0,0 +1,53 @@
+package workerpool
+
+import (
+	"testing"
+	"vsphere-facade/config"
+)
+
+func TestAcquire(t *testing.T) {
+	conf := &config.G.Server.RateLimit
+	conf.Enable = true
+	conf.MaxTasksPerClient = 2
+	conf.MaxTasksPerVC = 3
+	defer func() { config.G.Server.RateLimit.Enable = false }()
+
+	var releases []func()
+	for i := 0; i < 2; i++ {
+		release, err := acquire("c1", "vc1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		releases = append(releases, release)
+	}
+	if _, err := acquire("c1", "vc1"); err != ErrTooManyTasks {
+		t.Error("超过调用方的任务数应该拒绝", err)
+	}
+	release, err := acquire("c2", "vc1")
+	if err != nil {
+		t.Fatal("其他调用方不受影响", err)
+	}
+	if _, err = acquire("c3", "vc1"); err != ErrTooManyTasks {
+		t.Error("超过VC的任务数应该拒绝", err)
+	}
+	if _, err = acquire("c3", "vc2"); err != nil {
+		t.Error("其他VC不受影响", err)
+	}
+
+	release()
+	release()
+	releases[0]()
+	if _, err = acquire("c1", "vc1"); err != nil {
+		t.Error("任务结束后应该释放", err)
+	}
+}
+
+func TestStoragePolicy(t *testing.T) {
+	policies := map[string]string{"vmfs": "p1", "VSAN": "p2"}
+	if storagePolicy(policies, "VMFS") != "p1" || storagePolicy(policies, "VSAN") != "p2" {
+		t.Error("按数据存储类型查找存储策略时应该忽略大小写")
+	}
+	if storagePolicy(policies, "NFS") != "" || storagePolicy(nil, "VMFS") != "" {
+		t.Error("没有配置的类型不应该有存储策略")
+	}
+}